@@ -2,6 +2,7 @@ package main
 
 import (
 	"github.com/go-gl/mathgl/mgl32"
+	"github.com/markov/gojira2d/pkg/audio"
 	g "github.com/markov/gojira2d/pkg/graphics"
 	"math"
 )
@@ -38,7 +39,7 @@ var (
 )
 
 func createHud() {
-	track0 = NewTrack(win, 274.0, 0, 0.2)
+	track0 = NewTrack(win, audio.NewChartFromFile("bojack/charts/run.yaml"), 274.0, 0, 0.2)
 }
 
 func createGoGoGo() {
@@ -3,28 +3,33 @@ package main
 import (
 	"github.com/go-gl/glfw/v3.2/glfw"
 	"github.com/markov/gojira2d/pkg/app"
+	"github.com/markov/gojira2d/pkg/input"
 	"github.com/go-gl/mathgl/mgl32"
 )
 
-var (
-	keyCallbackFunc glfw.KeyCallback
-)
-
 func main() {
 	app.Init(win.w, win.h, false, "Run For Your Life!", true)
 	defer app.Terminate()
-	defer UnregisterKeyCallback()
 	createHud()
 	createGoGoGo()
 	scene := NewScene()
 
+	// Each player runs with a key of the keyboard
+	playerInput := input.NewPlayerInput(3)
+	defer playerInput.Close()
+	for i, key := range []glfw.Key{glfw.KeyB, glfw.KeyM, glfw.KeyT} {
+		keyboard := &input.KeyboardController{}
+		keyboard.SetMapping(input.NewKeyboardMapping(map[input.ControllerButton]glfw.Key{input.BUTTON_A: key}))
+		playerInput.Assign(i, keyboard)
+	}
+
 	players := []*Player{
 		NewPlayer(
 			mgl32.Vec3{-200, 900, 0.3},
 			mgl32.Vec2{0.35, 0.35},
 			"bojack",
 			4,
-			glfw.KeyB,
+			playerInput.Slot(0),
 			0,
 		),
 		NewPlayer(
@@ -32,7 +37,7 @@ func main() {
 			mgl32.Vec2{0.4, 0.4},
 			"monkey",
 			4,
-			glfw.KeyM,
+			playerInput.Slot(1),
 			150,
 		),
 		NewPlayer(
@@ -40,7 +45,7 @@ func main() {
 			mgl32.Vec2{0.34, 0.34},
 			"todd",
 			4,
-			glfw.KeyT,
+			playerInput.Slot(2),
 			300,
 		),
 	}
@@ -49,11 +54,9 @@ func main() {
 		NewZombie(mgl32.Vec3{-650, 1030, 0.15}, mgl32.Vec2{0.32, 0.32}, "other_zombie", 3),
 	}
 
-	RegisterKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
-		HandleKeyPress(key, action, players)
-	})
-
 	app.MainLoop(func(speed float64) {
+		playerInput.Update()
+		HandlePlayerInput(players)
 		scene.Update(speed)
 		updateHud()
 		for _, zombie := range zombies {
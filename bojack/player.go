@@ -5,6 +5,7 @@ import (
 	g "github.com/markov/gojira2d/pkg/graphics"
 	"fmt"
 	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/markov/gojira2d/pkg/input"
 	"math"
 )
 
@@ -35,8 +36,7 @@ type Player struct {
 	quad               *g.Primitive2D
 	shadowQuad         *g.Primitive2D
 	speed              float32
-	key0               glfw.Key
-	key1               glfw.Key
+	controller         input.GameController
 	lastKeyInteraction float64
 	position           mgl32.Vec3
 	runningSprites     []*g.Texture
@@ -57,7 +57,7 @@ func NewPlayer(
 	scale mgl32.Vec2,
 	playerName string,
 	numberOfFrames int,
-	key0 glfw.Key,
+	controller input.GameController,
 	offsetXStartLine float32) *Player {
 	p := &Player{}
 	p.canStart = false
@@ -72,7 +72,7 @@ func NewPlayer(
 	p.playerName = playerName
 	p.mugshotTexturePath = fmt.Sprintf("bojack/sprites/mugshots/%s.png", playerName)
 	p.speed = 1.9
-	p.key0 = key0
+	p.controller = controller
 	p.position = position
 	p.numberOfFrames = numberOfFrames
 	p.currentFrameIndex = 0
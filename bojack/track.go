@@ -2,8 +2,8 @@ package main
 
 import (
 	g "github.com/markov/gojira2d/pkg/graphics"
+	"github.com/markov/gojira2d/pkg/audio"
 	"container/list"
-	"math/rand"
 	"github.com/go-gl/mathgl/mgl32"
 	"github.com/go-gl/glfw/v3.2/glfw"
 )
@@ -22,16 +22,28 @@ type Track struct {
 	bottomOffset        float32
 	sizeInterpolator    float32
 	windowOfOpportunity float32
+	chart               *audio.Chart
+	timeline            *audio.Timeline
+	nextNote            int
+}
+
+// time returns the seconds of the song
+func (track *Track) time() float32 {
+	return float32(track.timeline.Time())
 }
 
 func (track *Track) Update() {
-	time := float32(glfw.GetTime())
-	if track.bars.Len() == 0 || track.bars.Front().Value.(bar).endTime < time-track.windowOfOpportunity && rand.Int31n(100) > 92 {
-		duration := rand.Float32()*(0.9-track.windowOfOpportunity) + track.windowOfOpportunity
-		size := duration * track.sizeInterpolator
+	track.timeline.Update(glfw.GetTime())
+	time := track.time()
+	notes := track.chart.Notes()
+	// The bars take 3 seconds to reach the button, at the time of their note
+	for track.nextNote < len(notes) && float32(notes[track.nextNote].Time)-3 <= time {
+		note := notes[track.nextNote]
+		track.nextNote++
+		size := float32(note.Duration) * track.sizeInterpolator
 		newBar := bar{
-			time,
-			time + duration,
+			float32(note.Time) - 3,
+			float32(note.End()) - 3,
 			size,
 			g.NewQuadPrimitive(
 				mgl32.Vec3{0, 10, 0.6},
@@ -42,6 +54,11 @@ func (track *Track) Update() {
 		newBar.quad.SetShader(g.NewShaderProgram(g.VertexShaderPrimitive2D, "", FragmentShaderTexture))
 		track.bars.PushFront(newBar)
 	}
+	// The chart starts over once played
+	if track.nextNote == len(notes) && track.bars.Len() == 0 {
+		track.nextNote = 0
+		track.timeline.Start(glfw.GetTime())
+	}
 
 	for e := track.bars.Front(); e != nil; e = e.Next() {
 		bar := e.Value.(bar)
@@ -99,7 +116,7 @@ func (track *Track) shouldPress() bool {
 		return false
 	}
 	lastBar := track.bars.Back().Value.(bar)
-	endTime := float32(glfw.GetTime()) - 3
+	endTime := track.time() - 3
 	return lastBar.creationTime < endTime && lastBar.endTime > endTime
 }
 
@@ -108,7 +125,7 @@ func (track *Track) pressOpportunity() bool {
 		return false
 	}
 	lastBar := track.bars.Back().Value.(bar)
-	endTime := float32(glfw.GetTime()) - 3
+	endTime := track.time() - 3
 	return mgl32.Abs(lastBar.creationTime-endTime) < track.windowOfOpportunity
 }
 
@@ -117,7 +134,7 @@ func (track *Track) releaseOpportunity() bool {
 		return false
 	}
 	lastBar := track.bars.Back().Value.(bar)
-	endTime := float32(glfw.GetTime()) - 3
+	endTime := track.time() - 3
 	return mgl32.Abs(lastBar.endTime-endTime) < track.windowOfOpportunity
 }
 
@@ -125,9 +142,12 @@ func (track *Track) isEmpty() bool {
 	return track.bars.Back() == nil
 }
 
-func NewTrack(win window, barHeight float32, bottomOffset float32, windowOfOpportunity float32) Track {
+func NewTrack(win window, chart *audio.Chart, barHeight float32, bottomOffset float32, windowOfOpportunity float32) Track {
 	track := Track{}
 	track.win = win
+	track.chart = chart
+	track.timeline = chart.Timeline()
+	track.timeline.Start(glfw.GetTime())
 	track.bars = list.New()
 	track.barStart = float32(0)
 	track.barHeight = barHeight
@@ -0,0 +1,126 @@
+package audio
+
+import (
+	"io"
+	"os"
+)
+
+// Backend plays the samples of a mixer, pulling them with Mixer.Mix
+type Backend interface {
+	// Open starts playing the mixer
+	Open(mixer *Mixer) error
+	Close() error
+	// Latency returns the seconds between mixing a sample and hearing it
+	Latency() float64
+}
+
+// NullBackend discards the samples, mixed when rendered. For the tests and
+// the servers without sound
+type NullBackend struct {
+	mixer  *Mixer
+	buffer []float32
+}
+
+func NewNullBackend() *NullBackend {
+	return &NullBackend{}
+}
+
+func (b *NullBackend) Open(mixer *Mixer) error {
+	b.mixer = mixer
+	return nil
+}
+
+func (b *NullBackend) Close() error {
+	b.mixer = nil
+	return nil
+}
+
+func (b *NullBackend) Latency() float64 {
+	return 0
+}
+
+// Render mixes seconds of samples, moving the voices forward
+func (b *NullBackend) Render(seconds float64) {
+	if b.mixer != nil {
+		b.buffer = render(b.mixer, seconds, b.buffer)
+	}
+}
+
+// FileBackend writes the samples to a 16 bits stereo WAV file as rendered,
+// e.g. to check the mix of a test by ear
+type FileBackend struct {
+	path   string
+	file   *os.File
+	mixer  *Mixer
+	size   uint32
+	buffer []float32
+	data   []byte
+}
+
+func NewFileBackend(path string) *FileBackend {
+	return &FileBackend{path: path}
+}
+
+func (b *FileBackend) Open(mixer *Mixer) error {
+	file, err := os.Create(b.path)
+	if err != nil {
+		return err
+	}
+	if err := writeWAVHeader(file, mixer.SampleRate(), 2, 0); err != nil {
+		file.Close()
+		return err
+	}
+	b.file = file
+	b.mixer = mixer
+	b.size = 0
+	return nil
+}
+
+// Close writes the size of the samples in the header and closes the file
+func (b *FileBackend) Close() error {
+	if b.file == nil {
+		return nil
+	}
+	file := b.file
+	b.file = nil
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		return err
+	}
+	if err := writeWAVHeader(file, b.mixer.SampleRate(), 2, b.size); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (b *FileBackend) Latency() float64 {
+	return 0
+}
+
+// Render mixes seconds of samples and writes them
+func (b *FileBackend) Render(seconds float64) error {
+	if b.file == nil {
+		return nil
+	}
+	b.buffer = render(b.mixer, seconds, b.buffer)
+	if cap(b.data) < len(b.buffer)*2 {
+		b.data = make([]byte, len(b.buffer)*2)
+	}
+	data := b.data[:len(b.buffer)*2]
+	toPCM16(b.buffer, data)
+	n, err := b.file.Write(data)
+	b.size += uint32(n)
+	return err
+}
+
+// render mixes seconds of stereo frames in buffer, grown if needed
+func render(mixer *Mixer, seconds float64, buffer []float32) []float32 {
+	size := int(seconds*float64(mixer.SampleRate())) * 2
+	if cap(buffer) < size {
+		buffer = make([]float32, size)
+	}
+	buffer = buffer[:size]
+	mixer.Mix(buffer)
+	return buffer
+}
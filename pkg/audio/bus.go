@@ -0,0 +1,61 @@
+package audio
+
+// Bus is a group of voices with a volume, e.g. the music or the effects.
+// The buses go to a parent bus, all of them to the master bus
+type Bus struct {
+	mixer  *Mixer
+	name   string
+	parent *Bus
+	volume float32
+	muted  bool
+}
+
+func (b *Bus) Name() string {
+	return b.name
+}
+
+// Parent returns the bus this one goes to, nil for the master bus
+func (b *Bus) Parent() *Bus {
+	return b.parent
+}
+
+// SetVolume changes the volume, 1 is unchanged
+func (b *Bus) SetVolume(volume float32) {
+	b.mixer.mutex.Lock()
+	defer b.mixer.mutex.Unlock()
+	if volume < 0 {
+		volume = 0
+	}
+	b.volume = volume
+}
+
+func (b *Bus) Volume() float32 {
+	b.mixer.mutex.Lock()
+	defer b.mixer.mutex.Unlock()
+	return b.volume
+}
+
+// SetMuted silences the bus, keeping its volume
+func (b *Bus) SetMuted(muted bool) {
+	b.mixer.mutex.Lock()
+	defer b.mixer.mutex.Unlock()
+	b.muted = muted
+}
+
+func (b *Bus) Muted() bool {
+	b.mixer.mutex.Lock()
+	defer b.mixer.mutex.Unlock()
+	return b.muted
+}
+
+// gain returns the volume with the parents', called with the mixer locked
+func (b *Bus) gain() float32 {
+	gain := float32(1)
+	for bus := b; bus != nil; bus = bus.parent {
+		if bus.muted {
+			return 0
+		}
+		gain *= bus.volume
+	}
+	return gain
+}
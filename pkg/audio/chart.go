@@ -0,0 +1,210 @@
+package audio
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ChartNote is a note of a chart, pressed at a time and held for a
+// duration
+type ChartNote struct {
+	Lane int
+	// Seconds of the song
+	Time     float64
+	Duration float64
+	// The same in beats
+	Beat float64
+	Hold float64
+}
+
+// End returns the seconds the note is released at
+func (n ChartNote) End() float64 {
+	return n.Time + n.Duration
+}
+
+// Chart is the notes of a song for a rhythm game, and its tempo
+type Chart struct {
+	title    string
+	song     string
+	timeline *Timeline
+	notes    []ChartNote
+	lanes    int
+}
+
+type chartNoteFile struct {
+	Lane int `json:"lane" yaml:"lane"`
+	// In beats, or seconds
+	Beat     *float64 `json:"beat" yaml:"beat"`
+	Hold     float64  `json:"hold" yaml:"hold"`
+	Time     *float64 `json:"time" yaml:"time"`
+	Duration float64  `json:"duration" yaml:"duration"`
+}
+
+type chartFile struct {
+	Title       string          `json:"title" yaml:"title"`
+	Song        string          `json:"song" yaml:"song"`
+	BPM         float64         `json:"bpm" yaml:"bpm"`
+	Offset      float64         `json:"offset" yaml:"offset"`
+	BeatsPerBar int             `json:"beats_per_bar" yaml:"beats_per_bar"`
+	Tempos      []TempoChange   `json:"tempos" yaml:"tempos"`
+	Notes       []chartNoteFile `json:"notes" yaml:"notes"`
+}
+
+// NewChartFromFile loads a chart from a YAML or JSON file:
+//
+//	title: Run For Your Life
+//	song: run.ogg
+//	bpm: 120
+//	offset: 0.08
+//	beats_per_bar: 4
+//	tempos:
+//	  - {beat: 64, bpm: 140}
+//	notes:
+//	  - {lane: 0, beat: 4}
+//	  - {lane: 1, beat: 6, hold: 1.5}
+//	  - {lane: 0, time: 12.5, duration: 0.4}
+//
+// The notes are at a beat and held for beats, or at seconds of the song and
+// held for seconds. The offset is the seconds of the first beat, the song
+// is relative to the chart
+func NewChartFromFile(filePath string) *Chart {
+	file, err := os.Open(filePath)
+	if err != nil {
+		log.Panicf("Loading chart. %s", err)
+	}
+	defer file.Close()
+	ext := strings.ToLower(filepath.Ext(filePath))
+	chart, err := ParseChart(file, ext == ".yaml" || ext == ".yml")
+	if err != nil {
+		log.Panicf("Error parsing chart %s: %v", filePath, err)
+	}
+	if chart.song != "" && !filepath.IsAbs(chart.song) {
+		chart.song = filepath.Join(filepath.Dir(filePath), chart.song)
+	}
+	return chart
+}
+
+// ParseChart parses a chart in YAML or JSON, as NewChartFromFile
+func ParseChart(reader io.Reader, isYAML bool) (*Chart, error) {
+	var f chartFile
+	if isYAML {
+		data, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(data, &f); err != nil {
+			return nil, err
+		}
+	} else if err := json.NewDecoder(reader).Decode(&f); err != nil {
+		return nil, err
+	}
+	if f.BPM <= 0 {
+		return nil, fmt.Errorf("invalid bpm %v", f.BPM)
+	}
+
+	timeline := NewTimeline(f.BPM, f.Offset)
+	if f.BeatsPerBar > 0 {
+		timeline.SetBeatsPerBar(f.BeatsPerBar)
+	}
+	for _, tempo := range f.Tempos {
+		if tempo.BPM <= 0 {
+			return nil, fmt.Errorf("invalid bpm %v at beat %v", tempo.BPM, tempo.Beat)
+		}
+		timeline.AddTempoChange(tempo.Beat, tempo.BPM)
+	}
+
+	chart := &Chart{title: f.Title, song: f.Song, timeline: timeline}
+	for i, n := range f.Notes {
+		note := ChartNote{Lane: n.Lane}
+		switch {
+		case n.Beat != nil && n.Time == nil:
+			note.Beat = *n.Beat
+			note.Hold = n.Hold
+			note.Time = timeline.TimeAt(note.Beat)
+			note.Duration = timeline.TimeAt(note.Beat+note.Hold) - note.Time
+		case n.Time != nil && n.Beat == nil:
+			note.Time = *n.Time
+			note.Duration = n.Duration
+			note.Beat = timeline.BeatAt(note.Time)
+			note.Hold = timeline.BeatAt(note.Time+note.Duration) - note.Beat
+		default:
+			return nil, fmt.Errorf("note %d needs a beat or a time", i)
+		}
+		if note.Lane < 0 || note.Duration < 0 {
+			return nil, fmt.Errorf("invalid note %d", i)
+		}
+		if note.Lane >= chart.lanes {
+			chart.lanes = note.Lane + 1
+		}
+		chart.notes = append(chart.notes, note)
+	}
+	sort.SliceStable(chart.notes, func(i, j int) bool { return chart.notes[i].Time < chart.notes[j].Time })
+	return chart, nil
+}
+
+func (c *Chart) Title() string {
+	return c.title
+}
+
+// Song returns the path of the song, empty if none
+func (c *Chart) Song() string {
+	return c.song
+}
+
+// Timeline returns the timeline of the tempo of the chart, to follow the
+// song or a clock
+func (c *Chart) Timeline() *Timeline {
+	return c.timeline
+}
+
+// NumLanes returns the lanes of the notes, the highest plus one
+func (c *Chart) NumLanes() int {
+	return c.lanes
+}
+
+// Notes returns the notes sorted by time
+func (c *Chart) Notes() []ChartNote {
+	return c.notes
+}
+
+// Lane returns the notes of a lane sorted by time
+func (c *Chart) Lane(lane int) []ChartNote {
+	var notes []ChartNote
+	for _, n := range c.notes {
+		if n.Lane == lane {
+			notes = append(notes, n)
+		}
+	}
+	return notes
+}
+
+// NotesBetween returns the notes pressed from start to before end seconds,
+// e.g. to spawn the ones coming up
+func (c *Chart) NotesBetween(start, end float64) []ChartNote {
+	first := sort.Search(len(c.notes), func(i int) bool { return c.notes[i].Time >= start })
+	last := sort.Search(len(c.notes), func(i int) bool { return c.notes[i].Time >= end })
+	if last < first {
+		last = first
+	}
+	return c.notes[first:last]
+}
+
+// Duration returns the seconds of the song until the last note is released
+func (c *Chart) Duration() float64 {
+	duration := 0.0
+	for _, n := range c.notes {
+		if n.End() > duration {
+			duration = n.End()
+		}
+	}
+	return duration
+}
@@ -0,0 +1,66 @@
+package audio
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseChart(t *testing.T) {
+	yaml := `
+title: Test
+bpm: 120
+offset: 1
+tempos:
+  - {beat: 4, bpm: 60}
+notes:
+  - {lane: 1, beat: 5, hold: 1}
+  - {beat: 2, hold: 4}
+  - {time: 1.5, duration: 0.25}
+`
+	chart, err := ParseChart(strings.NewReader(yaml), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []ChartNote{
+		{Lane: 0, Time: 1.5, Duration: 0.25, Beat: 1, Hold: 0.5},
+		{Lane: 0, Time: 2, Duration: 3, Beat: 2, Hold: 4},
+		{Lane: 1, Time: 4, Duration: 1, Beat: 5, Hold: 1},
+	}
+	notes := chart.Notes()
+	if len(notes) != len(expected) {
+		t.Fatalf("Got %v", notes)
+	}
+	for i, n := range expected {
+		o := notes[i]
+		if o.Lane != n.Lane || math.Abs(o.Time-n.Time) > 1e-9 || math.Abs(o.Duration-n.Duration) > 1e-9 ||
+			math.Abs(o.Beat-n.Beat) > 1e-9 || math.Abs(o.Hold-n.Hold) > 1e-9 {
+			t.Errorf("Got note %v, expecting %v", o, n)
+		}
+	}
+	if chart.Title() != "Test" || chart.NumLanes() != 2 || chart.Duration() != 5 {
+		t.Errorf("Got %q with %d lanes and %v seconds", chart.Title(), chart.NumLanes(), chart.Duration())
+	}
+	if between := chart.NotesBetween(1.5, 4); len(between) != 2 || between[1].Beat != 2 {
+		t.Errorf("Got %v between 1.5 and 4", between)
+	}
+	if lane := chart.Lane(1); len(lane) != 1 || lane[0].Beat != 5 {
+		t.Errorf("Got lane %v", lane)
+	}
+
+	json := `{"bpm": 100, "notes": [{"beat": 1}]}`
+	if chart, err := ParseChart(strings.NewReader(json), false); err != nil || chart.Notes()[0].Time != 0.6 {
+		t.Errorf("Got %v parsing JSON", err)
+	}
+
+	for _, invalid := range []string{
+		`{"notes": [{"beat": 1}]}`,
+		`{"bpm": 100, "notes": [{"lane": 1}]}`,
+		`{"bpm": 100, "notes": [{"beat": 1, "time": 1}]}`,
+		`{"bpm": 100, "tempos": [{"beat": 1, "bpm": 0}]}`,
+	} {
+		if _, err := ParseChart(strings.NewReader(invalid), false); err == nil {
+			t.Errorf("Parsed %s", invalid)
+		}
+	}
+}
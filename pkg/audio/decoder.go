@@ -0,0 +1,70 @@
+package audio
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Format is the encoding of an audio file
+type Format int
+
+const (
+	FORMAT_UNKNOWN Format = iota
+	FORMAT_WAV
+	FORMAT_OGG
+	FORMAT_MP3
+)
+
+// FormatOf returns the format of a file from its extension
+func FormatOf(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".wav", ".wave":
+		return FORMAT_WAV
+	case ".ogg", ".oga":
+		return FORMAT_OGG
+	case ".mp3":
+		return FORMAT_MP3
+	}
+	return FORMAT_UNKNOWN
+}
+
+func (f Format) String() string {
+	switch f {
+	case FORMAT_WAV:
+		return "wav"
+	case FORMAT_OGG:
+		return "ogg"
+	case FORMAT_MP3:
+		return "mp3"
+	}
+	return "unknown"
+}
+
+// Decoder reads the samples of an encoded file, a frame at a time being one
+// sample per channel
+type Decoder interface {
+	SampleRate() int
+	Channels() int
+	// Read fills samples with interleaved frames between -1 and 1, returning
+	// the number of samples read and io.EOF at the end
+	Read(samples []float32) (int, error)
+	// Length returns the number of frames, -1 if not known
+	Length() int64
+	// SetPosition moves to a frame
+	SetPosition(frame int64) error
+}
+
+// NewDecoder creates the decoder of a format, reading from reader
+func NewDecoder(reader io.ReadSeeker, format Format) (Decoder, error) {
+	switch format {
+	case FORMAT_WAV:
+		return newWAVDecoder(reader)
+	case FORMAT_OGG:
+		return newOggDecoder(reader)
+	case FORMAT_MP3:
+		return newMP3Decoder(reader)
+	}
+	return nil, fmt.Errorf("unknown audio format %v", format)
+}
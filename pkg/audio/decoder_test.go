@@ -0,0 +1,74 @@
+package audio
+
+import (
+	"os"
+	"testing"
+)
+
+// The fixtures are a second of a mono tone in Ogg Vorbis, from the tests of
+// github.com/jfreymuth/oggvorbis, and 8 silent MPEG-1 layer III frames
+func TestDecodeFiles(t *testing.T) {
+	var tests = []struct {
+		path     string
+		format   Format
+		rate     int
+		channels int
+		frames   int64
+		silent   bool
+	}{
+		{"testdata/mono.ogg", FORMAT_OGG, 44100, 1, 44100, false},
+		{"testdata/silence.mp3", FORMAT_MP3, 44100, 2, 8 * 1152, true},
+	}
+	for _, test := range tests {
+		if FormatOf(test.path) != test.format {
+			t.Errorf("%s: format %v", test.path, FormatOf(test.path))
+		}
+		sound := NewSoundFromFile(test.path)
+		if sound.SampleRate() != test.rate || sound.Channels() != test.channels {
+			t.Errorf("%s: %d channels at %dHz", test.path, sound.Channels(), sound.SampleRate())
+		}
+		if sound.Frames() != test.frames {
+			t.Errorf("%s: %d frames, expecting %d", test.path, sound.Frames(), test.frames)
+		}
+		peak := float32(0)
+		for _, s := range sound.samples {
+			if s > peak {
+				peak = s
+			} else if -s > peak {
+				peak = -s
+			}
+		}
+		if (peak == 0) != test.silent || peak > 1 {
+			t.Errorf("%s: peak at %v", test.path, peak)
+		}
+
+		// Streamed from the middle, the same samples
+		stream := NewStreamFromFile(test.path)
+		middle := sound.Frames() / 2
+		for _, index := range []int64{middle, middle + 1, 0, sound.Frames() - 1} {
+			frame := stream.frame(index)
+			if frame == nil {
+				t.Errorf("%s: no frame %d", test.path, index)
+				continue
+			}
+			if !near(frame[0], sound.frame(index)[0]) {
+				t.Errorf("%s: frame %d streamed %v, decoded %v", test.path, index, frame, sound.frame(index))
+			}
+		}
+		if stream.frame(sound.Frames()) != nil {
+			t.Errorf("%s: frame past the end", test.path)
+		}
+		if err := stream.Close(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	file, err := os.Open("testdata/mono.ogg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := DecodeSound(file, FORMAT_MP3); err == nil {
+		t.Error("Decoded Ogg Vorbis as MP3")
+	}
+}
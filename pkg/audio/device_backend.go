@@ -0,0 +1,83 @@
+package audio
+
+import (
+	"sync"
+
+	"github.com/hajimehoshi/oto"
+)
+
+// DefaultDeviceBuffer is the seconds buffered by the sound card, longer
+// crackles less but is heard later
+const DefaultDeviceBuffer = 0.05
+
+// DeviceBackend plays the mixer on the sound card
+type DeviceBackend struct {
+	bufferSeconds float64
+	chunkSeconds  float64
+	context       *oto.Context
+	player        *oto.Player
+	done          chan struct{}
+	wait          sync.WaitGroup
+}
+
+// NewDeviceBackend creates a backend buffering seconds, e.g.
+// DefaultDeviceBuffer
+func NewDeviceBackend(bufferSeconds float64) *DeviceBackend {
+	return &DeviceBackend{bufferSeconds: bufferSeconds}
+}
+
+func (b *DeviceBackend) Open(mixer *Mixer) error {
+	bufferFrames := int(b.bufferSeconds * float64(mixer.SampleRate()))
+	if bufferFrames < 256 {
+		bufferFrames = 256
+	}
+	context, err := oto.NewContext(mixer.SampleRate(), 2, 2, bufferFrames*4)
+	if err != nil {
+		return err
+	}
+	b.context = context
+	b.player = context.NewPlayer()
+	b.done = make(chan struct{})
+	// Mixed a quarter of the buffer at a time, the write blocks while full
+	chunkFrames := bufferFrames / 4
+	b.chunkSeconds = float64(chunkFrames) / float64(mixer.SampleRate())
+	b.wait.Add(1)
+	go b.play(mixer, chunkFrames)
+	return nil
+}
+
+func (b *DeviceBackend) play(mixer *Mixer, chunkFrames int) {
+	defer b.wait.Done()
+	samples := make([]float32, chunkFrames*2)
+	data := make([]byte, chunkFrames*4)
+	for {
+		select {
+		case <-b.done:
+			return
+		default:
+		}
+		mixer.Mix(samples)
+		toPCM16(samples, data)
+		if _, err := b.player.Write(data); err != nil {
+			return
+		}
+	}
+}
+
+func (b *DeviceBackend) Close() error {
+	if b.context == nil {
+		return nil
+	}
+	close(b.done)
+	b.wait.Wait()
+	b.player.Close()
+	err := b.context.Close()
+	b.context = nil
+	b.player = nil
+	return err
+}
+
+// Latency returns the buffer and the chunk being mixed
+func (b *DeviceBackend) Latency() float64 {
+	return b.bufferSeconds + b.chunkSeconds
+}
@@ -0,0 +1,232 @@
+package audio
+
+import (
+	"sync"
+)
+
+// DefaultSampleRate is the sample rate of the mixers, the sounds at other
+// rates are resampled
+const DefaultSampleRate = 44100
+
+// Mixer mixes the voices playing into stereo samples, pulled by a backend
+// from its own goroutine. The voices and the buses can be changed from any
+// goroutine:
+//
+//	mixer := audio.NewMixer(audio.DefaultSampleRate)
+//	mixer.Start(audio.NewDeviceBackend(audio.DefaultDeviceBuffer))
+//	defer mixer.Close()
+//	music := mixer.NewBus("music", nil)
+//	effects := mixer.NewBus("effects", nil)
+//	song := mixer.PlayStream(audio.NewStreamFromFile("song.ogg"), music)
+//	song.SetLoop(true)
+//	song.FadeIn(2)
+//	...
+//	mixer.Play(hit, effects).SetPan(-0.5)
+type Mixer struct {
+	mutex      sync.Mutex
+	sampleRate int
+	master     *Bus
+	voices     []*Voice
+	// Frames mixed since the start
+	frames  int64
+	backend Backend
+	// One Mix at a time, the streams are decoded by Mix only
+	mixMutex sync.Mutex
+	streams  []*Stream
+}
+
+// NewMixer creates a mixer at a sample rate, e.g. DefaultSampleRate
+func NewMixer(sampleRate int) *Mixer {
+	m := &Mixer{sampleRate: sampleRate}
+	m.master = &Bus{mixer: m, name: "master", volume: 1}
+	return m
+}
+
+func (m *Mixer) SampleRate() int {
+	return m.sampleRate
+}
+
+// Master returns the bus all the others go to
+func (m *Mixer) Master() *Bus {
+	return m.master
+}
+
+// NewBus creates a bus going to a parent, the master bus if nil
+func (m *Mixer) NewBus(name string, parent *Bus) *Bus {
+	if parent == nil {
+		parent = m.master
+	}
+	return &Bus{mixer: m, name: name, parent: parent, volume: 1}
+}
+
+// NewVoice creates a stopped voice of a sound on a bus, the master bus if
+// nil. Set it up and Play it
+func (m *Mixer) NewVoice(sound *Sound, bus *Bus) *Voice {
+	return m.newVoice(sound, bus)
+}
+
+// NewStreamVoice creates a stopped voice of a stream on a bus
+func (m *Mixer) NewStreamVoice(stream *Stream, bus *Bus) *Voice {
+	return m.newVoice(stream, bus)
+}
+
+func (m *Mixer) newVoice(source source, bus *Bus) *Voice {
+	if bus == nil {
+		bus = m.master
+	}
+	channels := source.channels()
+	return &Voice{
+		mixer:   m,
+		source:  source,
+		bus:     bus,
+		volume:  1,
+		pitch:   1,
+		fade:    1,
+		current: make([]float32, channels),
+		next:    make([]float32, channels),
+	}
+}
+
+// Play plays a sound on a bus, the master bus if nil
+func (m *Mixer) Play(sound *Sound, bus *Bus) *Voice {
+	voice := m.NewVoice(sound, bus)
+	voice.Play()
+	return voice
+}
+
+// PlayStream plays a stream on a bus, the master bus if nil
+func (m *Mixer) PlayStream(stream *Stream, bus *Bus) *Voice {
+	voice := m.NewStreamVoice(stream, bus)
+	voice.Play()
+	return voice
+}
+
+// NumVoices returns the number of voices playing or paused
+func (m *Mixer) NumVoices() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return len(m.voices)
+}
+
+// StopAll stops all the voices
+func (m *Mixer) StopAll() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, v := range m.voices {
+		v.state = voiceStopped
+		v.position = 0
+	}
+	m.voices = nil
+}
+
+// Time returns the seconds mixed since the start, ahead of what's heard by
+// the latency of the backend
+func (m *Mixer) Time() float64 {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return float64(m.frames) / float64(m.sampleRate)
+}
+
+// Start opens a backend to play the mixer
+func (m *Mixer) Start(backend Backend) error {
+	if err := backend.Open(m); err != nil {
+		return err
+	}
+	m.mutex.Lock()
+	m.backend = backend
+	m.mutex.Unlock()
+	return nil
+}
+
+// Close closes the backend and stops the voices
+func (m *Mixer) Close() error {
+	m.mutex.Lock()
+	backend := m.backend
+	m.backend = nil
+	m.mutex.Unlock()
+	m.StopAll()
+	if backend == nil {
+		return nil
+	}
+	return backend.Close()
+}
+
+// Latency returns the seconds between mixing and hearing, of the backend
+func (m *Mixer) Latency() float64 {
+	m.mutex.Lock()
+	backend := m.backend
+	m.mutex.Unlock()
+	if backend == nil {
+		return 0
+	}
+	return backend.Latency()
+}
+
+// Mix fills out with the next interleaved stereo frames of the voices,
+// between -1 and 1. Called by the backends
+func (m *Mixer) Mix(out []float32) {
+	m.mixMutex.Lock()
+	defer m.mixMutex.Unlock()
+
+	// The streams playing decode ahead before locking, so the voices can be
+	// changed meanwhile
+	m.mutex.Lock()
+	streams := m.streams[:0]
+	for _, v := range m.voices {
+		if stream, ok := v.source.(*Stream); ok && v.state == voicePlaying {
+			streams = append(streams, stream)
+		}
+	}
+	m.mutex.Unlock()
+	for i, stream := range streams {
+		stream.decodeAhead()
+		streams[i] = nil
+	}
+	m.streams = streams[:0]
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for i := range out {
+		out[i] = 0
+	}
+	frames := len(out) / 2
+	voices := m.voices[:0]
+	for _, v := range m.voices {
+		if v.state == voicePlaying {
+			v.mix(out[:frames*2], v.bus.gain())
+		}
+		if v.state != voiceStopped {
+			voices = append(voices, v)
+		}
+	}
+	for i := len(voices); i < len(m.voices); i++ {
+		m.voices[i] = nil
+	}
+	m.voices = voices
+	for i, s := range out {
+		if s > 1 {
+			out[i] = 1
+		} else if s < -1 {
+			out[i] = -1
+		}
+	}
+	m.frames += int64(frames)
+}
+
+func (m *Mixer) add(v *Voice) {
+	for _, o := range m.voices {
+		if o == v {
+			return
+		}
+	}
+	m.voices = append(m.voices, v)
+}
+
+func (m *Mixer) remove(v *Voice) {
+	for i, o := range m.voices {
+		if o == v {
+			m.voices = append(m.voices[:i], m.voices[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package audio
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+)
+
+func near(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-4
+}
+
+func constantSound(frames int, value float32) *Sound {
+	samples := make([]float32, frames)
+	for i := range samples {
+		samples[i] = value
+	}
+	return NewSound(100, 1, samples)
+}
+
+func TestMixerVoices(t *testing.T) {
+	mixer := NewMixer(100)
+	out := make([]float32, 8)
+
+	effects := mixer.NewBus("effects", nil)
+	effects.SetVolume(0.5)
+	voice := mixer.Play(constantSound(2, 1), effects)
+	voice.SetPan(-0.5)
+	mixer.Mix(out)
+	if !near(out[0], 0.5) || !near(out[1], 0.25) || out[4] != 0 {
+		t.Errorf("Got %v", out)
+	}
+	if voice.Playing() || mixer.NumVoices() != 0 {
+		t.Error("Still playing after the end")
+	}
+
+	// Resampled, half the speed
+	voice = mixer.Play(NewSound(100, 1, []float32{0, 1, 0}), nil)
+	voice.SetPitch(0.5)
+	mixer.Mix(out)
+	if !near(out[0], 0) || !near(out[2], 0.5) || !near(out[4], 1) || !near(out[6], 0.5) {
+		t.Errorf("Got %v at half pitch", out)
+	}
+
+	mixer.StopAll()
+	voice = mixer.Play(NewSound(100, 1, []float32{0.1, 0.2, 0.3}), nil)
+	voice.SetLoop(true)
+	voice.SetLoopStart(0.01)
+	mixer.Mix(out)
+	for i, s := range []float32{0.1, 0.2, 0.3, 0.2} {
+		if !near(out[i*2], s) {
+			t.Errorf("Got %v looping", out)
+			break
+		}
+	}
+	if !voice.Playing() || !near(float32(voice.Position()), 0.02) {
+		t.Errorf("Looping voice at %v", voice.Position())
+	}
+
+	effects.SetMuted(true)
+	voice.Stop()
+	mixer.Play(constantSound(10, 1), effects)
+	mixer.Mix(out)
+	if out[0] != 0 {
+		t.Errorf("Got %v muted", out)
+	}
+}
+
+func TestMixerFades(t *testing.T) {
+	mixer := NewMixer(100)
+	out := make([]float32, 8)
+
+	voice := mixer.Play(constantSound(100, 1), nil)
+	voice.FadeIn(0.04)
+	mixer.Mix(out)
+	for i, s := range []float32{0, 0.25, 0.5, 0.75} {
+		if !near(out[i*2], s) {
+			t.Errorf("Got %v fading in", out)
+			break
+		}
+	}
+	voice.FadeOut(0.02)
+	mixer.Mix(out)
+	if !near(out[0], 1) || !near(out[2], 0.5) || out[4] != 0 || out[6] != 0 {
+		t.Errorf("Got %v fading out", out)
+	}
+	if voice.Playing() {
+		t.Error("Playing after the fade out")
+	}
+
+	voice.Play()
+	voice.Pause()
+	mixer.Mix(out)
+	if out[0] != 0 || !voice.Playing() || voice.Position() != 0 {
+		t.Errorf("Got %v paused", out)
+	}
+}
+
+func TestStreamVoice(t *testing.T) {
+	var data []byte
+	for i := 0; i < streamBufferFrames*2+10; i++ {
+		data = append(data, 0, byte(i%64))
+	}
+	decoder, err := NewDecoder(bytes.NewReader(wavFile(wavFormatPCM, 1, 16, data)), FORMAT_WAV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := NewStream(decoder)
+	mixer := NewMixer(8000)
+	backend := NewNullBackend()
+	mixer.Start(backend)
+	voice := mixer.PlayStream(stream, nil)
+	if d := voice.Duration(); d*8000 != streamBufferFrames*2+10 {
+		t.Errorf("Got duration %v", d)
+	}
+
+	out := make([]float32, 2*(streamBufferFrames*2+10))
+	mixer.Mix(out)
+	for i := 0; i < len(out)/2; i++ {
+		if expected := float32(i%64) / 128; !near(out[i*2], expected) {
+			t.Fatalf("Got %v at %d, expecting %v", out[i*2], i, expected)
+		}
+	}
+	backend.Render(0.01)
+	if voice.Playing() {
+		t.Error("Stream playing after the end")
+	}
+
+	voice.Play()
+	voice.Seek(1)
+	mixer.Mix(out[:2])
+	if expected := float32(8000%64) / 128; !near(out[0], expected) {
+		t.Errorf("Got %v after seeking, expecting %v", out[0], expected)
+	}
+}
+
+// lockCheckDecoder counts the reads done with the lock of the mixer held
+type lockCheckDecoder struct {
+	Decoder
+	mixer       *Mixer
+	reads       int
+	lockedReads int
+}
+
+func (d *lockCheckDecoder) Read(samples []float32) (int, error) {
+	d.reads++
+	unlocked := make(chan bool)
+	go func() {
+		d.mixer.mutex.Lock()
+		d.mixer.mutex.Unlock()
+		close(unlocked)
+	}()
+	select {
+	case <-unlocked:
+	case <-time.After(time.Second):
+		d.lockedReads++
+	}
+	return d.Decoder.Read(samples)
+}
+
+func TestStreamDecodeAhead(t *testing.T) {
+	frames := streamBufferFrames*2 + 10
+	data := make([]byte, frames*2)
+	wav, err := NewDecoder(bytes.NewReader(wavFile(wavFormatPCM, 1, 16, data)), FORMAT_WAV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mixer := NewMixer(8000)
+	decoder := &lockCheckDecoder{Decoder: wav, mixer: mixer}
+	voice := mixer.PlayStream(NewStream(decoder), nil)
+
+	out := make([]float32, 2*1000)
+	for played := 0; played <= frames; played += 1000 {
+		mixer.Mix(out)
+	}
+	if voice.Playing() || decoder.reads < 3 {
+		t.Errorf("Stream playing after %d reads", decoder.reads)
+	}
+	if decoder.lockedReads != 0 {
+		t.Errorf("%d of %d reads with the mixer locked", decoder.lockedReads, decoder.reads)
+	}
+}
@@ -0,0 +1,63 @@
+package audio
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/hajimehoshi/go-mp3"
+)
+
+// mp3Decoder reads MP3 with a pure Go decoder, always giving 16 bits stereo
+type mp3Decoder struct {
+	decoder *mp3.Decoder
+	buffer  []byte
+}
+
+const mp3FrameSize = 4
+
+func newMP3Decoder(reader io.ReadSeeker) (*mp3Decoder, error) {
+	decoder, err := mp3.NewDecoder(reader)
+	if err != nil {
+		return nil, err
+	}
+	return &mp3Decoder{decoder: decoder}, nil
+}
+
+func (d *mp3Decoder) SampleRate() int {
+	return d.decoder.SampleRate()
+}
+
+func (d *mp3Decoder) Channels() int {
+	return 2
+}
+
+func (d *mp3Decoder) Length() int64 {
+	if length := d.decoder.Length(); length > 0 {
+		return length / mp3FrameSize
+	}
+	return -1
+}
+
+func (d *mp3Decoder) SetPosition(frame int64) error {
+	_, err := d.decoder.Seek(frame*mp3FrameSize, io.SeekStart)
+	return err
+}
+
+func (d *mp3Decoder) Read(samples []float32) (int, error) {
+	size := (len(samples) / 2) * mp3FrameSize
+	if cap(d.buffer) < size {
+		d.buffer = make([]byte, size)
+	}
+	n, err := io.ReadFull(d.decoder, d.buffer[:size])
+	if err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	count := n / mp3FrameSize * 2
+	for i := 0; i < count; i++ {
+		samples[i] = float32(int16(binary.LittleEndian.Uint16(d.buffer[i*2:]))) / (1 << 15)
+	}
+	if count > 0 && err == io.EOF {
+		err = nil
+	}
+	return count, err
+}
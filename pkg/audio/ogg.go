@@ -0,0 +1,44 @@
+package audio
+
+import (
+	"io"
+
+	"github.com/jfreymuth/oggvorbis"
+)
+
+// oggDecoder reads Ogg Vorbis with a pure Go decoder
+type oggDecoder struct {
+	reader *oggvorbis.Reader
+}
+
+func newOggDecoder(reader io.ReadSeeker) (*oggDecoder, error) {
+	r, err := oggvorbis.NewReader(reader)
+	if err != nil {
+		return nil, err
+	}
+	return &oggDecoder{reader: r}, nil
+}
+
+func (d *oggDecoder) SampleRate() int {
+	return d.reader.SampleRate()
+}
+
+func (d *oggDecoder) Channels() int {
+	return d.reader.Channels()
+}
+
+func (d *oggDecoder) Length() int64 {
+	if length := d.reader.Length(); length > 0 {
+		return length
+	}
+	return -1
+}
+
+func (d *oggDecoder) SetPosition(frame int64) error {
+	return d.reader.SetPosition(frame)
+}
+
+func (d *oggDecoder) Read(samples []float32) (int, error) {
+	channels := d.reader.Channels()
+	return d.reader.Read(samples[:len(samples)-len(samples)%channels])
+}
@@ -0,0 +1,276 @@
+package audio
+
+import (
+	"io"
+	"log"
+	"os"
+)
+
+// source is what a voice plays, read a frame at a time
+type source interface {
+	sampleRate() int
+	channels() int
+	// frame returns the samples of a frame, nil past the end
+	frame(index int64) []float32
+	// length returns the number of frames, -1 if not known
+	length() int64
+}
+
+// Sound is a short sound decoded in memory, e.g. an effect. Many voices can
+// play it at once
+type Sound struct {
+	rate       int
+	numChannel int
+	samples    []float32
+}
+
+// NewSound creates a sound from interleaved samples between -1 and 1
+func NewSound(sampleRate, channels int, samples []float32) *Sound {
+	return &Sound{
+		rate:       sampleRate,
+		numChannel: channels,
+		samples:    samples[:len(samples)-len(samples)%channels],
+	}
+}
+
+// DecodeSound decodes all the samples of a reader
+func DecodeSound(reader io.ReadSeeker, format Format) (*Sound, error) {
+	decoder, err := NewDecoder(reader, format)
+	if err != nil {
+		return nil, err
+	}
+	var samples []float32
+	if length := decoder.Length(); length > 0 {
+		samples = make([]float32, 0, length*int64(decoder.Channels()))
+	}
+	buffer := make([]float32, 4096*decoder.Channels())
+	for {
+		n, err := decoder.Read(buffer)
+		samples = append(samples, buffer[:n]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			break
+		}
+	}
+	return NewSound(decoder.SampleRate(), decoder.Channels(), samples), nil
+}
+
+// NewSoundFromFile decodes a WAV, Ogg Vorbis or MP3 file
+func NewSoundFromFile(path string) *Sound {
+	file, err := os.Open(path)
+	if err != nil {
+		log.Panicf("Loading sound. %s", err)
+	}
+	defer file.Close()
+	sound, err := DecodeSound(file, FormatOf(path))
+	if err != nil {
+		log.Panicf("Error parsing sound %s: %v", path, err)
+	}
+	return sound
+}
+
+func (s *Sound) SampleRate() int {
+	return s.rate
+}
+
+func (s *Sound) Channels() int {
+	return s.numChannel
+}
+
+// Frames returns the number of frames, a sample per channel
+func (s *Sound) Frames() int64 {
+	return int64(len(s.samples) / s.numChannel)
+}
+
+// Duration returns the length in seconds
+func (s *Sound) Duration() float64 {
+	return float64(s.Frames()) / float64(s.rate)
+}
+
+func (s *Sound) sampleRate() int {
+	return s.rate
+}
+
+func (s *Sound) channels() int {
+	return s.numChannel
+}
+
+func (s *Sound) frame(index int64) []float32 {
+	if index < 0 || index >= s.Frames() {
+		return nil
+	}
+	start := index * int64(s.numChannel)
+	return s.samples[start : start+int64(s.numChannel)]
+}
+
+func (s *Sound) length() int64 {
+	return s.Frames()
+}
+
+// Frames decoded at a time by a stream
+const streamBufferFrames = 4096
+
+// Stream is a long track, e.g. the music, decoded while it plays. A stream
+// is played by one voice at a time, close it when it's stopped.
+//
+// The mixer decodes the next frames ahead without holding its lock. A seek,
+// or a voice playing more than streamBufferFrames in one mix, decodes with
+// the lock held, blocking the changes to the voices until done
+type Stream struct {
+	decoder Decoder
+	closer  io.Closer
+	buffer  []float32
+	// The frame of the start of the buffer, and the frames in it
+	start  int64
+	frames int
+	ended  bool
+	// The frames decoded after the buffer, ready to take its place
+	ahead       []float32
+	aheadFrames int
+	aheadEnded  bool
+	aheadReady  bool
+}
+
+// NewStream creates a stream reading from a decoder
+func NewStream(decoder Decoder) *Stream {
+	return &Stream{
+		decoder: decoder,
+		buffer:  make([]float32, streamBufferFrames*decoder.Channels()),
+		// The buffer keeps a frame when filled
+		ahead: make([]float32, (streamBufferFrames-1)*decoder.Channels()),
+	}
+}
+
+// NewStreamFromFile opens a WAV, Ogg Vorbis or MP3 file to stream. The file
+// stays open until Close
+func NewStreamFromFile(path string) *Stream {
+	file, err := os.Open(path)
+	if err != nil {
+		log.Panicf("Loading stream. %s", err)
+	}
+	decoder, err := NewDecoder(file, FormatOf(path))
+	if err != nil {
+		file.Close()
+		log.Panicf("Error parsing stream %s: %v", path, err)
+	}
+	stream := NewStream(decoder)
+	stream.closer = file
+	return stream
+}
+
+// Close closes the file of the stream
+func (s *Stream) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	err := s.closer.Close()
+	s.closer = nil
+	return err
+}
+
+func (s *Stream) SampleRate() int {
+	return s.decoder.SampleRate()
+}
+
+func (s *Stream) Channels() int {
+	return s.decoder.Channels()
+}
+
+// Duration returns the length in seconds, -1 if not known
+func (s *Stream) Duration() float64 {
+	length := s.decoder.Length()
+	if length < 0 {
+		return -1
+	}
+	return float64(length) / float64(s.decoder.SampleRate())
+}
+
+func (s *Stream) sampleRate() int {
+	return s.decoder.SampleRate()
+}
+
+func (s *Stream) channels() int {
+	return s.decoder.Channels()
+}
+
+func (s *Stream) length() int64 {
+	return s.decoder.Length()
+}
+
+func (s *Stream) frame(index int64) []float32 {
+	if index < 0 {
+		return nil
+	}
+	if index < s.start || index > s.start+int64(s.frames) {
+		if err := s.decoder.SetPosition(index); err != nil {
+			return nil
+		}
+		s.start, s.frames, s.ended = index, 0, false
+		s.aheadReady = false
+	}
+	if index == s.start+int64(s.frames) {
+		if s.ended {
+			return nil
+		}
+		s.fill()
+		if index >= s.start+int64(s.frames) {
+			return nil
+		}
+	}
+	channels := s.decoder.Channels()
+	offset := int(index-s.start) * channels
+	return s.buffer[offset : offset+channels]
+}
+
+// fill moves to the next frames, the ones decoded ahead if ready, keeping
+// the last one for the interpolation
+func (s *Stream) fill() {
+	channels := s.decoder.Channels()
+	kept := 0
+	if s.frames > 0 {
+		copy(s.buffer, s.buffer[(s.frames-1)*channels:s.frames*channels])
+		kept = 1
+	}
+	s.start += int64(s.frames - kept)
+	s.frames = kept
+	if s.aheadReady {
+		copy(s.buffer[kept*channels:], s.ahead[:s.aheadFrames*channels])
+		s.frames += s.aheadFrames
+		s.ended = s.aheadEnded
+		s.aheadReady = false
+		return
+	}
+	n, ended := s.decode(s.buffer[kept*channels:])
+	s.frames += n
+	s.ended = ended
+}
+
+// decodeAhead decodes the frames after the buffer, called by the mixer
+// without its lock
+func (s *Stream) decodeAhead() {
+	if s.aheadReady || s.ended {
+		return
+	}
+	s.aheadFrames, s.aheadEnded = s.decode(s.ahead)
+	s.aheadReady = true
+}
+
+// decode reads frames until samples is full, returns the frames read and
+// true at the end
+func (s *Stream) decode(samples []float32) (int, bool) {
+	channels := s.decoder.Channels()
+	frames := 0
+	for frames*channels < len(samples) {
+		n, err := s.decoder.Read(samples[frames*channels:])
+		frames += n / channels
+		if err != nil || n == 0 {
+			return frames, true
+		}
+	}
+	return frames, false
+}
@@ -0,0 +1,287 @@
+package audio
+
+import (
+	"math"
+	"sort"
+)
+
+// TempoChange changes the BPM from a beat on
+type TempoChange struct {
+	Beat float64 `json:"beat" yaml:"beat"`
+	BPM  float64 `json:"bpm" yaml:"bpm"`
+}
+
+// tempoSegment is a tempo and the seconds from the first beat it starts at
+type tempoSegment struct {
+	beat float64
+	bpm  float64
+	time float64
+}
+
+const (
+	// Tempo of the timelines created without a valid one
+	DefaultBPM = 120
+	// Seconds the clock can drift from the song before jumping to it
+	timelineResync = 0.05
+	// Part of the drift corrected per update
+	timelineDrift = 0.1
+)
+
+// Timeline is the position in a song in beats and bars, from its tempo and
+// the offset of its first beat. It follows a voice playing the song, hiding
+// the latency of the backend and the steps of the mixing, or a clock if
+// there's no song:
+//
+//	timeline := audio.NewTimeline(120, 0.05)
+//	timeline.AddTempoChange(64, 140)
+//	timeline.SetOnBeat(func(beat int) { speaker.Pulse() })
+//	timeline.Follow(mixer.PlayStream(song, music))
+//	...
+//	timeline.Update(glfw.GetTime())
+//	if timeline.Beat() >= 16 {
+//		dancer.Jump()
+//	}
+type Timeline struct {
+	tempos      []tempoSegment
+	offset      float64
+	beatsPerBar int
+	latency     float64
+	voice       *Voice
+	running     bool
+	startTime   float64
+	// The seconds of the song heard and the clock when updated
+	time  float64
+	clock float64
+	// The whole beat of the last callback
+	lastBeat int
+	onBeat   func(beat int)
+	onBar    func(bar int)
+}
+
+// NewTimeline creates the timeline of a song at a tempo, the first beat
+// offset seconds from the start. 4 beats per bar, DefaultBPM if bpm isn't
+// positive
+func NewTimeline(bpm, offset float64) *Timeline {
+	if bpm <= 0 || math.IsNaN(bpm) || math.IsInf(bpm, 0) {
+		bpm = DefaultBPM
+	}
+	return &Timeline{
+		tempos:      []tempoSegment{{bpm: bpm}},
+		offset:      offset,
+		beatsPerBar: 4,
+	}
+}
+
+// AddTempoChange changes the BPM from a beat on, ignored if not positive
+func (t *Timeline) AddTempoChange(beat, bpm float64) {
+	if bpm <= 0 || math.IsNaN(bpm) || math.IsInf(bpm, 0) {
+		return
+	}
+	if beat <= 0 {
+		t.tempos[0].bpm = bpm
+	} else {
+		i := sort.Search(len(t.tempos), func(i int) bool { return t.tempos[i].beat >= beat })
+		if i < len(t.tempos) && t.tempos[i].beat == beat {
+			t.tempos[i].bpm = bpm
+		} else {
+			t.tempos = append(t.tempos, tempoSegment{})
+			copy(t.tempos[i+1:], t.tempos[i:])
+			t.tempos[i] = tempoSegment{beat: beat, bpm: bpm}
+		}
+	}
+	for i := 1; i < len(t.tempos); i++ {
+		previous := &t.tempos[i-1]
+		t.tempos[i].time = previous.time + (t.tempos[i].beat-previous.beat)*60/previous.bpm
+	}
+}
+
+// TempoChanges returns the tempo of the start and its changes
+func (t *Timeline) TempoChanges() []TempoChange {
+	changes := make([]TempoChange, len(t.tempos))
+	for i, tempo := range t.tempos {
+		changes[i] = TempoChange{Beat: tempo.beat, BPM: tempo.bpm}
+	}
+	return changes
+}
+
+// SetBeatsPerBar changes the beats of a bar, 4 by default
+func (t *Timeline) SetBeatsPerBar(beats int) {
+	if beats > 0 {
+		t.beatsPerBar = beats
+	}
+}
+
+func (t *Timeline) BeatsPerBar() int {
+	return t.beatsPerBar
+}
+
+func (t *Timeline) Offset() float64 {
+	return t.offset
+}
+
+// SetLatency sets the seconds the song is heard later than played, on top
+// of the latency of the backend, e.g. calibrated by the player
+func (t *Timeline) SetLatency(seconds float64) {
+	t.latency = seconds
+}
+
+func (t *Timeline) Latency() float64 {
+	return t.latency
+}
+
+// Follow takes the position from the voice playing the song, nil to stop
+func (t *Timeline) Follow(voice *Voice) {
+	t.voice = voice
+	t.running = false
+}
+
+// Start starts the song at a time of the clock, for the timelines without
+// a voice to follow
+func (t *Timeline) Start(time float64) {
+	t.voice = nil
+	t.running = true
+	t.startTime = time
+	t.clock = time
+	t.jump(-t.latency)
+}
+
+// Update moves to the position of the song at a time of the clock, e.g.
+// glfw.GetTime, calling the callbacks of the beats and the bars passed.
+// Call it once per frame
+func (t *Timeline) Update(time float64) {
+	elapsed := time - t.clock
+	t.clock = time
+	if t.voice == nil {
+		if t.running {
+			t.advance(time - t.startTime - t.latency)
+		}
+		return
+	}
+	v := t.voice
+	v.mixer.mutex.Lock()
+	playing := v.state == voicePlaying
+	pitch := v.pitch
+	reported := v.position / float64(v.source.sampleRate())
+	v.mixer.mutex.Unlock()
+	reported -= v.mixer.Latency() + t.latency
+	if !playing {
+		t.running = false
+		t.advance(t.time)
+		return
+	}
+	if !t.running {
+		t.running = true
+		t.jump(reported)
+		return
+	}
+	// The voice moves a mixed chunk at a time, the clock in between
+	estimate := t.time + elapsed*pitch
+	if math.Abs(estimate-reported) > timelineResync {
+		t.jump(reported)
+	} else {
+		t.advance(estimate + (reported-estimate)*timelineDrift)
+	}
+}
+
+// jump moves to a time without calling back the beats in between
+func (t *Timeline) jump(time float64) {
+	t.time = time
+	t.lastBeat = int(math.Floor(t.BeatAt(time)))
+	if t.BeatAt(time) == float64(t.lastBeat) {
+		t.lastBeat--
+	}
+	t.advance(time)
+}
+
+func (t *Timeline) advance(time float64) {
+	t.time = time
+	beat := int(math.Floor(t.BeatAt(time)))
+	if beat < t.lastBeat {
+		// Looped or rewound
+		t.lastBeat = beat
+		return
+	}
+	for t.lastBeat < beat {
+		t.lastBeat++
+		if t.lastBeat < 0 {
+			continue
+		}
+		if t.lastBeat%t.beatsPerBar == 0 && t.onBar != nil {
+			t.onBar(t.lastBeat / t.beatsPerBar)
+		}
+		if t.onBeat != nil {
+			t.onBeat(t.lastBeat)
+		}
+	}
+}
+
+// SetOnBeat sets the callback of each beat of the song, from 0. Called by
+// Update
+func (t *Timeline) SetOnBeat(callback func(beat int)) {
+	t.onBeat = callback
+}
+
+// SetOnBar sets the callback of each bar of the song, from 0, before the
+// callback of its first beat
+func (t *Timeline) SetOnBar(callback func(bar int)) {
+	t.onBar = callback
+}
+
+// Time returns the seconds of the song heard
+func (t *Timeline) Time() float64 {
+	return t.time
+}
+
+// Beat returns the beat heard, negative before the first
+func (t *Timeline) Beat() float64 {
+	return t.BeatAt(t.time)
+}
+
+// Bar returns the bar heard, from 0
+func (t *Timeline) Bar() int {
+	return int(math.Floor(t.Beat() / float64(t.beatsPerBar)))
+}
+
+// BeatInBar returns the beat in the bar heard, from 0 to BeatsPerBar
+func (t *Timeline) BeatInBar() float64 {
+	beat := math.Mod(t.Beat(), float64(t.beatsPerBar))
+	if beat < 0 {
+		beat += float64(t.beatsPerBar)
+	}
+	return beat
+}
+
+// BPM returns the tempo heard
+func (t *Timeline) BPM() float64 {
+	return t.BPMAt(t.Beat())
+}
+
+// BeatAt returns the beat at seconds of the song
+func (t *Timeline) BeatAt(time float64) float64 {
+	time -= t.offset
+	i := sort.Search(len(t.tempos), func(i int) bool { return t.tempos[i].time > time }) - 1
+	if i < 0 {
+		i = 0
+	}
+	tempo := &t.tempos[i]
+	return tempo.beat + (time-tempo.time)*tempo.bpm/60
+}
+
+// TimeAt returns the seconds of the song at a beat
+func (t *Timeline) TimeAt(beat float64) float64 {
+	tempo := &t.tempos[t.tempoAt(beat)]
+	return t.offset + tempo.time + (beat-tempo.beat)*60/tempo.bpm
+}
+
+// BPMAt returns the tempo at a beat
+func (t *Timeline) BPMAt(beat float64) float64 {
+	return t.tempos[t.tempoAt(beat)].bpm
+}
+
+func (t *Timeline) tempoAt(beat float64) int {
+	i := sort.Search(len(t.tempos), func(i int) bool { return t.tempos[i].beat > beat }) - 1
+	if i < 0 {
+		return 0
+	}
+	return i
+}
@@ -0,0 +1,98 @@
+package audio
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestTimelineTempo(t *testing.T) {
+	timeline := NewTimeline(120, 1)
+	timeline.AddTempoChange(8, 60)
+	timeline.AddTempoChange(4, 240)
+	var tests = []struct {
+		time float64
+		beat float64
+	}{{0, -2}, {1, 0}, {2, 2}, {3, 4}, {3.5, 6}, {4, 8}, {6, 10}}
+	for _, test := range tests {
+		if b := timeline.BeatAt(test.time); math.Abs(b-test.beat) > 1e-9 {
+			t.Errorf("Beat %v at %v, expecting %v", b, test.time, test.beat)
+		}
+		if time := timeline.TimeAt(test.beat); math.Abs(time-test.time) > 1e-9 {
+			t.Errorf("Time %v at beat %v, expecting %v", time, test.beat, test.time)
+		}
+	}
+	if bpm := timeline.BPMAt(5); bpm != 240 {
+		t.Errorf("Got %v bpm at beat 5", bpm)
+	}
+
+	for _, bpm := range []float64{0, -60, math.NaN(), math.Inf(1)} {
+		timeline := NewTimeline(bpm, 1)
+		timeline.AddTempoChange(4, bpm)
+		if timeline.BPMAt(8) != DefaultBPM || len(timeline.TempoChanges()) != 1 {
+			t.Errorf("Timeline at %v bpm: %v", bpm, timeline.TempoChanges())
+		}
+		if beat := timeline.BeatAt(2); beat != 2 {
+			t.Errorf("Timeline at %v bpm: beat %v a second in", bpm, beat)
+		}
+	}
+}
+
+func TestTimelineCallbacks(t *testing.T) {
+	timeline := NewTimeline(60, 0.5)
+	timeline.SetBeatsPerBar(2)
+	var events []string
+	timeline.SetOnBeat(func(beat int) { events = append(events, "beat"+string('0'+rune(beat))) })
+	timeline.SetOnBar(func(bar int) { events = append(events, "bar"+string('0'+rune(bar))) })
+
+	timeline.Start(10)
+	timeline.Update(10.4)
+	timeline.Update(10.5)
+	timeline.Update(13.2)
+	if got := strings.Join(events, " "); got != "bar0 beat0 beat1 bar1 beat2" {
+		t.Errorf("Got %q", got)
+	}
+	if timeline.Bar() != 1 || math.Abs(timeline.BeatInBar()-0.7) > 1e-9 {
+		t.Errorf("At bar %v beat %v", timeline.Bar(), timeline.BeatInBar())
+	}
+
+	// Restarted, no beats called back for the jump
+	events = nil
+	timeline.Start(20)
+	timeline.Update(20.6)
+	if got := strings.Join(events, " "); got != "bar0 beat0" {
+		t.Errorf("Got %q after restarting", got)
+	}
+}
+
+func TestTimelineFollowVoice(t *testing.T) {
+	mixer := NewMixer(100)
+	backend := NewNullBackend()
+	mixer.Start(backend)
+	voice := mixer.Play(constantSound(1000, 0), nil)
+	timeline := NewTimeline(60, 0)
+	timeline.SetLatency(0.05)
+	timeline.Follow(voice)
+
+	backend.Render(0.1)
+	timeline.Update(1)
+	if math.Abs(timeline.Time()-0.05) > 1e-9 {
+		t.Errorf("Got %v, expecting the voice minus the latency", timeline.Time())
+	}
+	// Between two chunks mixed the clock moves the timeline
+	timeline.Update(1.02)
+	if math.Abs(timeline.Time()-0.068) > 1e-9 {
+		t.Errorf("Got %v between chunks", timeline.Time())
+	}
+	// Far from the voice, jumps to it
+	voice.Seek(5)
+	timeline.Update(1.04)
+	if math.Abs(timeline.Time()-4.95) > 1e-9 {
+		t.Errorf("Got %v after seeking", timeline.Time())
+	}
+	voice.Pause()
+	timeline.Update(2)
+	if math.Abs(timeline.Time()-4.95) > 1e-9 {
+		t.Errorf("Got %v paused", timeline.Time())
+	}
+}
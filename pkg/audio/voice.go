@@ -0,0 +1,280 @@
+package audio
+
+type voiceState int
+
+const (
+	voiceStopped voiceState = iota
+	voicePlaying
+	voicePaused
+)
+
+// Voice is a sound or a stream playing in a mixer, with its own volume,
+// pan, pitch, loop and fades
+type Voice struct {
+	mixer  *Mixer
+	source source
+	bus    *Bus
+	state  voiceState
+	// The frame of the source played, between two frames when resampling
+	position  float64
+	volume    float32
+	pan       float32
+	pitch     float64
+	loop      bool
+	loopStart int64
+	// The fade gain goes to the target by step per frame mixed
+	fade       float32
+	fadeTarget float32
+	fadeStep   float32
+	stopOnFade bool
+	// The frames interpolated
+	current []float32
+	next    []float32
+}
+
+// Play starts playing, or resumes if paused
+func (v *Voice) Play() {
+	v.mixer.mutex.Lock()
+	defer v.mixer.mutex.Unlock()
+	v.state = voicePlaying
+	v.mixer.add(v)
+}
+
+// Pause pauses until Play
+func (v *Voice) Pause() {
+	v.mixer.mutex.Lock()
+	defer v.mixer.mutex.Unlock()
+	if v.state == voicePlaying {
+		v.state = voicePaused
+	}
+}
+
+// Stop stops and rewinds, Play starts over
+func (v *Voice) Stop() {
+	v.mixer.mutex.Lock()
+	defer v.mixer.mutex.Unlock()
+	v.stop()
+	v.mixer.remove(v)
+}
+
+func (v *Voice) stop() {
+	v.state = voiceStopped
+	v.position = 0
+	v.fadeStep = 0
+	v.fade = 1
+}
+
+// Playing returns true until the end, or until stopped. Paused voices are
+// playing
+func (v *Voice) Playing() bool {
+	v.mixer.mutex.Lock()
+	defer v.mixer.mutex.Unlock()
+	return v.state != voiceStopped
+}
+
+func (v *Voice) Paused() bool {
+	v.mixer.mutex.Lock()
+	defer v.mixer.mutex.Unlock()
+	return v.state == voicePaused
+}
+
+func (v *Voice) Bus() *Bus {
+	return v.bus
+}
+
+// SetVolume changes the volume, 1 is unchanged
+func (v *Voice) SetVolume(volume float32) {
+	v.mixer.mutex.Lock()
+	defer v.mixer.mutex.Unlock()
+	if volume < 0 {
+		volume = 0
+	}
+	v.volume = volume
+}
+
+func (v *Voice) Volume() float32 {
+	v.mixer.mutex.Lock()
+	defer v.mixer.mutex.Unlock()
+	return v.volume
+}
+
+// SetPan moves the voice from -1, left, to 1, right
+func (v *Voice) SetPan(pan float32) {
+	v.mixer.mutex.Lock()
+	defer v.mixer.mutex.Unlock()
+	if pan < -1 {
+		pan = -1
+	} else if pan > 1 {
+		pan = 1
+	}
+	v.pan = pan
+}
+
+func (v *Voice) Pan() float32 {
+	v.mixer.mutex.Lock()
+	defer v.mixer.mutex.Unlock()
+	return v.pan
+}
+
+// SetPitch changes the speed, 2 is an octave up and twice as fast
+func (v *Voice) SetPitch(pitch float64) {
+	v.mixer.mutex.Lock()
+	defer v.mixer.mutex.Unlock()
+	if pitch > 0 {
+		v.pitch = pitch
+	}
+}
+
+func (v *Voice) Pitch() float64 {
+	v.mixer.mutex.Lock()
+	defer v.mixer.mutex.Unlock()
+	return v.pitch
+}
+
+// SetLoop plays again from the loop start at the end
+func (v *Voice) SetLoop(loop bool) {
+	v.mixer.mutex.Lock()
+	defer v.mixer.mutex.Unlock()
+	v.loop = loop
+}
+
+// SetLoopStart sets the seconds the loop starts from, e.g. after the intro
+func (v *Voice) SetLoopStart(seconds float64) {
+	v.mixer.mutex.Lock()
+	defer v.mixer.mutex.Unlock()
+	v.loopStart = int64(seconds * float64(v.source.sampleRate()))
+	if v.loopStart < 0 {
+		v.loopStart = 0
+	}
+}
+
+func (v *Voice) Looping() bool {
+	v.mixer.mutex.Lock()
+	defer v.mixer.mutex.Unlock()
+	return v.loop
+}
+
+// FadeIn fades from silence to the volume in seconds
+func (v *Voice) FadeIn(seconds float64) {
+	v.mixer.mutex.Lock()
+	defer v.mixer.mutex.Unlock()
+	v.fade = 0
+	v.fadeTo(1, seconds, false)
+}
+
+// FadeOut fades to silence in seconds, then stops
+func (v *Voice) FadeOut(seconds float64) {
+	v.mixer.mutex.Lock()
+	defer v.mixer.mutex.Unlock()
+	v.fadeTo(0, seconds, true)
+}
+
+func (v *Voice) fadeTo(target float32, seconds float64, stop bool) {
+	v.fadeTarget = target
+	v.stopOnFade = stop
+	frames := float32(seconds * float64(v.mixer.sampleRate))
+	if frames < 1 {
+		v.fade = target
+		v.fadeStep = 0
+		if stop && target == 0 {
+			v.stop()
+			v.mixer.remove(v)
+		}
+		return
+	}
+	v.fadeStep = (target - v.fade) / frames
+}
+
+// Position returns the seconds played, ahead of what's heard by the latency
+// of the backend
+func (v *Voice) Position() float64 {
+	v.mixer.mutex.Lock()
+	defer v.mixer.mutex.Unlock()
+	return v.position / float64(v.source.sampleRate())
+}
+
+// Seek moves to a time in seconds
+func (v *Voice) Seek(seconds float64) {
+	v.mixer.mutex.Lock()
+	defer v.mixer.mutex.Unlock()
+	if seconds < 0 {
+		seconds = 0
+	}
+	v.position = seconds * float64(v.source.sampleRate())
+}
+
+// Duration returns the seconds of the sound or the stream, -1 if not known
+func (v *Voice) Duration() float64 {
+	length := v.source.length()
+	if length < 0 {
+		return -1
+	}
+	return float64(length) / float64(v.source.sampleRate())
+}
+
+// mix adds the voice to the stereo frames of out, called with the mixer
+// locked
+func (v *Voice) mix(out []float32, busGain float32) {
+	step := v.pitch * float64(v.source.sampleRate()) / float64(v.mixer.sampleRate)
+	channels := len(v.current)
+	left, right := float32(1), float32(1)
+	if v.pan > 0 {
+		left = 1 - v.pan
+	} else {
+		right = 1 + v.pan
+	}
+	for i := 0; i+1 < len(out); i += 2 {
+		index := int64(v.position)
+		if !v.read(index) {
+			if !v.loop || index == v.loopStart {
+				v.stop()
+				return
+			}
+			v.position = float64(v.loopStart) + (v.position - float64(index))
+			index = v.loopStart
+			if !v.read(index) {
+				v.stop()
+				return
+			}
+		}
+		t := float32(v.position - float64(index))
+		var l, r float32
+		if channels == 1 {
+			l = v.current[0] + (v.next[0]-v.current[0])*t
+			r = l
+		} else {
+			l = v.current[0] + (v.next[0]-v.current[0])*t
+			r = v.current[1] + (v.next[1]-v.current[1])*t
+		}
+		gain := v.volume * busGain * v.fade
+		out[i] += l * left * gain
+		out[i+1] += r * right * gain
+		v.position += step
+		if v.fadeStep != 0 {
+			v.fade += v.fadeStep
+			if (v.fadeStep > 0 && v.fade >= v.fadeTarget) || (v.fadeStep < 0 && v.fade <= v.fadeTarget) {
+				v.fade = v.fadeTarget
+				v.fadeStep = 0
+				if v.stopOnFade && v.fade == 0 {
+					v.stop()
+					return
+				}
+			}
+		}
+	}
+}
+
+// read copies a frame and the next one to interpolate, false past the end
+func (v *Voice) read(index int64) bool {
+	frame := v.source.frame(index)
+	if frame == nil {
+		return false
+	}
+	copy(v.current, frame)
+	if next := v.source.frame(index + 1); next != nil {
+		copy(v.next, next)
+	} else {
+		copy(v.next, v.current)
+	}
+	return true
+}
@@ -0,0 +1,216 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xFFFE
+)
+
+// wavDecoder reads the PCM, 8 to 32 bits, and the float samples of a RIFF
+// WAVE file
+type wavDecoder struct {
+	reader     io.ReadSeeker
+	sampleRate int
+	channels   int
+	bits       int
+	float      bool
+	dataStart  int64
+	frames     int64
+	frame      int64
+	buffer     []byte
+}
+
+func newWAVDecoder(reader io.ReadSeeker) (*wavDecoder, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil {
+		return nil, err
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return nil, errors.New("not a RIFF WAVE file")
+	}
+	d := &wavDecoder{reader: reader}
+	hasFormat := false
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(reader, chunk[:]); err != nil {
+			return nil, fmt.Errorf("no data chunk: %v", err)
+		}
+		size := int64(binary.LittleEndian.Uint32(chunk[4:]))
+		switch string(chunk[0:4]) {
+		case "fmt ":
+			if err := d.readFormat(size); err != nil {
+				return nil, err
+			}
+			hasFormat = true
+		case "data":
+			if !hasFormat {
+				return nil, errors.New("data chunk before the fmt chunk")
+			}
+			start, err := reader.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return nil, err
+			}
+			d.dataStart = start
+			d.frames = size / int64(d.frameSize())
+			return d, nil
+		default:
+			// The chunks are padded to an even size
+			if _, err := reader.Seek(size+size%2, io.SeekCurrent); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func (d *wavDecoder) readFormat(size int64) error {
+	if size < 16 {
+		return fmt.Errorf("fmt chunk of %d bytes", size)
+	}
+	data := make([]byte, size+size%2)
+	if _, err := io.ReadFull(d.reader, data); err != nil {
+		return err
+	}
+	tag := binary.LittleEndian.Uint16(data[0:])
+	d.channels = int(binary.LittleEndian.Uint16(data[2:]))
+	d.sampleRate = int(binary.LittleEndian.Uint32(data[4:]))
+	d.bits = int(binary.LittleEndian.Uint16(data[14:]))
+	if tag == wavFormatExtensible {
+		// The format is the start of the sub format GUID
+		if size < 26 {
+			return errors.New("extensible fmt chunk too short")
+		}
+		tag = binary.LittleEndian.Uint16(data[24:])
+	}
+	switch {
+	case tag == wavFormatPCM && (d.bits == 8 || d.bits == 16 || d.bits == 24 || d.bits == 32):
+	case tag == wavFormatFloat && (d.bits == 32 || d.bits == 64):
+		d.float = true
+	default:
+		return fmt.Errorf("unsupported WAVE format %d with %d bits", tag, d.bits)
+	}
+	if d.channels < 1 || d.sampleRate < 1 {
+		return fmt.Errorf("invalid WAVE with %d channels at %dHz", d.channels, d.sampleRate)
+	}
+	return nil
+}
+
+func (d *wavDecoder) frameSize() int {
+	return d.channels * d.bits / 8
+}
+
+func (d *wavDecoder) SampleRate() int {
+	return d.sampleRate
+}
+
+func (d *wavDecoder) Channels() int {
+	return d.channels
+}
+
+func (d *wavDecoder) Length() int64 {
+	return d.frames
+}
+
+func (d *wavDecoder) SetPosition(frame int64) error {
+	if frame < 0 {
+		frame = 0
+	} else if frame > d.frames {
+		frame = d.frames
+	}
+	if _, err := d.reader.Seek(d.dataStart+frame*int64(d.frameSize()), io.SeekStart); err != nil {
+		return err
+	}
+	d.frame = frame
+	return nil
+}
+
+func (d *wavDecoder) Read(samples []float32) (int, error) {
+	frames := int64(len(samples) / d.channels)
+	if left := d.frames - d.frame; frames > left {
+		frames = left
+	}
+	if frames == 0 {
+		if len(samples) < d.channels {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+	size := int(frames) * d.frameSize()
+	if cap(d.buffer) < size {
+		d.buffer = make([]byte, size)
+	}
+	data := d.buffer[:size]
+	n, err := io.ReadFull(d.reader, data)
+	if err == io.ErrUnexpectedEOF {
+		// A truncated file ends early
+		d.frames = d.frame + int64(n/d.frameSize())
+		err = nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	count := n / (d.bits / 8)
+	count -= count % d.channels
+	bytes := d.bits / 8
+	for i := 0; i < count; i++ {
+		samples[i] = d.sample(data[i*bytes:])
+	}
+	d.frame += int64(count / d.channels)
+	return count, nil
+}
+
+func (d *wavDecoder) sample(b []byte) float32 {
+	switch {
+	case d.float && d.bits == 32:
+		return math.Float32frombits(binary.LittleEndian.Uint32(b))
+	case d.float:
+		return float32(math.Float64frombits(binary.LittleEndian.Uint64(b)))
+	case d.bits == 8:
+		return (float32(b[0]) - 128) / 128
+	case d.bits == 16:
+		return float32(int16(binary.LittleEndian.Uint16(b))) / (1 << 15)
+	case d.bits == 24:
+		v := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8
+		return float32(v) / (1 << 23)
+	}
+	return float32(int32(binary.LittleEndian.Uint32(b))) / (1 << 31)
+}
+
+// writeWAVHeader writes the header of a 16 bits PCM WAVE file with dataSize
+// bytes of samples
+func writeWAVHeader(writer io.Writer, sampleRate, channels int, dataSize uint32) error {
+	var header [44]byte
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], 36+dataSize)
+	copy(header[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(header[16:], 16)
+	binary.LittleEndian.PutUint16(header[20:], wavFormatPCM)
+	binary.LittleEndian.PutUint16(header[22:], uint16(channels))
+	binary.LittleEndian.PutUint32(header[24:], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(header[28:], uint32(sampleRate*channels*2))
+	binary.LittleEndian.PutUint16(header[32:], uint16(channels*2))
+	binary.LittleEndian.PutUint16(header[34:], 16)
+	copy(header[36:], "data")
+	binary.LittleEndian.PutUint32(header[40:], dataSize)
+	_, err := writer.Write(header[:])
+	return err
+}
+
+// toPCM16 converts samples to 16 bits little endian, clipping them
+func toPCM16(samples []float32, data []byte) {
+	for i, s := range samples {
+		if s > 1 {
+			s = 1
+		} else if s < -1 {
+			s = -1
+		}
+		binary.LittleEndian.PutUint16(data[i*2:], uint16(int16(s*math.MaxInt16)))
+	}
+}
@@ -0,0 +1,108 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func wavFile(tag uint16, channels, bits int, data []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(4+8+16+8+4+8+len(data)))
+	b.WriteString("WAVEfmt ")
+	binary.Write(&b, binary.LittleEndian, uint32(16))
+	binary.Write(&b, binary.LittleEndian, tag)
+	binary.Write(&b, binary.LittleEndian, uint16(channels))
+	binary.Write(&b, binary.LittleEndian, uint32(8000))
+	binary.Write(&b, binary.LittleEndian, uint32(8000*channels*bits/8))
+	binary.Write(&b, binary.LittleEndian, uint16(channels*bits/8))
+	binary.Write(&b, binary.LittleEndian, uint16(bits))
+	// A chunk to skip
+	b.WriteString("LIST")
+	binary.Write(&b, binary.LittleEndian, uint32(3))
+	b.Write([]byte{1, 2, 3, 0})
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, uint32(len(data)))
+	b.Write(data)
+	return b.Bytes()
+}
+
+func TestDecodeWAV(t *testing.T) {
+	var tests = []struct {
+		tag      uint16
+		channels int
+		bits     int
+		data     []byte
+		samples  []float32
+	}{
+		{wavFormatPCM, 1, 8, []byte{128, 0, 192}, []float32{0, -1, 0.5}},
+		{wavFormatPCM, 2, 16, []byte{0, 0x40, 0, 0xC0}, []float32{0.5, -0.5}},
+		{wavFormatPCM, 1, 24, []byte{0, 0, 0x40, 0, 0, 0xC0}, []float32{0.5, -0.5}},
+		{wavFormatFloat, 1, 32, []byte{0, 0, 0x80, 0x3E}, []float32{0.25}},
+	}
+	for _, test := range tests {
+		data := wavFile(test.tag, test.channels, test.bits, test.data)
+		sound, err := DecodeSound(bytes.NewReader(data), FORMAT_WAV)
+		if err != nil {
+			t.Errorf("%d bits: %v", test.bits, err)
+			continue
+		}
+		if sound.Channels() != test.channels || sound.SampleRate() != 8000 {
+			t.Errorf("%d bits: got %d channels at %d", test.bits, sound.Channels(), sound.SampleRate())
+		}
+		if len(sound.samples) != len(test.samples) {
+			t.Errorf("%d bits: got %v, expecting %v", test.bits, sound.samples, test.samples)
+			continue
+		}
+		for i, s := range test.samples {
+			if sound.samples[i] != s {
+				t.Errorf("%d bits: got %v, expecting %v", test.bits, sound.samples, test.samples)
+				break
+			}
+		}
+	}
+
+	if _, err := DecodeSound(bytes.NewReader(wavFile(2, 1, 4, nil)), FORMAT_WAV); err == nil {
+		t.Error("Decoded ADPCM")
+	}
+	if _, err := DecodeSound(bytes.NewReader([]byte("RIFF....AVI ")), FORMAT_WAV); err == nil {
+		t.Error("Decoded an AVI")
+	}
+}
+
+func TestFileBackend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audio")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "mix.wav")
+
+	mixer := NewMixer(8000)
+	backend := NewFileBackend(path)
+	if err := mixer.Start(backend); err != nil {
+		t.Fatal(err)
+	}
+	mixer.Play(NewSound(8000, 1, []float32{0.5, 0.5, 0.5, 0.5}), nil).SetPan(1)
+	if err := backend.Render(0.001); err != nil {
+		t.Fatal(err)
+	}
+	if err := mixer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	sound := NewSoundFromFile(path)
+	if sound.Channels() != 2 || sound.Frames() != 8 {
+		t.Fatalf("Got %d channels and %d frames", sound.Channels(), sound.Frames())
+	}
+	if l, r := sound.frame(0)[0], sound.frame(0)[1]; l != 0 || r < 0.49 || r > 0.51 {
+		t.Errorf("Got %v %v panned right", l, r)
+	}
+	if f := sound.frame(4); f[0] != 0 || f[1] != 0 {
+		t.Errorf("Got %v after the end", f)
+	}
+}
@@ -102,3 +102,25 @@ func (c *Context) SetOrtho2DProjection(windowWidth int, windowHeight int, screen
 	}
 	c.projectionMatrix = mgl32.Ortho(left, right, top, bottom, 1, -1)
 }
+
+// ScreenToWorld converts a point in window pixels, with the origin at the
+// top left, to the coordinates of the projection
+func (c *Context) ScreenToWorld(point mgl32.Vec2, windowWidth int, windowHeight int) mgl32.Vec2 {
+	ndc := mgl32.Vec4{
+		2*point[0]/float32(windowWidth) - 1,
+		1 - 2*point[1]/float32(windowHeight),
+		0,
+		1,
+	}
+	return c.projectionMatrix.Inv().Mul4x1(ndc).Vec2()
+}
+
+// WorldToScreen converts a point in the coordinates of the projection to
+// window pixels, with the origin at the top left
+func (c *Context) WorldToScreen(point mgl32.Vec2, windowWidth int, windowHeight int) mgl32.Vec2 {
+	ndc := c.projectionMatrix.Mul4x1(mgl32.Vec4{point[0], point[1], 0, 1})
+	return mgl32.Vec2{
+		(ndc[0] + 1) / 2 * float32(windowWidth),
+		(1 - ndc[1]) / 2 * float32(windowHeight),
+	}
+}
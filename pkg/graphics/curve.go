@@ -0,0 +1,102 @@
+package graphics
+
+import "sort"
+
+// CurveKey is a control point of a Curve. Time is normalized in [0, 1]
+type CurveKey struct {
+	Time  float32
+	Value float32
+}
+
+// Curve is a piecewise linear function of the normalized time, used to
+// animate values over the lifetime of something (e.g. a particle)
+type Curve struct {
+	keys []CurveKey
+}
+
+// NewCurve creates a curve from a list of keys, in any order
+func NewCurve(keys ...CurveKey) *Curve {
+	c := &Curve{keys: append([]CurveKey{}, keys...)}
+	sort.Slice(c.keys, func(i, j int) bool { return c.keys[i].Time < c.keys[j].Time })
+	return c
+}
+
+// NewLinearCurve creates a curve going from start to end
+func NewLinearCurve(start, end float32) *Curve {
+	return NewCurve(CurveKey{0, start}, CurveKey{1, end})
+}
+
+// Evaluate returns the value of the curve at time t. Values before the first
+// key and after the last one are clamped
+func (c *Curve) Evaluate(t float32) float32 {
+	if c == nil || len(c.keys) == 0 {
+		return 1
+	}
+	if t <= c.keys[0].Time {
+		return c.keys[0].Value
+	}
+	last := c.keys[len(c.keys)-1]
+	if t >= last.Time {
+		return last.Value
+	}
+	for i := 1; i < len(c.keys); i++ {
+		k1 := c.keys[i]
+		if t <= k1.Time {
+			k0 := c.keys[i-1]
+			f := (t - k0.Time) / (k1.Time - k0.Time)
+			return k0.Value + (k1.Value-k0.Value)*f
+		}
+	}
+	return last.Value
+}
+
+// GradientKey is a control point of a Gradient. Time is normalized in [0, 1]
+type GradientKey struct {
+	Time  float32
+	Color Color
+}
+
+// Gradient is a piecewise linear interpolation between colors
+type Gradient struct {
+	keys []GradientKey
+}
+
+// NewGradient creates a gradient from a list of keys, in any order
+func NewGradient(keys ...GradientKey) *Gradient {
+	g := &Gradient{keys: append([]GradientKey{}, keys...)}
+	sort.Slice(g.keys, func(i, j int) bool { return g.keys[i].Time < g.keys[j].Time })
+	return g
+}
+
+// NewLinearGradient creates a gradient going from start to end
+func NewLinearGradient(start, end Color) *Gradient {
+	return NewGradient(GradientKey{0, start}, GradientKey{1, end})
+}
+
+// Evaluate returns the color of the gradient at time t
+func (g *Gradient) Evaluate(t float32) Color {
+	if g == nil || len(g.keys) == 0 {
+		return Color{1, 1, 1, 1}
+	}
+	if t <= g.keys[0].Time {
+		return g.keys[0].Color
+	}
+	last := g.keys[len(g.keys)-1]
+	if t >= last.Time {
+		return last.Color
+	}
+	for i := 1; i < len(g.keys); i++ {
+		k1 := g.keys[i]
+		if t <= k1.Time {
+			k0 := g.keys[i-1]
+			f := (t - k0.Time) / (k1.Time - k0.Time)
+			return Color{
+				k0.Color[0] + (k1.Color[0]-k0.Color[0])*f,
+				k0.Color[1] + (k1.Color[1]-k0.Color[1])*f,
+				k0.Color[2] + (k1.Color[2]-k0.Color[2])*f,
+				k0.Color[3] + (k1.Color[3]-k0.Color[3])*f,
+			}
+		}
+	}
+	return last.Color
+}
@@ -0,0 +1,256 @@
+package graphics
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// NineSliceMode defines how the edges and the center of a NineSlice fill
+// their area
+type NineSliceMode int
+
+const (
+	NINE_SLICE_STRETCH NineSliceMode = iota
+	NINE_SLICE_TILE
+)
+
+// Insets are the sizes in pixels of the borders of a nine-slice image
+type Insets struct {
+	Left   float32
+	Right  float32
+	Top    float32
+	Bottom float32
+}
+
+// NineSlice is a scalable image made of 4 fixed corners, 4 edges stretched
+// or tiled along one direction and a center stretched or tiled along both.
+// Useful for panels, buttons and dialog boxes
+type NineSlice struct {
+	primitive   *Primitive2D
+	texture     *Texture
+	region      mgl32.Vec4 // x, y, width, height in pixels
+	insets      Insets
+	borderScale float32
+	size        mgl32.Vec2
+	edgeMode    NineSliceMode
+	centerMode  NineSliceMode
+}
+
+var nineSliceShaderProgram *ShaderProgram
+
+// NewNineSlice creates a nine-slice from the whole texture
+func NewNineSlice(texture *Texture, insets Insets, position mgl32.Vec3, size mgl32.Vec2) *NineSlice {
+	region := mgl32.Vec4{0, 0, float32(texture.width), float32(texture.height)}
+	return NewNineSliceFromRegion(texture, region, insets, position, size)
+}
+
+// NewNineSliceFromRegion creates a nine-slice from a region of the texture
+// (x, y, width, height in pixels), e.g. a frame of a UI atlas
+func NewNineSliceFromRegion(
+	texture *Texture,
+	region mgl32.Vec4,
+	insets Insets,
+	position mgl32.Vec3,
+	size mgl32.Vec2,
+) *NineSlice {
+	if nineSliceShaderProgram == nil {
+		nineSliceShaderProgram = NewShaderProgram(VertexShaderPrimitive2D, "", FragmentShaderTextureColor)
+	}
+
+	n := &NineSlice{}
+	n.texture = texture
+	n.region = region
+	n.insets = insets
+	n.borderScale = 1
+	n.size = size
+
+	vertices, uvCoords := n.makeQuads()
+	// Vertices are in pixels, so the primitive size is 1
+	n.primitive = NewTriangles(vertices, uvCoords, texture, position, mgl32.Vec2{1, 1}, nineSliceShaderProgram)
+	n.primitive.SetColor(Color{1, 1, 1, 1})
+	return n
+}
+
+// SetSize resizes the nine-slice, corners keep their size
+func (n *NineSlice) SetSize(size mgl32.Vec2) {
+	if n.size != size {
+		n.size = size
+		n.uploadQuads()
+	}
+}
+
+// Size returns the size of the nine-slice
+func (n *NineSlice) Size() mgl32.Vec2 {
+	return n.size
+}
+
+// SetModes sets how edges and center are filled
+func (n *NineSlice) SetModes(edgeMode NineSliceMode, centerMode NineSliceMode) {
+	n.edgeMode = edgeMode
+	n.centerMode = centerMode
+	n.uploadQuads()
+}
+
+// SetInsets changes the borders of the source image
+func (n *NineSlice) SetInsets(insets Insets) {
+	n.insets = insets
+	n.uploadQuads()
+}
+
+// SetBorderScale scales the borders (and the tiles) on screen without
+// changing the overall size, e.g. 0.5 to use a 2x texture on a 1x screen
+func (n *NineSlice) SetBorderScale(scale float32) {
+	n.borderScale = scale
+	n.uploadQuads()
+}
+
+// SetPosition see Primitive2D.SetPosition
+func (n *NineSlice) SetPosition(position mgl32.Vec3) {
+	n.primitive.SetPosition(position)
+}
+
+// SetAnchor sets the anchor in pixels relative to the top left corner
+func (n *NineSlice) SetAnchor(anchor mgl32.Vec2) {
+	n.primitive.SetAnchor(anchor)
+}
+
+// SetAnchorToCenter see Primitive2D.SetAnchorToCenter
+func (n *NineSlice) SetAnchorToCenter() {
+	n.primitive.SetAnchor(mgl32.Vec2{n.size[0] / 2, n.size[1] / 2})
+}
+
+// SetAngle see Primitive2D.SetAngle
+func (n *NineSlice) SetAngle(radians float32) {
+	n.primitive.SetAngle(radians)
+}
+
+// SetColor tints the image
+func (n *NineSlice) SetColor(color Color) {
+	n.primitive.SetColor(color)
+}
+
+// EnqueueForDrawing see Drawable.EnqueueForDrawing
+func (n *NineSlice) EnqueueForDrawing(context *Context) {
+	context.EnqueueForDrawing(n)
+}
+
+// Drawable implementation
+
+// Texture returns drawable texture
+func (n *NineSlice) Texture() *Texture {
+	return n.texture
+}
+
+// Shader returns shader program
+func (n *NineSlice) Shader() *ShaderProgram {
+	return n.primitive.Shader()
+}
+
+// Draw runs all the necessary routines to make drawable appear on screen
+func (n *NineSlice) Draw(context *Context) {
+	n.primitive.Draw(context)
+}
+
+// DrawInBatch is like Draw() but without setting up texture and shader
+func (n *NineSlice) DrawInBatch(context *Context) {
+	n.primitive.DrawInBatch(context)
+}
+
+// Drawable end
+
+func (n *NineSlice) uploadQuads() {
+	vertices, uvCoords := n.makeQuads()
+	n.primitive.SetVertices(vertices)
+	n.primitive.SetUVCoords(uvCoords)
+}
+
+// nineSliceSpan is one of the three segments (border, middle, border) along
+// an axis: destination start and length, source start and length in pixels
+type nineSliceSpan struct {
+	dst, dstLen float32
+	src, srcLen float32
+}
+
+// spans splits an axis in 3 segments. If the size is smaller than the two
+// borders they are shrunk proportionally
+func (n *NineSlice) spans(size, regionStart, regionLen, before, after float32) [3]nineSliceSpan {
+	dstBefore := before * n.borderScale
+	dstAfter := after * n.borderScale
+	if dstBefore+dstAfter > size && dstBefore+dstAfter > 0 {
+		ratio := size / (dstBefore + dstAfter)
+		dstBefore *= ratio
+		dstAfter *= ratio
+	}
+	return [3]nineSliceSpan{
+		{0, dstBefore, regionStart, before},
+		{dstBefore, size - dstBefore - dstAfter, regionStart + before, regionLen - before - after},
+		{size - dstAfter, dstAfter, regionStart + regionLen - after, after},
+	}
+}
+
+// tile splits a span in repeated pieces of the source size (scaled by
+// borderScale), the last piece is cropped
+func (n *NineSlice) tile(span nineSliceSpan, mode NineSliceMode) []nineSliceSpan {
+	tileLen := span.srcLen * n.borderScale
+	if mode == NINE_SLICE_STRETCH || tileLen <= 0 {
+		return []nineSliceSpan{span}
+	}
+	pieces := make([]nineSliceSpan, 0, int(span.dstLen/tileLen)+1)
+	for offset := float32(0); offset < span.dstLen; offset += tileLen {
+		length := tileLen
+		if offset+length > span.dstLen {
+			length = span.dstLen - offset
+		}
+		pieces = append(pieces, nineSliceSpan{
+			span.dst + offset, length,
+			span.src, span.srcLen * length / tileLen,
+		})
+	}
+	return pieces
+}
+
+func (n *NineSlice) makeQuads() ([]float32, []float32) {
+	columns := n.spans(n.size.X(), n.region[0], n.region[2], n.insets.Left, n.insets.Right)
+	rows := n.spans(n.size.Y(), n.region[1], n.region[3], n.insets.Top, n.insets.Bottom)
+	texWidth := float32(n.texture.width)
+	texHeight := float32(n.texture.height)
+
+	vertices := make([]float32, 0, 9*12)
+	uvCoords := make([]float32, 0, 9*12)
+	for r, row := range rows {
+		for c, column := range columns {
+			if row.dstLen <= 0 || column.dstLen <= 0 {
+				continue
+			}
+			// Corners are never tiled, the center uses the center mode
+			modeX, modeY := NINE_SLICE_STRETCH, NINE_SLICE_STRETCH
+			if c == 1 && r == 1 {
+				modeX, modeY = n.centerMode, n.centerMode
+			} else if c == 1 {
+				modeX = n.edgeMode
+			} else if r == 1 {
+				modeY = n.edgeMode
+			}
+			for _, y := range n.tile(row, modeY) {
+				for _, x := range n.tile(column, modeX) {
+					vertices = append(vertices, nineSliceQuad(x.dst, y.dst, x.dstLen, y.dstLen)...)
+					uvCoords = append(uvCoords, nineSliceQuad(
+						x.src/texWidth, y.src/texHeight,
+						x.srcLen/texWidth, y.srcLen/texHeight,
+					)...)
+				}
+			}
+		}
+	}
+	return vertices, uvCoords
+}
+
+func nineSliceQuad(x, y, width, height float32) []float32 {
+	return []float32{
+		x, y + height, // bl
+		x + width, y + height, // br
+		x, y, // tl
+		x, y, // tl
+		x + width, y + height, // br
+		x + width, y, // tr
+	}
+}
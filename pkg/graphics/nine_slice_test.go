@@ -0,0 +1,57 @@
+package graphics
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestNineSliceQuads(t *testing.T) {
+	var tests = []struct {
+		size       mgl32.Vec2
+		edgeMode   NineSliceMode
+		centerMode NineSliceMode
+		numQuads   int
+		// Right edge of the first row of quads
+		topRight float32
+	}{
+		{mgl32.Vec2{100, 50}, NINE_SLICE_STRETCH, NINE_SLICE_STRETCH, 9, 100},
+		// 80x40px of edges and center tiled with 10px pieces
+		{mgl32.Vec2{100, 60}, NINE_SLICE_TILE, NINE_SLICE_STRETCH, 4 + 8*2 + 4*2 + 1, 100},
+		{mgl32.Vec2{100, 60}, NINE_SLICE_TILE, NINE_SLICE_TILE, 4 + 8*2 + 4*2 + 8*4, 100},
+		// Last tile is cropped
+		{mgl32.Vec2{95, 30}, NINE_SLICE_TILE, NINE_SLICE_STRETCH, 4 + 8*2 + 1*2 + 1, 95},
+		// Smaller than the borders: corners only, shrunk
+		{mgl32.Vec2{10, 10}, NINE_SLICE_STRETCH, NINE_SLICE_STRETCH, 4, 10},
+	}
+
+	for _, test := range tests {
+		n := &NineSlice{
+			texture:     &Texture{width: 30, height: 30},
+			region:      mgl32.Vec4{0, 0, 30, 30},
+			insets:      Insets{10, 10, 10, 10},
+			borderScale: 1,
+			size:        test.size,
+			edgeMode:    test.edgeMode,
+			centerMode:  test.centerMode,
+		}
+		vertices, uvCoords := n.makeQuads()
+		if len(vertices) != len(uvCoords) || len(vertices) != test.numQuads*12 {
+			t.Errorf("%v: got %d quads, expecting %d", test.size, len(vertices)/12, test.numQuads)
+			continue
+		}
+		var maxX float32
+		for i := 0; i < len(vertices); i += 2 {
+			if vertices[i] > maxX {
+				maxX = vertices[i]
+			}
+			if uvCoords[i] < 0 || uvCoords[i] > 1 || uvCoords[i+1] < 0 || uvCoords[i+1] > 1 {
+				t.Errorf("%v: UV out of range %v", test.size, uvCoords[i:i+2])
+				break
+			}
+		}
+		if maxX != test.topRight {
+			t.Errorf("%v: got width %v, expecting %v", test.size, maxX, test.topRight)
+		}
+	}
+}
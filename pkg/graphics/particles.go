@@ -0,0 +1,501 @@
+package graphics
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/go-gl/gl/v4.1-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// EmissionShape is the area where new particles are spawned
+type EmissionShape int
+
+const (
+	EMIT_POINT EmissionShape = iota
+	EMIT_CIRCLE
+	EMIT_RING
+	EMIT_RECTANGLE
+)
+
+// ParticleBurst emits Count particles at Time seconds since the start of the
+// emitter cycle. Bursts are repeated at each cycle when the emitter loops
+type ParticleBurst struct {
+	Time  float32
+	Count int
+}
+
+// ParticleEmitterConfig describes the behaviour of a ParticleEmitter.
+// Ranges are expressed as [min, max] pairs
+type ParticleEmitterConfig struct {
+	MaxParticles int
+	// Particles per second
+	SpawnRate float32
+	Bursts    []ParticleBurst
+	// Length of a cycle in seconds. Bursts are relative to the cycle start
+	Duration float32
+	Looping  bool
+
+	Shape EmissionShape
+	// Radius for EMIT_CIRCLE and EMIT_RING, width and height for EMIT_RECTANGLE
+	ShapeSize mgl32.Vec2
+
+	Lifetime mgl32.Vec2
+	// Direction and spread are in radians, speed in units per second
+	Direction float32
+	Spread    float32
+	Speed     mgl32.Vec2
+	// Rotation at spawn and angular velocity (radians per second)
+	Rotation        mgl32.Vec2
+	AngularVelocity mgl32.Vec2
+	// Units per second^2
+	Gravity mgl32.Vec2
+	// Fraction of the velocity lost per second
+	Drag float32
+
+	StartSize     mgl32.Vec2
+	SizeOverLife  *Curve
+	ColorOverLife *Gradient
+
+	Texture *Texture
+	// UV rectangles (u0, v0, u1, v1) of the texture. A random one is picked
+	// for each particle, or they are played in order if AnimateRegions is set
+	Regions        []mgl32.Vec4
+	AnimateRegions bool
+
+	// In local space particles move along with the emitter
+	LocalSpace bool
+	Additive   bool
+}
+
+// DefaultParticleEmitterConfig returns a simple fountain of white particles
+func DefaultParticleEmitterConfig() ParticleEmitterConfig {
+	return ParticleEmitterConfig{
+		MaxParticles: 1000,
+		SpawnRate:    50,
+		Duration:     1,
+		Looping:      true,
+		Lifetime:     mgl32.Vec2{1, 1},
+		Direction:    -math.Pi / 2,
+		Spread:       math.Pi / 8,
+		Speed:        mgl32.Vec2{100, 150},
+		StartSize:    mgl32.Vec2{8, 8},
+	}
+}
+
+type particle struct {
+	position        mgl32.Vec2
+	velocity        mgl32.Vec2
+	rotation        float32
+	angularVelocity float32
+	size            float32
+	age             float32
+	lifetime        float32
+	region          int
+}
+
+// Floats per particle instance: position (2), size, rotation, color (4), uv (4)
+const particleInstanceSize = 12
+
+// ParticleEmitter spawns, simulates and renders particles. All the particles
+// of an emitter are drawn with a single instanced draw call
+type ParticleEmitter struct {
+	Config ParticleEmitterConfig
+
+	position  mgl32.Vec3
+	particles []particle
+	emitting  bool
+	cycleTime float32
+	spawnDebt float32
+	random    *rand.Rand
+
+	instanceData []float32
+	dirty        bool
+	vaoId        uint32
+	vboQuad      uint32
+	vboInstances uint32
+	modelMatrix  mgl32.Mat4
+}
+
+var (
+	particleShaderTexture *ShaderProgram
+	particleShaderColor   *ShaderProgram
+)
+
+// NewParticleEmitter creates an emitter. OpenGL resources are created on the
+// first draw so emitters can be created and updated without a context
+func NewParticleEmitter(position mgl32.Vec3, config ParticleEmitterConfig) *ParticleEmitter {
+	e := &ParticleEmitter{}
+	e.Config = config
+	e.position = position
+	e.emitting = true
+	e.particles = make([]particle, 0, config.MaxParticles)
+	e.random = rand.New(rand.NewSource(time.Now().UnixNano()))
+	return e
+}
+
+// SetSeed makes the emitter deterministic
+func (e *ParticleEmitter) SetSeed(seed int64) {
+	e.random = rand.New(rand.NewSource(seed))
+}
+
+// SetPosition moves the emitter
+func (e *ParticleEmitter) SetPosition(position mgl32.Vec3) {
+	e.position = position
+}
+
+// Position returns the emitter position
+func (e *ParticleEmitter) Position() mgl32.Vec3 {
+	return e.position
+}
+
+// Start resumes the emission restarting the cycle
+func (e *ParticleEmitter) Start() {
+	e.emitting = true
+	e.cycleTime = 0
+	e.spawnDebt = 0
+}
+
+// Stop stops spawning new particles, the alive ones complete their life
+func (e *ParticleEmitter) Stop() {
+	e.emitting = false
+}
+
+// Clear kills all the particles
+func (e *ParticleEmitter) Clear() {
+	e.particles = e.particles[:0]
+	e.dirty = true
+}
+
+// Emitting returns true if the emitter is spawning particles
+func (e *ParticleEmitter) Emitting() bool {
+	return e.emitting
+}
+
+// NumParticles returns the number of alive particles
+func (e *ParticleEmitter) NumParticles() int {
+	return len(e.particles)
+}
+
+// Emit spawns count particles immediately
+func (e *ParticleEmitter) Emit(count int) {
+	for i := 0; i < count && len(e.particles) < e.Config.MaxParticles; i++ {
+		e.particles = append(e.particles, e.newParticle())
+	}
+	e.dirty = true
+}
+
+// Update spawns and simulates the particles. deltaTime is in seconds
+func (e *ParticleEmitter) Update(deltaTime float64) {
+	dt := float32(deltaTime)
+	drag := 1 - e.Config.Drag*dt
+	if drag < 0 {
+		drag = 0
+	}
+	gravity := e.Config.Gravity.Mul(dt)
+
+	// Dead particles are swapped with the last one to keep the slice compact
+	for i := 0; i < len(e.particles); {
+		p := &e.particles[i]
+		p.age += dt
+		if p.age >= p.lifetime {
+			last := len(e.particles) - 1
+			e.particles[i] = e.particles[last]
+			e.particles = e.particles[:last]
+			continue
+		}
+		p.velocity = p.velocity.Add(gravity).Mul(drag)
+		p.position = p.position.Add(p.velocity.Mul(dt))
+		p.rotation += p.angularVelocity * dt
+		i++
+	}
+
+	// New particles are spawned after the simulation step so they start at age 0
+	if e.emitting {
+		e.updateEmission(dt)
+	}
+	e.dirty = true
+}
+
+func (e *ParticleEmitter) updateEmission(dt float32) {
+	c := &e.Config
+	previous := e.cycleTime
+	e.cycleTime += dt
+
+	for _, burst := range c.Bursts {
+		if burst.Time >= previous && burst.Time < e.cycleTime {
+			e.Emit(burst.Count)
+		}
+	}
+
+	e.spawnDebt += c.SpawnRate * dt
+	if e.spawnDebt >= 1 {
+		count := int(e.spawnDebt)
+		e.spawnDebt -= float32(count)
+		e.Emit(count)
+	}
+
+	if c.Duration > 0 && e.cycleTime >= c.Duration {
+		if c.Looping {
+			e.cycleTime -= c.Duration
+			// Bursts falling in the wrapped part of the frame
+			for _, burst := range c.Bursts {
+				if burst.Time < e.cycleTime {
+					e.Emit(burst.Count)
+				}
+			}
+		} else {
+			e.emitting = false
+		}
+	}
+}
+
+func (e *ParticleEmitter) randomRange(r mgl32.Vec2) float32 {
+	return r[0] + e.random.Float32()*(r[1]-r[0])
+}
+
+func (e *ParticleEmitter) newParticle() particle {
+	c := &e.Config
+	p := particle{}
+
+	switch c.Shape {
+	case EMIT_CIRCLE, EMIT_RING:
+		angle := e.random.Float64() * 2 * math.Pi
+		radius := c.ShapeSize.X()
+		if c.Shape == EMIT_CIRCLE {
+			// Uniform distribution over the disk area
+			radius *= float32(math.Sqrt(e.random.Float64()))
+		}
+		p.position = mgl32.Vec2{float32(math.Cos(angle)) * radius, float32(math.Sin(angle)) * radius}
+	case EMIT_RECTANGLE:
+		p.position = mgl32.Vec2{
+			(e.random.Float32() - 0.5) * c.ShapeSize.X(),
+			(e.random.Float32() - 0.5) * c.ShapeSize.Y(),
+		}
+	}
+	if !c.LocalSpace {
+		p.position = p.position.Add(mgl32.Vec2{e.position.X(), e.position.Y()})
+	}
+
+	direction := float64(c.Direction + (e.random.Float32()-0.5)*c.Spread)
+	speed := e.randomRange(c.Speed)
+	p.velocity = mgl32.Vec2{float32(math.Cos(direction)) * speed, float32(math.Sin(direction)) * speed}
+	p.rotation = e.randomRange(c.Rotation)
+	p.angularVelocity = e.randomRange(c.AngularVelocity)
+	p.size = e.randomRange(c.StartSize)
+	p.lifetime = e.randomRange(c.Lifetime)
+	if p.lifetime <= 0 {
+		p.lifetime = math.SmallestNonzeroFloat32
+	}
+	if len(c.Regions) > 0 && !c.AnimateRegions {
+		p.region = e.random.Intn(len(c.Regions))
+	}
+	return p
+}
+
+// buildInstanceData fills the per-instance attributes of alive particles
+func (e *ParticleEmitter) buildInstanceData() {
+	c := &e.Config
+	size := len(e.particles) * particleInstanceSize
+	if cap(e.instanceData) < size {
+		e.instanceData = make([]float32, size)
+	}
+	e.instanceData = e.instanceData[:size]
+
+	for i := range e.particles {
+		p := &e.particles[i]
+		t := p.age / p.lifetime
+		color := c.ColorOverLife.Evaluate(t)
+		uv := mgl32.Vec4{0, 0, 1, 1}
+		if len(c.Regions) > 0 {
+			region := p.region
+			if c.AnimateRegions {
+				region = int(t * float32(len(c.Regions)))
+				if region >= len(c.Regions) {
+					region = len(c.Regions) - 1
+				}
+			}
+			uv = c.Regions[region]
+		}
+
+		d := e.instanceData[i*particleInstanceSize:]
+		d[0] = p.position.X()
+		d[1] = p.position.Y()
+		d[2] = p.size * c.SizeOverLife.Evaluate(t)
+		d[3] = p.rotation
+		d[4] = color[0]
+		d[5] = color[1]
+		d[6] = color[2]
+		d[7] = color[3]
+		d[8] = uv[0]
+		d[9] = uv[1]
+		d[10] = uv[2]
+		d[11] = uv[3]
+	}
+}
+
+func (e *ParticleEmitter) initBuffers() {
+	gl.GenVertexArrays(1, &e.vaoId)
+	gl.BindVertexArray(e.vaoId)
+
+	// Unit quad centered in 0,0 drawn as a triangle strip
+	quad := []float32{-0.5, -0.5, 0.5, -0.5, -0.5, 0.5, 0.5, 0.5}
+	gl.GenBuffers(1, &e.vboQuad)
+	gl.BindBuffer(gl.ARRAY_BUFFER, e.vboQuad)
+	gl.BufferData(gl.ARRAY_BUFFER, len(quad)*FLOAT32_SIZE, gl.Ptr(quad), gl.STATIC_DRAW)
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointer(0, 2, gl.FLOAT, false, 0, gl.PtrOffset(0))
+
+	gl.GenBuffers(1, &e.vboInstances)
+	gl.BindBuffer(gl.ARRAY_BUFFER, e.vboInstances)
+	stride := int32(particleInstanceSize * FLOAT32_SIZE)
+	for i := uint32(0); i < 3; i++ {
+		gl.EnableVertexAttribArray(1 + i)
+		gl.VertexAttribPointer(1+i, 4, gl.FLOAT, false, stride, gl.PtrOffset(int(i)*4*FLOAT32_SIZE))
+		gl.VertexAttribDivisor(1+i, 1)
+	}
+	gl.BindVertexArray(0)
+}
+
+// Release frees the OpenGL buffers
+func (e *ParticleEmitter) Release() {
+	if e.vaoId == 0 {
+		return
+	}
+	gl.DeleteBuffers(1, &e.vboQuad)
+	gl.DeleteBuffers(1, &e.vboInstances)
+	gl.DeleteVertexArrays(1, &e.vaoId)
+	e.vaoId = 0
+}
+
+// EnqueueForDrawing adds the emitter to the drawing list of the context
+func (e *ParticleEmitter) EnqueueForDrawing(context *Context) {
+	context.EnqueueForDrawing(e)
+}
+
+// Drawable implementation
+
+// Texture returns the particles texture
+func (e *ParticleEmitter) Texture() *Texture {
+	return e.Config.Texture
+}
+
+// Shader returns the instancing shader, textured or not
+func (e *ParticleEmitter) Shader() *ShaderProgram {
+	if e.Config.Texture != nil {
+		if particleShaderTexture == nil {
+			particleShaderTexture = NewShaderProgram(VertexShaderParticles, "", FragmentShaderParticlesTexture)
+		}
+		return particleShaderTexture
+	}
+	if particleShaderColor == nil {
+		particleShaderColor = NewShaderProgram(VertexShaderParticles, "", FragmentShaderParticlesColor)
+	}
+	return particleShaderColor
+}
+
+// Draw binds texture and shader and draws the particles
+func (e *ParticleEmitter) Draw(context *Context) {
+	if e.Config.Texture != nil {
+		gl.BindTexture(gl.TEXTURE_2D, e.Config.Texture.Id())
+	}
+	shader := e.Shader()
+	gl.UseProgram(shader.Id())
+	shader.SetUniform("mProjection", &context.projectionMatrix)
+	e.DrawInBatch(context)
+}
+
+// DrawInBatch draws the particles, texture and shader are already bound
+func (e *ParticleEmitter) DrawInBatch(context *Context) {
+	if len(e.particles) == 0 {
+		return
+	}
+	if e.vaoId == 0 {
+		e.initBuffers()
+	}
+	if e.dirty {
+		e.buildInstanceData()
+		gl.BindBuffer(gl.ARRAY_BUFFER, e.vboInstances)
+		// Orphan the buffer to avoid waiting for the previous frame
+		gl.BufferData(gl.ARRAY_BUFFER, len(e.instanceData)*FLOAT32_SIZE, nil, gl.STREAM_DRAW)
+		gl.BufferData(gl.ARRAY_BUFFER, len(e.instanceData)*FLOAT32_SIZE, gl.Ptr(e.instanceData), gl.STREAM_DRAW)
+		e.dirty = false
+	}
+
+	if e.Config.LocalSpace {
+		e.modelMatrix = mgl32.Translate3D(e.position.X(), e.position.Y(), e.position.Z())
+	} else {
+		e.modelMatrix = mgl32.Translate3D(0, 0, e.position.Z())
+	}
+	e.Shader().SetUniform("mModel", &e.modelMatrix)
+
+	// Particles are translucent: they must not hide what is drawn after them
+	gl.DepthMask(false)
+	if e.Config.Additive {
+		gl.BlendFunc(gl.SRC_ALPHA, gl.ONE)
+	}
+	gl.BindVertexArray(e.vaoId)
+	gl.DrawArraysInstanced(gl.TRIANGLE_STRIP, 0, 4, int32(len(e.particles)))
+	gl.BindVertexArray(0)
+	if e.Config.Additive {
+		gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	}
+	gl.DepthMask(true)
+}
+
+// Drawable end
+
+const (
+	VertexShaderParticles = `
+        #version 410 core
+
+        uniform mat4 mModel;
+        uniform mat4 mProjection;
+
+        layout(location=0) in vec2 vertex;
+        // x, y, size, rotation
+        layout(location=1) in vec4 transform;
+        layout(location=2) in vec4 color;
+        // u0, v0, u1, v1
+        layout(location=3) in vec4 region;
+
+        out vec2 uv_out;
+        out vec4 color_out;
+
+        void main() {
+            float s = sin(transform.w);
+            float c = cos(transform.w);
+            vec2 position = vec2(c*vertex.x - s*vertex.y, s*vertex.x + c*vertex.y) * transform.z + transform.xy;
+            gl_Position = mProjection * mModel * vec4(position, 0, 1);
+            uv_out = mix(region.xy, region.zw, vertex + 0.5);
+            color_out = color;
+        }
+        ` + "\x00"
+
+	FragmentShaderParticlesTexture = `
+        #version 410 core
+
+        in vec2 uv_out;
+        in vec4 color_out;
+        out vec4 out_color;
+
+        uniform sampler2D tex;
+
+        void main() {
+            out_color = texture(tex, uv_out) * color_out;
+        }
+        ` + "\x00"
+
+	FragmentShaderParticlesColor = `
+        #version 410 core
+
+        in vec2 uv_out;
+        in vec4 color_out;
+        out vec4 out_color;
+
+        void main() {
+            out_color = color_out;
+        }
+        ` + "\x00"
+)
@@ -0,0 +1,78 @@
+package graphics
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestCurveEvaluate(t *testing.T) {
+	curve := NewCurve(CurveKey{1, 0}, CurveKey{0, 1}, CurveKey{0.5, 2})
+	var tests = []struct {
+		time  float32
+		value float32
+	}{{-1, 1}, {0, 1}, {0.25, 1.5}, {0.5, 2}, {0.75, 1}, {1, 0}, {2, 0}}
+	for _, test := range tests {
+		if v := curve.Evaluate(test.time); v != test.value {
+			t.Errorf("At %v got %v, expecting %v", test.time, v, test.value)
+		}
+	}
+
+	gradient := NewLinearGradient(Color{0, 0, 0, 1}, Color{1, 0.5, 0, 0})
+	if c := gradient.Evaluate(0.5); c != (Color{0.5, 0.25, 0, 0.5}) {
+		t.Errorf("Got color %v", c)
+	}
+}
+
+func TestParticleEmitterUpdate(t *testing.T) {
+	config := DefaultParticleEmitterConfig()
+	config.SpawnRate = 10
+	config.Bursts = []ParticleBurst{{Time: 0, Count: 5}}
+	config.Duration = 2
+	config.Looping = false
+	config.Lifetime = mgl32.Vec2{1.5, 1.5}
+	config.Gravity = mgl32.Vec2{0, 100}
+
+	e := NewParticleEmitter(mgl32.Vec3{100, 100, 0}, config)
+	e.SetSeed(1)
+
+	// 5 from the burst and 10 per second
+	e.Update(0.5)
+	if n := e.NumParticles(); n != 10 {
+		t.Errorf("Got %d particles, expecting 10", n)
+	}
+	e.Update(0.5)
+	if n := e.NumParticles(); n != 15 {
+		t.Errorf("Got %d particles, expecting 15", n)
+	}
+	e.Update(0.75)
+	if n := e.NumParticles(); n != 22 {
+		t.Errorf("Got %d particles, expecting 22", n)
+	}
+	// The first 15 particles die and the emitter stops after 2s
+	e.Update(0.75)
+	if n := e.NumParticles(); n != 15 {
+		t.Errorf("Got %d particles, expecting 15", n)
+	}
+	if e.Emitting() {
+		t.Errorf("Not looping emitter should stop")
+	}
+	e.Update(2)
+	if n := e.NumParticles(); n != 0 {
+		t.Errorf("Got %d particles, expecting none", n)
+	}
+}
+
+func TestParticleEmitterMaxParticles(t *testing.T) {
+	config := DefaultParticleEmitterConfig()
+	config.MaxParticles = 100
+	e := NewParticleEmitter(mgl32.Vec3{}, config)
+	e.Emit(500)
+	if n := e.NumParticles(); n != 100 {
+		t.Errorf("Got %d particles, expecting 100", n)
+	}
+	e.buildInstanceData()
+	if len(e.instanceData) != 100*particleInstanceSize {
+		t.Errorf("Wrong instance data size %d", len(e.instanceData))
+	}
+}
@@ -32,6 +32,8 @@ type Primitive2D struct {
 	flipY       bool
 	color       Color
 	modelMatrix ModelMatrix
+	// Buffers of the additional vertex attributes by shader location
+	vboAttributes map[uint32]uint32
 }
 
 func (p *Primitive2D) SetPosition(position mgl32.Vec3) {
@@ -167,6 +169,46 @@ func NewQuadPrimitive(position mgl32.Vec3, size mgl32.Vec2) *Primitive2D {
 	return q
 }
 
+var rectangleShaderProgram *ShaderProgram
+
+// NewRectanglePrimitive creates a filled rectangle drawn with a solid color.
+// All the rectangles share the same shader
+func NewRectanglePrimitive(position mgl32.Vec3, size mgl32.Vec2) *Primitive2D {
+	if rectangleShaderProgram == nil {
+		rectangleShaderProgram = NewShaderProgram(VertexShaderPrimitive2D, "", FragmentShaderSolidColor)
+	}
+
+	q := &Primitive2D{}
+	q.position = position
+	q.size = size
+	q.scale = mgl32.Vec2{1, 1}
+	q.shaderProgram = rectangleShaderProgram
+	q.rebuildMatrices()
+
+	q.arrayMode = gl.TRIANGLE_FAN
+	q.arraySize = 4
+	q.SetVertices([]float32{0, 0, 0, 1, 1, 1, 1, 0})
+	return q
+}
+
+// NewLineStripPrimitive creates a line through vertices set later with
+// SetVertices, relative to the top left and scaled by size. Drawn with a
+// solid color, the shader is shared with the rectangles
+func NewLineStripPrimitive(position mgl32.Vec3, size mgl32.Vec2) *Primitive2D {
+	if rectangleShaderProgram == nil {
+		rectangleShaderProgram = NewShaderProgram(VertexShaderPrimitive2D, "", FragmentShaderSolidColor)
+	}
+
+	p := &Primitive2D{}
+	p.position = position
+	p.size = size
+	p.scale = mgl32.Vec2{1, 1}
+	p.shaderProgram = rectangleShaderProgram
+	p.rebuildMatrices()
+	p.arrayMode = gl.LINE_STRIP
+	return p
+}
+
 func NewRegularPolygonPrimitive(position mgl32.Vec3, radius float32, numSegments int, filled bool) *Primitive2D {
 	circlePoints, err := utils.CircleToPolygon(mgl32.Vec2{0.5, 0.5}, 0.5, numSegments, 0)
 	if err != nil {
@@ -284,6 +326,49 @@ func (p *Primitive2D) SetUVCoords(uvCoords []float32) {
 	gl.BindVertexArray(0)
 }
 
+// SetVertexAttribute uploads an additional per-vertex attribute for custom
+// shaders. Locations 0 and 1 are taken by vertices and UV coordinates, size
+// is the number of components of each value
+func (p *Primitive2D) SetVertexAttribute(location uint32, size int32, values []float32) {
+	if p.vaoId == 0 {
+		gl.GenVertexArrays(1, &p.vaoId)
+	}
+	if p.vboAttributes == nil {
+		p.vboAttributes = make(map[uint32]uint32)
+	}
+	gl.BindVertexArray(p.vaoId)
+	vbo, ok := p.vboAttributes[location]
+	if !ok {
+		gl.GenBuffers(1, &vbo)
+		p.vboAttributes[location] = vbo
+	}
+	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, len(values)*FLOAT32_SIZE, gl.Ptr(values), gl.STATIC_DRAW)
+	gl.EnableVertexAttribArray(location)
+	gl.VertexAttribPointer(location, size, gl.FLOAT, false, 0, gl.PtrOffset(0))
+	gl.BindVertexArray(0)
+}
+
+// Release frees the OpenGL buffers of the primitive
+func (p *Primitive2D) Release() {
+	for location, vbo := range p.vboAttributes {
+		gl.DeleteBuffers(1, &vbo)
+		delete(p.vboAttributes, location)
+	}
+	if p.vboVertices != 0 {
+		gl.DeleteBuffers(1, &p.vboVertices)
+		p.vboVertices = 0
+	}
+	if p.vboUVCoords != 0 {
+		gl.DeleteBuffers(1, &p.vboUVCoords)
+		p.vboUVCoords = 0
+	}
+	if p.vaoId != 0 {
+		gl.DeleteVertexArrays(1, &p.vaoId)
+		p.vaoId = 0
+	}
+}
+
 const (
 	VertexShaderPrimitive2D = `
         #version 410 core
@@ -191,4 +191,23 @@ const (
             color = texture(tex, uv_out);
         }
         ` + "\x00"
+
+	FragmentShaderTextureColor = `
+        #version 410 core
+
+        in vec2 uv_out;
+        out vec4 out_color;
+
+        uniform sampler2D tex;
+        uniform vec4 color;
+
+        void main() {
+            vec4 texel = texture(tex, uv_out);
+            if(texel.a == 0.0)
+            {
+                discard;
+            }
+            out_color = texel * color;
+        }
+        ` + "\x00"
 )
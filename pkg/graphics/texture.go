@@ -92,6 +92,35 @@ func NewEmptyTexture(width int, height int) (*Texture, error) {
 	return texture, nil
 }
 
+// SetImage replaces the content of the texture, the size can change
+func (t *Texture) SetImage(imageData *image.RGBA) {
+	t.width = int32(imageData.Bounds().Dx())
+	t.height = int32(imageData.Bounds().Dy())
+	gl.BindTexture(gl.TEXTURE_2D, t.id)
+	gl.TexImage2D(
+		gl.TEXTURE_2D, 0, gl.RGBA, t.width, t.height,
+		0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(imageData.Pix),
+	)
+}
+
+// Image reads the content of the texture back from the GPU
+func (t *Texture) Image() *image.RGBA {
+	imageData := image.NewRGBA(image.Rect(0, 0, int(t.width), int(t.height)))
+	gl.BindTexture(gl.TEXTURE_2D, t.id)
+	gl.GetTexImage(gl.TEXTURE_2D, 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(imageData.Pix))
+	return imageData
+}
+
 func (t *Texture) Id() uint32 {
 	return t.id
 }
+
+// Width returns the width in pixels
+func (t *Texture) Width() int {
+	return int(t.width)
+}
+
+// Height returns the height in pixels
+func (t *Texture) Height() int {
+	return int(t.height)
+}
@@ -0,0 +1,813 @@
+package graphics
+
+// Tile map loader and renderer for maps made with Tiled, see
+// http://doc.mapeditor.org/en/stable/reference/tmx-map-format/
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// TileMapOrientation is the projection used to lay out the tiles
+type TileMapOrientation int
+
+const (
+	ORTHOGONAL TileMapOrientation = iota
+	ISOMETRIC
+)
+
+// Bits used by Tiled to store the flip flags in the upper part of a gid
+const (
+	tileFlippedHorizontally uint32 = 0x80000000
+	tileFlippedVertically   uint32 = 0x40000000
+	tileFlippedDiagonally   uint32 = 0x20000000
+	tileFlagsMask                  = tileFlippedHorizontally | tileFlippedVertically | tileFlippedDiagonally
+)
+
+// Number of tiles per side of a render chunk
+const tileMapChunkSize = 16
+
+// Properties holds the custom properties set in Tiled on maps, layers,
+// tilesets, tiles and objects
+type Properties map[string]Property
+
+// Property is a single custom property. Value is kept in its textual form
+type Property struct {
+	Name  string
+	Type  string
+	Value string
+}
+
+// String returns the property value or def if the property is missing
+func (p Properties) String(name string, def string) string {
+	if prop, ok := p[name]; ok {
+		return prop.Value
+	}
+	return def
+}
+
+// Int returns the property value as int or def if missing or not a number
+func (p Properties) Int(name string, def int) int {
+	if prop, ok := p[name]; ok {
+		if v, err := strconv.Atoi(prop.Value); err == nil {
+			return v
+		}
+	}
+	return def
+}
+
+// Float returns the property value as float32 or def if missing or not a number
+func (p Properties) Float(name string, def float32) float32 {
+	if prop, ok := p[name]; ok {
+		if v, err := strconv.ParseFloat(prop.Value, 32); err == nil {
+			return float32(v)
+		}
+	}
+	return def
+}
+
+// Bool returns the property value as bool or def if missing or not a bool
+func (p Properties) Bool(name string, def bool) bool {
+	if prop, ok := p[name]; ok {
+		if v, err := strconv.ParseBool(prop.Value); err == nil {
+			return v
+		}
+	}
+	return def
+}
+
+// TileFrame is a single frame of an animated tile
+type TileFrame struct {
+	TileID   int
+	Duration int // milliseconds
+}
+
+// TileInfo holds the per-tile data defined in a tileset
+type TileInfo struct {
+	ID         int
+	Type       string
+	Properties Properties
+	Animation  []TileFrame
+	// Only used by image collection tilesets
+	Image       string
+	ImageWidth  int
+	ImageHeight int
+	texture     *Texture
+}
+
+// Tileset is a set of tiles sharing the same image (or a collection of images)
+type Tileset struct {
+	FirstGID    int
+	Name        string
+	TileWidth   int
+	TileHeight  int
+	Spacing     int
+	Margin      int
+	TileCount   int
+	Columns     int
+	TileOffset  mgl32.Vec2
+	Image       string
+	ImageWidth  int
+	ImageHeight int
+	Properties  Properties
+	Tiles       map[int]*TileInfo
+	texture     *Texture
+	totalFrames map[int]int
+}
+
+// Texture returns the tileset image texture, nil for image collections
+func (ts *Tileset) Texture() *Texture {
+	return ts.texture
+}
+
+// Contains returns true if the gid (flags stripped) belongs to the tileset
+func (ts *Tileset) Contains(gid int) bool {
+	return gid >= ts.FirstGID && gid < ts.FirstGID+ts.TileCount
+}
+
+// tileRegion returns the texture, the size in pixels and the UV rectangle
+// (u0, v0, u1, v1) of a tile given its local id
+func (ts *Tileset) tileRegion(id int) (*Texture, mgl32.Vec2, [4]float32) {
+	if ts.texture == nil {
+		info, ok := ts.Tiles[id]
+		if !ok || info.texture == nil {
+			return nil, mgl32.Vec2{}, [4]float32{}
+		}
+		size := mgl32.Vec2{float32(info.ImageWidth), float32(info.ImageHeight)}
+		return info.texture, size, [4]float32{0, 0, 1, 1}
+	}
+	columns := ts.Columns
+	if columns <= 0 {
+		columns = 1
+	}
+	x := ts.Margin + (id%columns)*(ts.TileWidth+ts.Spacing)
+	y := ts.Margin + (id/columns)*(ts.TileHeight+ts.Spacing)
+	w := float32(ts.texture.width)
+	h := float32(ts.texture.height)
+	uv := [4]float32{
+		float32(x) / w,
+		float32(y) / h,
+		float32(x+ts.TileWidth) / w,
+		float32(y+ts.TileHeight) / h,
+	}
+	return ts.texture, mgl32.Vec2{float32(ts.TileWidth), float32(ts.TileHeight)}, uv
+}
+
+// animatedTile returns the local id of the frame to show at the given time
+func (ts *Tileset) animatedTile(id int, timeMs int) int {
+	info, ok := ts.Tiles[id]
+	if !ok || len(info.Animation) == 0 {
+		return id
+	}
+	total := ts.totalFrames[id]
+	if total <= 0 {
+		return info.Animation[0].TileID
+	}
+	t := timeMs % total
+	for _, frame := range info.Animation {
+		if t < frame.Duration {
+			return frame.TileID
+		}
+		t -= frame.Duration
+	}
+	return info.Animation[len(info.Animation)-1].TileID
+}
+
+func (ts *Tileset) computeColumns() {
+	if ts.Columns == 0 && ts.ImageWidth > 0 && ts.TileWidth > 0 {
+		ts.Columns = (ts.ImageWidth - 2*ts.Margin + ts.Spacing) / (ts.TileWidth + ts.Spacing)
+	}
+	if ts.TileCount == 0 {
+		if ts.Image != "" && ts.TileHeight > 0 && ts.Columns > 0 {
+			rows := (ts.ImageHeight - 2*ts.Margin + ts.Spacing) / (ts.TileHeight + ts.Spacing)
+			ts.TileCount = rows * ts.Columns
+		} else {
+			for id := range ts.Tiles {
+				if id+1 > ts.TileCount {
+					ts.TileCount = id + 1
+				}
+			}
+		}
+	}
+	ts.totalFrames = make(map[int]int)
+	for id, info := range ts.Tiles {
+		total := 0
+		for _, frame := range info.Animation {
+			total += frame.Duration
+		}
+		ts.totalFrames[id] = total
+	}
+}
+
+// Tile is a cell of a tile layer
+type Tile struct {
+	GID      int
+	FlipX    bool
+	FlipY    bool
+	FlipDiag bool
+}
+
+// Empty returns true if there is no tile in the cell
+func (t Tile) Empty() bool {
+	return t.GID == 0
+}
+
+func decodeTile(raw uint32) Tile {
+	return Tile{
+		GID:      int(raw &^ tileFlagsMask),
+		FlipX:    raw&tileFlippedHorizontally != 0,
+		FlipY:    raw&tileFlippedVertically != 0,
+		FlipDiag: raw&tileFlippedDiagonally != 0,
+	}
+}
+
+// TileLayer is a grid of tiles
+type TileLayer struct {
+	Name       string
+	Width      int
+	Height     int
+	Opacity    float32
+	Visible    bool
+	Offset     mgl32.Vec2
+	Properties Properties
+	tiles      []Tile
+	chunks     []*tileChunk
+}
+
+// Tile returns the tile at col, row. An empty tile is returned if out of bounds
+func (l *TileLayer) Tile(col, row int) Tile {
+	if col < 0 || row < 0 || col >= l.Width || row >= l.Height {
+		return Tile{}
+	}
+	return l.tiles[row*l.Width+col]
+}
+
+// ObjectShape is the shape of a Tiled object
+type ObjectShape int
+
+const (
+	SHAPE_RECTANGLE ObjectShape = iota
+	SHAPE_ELLIPSE
+	SHAPE_POINT
+	SHAPE_POLYGON
+	SHAPE_POLYLINE
+	SHAPE_TILE
+)
+
+// Object is an element of an object layer: spawn points, triggers, colliders...
+type Object struct {
+	ID         int
+	Name       string
+	Type       string
+	Position   mgl32.Vec2
+	Size       mgl32.Vec2
+	Rotation   float32 // degrees, clockwise
+	Visible    bool
+	Shape      ObjectShape
+	Points     []mgl32.Vec2 // polygon and polyline points, relative to Position
+	Tile       Tile         // only set for tile objects
+	Properties Properties
+}
+
+// ObjectGroup is an object layer
+type ObjectGroup struct {
+	Name       string
+	Visible    bool
+	Offset     mgl32.Vec2
+	Properties Properties
+	Objects    []*Object
+}
+
+// ObjectByName returns the first object with the given name or nil
+func (og *ObjectGroup) ObjectByName(name string) *Object {
+	for _, o := range og.Objects {
+		if o.Name == name {
+			return o
+		}
+	}
+	return nil
+}
+
+// ObjectsByType returns all the objects of the given type
+func (og *ObjectGroup) ObjectsByType(objectType string) []*Object {
+	objects := make([]*Object, 0)
+	for _, o := range og.Objects {
+		if o.Type == objectType {
+			objects = append(objects, o)
+		}
+	}
+	return objects
+}
+
+// TileMap is a map made of tile layers and object layers
+type TileMap struct {
+	Orientation  TileMapOrientation
+	Width        int
+	Height       int
+	TileWidth    int
+	TileHeight   int
+	Properties   Properties
+	Tilesets     []*Tileset
+	Layers       []*TileLayer
+	ObjectGroups []*ObjectGroup
+
+	position       mgl32.Vec3
+	layerDepthStep float32
+	viewMin        mgl32.Vec2
+	viewMax        mgl32.Vec2
+	hasViewport    bool
+	timeMs         float64
+	frames         map[*Tileset]map[int]int
+	shaderProgram  *ShaderProgram
+}
+
+// tileChunk is a block of tileMapChunkSize^2 tiles of a layer sharing the
+// same texture, uploaded as a single set of triangles
+type tileChunk struct {
+	layer     *TileLayer
+	texture   *Texture
+	col, row  int
+	boundsMin mgl32.Vec2
+	boundsMax mgl32.Vec2
+	tilesets  map[*Tileset]bool
+	animated  bool
+	primitive *Primitive2D
+}
+
+var tileMapShaderProgram *ShaderProgram
+
+// NewTileMapFromFile loads a Tiled map in TMX (.tmx) or JSON (.json, .tmj)
+// format along with its tilesets and textures
+func NewTileMapFromFile(filePath string) *TileMap {
+	tm, err := parseTileMapFile(filePath)
+	if err != nil {
+		log.Panicf("Loading tile map. %s", err)
+		return nil
+	}
+	tm.loadTextures(filepath.Dir(filePath))
+	tm.buildChunks()
+	return tm
+}
+
+func parseTileMapFile(filePath string) (*TileMap, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	dir := filepath.Dir(filePath)
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".json", ".tmj":
+		return parseTileMapJSON(file, dir)
+	default:
+		return parseTileMapTMX(file, dir)
+	}
+}
+
+func newTileMap() *TileMap {
+	return &TileMap{
+		Properties:     make(Properties),
+		layerDepthStep: 0.001,
+		frames:         make(map[*Tileset]map[int]int),
+	}
+}
+
+func (tm *TileMap) loadTextures(dir string) {
+	for _, ts := range tm.Tilesets {
+		if ts.Image != "" {
+			ts.texture = NewTextureFromFile(filepath.Join(dir, ts.Image))
+			ts.ImageWidth = int(ts.texture.width)
+			ts.ImageHeight = int(ts.texture.height)
+		}
+		for _, info := range ts.Tiles {
+			if info.Image != "" {
+				info.texture = NewTextureFromFile(filepath.Join(dir, info.Image))
+				info.ImageWidth = int(info.texture.width)
+				info.ImageHeight = int(info.texture.height)
+			}
+		}
+		ts.computeColumns()
+	}
+}
+
+// PixelSize returns the size of the whole map in pixels
+func (tm *TileMap) PixelSize() mgl32.Vec2 {
+	if tm.Orientation == ISOMETRIC {
+		return mgl32.Vec2{
+			float32((tm.Width + tm.Height) * tm.TileWidth / 2),
+			float32((tm.Width + tm.Height) * tm.TileHeight / 2),
+		}
+	}
+	return mgl32.Vec2{float32(tm.Width * tm.TileWidth), float32(tm.Height * tm.TileHeight)}
+}
+
+// SetPosition moves the whole map. Z is used for the first layer, following
+// layers are drawn slightly closer to the camera
+func (tm *TileMap) SetPosition(position mgl32.Vec3) {
+	tm.position = position
+	for i, layer := range tm.Layers {
+		for _, chunk := range layer.chunks {
+			chunk.primitive.SetPosition(tm.layerPosition(i, layer))
+		}
+	}
+}
+
+// Position returns the map position
+func (tm *TileMap) Position() mgl32.Vec3 {
+	return tm.position
+}
+
+// SetLayerDepthStep changes the z distance between two consecutive layers
+func (tm *TileMap) SetLayerDepthStep(step float32) {
+	tm.layerDepthStep = step
+	tm.SetPosition(tm.position)
+}
+
+// SetViewport sets the visible rectangle in context coordinates, chunks
+// outside of it are culled. Without a viewport every chunk is drawn
+func (tm *TileMap) SetViewport(topLeft mgl32.Vec2, size mgl32.Vec2) {
+	tm.viewMin = topLeft
+	tm.viewMax = topLeft.Add(size)
+	tm.hasViewport = true
+}
+
+// ClearViewport disables culling
+func (tm *TileMap) ClearViewport() {
+	tm.hasViewport = false
+}
+
+// Layer returns the tile layer with the given name or nil
+func (tm *TileMap) Layer(name string) *TileLayer {
+	for _, l := range tm.Layers {
+		if l.Name == name {
+			return l
+		}
+	}
+	return nil
+}
+
+// ObjectGroup returns the object layer with the given name or nil
+func (tm *TileMap) ObjectGroup(name string) *ObjectGroup {
+	for _, og := range tm.ObjectGroups {
+		if og.Name == name {
+			return og
+		}
+	}
+	return nil
+}
+
+// TilesetForGID returns the tileset containing gid (flags are ignored)
+func (tm *TileMap) TilesetForGID(gid int) *Tileset {
+	gid = int(uint32(gid) &^ tileFlagsMask)
+	if gid == 0 {
+		return nil
+	}
+	// Tilesets are sorted by FirstGID, the last one starting before gid wins
+	var found *Tileset
+	for _, ts := range tm.Tilesets {
+		if ts.FirstGID <= gid {
+			found = ts
+		}
+	}
+	return found
+}
+
+// TileInfo returns the tileset data (type, properties, animation) of a gid
+func (tm *TileMap) TileInfo(gid int) *TileInfo {
+	ts := tm.TilesetForGID(gid)
+	if ts == nil {
+		return nil
+	}
+	return ts.Tiles[int(uint32(gid)&^tileFlagsMask)-ts.FirstGID]
+}
+
+// TileProperties returns the properties of the tile at col, row of a layer
+func (tm *TileMap) TileProperties(layer *TileLayer, col, row int) Properties {
+	info := tm.TileInfo(layer.Tile(col, row).GID)
+	if info == nil {
+		return Properties{}
+	}
+	return info.Properties
+}
+
+// TileCoordsAt converts a point in map space (pixels, origin at the top left
+// corner of the map) into tile coordinates. Coordinates can be out of bounds
+func (tm *TileMap) TileCoordsAt(point mgl32.Vec2) (col int, row int) {
+	tw := float64(tm.TileWidth)
+	th := float64(tm.TileHeight)
+	if tm.Orientation == ISOMETRIC {
+		x := float64(point.X()) - float64(tm.Height)*tw/2
+		y := float64(point.Y())
+		col = int(math.Floor(y/th + x/tw))
+		row = int(math.Floor(y/th - x/tw))
+		return col, row
+	}
+	return int(math.Floor(float64(point.X()) / tw)), int(math.Floor(float64(point.Y()) / th))
+}
+
+// TileCoordsAtWorld is like TileCoordsAt but takes the map position into account
+func (tm *TileMap) TileCoordsAtWorld(point mgl32.Vec2) (col int, row int) {
+	return tm.TileCoordsAt(point.Sub(mgl32.Vec2{tm.position.X(), tm.position.Y()}))
+}
+
+// TileOrigin returns the top left corner in map space of the bounding box of
+// the cell at col, row
+func (tm *TileMap) TileOrigin(col, row int) mgl32.Vec2 {
+	if tm.Orientation == ISOMETRIC {
+		return mgl32.Vec2{
+			float32((col-row)*tm.TileWidth/2 + (tm.Height-1)*tm.TileWidth/2),
+			float32((col + row) * tm.TileHeight / 2),
+		}
+	}
+	return mgl32.Vec2{float32(col * tm.TileWidth), float32(row * tm.TileHeight)}
+}
+
+// TileAt returns the tile of a layer under a point in map space
+func (tm *TileMap) TileAt(layer *TileLayer, point mgl32.Vec2) Tile {
+	col, row := tm.TileCoordsAt(point.Sub(layer.Offset))
+	return layer.Tile(col, row)
+}
+
+// SetTile changes a tile of a layer and rebuilds the chunk containing it
+func (tm *TileMap) SetTile(layer *TileLayer, col, row int, tile Tile) {
+	if col < 0 || row < 0 || col >= layer.Width || row >= layer.Height {
+		return
+	}
+	layer.tiles[row*layer.Width+col] = tile
+	if layer.chunks == nil {
+		return
+	}
+	chunkCol := col / tileMapChunkSize
+	chunkRow := row / tileMapChunkSize
+	// Drop the chunks of the affected block and rebuild them
+	chunks := layer.chunks[:0]
+	for _, chunk := range layer.chunks {
+		if chunk.col != chunkCol || chunk.row != chunkRow {
+			chunks = append(chunks, chunk)
+		} else {
+			chunk.primitive.Release()
+		}
+	}
+	layer.chunks = chunks
+	for i, l := range tm.Layers {
+		if l == layer {
+			tm.buildLayerChunk(i, layer, chunkCol, chunkRow)
+		}
+	}
+}
+
+// Update advances the animated tiles. deltaTime is in seconds
+func (tm *TileMap) Update(deltaTime float64) {
+	tm.timeMs += deltaTime * 1000
+
+	// Find the tilesets having at least one animation changing frame
+	changed := make(map[*Tileset]bool)
+	for _, ts := range tm.Tilesets {
+		for id := range ts.totalFrames {
+			if ts.totalFrames[id] == 0 {
+				continue
+			}
+			frame := ts.animatedTile(id, int(tm.timeMs))
+			if tm.frames[ts] == nil {
+				tm.frames[ts] = make(map[int]int)
+			}
+			if tm.frames[ts][id] != frame {
+				tm.frames[ts][id] = frame
+				changed[ts] = true
+			}
+		}
+	}
+	if len(changed) == 0 {
+		return
+	}
+
+	for _, layer := range tm.Layers {
+		for _, chunk := range layer.chunks {
+			if !chunk.animated {
+				continue
+			}
+			for ts := range changed {
+				if chunk.tilesets[ts] {
+					_, uvCoords := tm.chunkVertices(chunk, int(tm.timeMs))
+					chunk.primitive.SetUVCoords(uvCoords)
+					break
+				}
+			}
+		}
+	}
+}
+
+// EnqueueForDrawing adds the visible chunks of the visible layers to the
+// drawing list of the context
+func (tm *TileMap) EnqueueForDrawing(context *Context) {
+	for _, layer := range tm.Layers {
+		if !layer.Visible {
+			continue
+		}
+		for _, chunk := range layer.chunks {
+			if tm.chunkVisible(chunk) {
+				context.EnqueueForDrawing(chunk.primitive)
+			}
+		}
+	}
+}
+
+// Draw draws the visible chunks straight away
+func (tm *TileMap) Draw(context *Context) {
+	for _, layer := range tm.Layers {
+		if !layer.Visible {
+			continue
+		}
+		for _, chunk := range layer.chunks {
+			if tm.chunkVisible(chunk) {
+				chunk.primitive.Draw(context)
+			}
+		}
+	}
+}
+
+func (tm *TileMap) chunkVisible(chunk *tileChunk) bool {
+	if !tm.hasViewport {
+		return true
+	}
+	offset := mgl32.Vec2{tm.position.X(), tm.position.Y()}.Add(chunk.layer.Offset)
+	min := chunk.boundsMin.Add(offset)
+	max := chunk.boundsMax.Add(offset)
+	return max.X() >= tm.viewMin.X() && min.X() <= tm.viewMax.X() &&
+		max.Y() >= tm.viewMin.Y() && min.Y() <= tm.viewMax.Y()
+}
+
+func (tm *TileMap) layerPosition(index int, layer *TileLayer) mgl32.Vec3 {
+	return mgl32.Vec3{
+		tm.position.X() + layer.Offset.X(),
+		tm.position.Y() + layer.Offset.Y(),
+		tm.position.Z() - float32(index)*tm.layerDepthStep,
+	}
+}
+
+func (tm *TileMap) buildChunks() {
+	if tileMapShaderProgram == nil {
+		tileMapShaderProgram = NewShaderProgram(VertexShaderPrimitive2D, "", FragmentShaderTextureColor)
+	}
+	tm.shaderProgram = tileMapShaderProgram
+
+	for i, layer := range tm.Layers {
+		layer.chunks = make([]*tileChunk, 0)
+		for row := 0; row*tileMapChunkSize < layer.Height; row++ {
+			for col := 0; col*tileMapChunkSize < layer.Width; col++ {
+				tm.buildLayerChunk(i, layer, col, row)
+			}
+		}
+	}
+}
+
+// buildLayerChunk creates one chunk per texture used in the block of tiles
+func (tm *TileMap) buildLayerChunk(index int, layer *TileLayer, chunkCol, chunkRow int) {
+	chunksByTexture := make(map[*Texture]*tileChunk)
+	tm.eachChunkTile(layer, chunkCol, chunkRow, func(col, row int, tile Tile, ts *Tileset) {
+		texture, _, _ := ts.tileRegion(tile.GID - ts.FirstGID)
+		if texture == nil {
+			return
+		}
+		chunk, ok := chunksByTexture[texture]
+		if !ok {
+			chunk = &tileChunk{
+				layer:    layer,
+				texture:  texture,
+				col:      chunkCol,
+				row:      chunkRow,
+				tilesets: make(map[*Tileset]bool),
+			}
+			chunksByTexture[texture] = chunk
+		}
+		chunk.tilesets[ts] = true
+		if info, ok := ts.Tiles[tile.GID-ts.FirstGID]; ok && len(info.Animation) > 0 {
+			chunk.animated = true
+		}
+	})
+
+	for _, chunk := range chunksByTexture {
+		vertices, uvCoords := tm.chunkVertices(chunk, int(tm.timeMs))
+		chunk.primitive = NewTriangles(
+			vertices, uvCoords, chunk.texture,
+			tm.layerPosition(index, layer), mgl32.Vec2{1, 1}, tm.shaderProgram,
+		)
+		chunk.primitive.SetColor(Color{1, 1, 1, layer.Opacity})
+		layer.chunks = append(layer.chunks, chunk)
+	}
+}
+
+func (tm *TileMap) eachChunkTile(layer *TileLayer, chunkCol, chunkRow int, f func(col, row int, tile Tile, ts *Tileset)) {
+	startCol := chunkCol * tileMapChunkSize
+	startRow := chunkRow * tileMapChunkSize
+	for row := startRow; row < startRow+tileMapChunkSize && row < layer.Height; row++ {
+		for col := startCol; col < startCol+tileMapChunkSize && col < layer.Width; col++ {
+			tile := layer.tiles[row*layer.Width+col]
+			if tile.Empty() {
+				continue
+			}
+			ts := tm.TilesetForGID(tile.GID)
+			if ts == nil {
+				continue
+			}
+			f(col, row, tile, ts)
+		}
+	}
+}
+
+// chunkVertices generates the triangles (in pixels, relative to the layer
+// origin) and UV coordinates of all the tiles of a chunk. It also updates
+// the chunk bounding box used for culling
+func (tm *TileMap) chunkVertices(chunk *tileChunk, timeMs int) ([]float32, []float32) {
+	vertices := make([]float32, 0, tileMapChunkSize*tileMapChunkSize*12)
+	uvCoords := make([]float32, 0, tileMapChunkSize*tileMapChunkSize*12)
+	boundsMin := mgl32.Vec2{math.MaxFloat32, math.MaxFloat32}
+	boundsMax := mgl32.Vec2{-math.MaxFloat32, -math.MaxFloat32}
+
+	tm.eachChunkTile(chunk.layer, chunk.col, chunk.row, func(col, row int, tile Tile, ts *Tileset) {
+		id := ts.animatedTile(tile.GID-ts.FirstGID, timeMs)
+		texture, size, uv := ts.tileRegion(id)
+		if texture != chunk.texture {
+			return
+		}
+
+		// Tiles are aligned to the bottom left corner of their cell
+		origin := tm.TileOrigin(col, row)
+		x := origin.X() + ts.TileOffset.X()
+		y := origin.Y() + float32(tm.TileHeight) - size.Y() + ts.TileOffset.Y()
+		w := size.X()
+		h := size.Y()
+		vertices = append(vertices,
+			x, y+h, // bl
+			x+w, y+h, // br
+			x, y, // tl
+			x, y, // tl
+			x+w, y+h, // br
+			x+w, y, // tr
+		)
+		uvCoords = append(uvCoords, tileUVCoords(uv, tile)...)
+
+		boundsMin = mgl32.Vec2{float32(math.Min(float64(boundsMin.X()), float64(x))), float32(math.Min(float64(boundsMin.Y()), float64(y)))}
+		boundsMax = mgl32.Vec2{float32(math.Max(float64(boundsMax.X()), float64(x+w))), float32(math.Max(float64(boundsMax.Y()), float64(y+h)))}
+	})
+
+	chunk.boundsMin = boundsMin
+	chunk.boundsMax = boundsMax
+	return vertices, uvCoords
+}
+
+// tileUVCoords returns the UV coordinates of the 6 vertices of a tile quad
+// applying the flip flags the same way Tiled does: diagonal, then horizontal,
+// then vertical
+func tileUVCoords(uv [4]float32, tile Tile) []float32 {
+	corner := func(cx, cy int) (float32, float32) {
+		if tile.FlipY {
+			cy = 1 - cy
+		}
+		if tile.FlipX {
+			cx = 1 - cx
+		}
+		if tile.FlipDiag {
+			cx, cy = cy, cx
+		}
+		u := uv[0]
+		if cx == 1 {
+			u = uv[2]
+		}
+		v := uv[1]
+		if cy == 1 {
+			v = uv[3]
+		}
+		return u, v
+	}
+	blU, blV := corner(0, 1)
+	brU, brV := corner(1, 1)
+	tlU, tlV := corner(0, 0)
+	trU, trV := corner(1, 0)
+	return []float32{
+		blU, blV,
+		brU, brV,
+		tlU, tlV,
+		tlU, tlV,
+		brU, brV,
+		trU, trV,
+	}
+}
+
+func parseTileMapOrientation(orientation string) (TileMapOrientation, error) {
+	switch orientation {
+	case "orthogonal", "":
+		return ORTHOGONAL, nil
+	case "isometric":
+		return ISOMETRIC, nil
+	}
+	return ORTHOGONAL, fmt.Errorf("unsupported map orientation '%s'", orientation)
+}
@@ -0,0 +1,336 @@
+package graphics
+
+// JSON flavour of the Tiled map format, see
+// http://doc.mapeditor.org/en/stable/reference/json-map-format/
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+type jsonProperty struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+type jsonProperties []jsonProperty
+
+type jsonFrame struct {
+	TileID   int `json:"tileid"`
+	Duration int `json:"duration"`
+}
+
+type jsonTile struct {
+	ID          int            `json:"id"`
+	Type        string         `json:"type"`
+	Class       string         `json:"class"`
+	Properties  jsonProperties `json:"properties"`
+	Image       string         `json:"image"`
+	ImageWidth  int            `json:"imagewidth"`
+	ImageHeight int            `json:"imageheight"`
+	Animation   []jsonFrame    `json:"animation"`
+}
+
+type jsonTileset struct {
+	FirstGID    int            `json:"firstgid"`
+	Source      string         `json:"source"`
+	Name        string         `json:"name"`
+	TileWidth   int            `json:"tilewidth"`
+	TileHeight  int            `json:"tileheight"`
+	Spacing     int            `json:"spacing"`
+	Margin      int            `json:"margin"`
+	TileCount   int            `json:"tilecount"`
+	Columns     int            `json:"columns"`
+	Image       string         `json:"image"`
+	ImageWidth  int            `json:"imagewidth"`
+	ImageHeight int            `json:"imageheight"`
+	Properties  jsonProperties `json:"properties"`
+	Tiles       []jsonTile     `json:"tiles"`
+	TileOffset  *struct {
+		X float32 `json:"x"`
+		Y float32 `json:"y"`
+	} `json:"tileoffset"`
+}
+
+type jsonPoint struct {
+	X float32 `json:"x"`
+	Y float32 `json:"y"`
+}
+
+type jsonObject struct {
+	ID         int            `json:"id"`
+	Name       string         `json:"name"`
+	Type       string         `json:"type"`
+	Class      string         `json:"class"`
+	X          float32        `json:"x"`
+	Y          float32        `json:"y"`
+	Width      float32        `json:"width"`
+	Height     float32        `json:"height"`
+	Rotation   float32        `json:"rotation"`
+	GID        uint32         `json:"gid"`
+	Visible    *bool          `json:"visible"`
+	Ellipse    bool           `json:"ellipse"`
+	Point      bool           `json:"point"`
+	Polygon    []jsonPoint    `json:"polygon"`
+	Polyline   []jsonPoint    `json:"polyline"`
+	Properties jsonProperties `json:"properties"`
+}
+
+type jsonLayer struct {
+	Type        string          `json:"type"`
+	Name        string          `json:"name"`
+	Width       int             `json:"width"`
+	Height      int             `json:"height"`
+	Opacity     *float32        `json:"opacity"`
+	Visible     *bool           `json:"visible"`
+	OffsetX     float32         `json:"offsetx"`
+	OffsetY     float32         `json:"offsety"`
+	Encoding    string          `json:"encoding"`
+	Compression string          `json:"compression"`
+	Data        json.RawMessage `json:"data"`
+	Chunks      json.RawMessage `json:"chunks"`
+	Objects     []jsonObject    `json:"objects"`
+	Layers      []jsonLayer     `json:"layers"`
+	Properties  jsonProperties  `json:"properties"`
+}
+
+type jsonMap struct {
+	Orientation string         `json:"orientation"`
+	Width       int            `json:"width"`
+	Height      int            `json:"height"`
+	TileWidth   int            `json:"tilewidth"`
+	TileHeight  int            `json:"tileheight"`
+	Infinite    bool           `json:"infinite"`
+	Tilesets    []jsonTileset  `json:"tilesets"`
+	Layers      []jsonLayer    `json:"layers"`
+	Properties  jsonProperties `json:"properties"`
+}
+
+func jsonDecode(reader io.Reader, v interface{}) error {
+	return json.NewDecoder(reader).Decode(v)
+}
+
+func parseTileMapJSON(reader io.Reader, dir string) (*TileMap, error) {
+	var m jsonMap
+	if err := jsonDecode(reader, &m); err != nil {
+		return nil, err
+	}
+	if m.Infinite {
+		return nil, fmt.Errorf("infinite maps are not supported")
+	}
+
+	var err error
+	tm := newTileMap()
+	tm.Orientation, err = parseTileMapOrientation(m.Orientation)
+	if err != nil {
+		return nil, err
+	}
+	tm.Width = m.Width
+	tm.Height = m.Height
+	tm.TileWidth = m.TileWidth
+	tm.TileHeight = m.TileHeight
+	tm.Properties = m.Properties.toProperties()
+
+	for _, t := range m.Tilesets {
+		ts, err := t.toTileset(dir, "")
+		if err != nil {
+			return nil, err
+		}
+		tm.Tilesets = append(tm.Tilesets, ts)
+	}
+
+	if err := tm.addJSONLayers(m.Layers, mgl32.Vec2{}, 1, true); err != nil {
+		return nil, err
+	}
+	return tm, nil
+}
+
+func (tm *TileMap) addJSONLayers(layers []jsonLayer, offset mgl32.Vec2, opacity float32, visible bool) error {
+	for _, l := range layers {
+		layerOffset := offset.Add(mgl32.Vec2{l.OffsetX, l.OffsetY})
+		layerOpacity := opacity
+		if l.Opacity != nil {
+			layerOpacity *= *l.Opacity
+		}
+		layerVisible := visible && (l.Visible == nil || *l.Visible)
+
+		switch l.Type {
+		case "tilelayer":
+			if len(l.Chunks) > 0 {
+				return fmt.Errorf("layer '%s': chunked (infinite) layers are not supported", l.Name)
+			}
+			raw, err := l.decodeData()
+			if err != nil {
+				return fmt.Errorf("layer '%s': %v", l.Name, err)
+			}
+			layer, err := newTileLayer(
+				l.Name, l.Width, l.Height, raw,
+				layerOpacity, layerVisible, layerOffset,
+				l.Properties.toProperties(),
+			)
+			if err != nil {
+				return err
+			}
+			tm.Layers = append(tm.Layers, layer)
+		case "objectgroup":
+			og := &ObjectGroup{
+				Name:       l.Name,
+				Visible:    layerVisible,
+				Offset:     layerOffset,
+				Properties: l.Properties.toProperties(),
+			}
+			for _, o := range l.Objects {
+				og.Objects = append(og.Objects, o.toObject())
+			}
+			tm.ObjectGroups = append(tm.ObjectGroups, og)
+		case "group":
+			if err := tm.addJSONLayers(l.Layers, layerOffset, layerOpacity, layerVisible); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// decodeData handles both the array of gids and the base64 string forms
+func (l *jsonLayer) decodeData() ([]uint32, error) {
+	if l.Encoding == "base64" {
+		var content string
+		if err := json.Unmarshal(l.Data, &content); err != nil {
+			return nil, err
+		}
+		return decodeTileData(l.Encoding, l.Compression, content, nil)
+	}
+	var raw []uint32
+	if err := json.Unmarshal(l.Data, &raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+func (p jsonProperties) toProperties() Properties {
+	properties := make(Properties)
+	for _, prop := range p {
+		var value string
+		switch v := prop.Value.(type) {
+		case string:
+			value = v
+		case float64:
+			value = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			value = strconv.FormatBool(v)
+		case nil:
+			value = ""
+		default:
+			// Class properties are kept as JSON
+			encoded, _ := json.Marshal(v)
+			value = string(encoded)
+		}
+		typ := prop.Type
+		if typ == "" {
+			typ = "string"
+		}
+		properties[prop.Name] = Property{Name: prop.Name, Type: typ, Value: value}
+	}
+	return properties
+}
+
+func (t *jsonTileset) toTileset(dir string, subDir string) (*Tileset, error) {
+	if t.Source != "" {
+		external, err := loadTilesetFile(dir, t.Source)
+		if err != nil {
+			return nil, err
+		}
+		external.FirstGID = t.FirstGID
+		return external, nil
+	}
+
+	ts := &Tileset{
+		FirstGID:    t.FirstGID,
+		Name:        t.Name,
+		TileWidth:   t.TileWidth,
+		TileHeight:  t.TileHeight,
+		Spacing:     t.Spacing,
+		Margin:      t.Margin,
+		TileCount:   t.TileCount,
+		Columns:     t.Columns,
+		ImageWidth:  t.ImageWidth,
+		ImageHeight: t.ImageHeight,
+		Properties:  t.Properties.toProperties(),
+		Tiles:       make(map[int]*TileInfo),
+	}
+	if t.Image != "" {
+		ts.Image = filepath.Join(subDir, t.Image)
+	}
+	if t.TileOffset != nil {
+		ts.TileOffset = mgl32.Vec2{t.TileOffset.X, t.TileOffset.Y}
+	}
+	for _, tile := range t.Tiles {
+		info := &TileInfo{
+			ID:          tile.ID,
+			Type:        tile.Type,
+			Properties:  tile.Properties.toProperties(),
+			ImageWidth:  tile.ImageWidth,
+			ImageHeight: tile.ImageHeight,
+		}
+		if info.Type == "" {
+			info.Type = tile.Class
+		}
+		if tile.Image != "" {
+			info.Image = filepath.Join(subDir, tile.Image)
+		}
+		for _, frame := range tile.Animation {
+			info.Animation = append(info.Animation, TileFrame{TileID: frame.TileID, Duration: frame.Duration})
+		}
+		ts.Tiles[tile.ID] = info
+	}
+	ts.computeColumns()
+	return ts, nil
+}
+
+func (o *jsonObject) toObject() *Object {
+	object := &Object{
+		ID:         o.ID,
+		Name:       o.Name,
+		Type:       o.Type,
+		Position:   mgl32.Vec2{o.X, o.Y},
+		Size:       mgl32.Vec2{o.Width, o.Height},
+		Rotation:   o.Rotation,
+		Visible:    o.Visible == nil || *o.Visible,
+		Shape:      SHAPE_RECTANGLE,
+		Properties: o.Properties.toProperties(),
+	}
+	if object.Type == "" {
+		object.Type = o.Class
+	}
+	switch {
+	case o.GID != 0:
+		object.Shape = SHAPE_TILE
+		object.Tile = decodeTile(o.GID)
+	case o.Ellipse:
+		object.Shape = SHAPE_ELLIPSE
+	case o.Point:
+		object.Shape = SHAPE_POINT
+	case o.Polygon != nil:
+		object.Shape = SHAPE_POLYGON
+		object.Points = jsonPointsToVec2(o.Polygon)
+	case o.Polyline != nil:
+		object.Shape = SHAPE_POLYLINE
+		object.Points = jsonPointsToVec2(o.Polyline)
+	}
+	return object
+}
+
+func jsonPointsToVec2(points []jsonPoint) []mgl32.Vec2 {
+	result := make([]mgl32.Vec2, len(points))
+	for i, p := range points {
+		result[i] = mgl32.Vec2{p.X, p.Y}
+	}
+	return result
+}
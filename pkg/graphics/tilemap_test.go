@@ -0,0 +1,214 @@
+package graphics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const testTMX = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.2" orientation="orthogonal" width="3" height="2" tilewidth="16" tileheight="16" infinite="0">
+ <properties>
+  <property name="music" value="level1.ogg"/>
+  <property name="gravity" type="float" value="9.8"/>
+ </properties>
+ <tileset firstgid="1" name="ground" tilewidth="16" tileheight="16" spacing="2" margin="1" tilecount="8" columns="4">
+  <image source="ground.png" width="73" height="37"/>
+  <tile id="2" type="water">
+   <properties>
+    <property name="solid" type="bool" value="false"/>
+   </properties>
+   <animation>
+    <frame tileid="2" duration="100"/>
+    <frame tileid="3" duration="200"/>
+   </animation>
+  </tile>
+ </tileset>
+ <layer name="background" width="3" height="2">
+  <data encoding="csv">
+1,2,3,
+2147483652,0,5
+</data>
+ </layer>
+ <group name="front" offsetx="10" opacity="0.5">
+  <layer name="decorations" width="3" height="2" offsety="4">
+   <data encoding="base64" compression="zlib">eJxjZGBgYAJiZiBmYUAAAAC4AAs=</data>
+  </layer>
+  <objectgroup name="spawns">
+   <object id="1" name="player" type="spawn" x="8" y="24"><point/></object>
+   <object id="2" name="wall" x="0" y="0" width="16" height="32"/>
+   <object id="3" type="trigger" x="0" y="0"><polygon points="0,0 10,0 10,10"/></object>
+  </objectgroup>
+ </group>
+</map>
+`
+
+func TestParseTileMapTMX(t *testing.T) {
+	tm, err := parseTileMapTMX(strings.NewReader(testTMX), "")
+	if err != nil {
+		t.Fatalf("Not expecting error %v", err)
+	}
+	if tm.Width != 3 || tm.Height != 2 || tm.TileWidth != 16 || tm.Orientation != ORTHOGONAL {
+		t.Errorf("Wrong map header %+v", tm)
+	}
+	if tm.Properties.String("music", "") != "level1.ogg" || tm.Properties.Float("gravity", 0) != 9.8 {
+		t.Errorf("Wrong map properties %v", tm.Properties)
+	}
+	if len(tm.Layers) != 2 || len(tm.ObjectGroups) != 1 {
+		t.Fatalf("Got %d layers and %d object groups, expecting 2 and 1", len(tm.Layers), len(tm.ObjectGroups))
+	}
+
+	background := tm.Layer("background")
+	flipped := background.Tile(0, 1)
+	if flipped.GID != 4 || !flipped.FlipX || flipped.FlipY || flipped.FlipDiag {
+		t.Errorf("Wrong flipped tile %+v", flipped)
+	}
+	if !background.Tile(1, 1).Empty() || !background.Tile(5, 5).Empty() {
+		t.Errorf("Expecting empty tiles")
+	}
+
+	decorations := tm.Layer("decorations")
+	if decorations.Offset != (mgl32.Vec2{10, 4}) || decorations.Opacity != 0.5 {
+		t.Errorf("Group offset and opacity not applied: %v %v", decorations.Offset, decorations.Opacity)
+	}
+	expected := []int{1, 2, 3, 4, 0, 0}
+	for i, gid := range expected {
+		if tile := decorations.Tile(i%3, i/3); tile.GID != gid {
+			t.Errorf("Tile %d: got gid %d, expecting %d", i, tile.GID, gid)
+		}
+	}
+
+	info := tm.TileInfo(3)
+	if info == nil || info.Type != "water" || info.Properties.Bool("solid", true) {
+		t.Errorf("Wrong tile info %+v", info)
+	}
+	if props := tm.TileProperties(background, 2, 0); props.Bool("solid", true) {
+		t.Errorf("Wrong tile properties %v", props)
+	}
+
+	spawns := tm.ObjectGroup("spawns")
+	if player := spawns.ObjectByName("player"); player == nil || player.Shape != SHAPE_POINT || player.Position != (mgl32.Vec2{8, 24}) {
+		t.Errorf("Wrong player object %+v", player)
+	}
+	triggers := spawns.ObjectsByType("trigger")
+	if len(triggers) != 1 || triggers[0].Shape != SHAPE_POLYGON || len(triggers[0].Points) != 3 {
+		t.Errorf("Wrong trigger objects %+v", triggers)
+	}
+}
+
+const testTileMapJSON = `{
+ "orientation": "isometric", "width": 2, "height": 2, "tilewidth": 64, "tileheight": 32, "infinite": false,
+ "tilesets": [{"firstgid": 1, "name": "iso", "tilewidth": 64, "tileheight": 64, "tilecount": 4, "columns": 2,
+   "image": "iso.png", "imagewidth": 128, "imageheight": 128, "tileoffset": {"x": 0, "y": 16},
+   "tiles": [{"id": 1, "properties": [{"name": "cost", "type": "int", "value": 3}]}]}],
+ "layers": [
+  {"type": "tilelayer", "name": "floor", "width": 2, "height": 2, "data": [1, 2, 3, 1073741828]},
+  {"type": "group", "name": "g", "visible": false, "layers": [
+    {"type": "objectgroup", "name": "items", "objects": [
+      {"id": 7, "name": "coin", "gid": 2, "x": 32, "y": 32, "width": 64, "height": 64}]}]}
+ ]
+}`
+
+func TestParseTileMapJSON(t *testing.T) {
+	tm, err := parseTileMapJSON(strings.NewReader(testTileMapJSON), "")
+	if err != nil {
+		t.Fatalf("Not expecting error %v", err)
+	}
+	if tm.Orientation != ISOMETRIC {
+		t.Errorf("Expecting isometric orientation")
+	}
+	floor := tm.Layer("floor")
+	if tile := floor.Tile(1, 1); tile.GID != 4 || !tile.FlipY {
+		t.Errorf("Wrong flipped tile %+v", tile)
+	}
+	if tm.TileProperties(floor, 1, 0).Int("cost", 0) != 3 {
+		t.Errorf("Wrong tile properties")
+	}
+	if tm.Tilesets[0].TileOffset != (mgl32.Vec2{0, 16}) {
+		t.Errorf("Wrong tile offset %v", tm.Tilesets[0].TileOffset)
+	}
+	items := tm.ObjectGroup("items")
+	if items == nil || items.Visible {
+		t.Fatalf("Group visibility not applied to %+v", items)
+	}
+	if coin := items.ObjectByName("coin"); coin.Shape != SHAPE_TILE || coin.Tile.GID != 2 {
+		t.Errorf("Wrong tile object %+v", coin)
+	}
+}
+
+func TestTileCoordsAt(t *testing.T) {
+	var tests = []struct {
+		orientation TileMapOrientation
+		point       mgl32.Vec2
+		col, row    int
+	}{
+		{ORTHOGONAL, mgl32.Vec2{0, 0}, 0, 0},
+		{ORTHOGONAL, mgl32.Vec2{70, 40}, 1, 1},
+		{ORTHOGONAL, mgl32.Vec2{-1, 10}, -1, 0},
+		// Top vertex of the tile 0,0 is at the horizontal center of the map
+		{ISOMETRIC, mgl32.Vec2{128, 1}, 0, 0},
+		{ISOMETRIC, mgl32.Vec2{160, 16}, 1, 0},
+		{ISOMETRIC, mgl32.Vec2{96, 16}, 0, 1},
+		{ISOMETRIC, mgl32.Vec2{128, 40}, 1, 1},
+	}
+
+	for _, test := range tests {
+		tm := &TileMap{Orientation: test.orientation, Width: 4, Height: 4, TileWidth: 64, TileHeight: 32}
+		col, row := tm.TileCoordsAt(test.point)
+		if col != test.col || row != test.row {
+			t.Errorf("%v: got %d,%d expecting %d,%d", test.point, col, row, test.col, test.row)
+		}
+		// The tile origin must map back to the same tile
+		origin := tm.TileOrigin(test.col, test.row)
+		center := origin.Add(mgl32.Vec2{32, 16})
+		if col, row = tm.TileCoordsAt(center); col != test.col || row != test.row {
+			t.Errorf("Center of %d,%d mapped to %d,%d", test.col, test.row, col, row)
+		}
+	}
+}
+
+func TestTileUVCoords(t *testing.T) {
+	uv := [4]float32{0, 0, 1, 1}
+	var tests = []struct {
+		tile Tile
+		// UVs of the bottom left and top right corners
+		bl, tr [2]float32
+	}{
+		{Tile{}, [2]float32{0, 1}, [2]float32{1, 0}},
+		{Tile{FlipX: true}, [2]float32{1, 1}, [2]float32{0, 0}},
+		{Tile{FlipY: true}, [2]float32{0, 0}, [2]float32{1, 1}},
+		{Tile{FlipDiag: true}, [2]float32{1, 0}, [2]float32{0, 1}},
+		// 90 degrees clockwise rotation
+		{Tile{FlipDiag: true, FlipX: true}, [2]float32{1, 1}, [2]float32{0, 0}},
+	}
+
+	for _, test := range tests {
+		coords := tileUVCoords(uv, test.tile)
+		bl := [2]float32{coords[0], coords[1]}
+		tr := [2]float32{coords[10], coords[11]}
+		if bl != test.bl || tr != test.tr {
+			t.Errorf("%+v: got %v %v expecting %v %v", test.tile, bl, tr, test.bl, test.tr)
+		}
+	}
+}
+
+func TestAnimatedTile(t *testing.T) {
+	tm, err := parseTileMapTMX(strings.NewReader(testTMX), "")
+	if err != nil {
+		t.Fatalf("Not expecting error %v", err)
+	}
+	ts := tm.Tilesets[0]
+	var tests = []struct {
+		time  int
+		frame int
+	}{{0, 2}, {99, 2}, {100, 3}, {299, 3}, {300, 2}}
+	for _, test := range tests {
+		if frame := ts.animatedTile(2, test.time); frame != test.frame {
+			t.Errorf("At %dms got frame %d, expecting %d", test.time, frame, test.frame)
+		}
+	}
+	if ts.animatedTile(0, 150) != 0 {
+		t.Errorf("Not animated tiles should not change")
+	}
+}
@@ -0,0 +1,539 @@
+package graphics
+
+// TMX (XML) flavour of the Tiled map format, see
+// http://doc.mapeditor.org/en/stable/reference/tmx-map-format/
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+type tmxProperty struct {
+	Name  string `xml:"name,attr"`
+	Type  string `xml:"type,attr"`
+	Value string `xml:"value,attr"`
+	Text  string `xml:",chardata"`
+}
+
+type tmxProperties struct {
+	Properties []tmxProperty `xml:"property"`
+}
+
+type tmxImage struct {
+	Source string `xml:"source,attr"`
+	Width  int    `xml:"width,attr"`
+	Height int    `xml:"height,attr"`
+}
+
+type tmxFrame struct {
+	TileID   int `xml:"tileid,attr"`
+	Duration int `xml:"duration,attr"`
+}
+
+type tmxTile struct {
+	ID         int           `xml:"id,attr"`
+	Type       string        `xml:"type,attr"`
+	Class      string        `xml:"class,attr"`
+	Properties tmxProperties `xml:"properties"`
+	Image      *tmxImage     `xml:"image"`
+	Animation  []tmxFrame    `xml:"animation>frame"`
+}
+
+type tmxTileOffset struct {
+	X float32 `xml:"x,attr"`
+	Y float32 `xml:"y,attr"`
+}
+
+type tmxTileset struct {
+	FirstGID   int            `xml:"firstgid,attr"`
+	Source     string         `xml:"source,attr"`
+	Name       string         `xml:"name,attr"`
+	TileWidth  int            `xml:"tilewidth,attr"`
+	TileHeight int            `xml:"tileheight,attr"`
+	Spacing    int            `xml:"spacing,attr"`
+	Margin     int            `xml:"margin,attr"`
+	TileCount  int            `xml:"tilecount,attr"`
+	Columns    int            `xml:"columns,attr"`
+	TileOffset *tmxTileOffset `xml:"tileoffset"`
+	Image      *tmxImage      `xml:"image"`
+	Properties tmxProperties  `xml:"properties"`
+	Tiles      []tmxTile      `xml:"tile"`
+}
+
+type tmxDataTile struct {
+	GID uint32 `xml:"gid,attr"`
+}
+
+type tmxData struct {
+	Encoding    string        `xml:"encoding,attr"`
+	Compression string        `xml:"compression,attr"`
+	Tiles       []tmxDataTile `xml:"tile"`
+	Chunks      []struct{}    `xml:"chunk"`
+	Content     string        `xml:",chardata"`
+}
+
+type tmxLayerCommon struct {
+	Name       string        `xml:"name,attr"`
+	Opacity    *float32      `xml:"opacity,attr"`
+	Visible    *int          `xml:"visible,attr"`
+	OffsetX    float32       `xml:"offsetx,attr"`
+	OffsetY    float32       `xml:"offsety,attr"`
+	Properties tmxProperties `xml:"properties"`
+}
+
+type tmxLayer struct {
+	tmxLayerCommon
+	Width  int     `xml:"width,attr"`
+	Height int     `xml:"height,attr"`
+	Data   tmxData `xml:"data"`
+}
+
+type tmxPoints struct {
+	Points string `xml:"points,attr"`
+}
+
+type tmxObject struct {
+	ID         int           `xml:"id,attr"`
+	Name       string        `xml:"name,attr"`
+	Type       string        `xml:"type,attr"`
+	Class      string        `xml:"class,attr"`
+	X          float32       `xml:"x,attr"`
+	Y          float32       `xml:"y,attr"`
+	Width      float32       `xml:"width,attr"`
+	Height     float32       `xml:"height,attr"`
+	Rotation   float32       `xml:"rotation,attr"`
+	GID        uint32        `xml:"gid,attr"`
+	Visible    *int          `xml:"visible,attr"`
+	Properties tmxProperties `xml:"properties"`
+	Ellipse    *struct{}     `xml:"ellipse"`
+	Point      *struct{}     `xml:"point"`
+	Polygon    *tmxPoints    `xml:"polygon"`
+	Polyline   *tmxPoints    `xml:"polyline"`
+}
+
+type tmxObjectGroup struct {
+	tmxLayerCommon
+	Objects []tmxObject `xml:"object"`
+}
+
+// tmxGroup keeps the children in document order, which is the drawing order
+type tmxGroup struct {
+	tmxLayerCommon
+	children []interface{}
+}
+
+func (g *tmxGroup) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "name":
+			g.Name = attr.Value
+		case "opacity":
+			v, _ := strconv.ParseFloat(attr.Value, 32)
+			opacity := float32(v)
+			g.Opacity = &opacity
+		case "visible":
+			v, _ := strconv.Atoi(attr.Value)
+			g.Visible = &v
+		case "offsetx":
+			v, _ := strconv.ParseFloat(attr.Value, 32)
+			g.OffsetX = float32(v)
+		case "offsety":
+			v, _ := strconv.ParseFloat(attr.Value, 32)
+			g.OffsetY = float32(v)
+		}
+	}
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			var child interface{}
+			switch t.Name.Local {
+			case "layer":
+				child = &tmxLayer{}
+			case "objectgroup":
+				child = &tmxObjectGroup{}
+			case "group":
+				child = &tmxGroup{}
+			case "properties":
+				child = &g.Properties
+			default:
+				if err := d.Skip(); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := d.DecodeElement(child, &t); err != nil {
+				return err
+			}
+			if t.Name.Local != "properties" {
+				g.children = append(g.children, child)
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+type tmxMap struct {
+	Orientation string        `xml:"orientation,attr"`
+	Width       int           `xml:"width,attr"`
+	Height      int           `xml:"height,attr"`
+	TileWidth   int           `xml:"tilewidth,attr"`
+	TileHeight  int           `xml:"tileheight,attr"`
+	Infinite    int           `xml:"infinite,attr"`
+	Tilesets    []tmxTileset  `xml:"tileset"`
+	Properties  tmxProperties `xml:"properties"`
+}
+
+func parseTileMapTMX(reader io.Reader, dir string) (*TileMap, error) {
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	var m tmxMap
+	if err := xml.Unmarshal(content, &m); err != nil {
+		return nil, err
+	}
+	if m.Infinite != 0 {
+		return nil, fmt.Errorf("infinite maps are not supported")
+	}
+	// Layers are decoded as the children of a group to keep their order
+	var root tmxGroup
+	if err := xml.Unmarshal(content, &root); err != nil {
+		return nil, err
+	}
+
+	tm := newTileMap()
+	tm.Orientation, err = parseTileMapOrientation(m.Orientation)
+	if err != nil {
+		return nil, err
+	}
+	tm.Width = m.Width
+	tm.Height = m.Height
+	tm.TileWidth = m.TileWidth
+	tm.TileHeight = m.TileHeight
+	tm.Properties = m.Properties.toProperties()
+
+	for _, t := range m.Tilesets {
+		ts, err := t.toTileset(dir, "")
+		if err != nil {
+			return nil, err
+		}
+		tm.Tilesets = append(tm.Tilesets, ts)
+	}
+
+	if err := tm.addTMXChildren(root.children, mgl32.Vec2{}, 1, true); err != nil {
+		return nil, err
+	}
+	return tm, nil
+}
+
+// addTMXChildren flattens the group hierarchy adding offset, opacity and
+// visibility of the parents to their children
+func (tm *TileMap) addTMXChildren(children []interface{}, offset mgl32.Vec2, opacity float32, visible bool) error {
+	for _, child := range children {
+		switch c := child.(type) {
+		case *tmxLayer:
+			layer, err := c.toTileLayer(offset, opacity, visible)
+			if err != nil {
+				return err
+			}
+			tm.Layers = append(tm.Layers, layer)
+		case *tmxObjectGroup:
+			og := &ObjectGroup{
+				Name:       c.Name,
+				Visible:    visible && c.visible(),
+				Offset:     offset.Add(mgl32.Vec2{c.OffsetX, c.OffsetY}),
+				Properties: c.Properties.toProperties(),
+			}
+			for _, o := range c.Objects {
+				og.Objects = append(og.Objects, o.toObject())
+			}
+			tm.ObjectGroups = append(tm.ObjectGroups, og)
+		case *tmxGroup:
+			err := tm.addTMXChildren(
+				c.children,
+				offset.Add(mgl32.Vec2{c.OffsetX, c.OffsetY}),
+				opacity*c.opacity(),
+				visible && c.visible(),
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (l *tmxLayerCommon) opacity() float32 {
+	if l.Opacity == nil {
+		return 1
+	}
+	return *l.Opacity
+}
+
+func (l *tmxLayerCommon) visible() bool {
+	return l.Visible == nil || *l.Visible != 0
+}
+
+func (p tmxProperties) toProperties() Properties {
+	properties := make(Properties)
+	for _, prop := range p.Properties {
+		value := prop.Value
+		if value == "" {
+			// Multi-line strings are stored as element content
+			value = prop.Text
+		}
+		typ := prop.Type
+		if typ == "" {
+			typ = "string"
+		}
+		properties[prop.Name] = Property{Name: prop.Name, Type: typ, Value: value}
+	}
+	return properties
+}
+
+// toTileset converts the XML tileset, loading it from file if external.
+// Image paths are made relative to the map directory
+func (t *tmxTileset) toTileset(dir string, subDir string) (*Tileset, error) {
+	if t.Source != "" {
+		external, err := loadTilesetFile(dir, t.Source)
+		if err != nil {
+			return nil, err
+		}
+		external.FirstGID = t.FirstGID
+		return external, nil
+	}
+
+	ts := &Tileset{
+		FirstGID:   t.FirstGID,
+		Name:       t.Name,
+		TileWidth:  t.TileWidth,
+		TileHeight: t.TileHeight,
+		Spacing:    t.Spacing,
+		Margin:     t.Margin,
+		TileCount:  t.TileCount,
+		Columns:    t.Columns,
+		Properties: t.Properties.toProperties(),
+		Tiles:      make(map[int]*TileInfo),
+	}
+	if t.TileOffset != nil {
+		ts.TileOffset = mgl32.Vec2{t.TileOffset.X, t.TileOffset.Y}
+	}
+	if t.Image != nil {
+		ts.Image = filepath.Join(subDir, t.Image.Source)
+		ts.ImageWidth = t.Image.Width
+		ts.ImageHeight = t.Image.Height
+	}
+	for _, tile := range t.Tiles {
+		info := &TileInfo{
+			ID:         tile.ID,
+			Type:       tile.Type,
+			Properties: tile.Properties.toProperties(),
+		}
+		if info.Type == "" {
+			info.Type = tile.Class
+		}
+		for _, frame := range tile.Animation {
+			info.Animation = append(info.Animation, TileFrame{TileID: frame.TileID, Duration: frame.Duration})
+		}
+		if tile.Image != nil {
+			info.Image = filepath.Join(subDir, tile.Image.Source)
+			info.ImageWidth = tile.Image.Width
+			info.ImageHeight = tile.Image.Height
+		}
+		ts.Tiles[tile.ID] = info
+	}
+	ts.computeColumns()
+	return ts, nil
+}
+
+// loadTilesetFile loads an external tileset in TSX or JSON format
+func loadTilesetFile(dir string, source string) (*Tileset, error) {
+	file, err := os.Open(filepath.Join(dir, source))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	subDir := filepath.Dir(source)
+	switch strings.ToLower(filepath.Ext(source)) {
+	case ".json", ".tsj":
+		var t jsonTileset
+		if err := jsonDecode(file, &t); err != nil {
+			return nil, err
+		}
+		return t.toTileset(dir, subDir)
+	default:
+		var t tmxTileset
+		if err := xml.NewDecoder(file).Decode(&t); err != nil {
+			return nil, err
+		}
+		return t.toTileset(dir, subDir)
+	}
+}
+
+func (l *tmxLayer) toTileLayer(offset mgl32.Vec2, opacity float32, visible bool) (*TileLayer, error) {
+	if len(l.Data.Chunks) > 0 {
+		return nil, fmt.Errorf("layer '%s': chunked (infinite) layers are not supported", l.Name)
+	}
+	raw, err := decodeTileData(l.Data.Encoding, l.Data.Compression, l.Data.Content, l.Data.Tiles)
+	if err != nil {
+		return nil, fmt.Errorf("layer '%s': %v", l.Name, err)
+	}
+	return newTileLayer(
+		l.Name, l.Width, l.Height, raw,
+		opacity*l.opacity(),
+		visible && l.visible(),
+		offset.Add(mgl32.Vec2{l.OffsetX, l.OffsetY}),
+		l.Properties.toProperties(),
+	)
+}
+
+func newTileLayer(
+	name string,
+	width, height int,
+	raw []uint32,
+	opacity float32,
+	visible bool,
+	offset mgl32.Vec2,
+	properties Properties,
+) (*TileLayer, error) {
+	if len(raw) != width*height {
+		return nil, fmt.Errorf("layer '%s': got %d tiles, expecting %d", name, len(raw), width*height)
+	}
+	layer := &TileLayer{
+		Name:       name,
+		Width:      width,
+		Height:     height,
+		Opacity:    opacity,
+		Visible:    visible,
+		Offset:     offset,
+		Properties: properties,
+		tiles:      make([]Tile, len(raw)),
+	}
+	for i, gid := range raw {
+		layer.tiles[i] = decodeTile(gid)
+	}
+	return layer, nil
+}
+
+// decodeTileData decodes the gids of a layer stored as csv, base64
+// (optionally zlib or gzip compressed) or as a list of tile elements
+func decodeTileData(encoding, compression, content string, tiles []tmxDataTile) ([]uint32, error) {
+	switch encoding {
+	case "":
+		raw := make([]uint32, len(tiles))
+		for i, t := range tiles {
+			raw[i] = t.GID
+		}
+		return raw, nil
+	case "csv":
+		fields := strings.FieldsFunc(content, func(r rune) bool {
+			return r == ',' || r == '\n' || r == '\r' || r == ' ' || r == '\t'
+		})
+		raw := make([]uint32, len(fields))
+		for i, field := range fields {
+			gid, err := strconv.ParseUint(field, 10, 32)
+			if err != nil {
+				return nil, err
+			}
+			raw[i] = uint32(gid)
+		}
+		return raw, nil
+	case "base64":
+		data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(content))
+		if err != nil {
+			return nil, err
+		}
+		var r io.Reader = bytes.NewReader(data)
+		switch compression {
+		case "":
+		case "zlib":
+			if r, err = zlib.NewReader(r); err != nil {
+				return nil, err
+			}
+		case "gzip":
+			if r, err = gzip.NewReader(r); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unsupported compression '%s'", compression)
+		}
+		data, err = ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		raw := make([]uint32, len(data)/4)
+		for i := range raw {
+			raw[i] = binary.LittleEndian.Uint32(data[i*4:])
+		}
+		return raw, nil
+	}
+	return nil, fmt.Errorf("unsupported encoding '%s'", encoding)
+}
+
+func (o *tmxObject) toObject() *Object {
+	object := &Object{
+		ID:         o.ID,
+		Name:       o.Name,
+		Type:       o.Type,
+		Position:   mgl32.Vec2{o.X, o.Y},
+		Size:       mgl32.Vec2{o.Width, o.Height},
+		Rotation:   o.Rotation,
+		Visible:    o.Visible == nil || *o.Visible != 0,
+		Shape:      SHAPE_RECTANGLE,
+		Properties: o.Properties.toProperties(),
+	}
+	if object.Type == "" {
+		object.Type = o.Class
+	}
+	switch {
+	case o.GID != 0:
+		object.Shape = SHAPE_TILE
+		object.Tile = decodeTile(o.GID)
+	case o.Ellipse != nil:
+		object.Shape = SHAPE_ELLIPSE
+	case o.Point != nil:
+		object.Shape = SHAPE_POINT
+	case o.Polygon != nil:
+		object.Shape = SHAPE_POLYGON
+		object.Points = parseTMXPoints(o.Polygon.Points)
+	case o.Polyline != nil:
+		object.Shape = SHAPE_POLYLINE
+		object.Points = parseTMXPoints(o.Polyline.Points)
+	}
+	return object
+}
+
+// parseTMXPoints parses the "x1,y1 x2,y2 ..." format of polygons and polylines
+func parseTMXPoints(points string) []mgl32.Vec2 {
+	fields := strings.Fields(points)
+	result := make([]mgl32.Vec2, 0, len(fields))
+	for _, field := range fields {
+		xy := strings.Split(field, ",")
+		if len(xy) != 2 {
+			continue
+		}
+		x, _ := strconv.ParseFloat(xy[0], 32)
+		y, _ := strconv.ParseFloat(xy[1], 32)
+		result = append(result, mgl32.Vec2{float32(x), float32(y)})
+	}
+	return result
+}
@@ -0,0 +1,623 @@
+package input
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+
+	"github.com/markov/gojira2d/pkg/app"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+type ActionType int
+
+const (
+	// Pressed and released, e.g. jump
+	ACTION_BUTTON ActionType = iota
+	// A value between -1 and 1, e.g. throttle
+	ACTION_AXIS
+	// A vector up to length 1, e.g. move
+	ACTION_AXIS_2D
+)
+
+// KeyState tells which keys and mouse buttons are down, the ones of the app
+// window or of an InputPlayback
+type KeyState interface {
+	KeyDown(key glfw.Key) bool
+	MouseButtonDown(button MouseButton) bool
+}
+
+type windowKeyState struct {
+	window *glfw.Window
+}
+
+// WindowKeyState returns the state of the keys and the mouse buttons of the
+// app window
+func WindowKeyState() KeyState {
+	return windowKeyState{app.GetWindow()}
+}
+
+func (s windowKeyState) KeyDown(key glfw.Key) bool {
+	return s.window != nil && s.window.GetKey(key) == glfw.Press
+}
+
+func (s windowKeyState) MouseButtonDown(button MouseButton) bool {
+	return s.window != nil && s.window.GetMouseButton(glfw.MouseButton(button)) == glfw.Press
+}
+
+// Action is something the player does, bound to one or more inputs
+type Action struct {
+	name     string
+	kind     ActionType
+	bindings []Binding
+	defaults []Binding
+	down     bool
+	pressed  bool
+	released bool
+	value    float32
+	vector   mgl32.Vec2
+}
+
+func (a *Action) Name() string {
+	return a.name
+}
+
+func (a *Action) Type() ActionType {
+	return a.kind
+}
+
+// Bindings returns a copy of the bindings of the action
+func (a *Action) Bindings() []Binding {
+	return append([]Binding(nil), a.bindings...)
+}
+
+// Down returns true while a binding is down, or an axis is not 0
+func (a *Action) Down() bool {
+	return a.down
+}
+
+// Pressed returns true if the action went down this frame
+func (a *Action) Pressed() bool {
+	return a.pressed
+}
+
+// Released returns true if the action went up this frame
+func (a *Action) Released() bool {
+	return a.released
+}
+
+// Value returns the value of an axis, the length of a 2D axis, 1 for the
+// buttons down or how much a trigger bound to them is pushed
+func (a *Action) Value() float32 {
+	return a.value
+}
+
+// Vector returns the value of a 2D axis
+func (a *Action) Vector() mgl32.Vec2 {
+	return a.vector
+}
+
+// ActionMap reads the actions of the game from their bindings, so the game
+// doesn't depend on keys and buttons and the player can change them:
+//
+//	actions := input.NewActionMap(joystick)
+//	actions.AddButton("jump", input.KeyBinding(glfw.KeySpace, 0), input.ButtonBinding(input.BUTTON_A))
+//	actions.AddAxis2D("move",
+//		input.KeyBinding(glfw.KeyA, 0).To(0, -1),
+//		input.KeyBinding(glfw.KeyD, 0),
+//		input.KeyBinding(glfw.KeyW, 0).To(1, -1),
+//		input.KeyBinding(glfw.KeyS, 0).To(1, 1),
+//		input.AxisBinding(input.AXIS_LEFT_X, 0),
+//		input.AxisBinding(input.AXIS_LEFT_Y, 0).To(1, 1),
+//	)
+//	actions.LoadBindings("bindings.json")
+//	...
+//	joystick.Update()
+//	actions.Update()
+//	if actions.Pressed("jump") {
+//
+// Keys and mouse buttons are read from the app window, or SetKeyState, the
+// others from the controller. Among the key bindings down, the ones with more modifiers
+// held win: Ctrl+S doesn't move down bound to S
+type ActionMap struct {
+	keys       KeyState
+	controller GameController
+	actions    map[string]*Action
+	order      []*Action
+	// Bindings just bound, ignored until released
+	ignored []Binding
+	rebind  *rebinding
+}
+
+// rebinding is the state of Rebind, waiting for an input
+type rebinding struct {
+	action   *Action
+	index    int
+	done     func(binding Binding, ok bool)
+	keys     map[glfw.Key]bool
+	mouse    [MAX_NUM_MOUSE_BUTTONS]bool
+	buttons  [NUM_CONTROLLER_BUTTONS]bool
+	axes     [NUM_CONTROLLER_AXES]int
+	modifier glfw.Key
+}
+
+// NewActionMap creates an empty action map reading the keyboard, the mouse
+// and a controller, nil if none
+func NewActionMap(controller GameController) *ActionMap {
+	return &ActionMap{
+		keys:       WindowKeyState(),
+		controller: controller,
+		actions:    make(map[string]*Action),
+	}
+}
+
+// SetController changes the controller read, nil for none
+func (m *ActionMap) SetController(controller GameController) {
+	m.controller = controller
+}
+
+// SetKeyState changes where the keys and the mouse buttons are read from
+func (m *ActionMap) SetKeyState(keys KeyState) {
+	m.keys = keys
+}
+
+// AddButton adds an action pressed and released
+func (m *ActionMap) AddButton(name string, bindings ...Binding) *Action {
+	return m.add(name, ACTION_BUTTON, bindings)
+}
+
+// AddAxis adds an action with a value between -1 and 1. Keys and buttons
+// give the Scale of their binding, axes their value by it
+func (m *ActionMap) AddAxis(name string, bindings ...Binding) *Action {
+	return m.add(name, ACTION_AXIS, bindings)
+}
+
+// AddAxis2D adds an action with a vector up to length 1. Each binding
+// changes the Component of the vector, see Binding.To
+func (m *ActionMap) AddAxis2D(name string, bindings ...Binding) *Action {
+	return m.add(name, ACTION_AXIS_2D, bindings)
+}
+
+func (m *ActionMap) add(name string, kind ActionType, bindings []Binding) *Action {
+	a := &Action{
+		name:     name,
+		kind:     kind,
+		bindings: append([]Binding(nil), bindings...),
+		defaults: append([]Binding(nil), bindings...),
+	}
+	if old, ok := m.actions[name]; ok {
+		for i, o := range m.order {
+			if o == old {
+				m.order[i] = a
+			}
+		}
+	} else {
+		m.order = append(m.order, a)
+	}
+	m.actions[name] = a
+	return a
+}
+
+// Action returns an action by name, nil if missing
+func (m *ActionMap) Action(name string) *Action {
+	return m.actions[name]
+}
+
+// Actions returns the actions in the order they were added
+func (m *ActionMap) Actions() []*Action {
+	return append([]*Action(nil), m.order...)
+}
+
+func (m *ActionMap) Down(name string) bool {
+	a := m.actions[name]
+	return a != nil && a.down
+}
+
+func (m *ActionMap) Pressed(name string) bool {
+	a := m.actions[name]
+	return a != nil && a.pressed
+}
+
+func (m *ActionMap) Released(name string) bool {
+	a := m.actions[name]
+	return a != nil && a.released
+}
+
+func (m *ActionMap) Value(name string) float32 {
+	if a := m.actions[name]; a != nil {
+		return a.value
+	}
+	return 0
+}
+
+func (m *ActionMap) Vector(name string) mgl32.Vec2 {
+	if a := m.actions[name]; a != nil {
+		return a.vector
+	}
+	return mgl32.Vec2{}
+}
+
+// SetBindings replaces the bindings of an action
+func (m *ActionMap) SetBindings(name string, bindings ...Binding) {
+	if a := m.actions[name]; a != nil {
+		a.bindings = append([]Binding(nil), bindings...)
+	}
+}
+
+// Bind adds a binding to an action
+func (m *ActionMap) Bind(name string, binding Binding) {
+	if a := m.actions[name]; a != nil {
+		a.bindings = append(a.bindings, binding)
+	}
+}
+
+// Unbind removes a binding of an action by index
+func (m *ActionMap) Unbind(name string, index int) {
+	if a := m.actions[name]; a != nil && index >= 0 && index < len(a.bindings) {
+		a.bindings = append(a.bindings[:index], a.bindings[index+1:]...)
+	}
+}
+
+// ResetBindings restores the bindings the actions were added with
+func (m *ActionMap) ResetBindings() {
+	for _, a := range m.order {
+		a.bindings = append([]Binding(nil), a.defaults...)
+	}
+}
+
+// Update reads the inputs, call it once per frame after updating the
+// controller. While rebinding all the actions are up
+func (m *ActionMap) Update() {
+	mods := m.modifiersDown()
+	if m.rebind != nil {
+		m.listen(mods)
+		for _, a := range m.order {
+			a.set(false, 0, mgl32.Vec2{})
+		}
+		return
+	}
+
+	// Bindings just bound are ignored until released
+	ignored := m.ignored[:0]
+	for _, b := range m.ignored {
+		if down, _ := m.rawState(b); down {
+			ignored = append(ignored, b)
+		}
+	}
+	m.ignored = ignored
+
+	// The key and mouse bindings with the most modifiers held win
+	winners := make(map[int]int)
+	for _, a := range m.order {
+		for _, b := range a.bindings {
+			if id, ok := modifiableInput(b); ok && mods&b.Modifiers == b.Modifiers {
+				if down, _ := m.rawState(b); down {
+					if count, ok := winners[id]; !ok || modifierCount(b.Modifiers) > count {
+						winners[id] = modifierCount(b.Modifiers)
+					}
+				}
+			}
+		}
+	}
+
+	for _, a := range m.order {
+		var down bool
+		var value float32
+		var vector mgl32.Vec2
+		for _, b := range a.bindings {
+			if m.isIgnored(b) {
+				continue
+			}
+			d, v := m.rawState(b)
+			if id, ok := modifiableInput(b); ok && d {
+				d = mods&b.Modifiers == b.Modifiers && winners[id] == modifierCount(b.Modifiers)
+				if !d {
+					v = 0
+				}
+			}
+			switch a.kind {
+			case ACTION_BUTTON:
+				down = down || d
+				if d && v > value {
+					value = v
+				}
+			case ACTION_AXIS:
+				value += v * b.Scale
+			case ACTION_AXIS_2D:
+				vector[b.Component&1] += v * b.Scale
+			}
+		}
+		switch a.kind {
+		case ACTION_AXIS:
+			value = mgl32.Clamp(value, -1, 1)
+			down = value != 0
+		case ACTION_AXIS_2D:
+			value = vector.Len()
+			if value > 1 {
+				vector = vector.Mul(1 / value)
+				value = 1
+			}
+			down = value != 0
+		}
+		a.set(down, value, vector)
+	}
+}
+
+func (a *Action) set(down bool, value float32, vector mgl32.Vec2) {
+	a.pressed = down && !a.down
+	a.released = !down && a.down
+	a.down = down
+	a.value = value
+	a.vector = vector
+}
+
+// modifiableInput returns an id of the key or the mouse button of a binding
+func modifiableInput(b Binding) (int, bool) {
+	switch b.Type {
+	case BINDING_KEY:
+		return int(b.Key), true
+	case BINDING_MOUSE_BUTTON:
+		return -1 - int(b.MouseButton), true
+	}
+	return 0, false
+}
+
+func modifierCount(mods glfw.ModifierKey) int {
+	count := 0
+	for _, m := range modifiers {
+		if mods&m.modifier != 0 {
+			count++
+		}
+	}
+	return count
+}
+
+func (m *ActionMap) isIgnored(b Binding) bool {
+	for _, i := range m.ignored {
+		if i == b {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *ActionMap) keyDown(key glfw.Key) bool {
+	return m.keys != nil && m.keys.KeyDown(key)
+}
+
+func (m *ActionMap) mouseDown(button MouseButton) bool {
+	return m.keys != nil && m.keys.MouseButtonDown(button)
+}
+
+func (m *ActionMap) modifiersDown() glfw.ModifierKey {
+	var mods glfw.ModifierKey
+	for _, modifier := range modifiers {
+		for _, key := range modifier.keys {
+			if m.keyDown(key) {
+				mods |= modifier.modifier
+			}
+		}
+	}
+	return mods
+}
+
+func (m *ActionMap) controllerConnected() bool {
+	return m.controller != nil && m.controller.Connected()
+}
+
+// rawState returns if the input of a binding is down, without the
+// modifiers, and its value: 0 or 1, between 0 and 1 for the triggers and
+// the halves of the axes, between -1 and 1 for the whole axes
+func (m *ActionMap) rawState(b Binding) (bool, float32) {
+	var down bool
+	switch b.Type {
+	case BINDING_KEY:
+		down = m.keyDown(b.Key)
+	case BINDING_MOUSE_BUTTON:
+		down = m.mouseDown(b.MouseButton)
+	case BINDING_CONTROLLER_BUTTON:
+		down = m.controllerConnected() && m.controller.ButtonDown(b.Button)
+	case BINDING_CONTROLLER_AXIS:
+		// The keyboard controllers have the left stick only
+		if !m.controllerConnected() || int(b.Axis) >= m.controller.NumAxes() {
+			return false, 0
+		}
+		value := m.controller.AxisValue(b.Axis)
+		digital := m.controller.AxisDigitalValue(b.Axis)
+		if b.Axis == AXIS_TRIGGER_LEFT || b.Axis == AXIS_TRIGGER_RIGHT {
+			value = (value + 1) / 2
+		}
+		switch {
+		case b.AxisHalf > 0:
+			return digital > 0, mgl32.Clamp(value, 0, 1)
+		case b.AxisHalf < 0:
+			return digital < 0, mgl32.Clamp(-value, 0, 1)
+		}
+		return digital != 0, value
+	}
+	if down {
+		return true, 1
+	}
+	return false, 0
+}
+
+// Rebind waits for the next input to bind it to an action, replacing the
+// binding at index or adding one if index is out of range. The new binding
+// keeps the axis component and the scale of the one replaced. Keys are
+// bound with the modifiers held, modifier keys when released alone, axes
+// by the half pushed. Escape or CancelRebind stop waiting, done is called
+// with ok false
+func (m *ActionMap) Rebind(name string, index int, done func(binding Binding, ok bool)) {
+	a := m.actions[name]
+	if a == nil {
+		if done != nil {
+			done(Binding{}, false)
+		}
+		return
+	}
+	m.CancelRebind()
+	r := &rebinding{action: a, index: index, done: done, keys: make(map[glfw.Key]bool), modifier: glfw.KeyUnknown}
+	// Inputs held when rebinding starts are not bound
+	for key := range keyNames {
+		r.keys[key] = m.keyDown(key)
+	}
+	for i := range r.mouse {
+		r.mouse[i] = m.mouseDown(MouseButton(i))
+	}
+	if m.controllerConnected() {
+		for i := range r.buttons {
+			r.buttons[i] = m.controller.ButtonDown(ControllerButton(i))
+		}
+		for i := range r.axes {
+			r.axes[i] = m.controller.AxisDigitalValue(ControllerAxis(i))
+		}
+	}
+	m.rebind = r
+}
+
+// Rebinding returns true while waiting for an input to bind
+func (m *ActionMap) Rebinding() bool {
+	return m.rebind != nil
+}
+
+// CancelRebind stops waiting for an input to bind
+func (m *ActionMap) CancelRebind() {
+	if r := m.rebind; r != nil {
+		m.rebind = nil
+		if r.done != nil {
+			r.done(Binding{}, false)
+		}
+	}
+}
+
+func isModifierKey(key glfw.Key) bool {
+	for _, modifier := range modifiers {
+		for _, k := range modifier.keys {
+			if k == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// listen looks for a new input while rebinding
+func (m *ActionMap) listen(mods glfw.ModifierKey) {
+	r := m.rebind
+	found := false
+	var binding Binding
+	for key, wasDown := range r.keys {
+		down := m.keyDown(key)
+		r.keys[key] = down
+		switch {
+		case down && !wasDown && key == glfw.KeyEscape:
+			m.CancelRebind()
+			return
+		case down && !wasDown && isModifierKey(key):
+			r.modifier = key
+		case down && !wasDown && !found:
+			binding, found = KeyBinding(key, mods), true
+		case !down && wasDown && key == r.modifier && !found:
+			binding, found = KeyBinding(key, 0), true
+		}
+	}
+	for i, wasDown := range r.mouse {
+		down := m.mouseDown(MouseButton(i))
+		r.mouse[i] = down
+		if down && !wasDown && !found {
+			binding, found = MouseBinding(MouseButton(i), mods), true
+		}
+	}
+	if m.controllerConnected() {
+		for i, wasDown := range r.buttons {
+			down := m.controller.ButtonDown(ControllerButton(i))
+			r.buttons[i] = down
+			if down && !wasDown && !found {
+				binding, found = ButtonBinding(ControllerButton(i)), true
+			}
+		}
+		for i, last := range r.axes {
+			digital := m.controller.AxisDigitalValue(ControllerAxis(i))
+			r.axes[i] = digital
+			if digital != 0 && digital != last && !found {
+				binding, found = AxisBinding(ControllerAxis(i), digital), true
+			}
+		}
+	}
+	if !found {
+		return
+	}
+
+	a := r.action
+	binding.Component, binding.Scale = 0, 1
+	if r.index >= 0 && r.index < len(a.bindings) {
+		binding.Component = a.bindings[r.index].Component
+		binding.Scale = a.bindings[r.index].Scale
+		a.bindings[r.index] = binding
+	} else {
+		a.bindings = append(a.bindings, binding)
+	}
+	m.ignored = append(m.ignored, binding)
+	m.rebind = nil
+	if r.done != nil {
+		r.done(binding, true)
+	}
+}
+
+// SaveBindings writes the bindings of the actions to a JSON file, the
+// bindings in the format of ParseBinding:
+//
+//	{
+//	  "jump": ["Space", "PadA"],
+//	  "move": ["A:-", "D", "W:Y-", "S:Y", "PadLeftX", "PadLeftY:Y"]
+//	}
+func (m *ActionMap) SaveBindings(filePath string) error {
+	bindings := make(map[string][]string)
+	for _, a := range m.order {
+		bindings[a.name] = make([]string, len(a.bindings))
+		for i, b := range a.bindings {
+			bindings[a.name][i] = b.String()
+		}
+	}
+	data, err := json.MarshalIndent(bindings, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filePath, data, 0644)
+}
+
+// LoadBindings replaces the bindings of the actions in a file written by
+// SaveBindings. Actions missing from the file keep their bindings, unknown
+// ones are skipped. Nothing changes if the file has errors
+func (m *ActionMap) LoadBindings(filePath string) error {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	var file map[string][]string
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("%s: %v", filePath, err)
+	}
+	parsed := make(map[*Action][]Binding)
+	for name, texts := range file {
+		a := m.actions[name]
+		if a == nil {
+			log.Printf("Skipping the bindings of unknown action %s", name)
+			continue
+		}
+		bindings := make([]Binding, len(texts))
+		for i, text := range texts {
+			if bindings[i], err = ParseBinding(text); err != nil {
+				return fmt.Errorf("%s: action %s: %v", filePath, name, err)
+			}
+		}
+		parsed[a] = bindings
+	}
+	for a, bindings := range parsed {
+		a.bindings = bindings
+	}
+	return nil
+}
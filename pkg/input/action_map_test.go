@@ -0,0 +1,39 @@
+package input
+
+import (
+	"testing"
+)
+
+func TestActionMapKeyboardTrigger(t *testing.T) {
+	keyboard := &KeyboardController{connected: true, numAxes: 2}
+	keyboard.analog.reset()
+	actions := NewActionMap(keyboard)
+	actions.AddAxis("brake", AxisBinding(AXIS_TRIGGER_LEFT, 0))
+	actions.AddButton("boost", AxisBinding(AXIS_TRIGGER_RIGHT, 1))
+	actions.Update()
+	if v := actions.Value("brake"); v != 0 {
+		t.Errorf("Trigger of a keyboard at %v", v)
+	}
+	if actions.Down("boost") {
+		t.Error("Trigger of a keyboard down")
+	}
+	if v := keyboard.AxisValue(AXIS_TRIGGER_RIGHT); v != -1 {
+		t.Errorf("Trigger of a keyboard at %v, expecting released", v)
+	}
+}
+
+func TestActionMapScale(t *testing.T) {
+	keyboard := &KeyboardController{connected: true, numButtons: 15, numAxes: 2, buttonsDown: make([]bool, 15)}
+	keyboard.buttonsDown[BUTTON_DIR_PAD_RIGHT] = true
+	keyboard.buttonsDown[BUTTON_DIR_PAD_UP] = true
+	actions := NewActionMap(keyboard)
+	actions.AddAxis2D("move",
+		ButtonBinding(BUTTON_DIR_PAD_LEFT).To(0, -1),
+		ButtonBinding(BUTTON_DIR_PAD_RIGHT).To(0, 0.5),
+		ButtonBinding(BUTTON_DIR_PAD_UP).To(1, -0.5),
+	)
+	actions.Update()
+	if v := actions.Vector("move"); !nearly(v.X(), 0.5) || !nearly(v.Y(), -0.5) {
+		t.Errorf("Moving %v", v)
+	}
+}
@@ -0,0 +1,240 @@
+package input
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+type Stick int
+
+const (
+	STICK_LEFT Stick = iota
+	STICK_RIGHT
+)
+
+type Direction int
+
+const (
+	DIRECTION_UP Direction = iota
+	DIRECTION_DOWN
+	DIRECTION_LEFT
+	DIRECTION_RIGHT
+)
+
+type DeadZoneMode int
+
+const (
+	// The dead zone is a circle, the direction of the stick is kept
+	DEAD_ZONE_RADIAL DeadZoneMode = iota
+	// Each axis has its own dead zone, a cross, snapping to the axes
+	DEAD_ZONE_AXIAL
+)
+
+// ResponseCurve maps the value of an axis out of the dead zone, between 0
+// and 1, to the value returned by the controller. Any function keeping 0
+// and 1 is a valid curve
+type ResponseCurve func(value float32) float32
+
+var (
+	CurveLinear    ResponseCurve = func(value float32) float32 { return value }
+	CurveQuadratic ResponseCurve = func(value float32) float32 { return value * value }
+	CurveCubic     ResponseCurve = func(value float32) float32 { return value * value * value }
+)
+
+// NewPowerCurve returns a curve raising the value to exponent, below 1 it's
+// more sensitive near the center
+func NewPowerCurve(exponent float32) ResponseCurve {
+	return func(value float32) float32 {
+		return float32(math.Pow(float64(value), float64(exponent)))
+	}
+}
+
+// AxisSettings are the dead zones and the response curve of a stick or a
+// trigger
+type AxisSettings struct {
+	// Values up to DeadZone are 0
+	DeadZone float32
+	// Values beyond 1 - OuterDeadZone are 1, as most sticks don't reach 1.
+	// If the dead zones add up to 1 or more, the axis is 0 or 1
+	OuterDeadZone float32
+	// Linear if nil
+	Curve ResponseCurve
+}
+
+// apply maps a value between 0 and 1
+func (s *AxisSettings) apply(value float32) float32 {
+	if value <= s.DeadZone {
+		return 0
+	}
+	span := 1 - s.DeadZone - s.OuterDeadZone
+	if span <= 0 {
+		return 1
+	}
+	value = (value - s.DeadZone) / span
+	if value >= 1 {
+		return 1
+	}
+	if s.Curve != nil {
+		value = mgl32.Clamp(s.Curve(value), 0, 1)
+	}
+	return value
+}
+
+// AxisFilter turns the raw values of the axes of a controller in the values
+// it returns, and tells when they're pushed like buttons. Change it with
+// the SetAxisFilter of the controllers, starting from DefaultAxisFilter
+type AxisFilter struct {
+	Sticks    [2]AxisSettings
+	StickMode DeadZoneMode
+	Triggers  [2]AxisSettings
+	// An axis is pushed, its digital value is 1 or -1, beyond PressThreshold
+	// and released within ReleaseThreshold. The gap keeps noisy values from
+	// flickering
+	PressThreshold   float32
+	ReleaseThreshold float32
+}
+
+// DefaultAxisFilter returns the filter of the controllers: radial dead zones
+// of 0.15 for the sticks, 0.05 for the triggers, linear responses
+func DefaultAxisFilter() *AxisFilter {
+	stick := AxisSettings{DeadZone: 0.15, OuterDeadZone: 0.05}
+	trigger := AxisSettings{DeadZone: 0.05, OuterDeadZone: 0.02}
+	return &AxisFilter{
+		Sticks:           [2]AxisSettings{stick, stick},
+		StickMode:        DEAD_ZONE_RADIAL,
+		Triggers:         [2]AxisSettings{trigger, trigger},
+		PressThreshold:   0.5,
+		ReleaseThreshold: 0.35,
+	}
+}
+
+// stickAxes returns the axes of a stick
+func stickAxes(stick Stick) (ControllerAxis, ControllerAxis) {
+	if stick == STICK_RIGHT {
+		return AXIS_RIGHT_X, AXIS_RIGHT_Y
+	}
+	return AXIS_LEFT_X, AXIS_LEFT_Y
+}
+
+// filterStick applies the dead zones and the curve to a stick
+func (f *AxisFilter) filterStick(stick Stick, x, y float32) (float32, float32) {
+	settings := &f.Sticks[stick]
+	if f.StickMode == DEAD_ZONE_AXIAL {
+		return filterSigned(settings, x), filterSigned(settings, y)
+	}
+	length := float32(math.Hypot(float64(x), float64(y)))
+	if length == 0 {
+		return 0, 0
+	}
+	scale := settings.apply(mgl32.Clamp(length, 0, 1)) / length
+	return x * scale, y * scale
+}
+
+func filterSigned(settings *AxisSettings, value float32) float32 {
+	if value < 0 {
+		return -settings.apply(-value)
+	}
+	return settings.apply(value)
+}
+
+// filterTrigger applies the dead zones and the curve to a trigger, between
+// -1 released and 1
+func (f *AxisFilter) filterTrigger(trigger int, value float32) float32 {
+	pushed := mgl32.Clamp((value+1)/2, 0, 1)
+	return 2*f.Triggers[trigger].apply(pushed) - 1
+}
+
+// digital returns the digital value of an axis from its last one
+func (f *AxisFilter) digital(value float32, last int) int {
+	switch {
+	case value >= f.PressThreshold:
+		return 1
+	case value <= -f.PressThreshold:
+		return -1
+	case last > 0 && value > f.ReleaseThreshold:
+		return 1
+	case last < 0 && value < -f.ReleaseThreshold:
+		return -1
+	}
+	return 0
+}
+
+// axisState keeps the filtered and digital values of the axes of a
+// controller
+type axisState struct {
+	filter          *AxisFilter
+	values          [NUM_CONTROLLER_AXES]float32
+	digital         [NUM_CONTROLLER_AXES]int
+	previousDigital [NUM_CONTROLLER_AXES]int
+}
+
+// update filters the raw values of the axes, triggers are -1 when released
+func (s *axisState) update(raw [NUM_CONTROLLER_AXES]float32) {
+	if s.filter == nil {
+		s.filter = DefaultAxisFilter()
+	}
+	for _, stick := range []Stick{STICK_LEFT, STICK_RIGHT} {
+		x, y := stickAxes(stick)
+		s.values[x], s.values[y] = s.filter.filterStick(stick, raw[x], raw[y])
+	}
+	s.values[AXIS_TRIGGER_LEFT] = s.filter.filterTrigger(0, raw[AXIS_TRIGGER_LEFT])
+	s.values[AXIS_TRIGGER_RIGHT] = s.filter.filterTrigger(1, raw[AXIS_TRIGGER_RIGHT])
+
+	s.previousDigital = s.digital
+	for i, value := range s.values {
+		if ControllerAxis(i) == AXIS_TRIGGER_LEFT || ControllerAxis(i) == AXIS_TRIGGER_RIGHT {
+			// Triggers are pushed from -1 to 1, so one way only
+			value = (value + 1) / 2
+		}
+		s.digital[i] = s.filter.digital(value, s.digital[i])
+	}
+}
+
+// reset releases all the axes
+func (s *axisState) reset() {
+	s.values = [NUM_CONTROLLER_AXES]float32{}
+	s.values[AXIS_TRIGGER_LEFT] = -1
+	s.values[AXIS_TRIGGER_RIGHT] = -1
+	s.digital = [NUM_CONTROLLER_AXES]int{}
+	s.previousDigital = [NUM_CONTROLLER_AXES]int{}
+}
+
+func (s *axisState) value(axis ControllerAxis) float32 {
+	if int(axis) >= NUM_CONTROLLER_AXES || axis < 0 {
+		return 0
+	}
+	return s.values[axis]
+}
+
+func (s *axisState) digitalValue(axis ControllerAxis) int {
+	if int(axis) >= NUM_CONTROLLER_AXES || axis < 0 {
+		return 0
+	}
+	return s.digital[axis]
+}
+
+// stickDirection returns the axis and the digital value of a direction, up
+// is negative like the axes of the joysticks
+func stickDirection(stick Stick, direction Direction) (ControllerAxis, int) {
+	x, y := stickAxes(stick)
+	switch direction {
+	case DIRECTION_UP:
+		return y, -1
+	case DIRECTION_DOWN:
+		return y, 1
+	case DIRECTION_LEFT:
+		return x, -1
+	}
+	return x, 1
+}
+
+func (s *axisState) stickPressed(stick Stick, direction Direction) bool {
+	axis, value := stickDirection(stick, direction)
+	return s.digital[axis] == value && s.previousDigital[axis] != value
+}
+
+func (s *axisState) stickReleased(stick Stick, direction Direction) bool {
+	axis, value := stickDirection(stick, direction)
+	return s.digital[axis] != value && s.previousDigital[axis] == value
+}
@@ -0,0 +1,97 @@
+package input
+
+import (
+	"math"
+	"testing"
+)
+
+func nearly(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-5
+}
+
+func TestAxisSettings(t *testing.T) {
+	var tests = []struct {
+		settings AxisSettings
+		value    float32
+		expected float32
+	}{
+		{AxisSettings{DeadZone: 0.2}, 0.1, 0},
+		{AxisSettings{DeadZone: 0.2}, 0.2, 0},
+		{AxisSettings{DeadZone: 0.2}, 0.6, 0.5},
+		{AxisSettings{DeadZone: 0.2, OuterDeadZone: 0.2}, 0.5, 0.5},
+		{AxisSettings{DeadZone: 0.2, OuterDeadZone: 0.2}, 0.9, 1},
+		{AxisSettings{DeadZone: 0.2, Curve: CurveQuadratic}, 0.6, 0.25},
+		{AxisSettings{Curve: NewPowerCurve(0.5)}, 0.25, 0.5},
+		// The dead zones covering everything are a step
+		{AxisSettings{DeadZone: 0.5, OuterDeadZone: 0.5}, 0.5, 0},
+		{AxisSettings{DeadZone: 0.5, OuterDeadZone: 0.5}, 0.51, 1},
+		{AxisSettings{DeadZone: 0.6, OuterDeadZone: 0.6}, 0.7, 1},
+		// A curve out of range is clamped
+		{AxisSettings{Curve: func(v float32) float32 { return v * 3 }}, 0.5, 1},
+	}
+	for i, test := range tests {
+		if v := test.settings.apply(test.value); !nearly(v, test.expected) {
+			t.Errorf("%d: got %v for %v, expecting %v", i, v, test.value, test.expected)
+		}
+	}
+}
+
+func TestAxisFilter(t *testing.T) {
+	f := DefaultAxisFilter()
+	f.Sticks[STICK_LEFT] = AxisSettings{DeadZone: 0.2}
+
+	// Radial keeps the direction
+	if x, y := f.filterStick(STICK_LEFT, 0.36, 0.48); !nearly(x, 0.3) || !nearly(y, 0.4) {
+		t.Errorf("Radial got %v %v", x, y)
+	}
+	if x, y := f.filterStick(STICK_LEFT, 0.1, 0.1); x != 0 || y != 0 {
+		t.Errorf("Radial got %v %v in the dead zone", x, y)
+	}
+	// Axial snaps to the axes
+	f.StickMode = DEAD_ZONE_AXIAL
+	if x, y := f.filterStick(STICK_LEFT, 0.1, -0.6); x != 0 || !nearly(y, -0.5) {
+		t.Errorf("Axial got %v %v", x, y)
+	}
+
+	f.Triggers[0] = AxisSettings{DeadZone: 0.2}
+	var triggerTests = []struct{ raw, expected float32 }{{-1, -1}, {-0.7, -1}, {0.2, 0}, {1, 1}}
+	for _, test := range triggerTests {
+		if v := f.filterTrigger(0, test.raw); !nearly(v, test.expected) {
+			t.Errorf("Trigger got %v for %v, expecting %v", v, test.raw, test.expected)
+		}
+	}
+
+	// Pushed beyond the press threshold, released within the release one
+	digital := 0
+	for _, test := range []struct {
+		value    float32
+		expected int
+	}{{0.4, 0}, {0.5, 1}, {0.4, 1}, {0.3, 0}, {-0.6, -1}, {-0.4, -1}, {0.6, 1}} {
+		digital = f.digital(test.value, digital)
+		if digital != test.expected {
+			t.Errorf("Digital %d at %v, expecting %d", digital, test.value, test.expected)
+		}
+	}
+}
+
+func TestAxisState(t *testing.T) {
+	var s axisState
+	s.reset()
+	var raw [NUM_CONTROLLER_AXES]float32
+	raw[AXIS_TRIGGER_LEFT] = -1
+	raw[AXIS_TRIGGER_RIGHT] = 0.5
+	raw[AXIS_LEFT_X] = 1
+	s.update(raw)
+	if !s.stickPressed(STICK_LEFT, DIRECTION_RIGHT) || s.stickPressed(STICK_LEFT, DIRECTION_LEFT) {
+		t.Error("Stick right not pressed")
+	}
+	// A trigger half pushed is pushed, a released one isn't -1
+	if s.digitalValue(AXIS_TRIGGER_RIGHT) != 1 || s.digitalValue(AXIS_TRIGGER_LEFT) != 0 {
+		t.Errorf("Triggers %d %d", s.digitalValue(AXIS_TRIGGER_LEFT), s.digitalValue(AXIS_TRIGGER_RIGHT))
+	}
+	raw[AXIS_LEFT_X] = 0
+	s.update(raw)
+	if !s.stickReleased(STICK_LEFT, DIRECTION_RIGHT) || s.stickPressed(STICK_LEFT, DIRECTION_RIGHT) {
+		t.Error("Stick right not released")
+	}
+}
@@ -0,0 +1,299 @@
+package input
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+type BindingType int
+
+const (
+	BINDING_KEY BindingType = iota
+	BINDING_MOUSE_BUTTON
+	BINDING_CONTROLLER_BUTTON
+	BINDING_CONTROLLER_AXIS
+)
+
+// Binding is an input bound to an action: a key or a mouse button with the
+// modifiers held, a button or an axis of the controller
+type Binding struct {
+	Type        BindingType
+	Key         glfw.Key
+	MouseButton MouseButton
+	Button      ControllerButton
+	Axis        ControllerAxis
+	// Half of the axis, 1 the positive one, -1 the negative one, 0 the
+	// whole axis
+	AxisHalf int
+	// Keys held with a key or a mouse button, e.g. glfw.ModControl
+	Modifiers glfw.ModifierKey
+	// Component of a 2D axis changed by the binding, 0 x and 1 y
+	Component int
+	// Multiplies the value given to an axis, -1 for the negative direction
+	Scale float32
+}
+
+// KeyBinding binds a key, pressed with the modifiers held
+func KeyBinding(key glfw.Key, modifiers glfw.ModifierKey) Binding {
+	return Binding{Type: BINDING_KEY, Key: key, Modifiers: modifiers, Scale: 1}
+}
+
+// MouseBinding binds a mouse button, pressed with the modifiers held
+func MouseBinding(button MouseButton, modifiers glfw.ModifierKey) Binding {
+	return Binding{Type: BINDING_MOUSE_BUTTON, MouseButton: button, Modifiers: modifiers, Scale: 1}
+}
+
+// ButtonBinding binds a button of the controller
+func ButtonBinding(button ControllerButton) Binding {
+	return Binding{Type: BINDING_CONTROLLER_BUTTON, Button: button, Scale: 1}
+}
+
+// AxisBinding binds an axis of the controller, or one of its halves. Bound
+// to buttons an axis is down when pushed, see AxisFilter
+func AxisBinding(axis ControllerAxis, half int) Binding {
+	return Binding{Type: BINDING_CONTROLLER_AXIS, Axis: axis, AxisHalf: half, Scale: 1}
+}
+
+// To returns the binding changing a component of a 2D axis, 0 x and 1 y, by
+// scale. Scale is used by 1D axes too, e.g. -1 for the key moving left
+func (b Binding) To(component int, scale float32) Binding {
+	b.Component = component
+	b.Scale = scale
+	return b
+}
+
+var (
+	keyNames   = make(map[glfw.Key]string)
+	keysByName = make(map[string]glfw.Key)
+	modifiers  = []struct {
+		modifier glfw.ModifierKey
+		name     string
+		keys     []glfw.Key
+	}{
+		{glfw.ModControl, "Ctrl", []glfw.Key{glfw.KeyLeftControl, glfw.KeyRightControl}},
+		{glfw.ModShift, "Shift", []glfw.Key{glfw.KeyLeftShift, glfw.KeyRightShift}},
+		{glfw.ModAlt, "Alt", []glfw.Key{glfw.KeyLeftAlt, glfw.KeyRightAlt}},
+		{glfw.ModSuper, "Super", []glfw.Key{glfw.KeyLeftSuper, glfw.KeyRightSuper}},
+	}
+	mouseButtonNames = []string{
+		"MouseLeft", "MouseRight", "MouseMiddle", "Mouse4", "Mouse5", "Mouse6", "Mouse7", "Mouse8",
+	}
+	controllerButtonNames = []string{
+		"PadA", "PadB", "PadX", "PadY", "PadBack", "PadGuide", "PadStart",
+		"PadLeftStick", "PadRightStick", "PadLeftShoulder", "PadRightShoulder",
+		"PadUp", "PadDown", "PadLeft", "PadRight",
+	}
+	controllerAxisNames = []string{
+		"PadLeftX", "PadLeftY", "PadRightX", "PadRightY", "PadLeftTrigger", "PadRightTrigger",
+	}
+)
+
+func init() {
+	addKey := func(key glfw.Key, name string) {
+		keyNames[key] = name
+		keysByName[strings.ToLower(name)] = key
+	}
+	for i := 0; i < 26; i++ {
+		addKey(glfw.KeyA+glfw.Key(i), string(rune('A'+i)))
+	}
+	for i := 0; i < 10; i++ {
+		addKey(glfw.Key0+glfw.Key(i), string(rune('0'+i)))
+		addKey(glfw.KeyKP0+glfw.Key(i), fmt.Sprintf("KP%d", i))
+	}
+	for i := 0; i < 25; i++ {
+		addKey(glfw.KeyF1+glfw.Key(i), fmt.Sprintf("F%d", i+1))
+	}
+	for _, k := range []struct {
+		key  glfw.Key
+		name string
+	}{
+		{glfw.KeySpace, "Space"}, {glfw.KeyApostrophe, "Apostrophe"}, {glfw.KeyComma, "Comma"},
+		{glfw.KeyMinus, "Minus"}, {glfw.KeyPeriod, "Period"}, {glfw.KeySlash, "Slash"},
+		{glfw.KeySemicolon, "Semicolon"}, {glfw.KeyEqual, "Equal"}, {glfw.KeyLeftBracket, "LeftBracket"},
+		{glfw.KeyBackslash, "Backslash"}, {glfw.KeyRightBracket, "RightBracket"},
+		{glfw.KeyGraveAccent, "GraveAccent"}, {glfw.KeyWorld1, "World1"}, {glfw.KeyWorld2, "World2"},
+		{glfw.KeyEscape, "Escape"}, {glfw.KeyEnter, "Enter"}, {glfw.KeyTab, "Tab"},
+		{glfw.KeyBackspace, "Backspace"}, {glfw.KeyInsert, "Insert"}, {glfw.KeyDelete, "Delete"},
+		{glfw.KeyRight, "Right"}, {glfw.KeyLeft, "Left"}, {glfw.KeyDown, "Down"}, {glfw.KeyUp, "Up"},
+		{glfw.KeyPageUp, "PageUp"}, {glfw.KeyPageDown, "PageDown"}, {glfw.KeyHome, "Home"},
+		{glfw.KeyEnd, "End"}, {glfw.KeyCapsLock, "CapsLock"}, {glfw.KeyScrollLock, "ScrollLock"},
+		{glfw.KeyNumLock, "NumLock"}, {glfw.KeyPrintScreen, "PrintScreen"}, {glfw.KeyPause, "Pause"},
+		{glfw.KeyKPDecimal, "KPDecimal"}, {glfw.KeyKPDivide, "KPDivide"},
+		{glfw.KeyKPMultiply, "KPMultiply"}, {glfw.KeyKPSubtract, "KPSubtract"},
+		{glfw.KeyKPAdd, "KPAdd"}, {glfw.KeyKPEnter, "KPEnter"}, {glfw.KeyKPEqual, "KPEqual"},
+		{glfw.KeyLeftShift, "LeftShift"}, {glfw.KeyLeftControl, "LeftControl"},
+		{glfw.KeyLeftAlt, "LeftAlt"}, {glfw.KeyLeftSuper, "LeftSuper"},
+		{glfw.KeyRightShift, "RightShift"}, {glfw.KeyRightControl, "RightControl"},
+		{glfw.KeyRightAlt, "RightAlt"}, {glfw.KeyRightSuper, "RightSuper"}, {glfw.KeyMenu, "Menu"},
+	} {
+		addKey(k.key, k.name)
+	}
+}
+
+// KeyName returns the name of a key used by the bindings, e.g. "W", "Space"
+// or "F1", empty if unknown
+func KeyName(key glfw.Key) string {
+	return keyNames[key]
+}
+
+// String returns the binding in the format of ParseBinding
+func (b Binding) String() string {
+	var input string
+	switch b.Type {
+	case BINDING_KEY:
+		input = keyNames[b.Key]
+		if input == "" {
+			input = fmt.Sprintf("Key%d", int(b.Key))
+		}
+	case BINDING_MOUSE_BUTTON:
+		if int(b.MouseButton) < len(mouseButtonNames) {
+			input = mouseButtonNames[b.MouseButton]
+		}
+	case BINDING_CONTROLLER_BUTTON:
+		if int(b.Button) < len(controllerButtonNames) {
+			input = controllerButtonNames[b.Button]
+		}
+	case BINDING_CONTROLLER_AXIS:
+		if int(b.Axis) < len(controllerAxisNames) {
+			input = controllerAxisNames[b.Axis]
+		}
+		if b.AxisHalf > 0 {
+			input += "+"
+		} else if b.AxisHalf < 0 {
+			input += "-"
+		}
+	}
+	if b.Type == BINDING_KEY || b.Type == BINDING_MOUSE_BUTTON {
+		prefix := ""
+		for _, m := range modifiers {
+			if b.Modifiers&m.modifier != 0 {
+				prefix += m.name + "+"
+			}
+		}
+		input = prefix + input
+	}
+	if scale := math.Abs(float64(b.Scale)); scale != 1 {
+		input += "*" + strconv.FormatFloat(scale, 'g', -1, 32)
+	}
+
+	target := ""
+	if b.Component == 1 {
+		target = "Y"
+	}
+	if b.Scale < 0 {
+		target += "-"
+	}
+	if target != "" {
+		return input + ":" + target
+	}
+	return input
+}
+
+// ParseBinding parses a binding written as an input, optionally held with
+// modifiers, followed by what it changes in an axis:
+//
+//	Space                 the space key
+//	Ctrl+Shift+S          S with control and shift held
+//	MouseLeft             the left mouse button, MouseRight, MouseMiddle, Mouse4...
+//	PadA                  a button of the controller, PadStart, PadUp...
+//	PadLeftX              an axis of the controller, PadRightY, PadLeftTrigger...
+//	PadLeftY-             the negative half of an axis
+//	A:-                   the negative direction of a 1D axis
+//	W:Y-                  the negative direction of the y of a 2D axis
+//	PadRightY:Y           the y of a 2D axis
+//	PadLeftX*0.5          an axis at half its value
+//	S*0.5:Y               S moving down at half the speed
+//
+// Keys are named after the glfw.Key constants, e.g. Left, PageUp, KP0, F12
+func ParseBinding(text string) (Binding, error) {
+	text = strings.TrimSpace(text)
+	target := ""
+	if i := strings.LastIndex(text, ":"); i >= 0 {
+		text, target = text[:i], strings.ToUpper(text[i+1:])
+	}
+	scale := float32(1)
+	if i := strings.LastIndex(text, "*"); i >= 0 {
+		value, err := strconv.ParseFloat(text[i+1:], 32)
+		if err != nil || value < 0 || math.IsInf(value, 0) || math.IsNaN(value) {
+			return Binding{}, fmt.Errorf("invalid scale of %q", text)
+		}
+		text, scale = text[:i], float32(value)
+	}
+
+	var mods glfw.ModifierKey
+	for found := true; found; {
+		found = false
+		for _, m := range modifiers {
+			prefix := m.name + "+"
+			if len(text) > len(prefix) && strings.EqualFold(text[:len(prefix)], prefix) {
+				mods |= m.modifier
+				text = text[len(prefix):]
+				found = true
+			}
+		}
+	}
+
+	b, ok := parseBindingInput(text)
+	if !ok {
+		return b, fmt.Errorf("unknown input %q", text)
+	}
+	if mods != 0 {
+		if b.Type != BINDING_KEY && b.Type != BINDING_MOUSE_BUTTON {
+			return b, fmt.Errorf("modifiers of %q, only keys and mouse buttons have them", text)
+		}
+		b.Modifiers = mods
+	}
+
+	if strings.HasPrefix(target, "X") {
+		target = target[1:]
+	} else if strings.HasPrefix(target, "Y") {
+		b.Component = 1
+		target = target[1:]
+	}
+	switch target {
+	case "", "+":
+		b.Scale = scale
+	case "-":
+		b.Scale = -scale
+	default:
+		return b, fmt.Errorf("invalid axis target of %q", text)
+	}
+	return b, nil
+}
+
+func parseBindingInput(text string) (Binding, bool) {
+	lower := strings.ToLower(text)
+	if key, ok := keysByName[lower]; ok {
+		return KeyBinding(key, 0), true
+	}
+	var code int
+	if _, err := fmt.Sscanf(lower, "key%d", &code); err == nil {
+		return KeyBinding(glfw.Key(code), 0), true
+	}
+	for i, name := range mouseButtonNames {
+		if strings.EqualFold(name, text) {
+			return MouseBinding(MouseButton(i), 0), true
+		}
+	}
+	for i, name := range controllerButtonNames {
+		if strings.EqualFold(name, text) {
+			return ButtonBinding(ControllerButton(i)), true
+		}
+	}
+	half := 0
+	if strings.HasSuffix(text, "+") {
+		half, text = 1, text[:len(text)-1]
+	} else if strings.HasSuffix(text, "-") {
+		half, text = -1, text[:len(text)-1]
+	}
+	for i, name := range controllerAxisNames {
+		if strings.EqualFold(name, text) {
+			return AxisBinding(ControllerAxis(i), half), true
+		}
+	}
+	return Binding{}, false
+}
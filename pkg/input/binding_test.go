@@ -0,0 +1,48 @@
+package input
+
+import (
+	"testing"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+func TestBindingString(t *testing.T) {
+	var tests = []struct {
+		binding Binding
+		text    string
+	}{
+		{KeyBinding(glfw.KeySpace, 0), "Space"},
+		{KeyBinding(glfw.KeyS, glfw.ModControl|glfw.ModShift), "Ctrl+Shift+S"},
+		{KeyBinding(glfw.Key(500), 0), "Key500"},
+		{MouseBinding(MOUSE_BUTTON_RIGHT, glfw.ModAlt), "Alt+MouseRight"},
+		{ButtonBinding(BUTTON_START), "PadStart"},
+		{AxisBinding(AXIS_LEFT_Y, -1), "PadLeftY-"},
+		{KeyBinding(glfw.KeyA, 0).To(0, -1), "A:-"},
+		{KeyBinding(glfw.KeyW, 0).To(1, -1), "W:Y-"},
+		{AxisBinding(AXIS_RIGHT_Y, 0).To(1, 1), "PadRightY:Y"},
+		{AxisBinding(AXIS_LEFT_X, 0).To(0, 0.5), "PadLeftX*0.5"},
+		{KeyBinding(glfw.KeyS, 0).To(1, -0.25), "S*0.25:Y-"},
+		{AxisBinding(AXIS_LEFT_X, 1).To(0, 2), "PadLeftX+*2"},
+	}
+	for _, test := range tests {
+		if text := test.binding.String(); text != test.text {
+			t.Errorf("Got %q, expecting %q", text, test.text)
+		}
+		b, err := ParseBinding(test.text)
+		if err != nil {
+			t.Errorf("Parsing %q: %v", test.text, err)
+		} else if b != test.binding {
+			t.Errorf("Parsed %q as %+v, expecting %+v", test.text, b, test.binding)
+		}
+	}
+
+	// Case and spaces don't matter
+	if b, err := ParseBinding(" ctrl+kp5:y "); err != nil || b != KeyBinding(glfw.KeyKP5, glfw.ModControl).To(1, 1) {
+		t.Errorf("Got %+v, %v", b, err)
+	}
+	for _, invalid := range []string{"", "Nope", "Ctrl+PadA", "A:Z", "A*", "A*x", "A*-1", "PadLeftX*0.5*"} {
+		if _, err := ParseBinding(invalid); err == nil {
+			t.Errorf("Parsed %q", invalid)
+		}
+	}
+}
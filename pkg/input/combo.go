@@ -0,0 +1,333 @@
+package input
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ComboStep is an input of a combo: a direction, buttons pressed together,
+// or a direction and then buttons
+type ComboStep struct {
+	// HEADING_ANY for the buttons only
+	Heading Heading
+	Buttons []ControllerButton
+	// Seconds the heading is held before the next step, 0 if not a charge
+	Charge float64
+	// Max seconds from the previous step, Combo.Window if 0
+	Window float64
+}
+
+// Combo is a sequence of inputs done in time, matched by InputBuffer.Match
+type Combo struct {
+	Name  string
+	Steps []ComboStep
+	// Max seconds between the steps
+	Window float64
+	// Max seconds between the buttons of a step
+	ButtonWindow float64
+	// Seconds the combo still matches after its last input, 0 in the frame
+	// it's done only
+	Buffer float64
+}
+
+// NewCombo creates a combo with the default windows
+func NewCombo(name string, steps ...ComboStep) *Combo {
+	return &Combo{
+		Name:         name,
+		Steps:        steps,
+		Window:       DefaultComboWindow,
+		ButtonWindow: DefaultComboButtonWindow,
+	}
+}
+
+// ParseCombo parses a combo in the numpad notation of the fighting games,
+// the directions as on a numpad for a character facing right:
+//
+//	7 8 9        up back    up    up forward
+//	4 5 6        back       none  forward
+//	1 2 3        down back  down  down forward
+//
+// followed by buttons, each step separated by spaces or not:
+//
+//	236+X        quarter circle forward and X, a fireball
+//	623+A+B      dragon punch with A and B together
+//	[4]6+X       back held DefaultChargeTime, then forward and X
+//	6 6          a dash
+//	X X Y        buttons only, any direction
+//
+// The buttons are named A, B, X, Y, Back, Start, LB, RB, or as in
+// ParseBinding, e.g. PadLeftShoulder
+func ParseCombo(name, notation string) (*Combo, error) {
+	combo := NewCombo(name)
+	for _, token := range strings.Fields(notation) {
+		steps, err := parseComboToken(token)
+		if err != nil {
+			return nil, err
+		}
+		combo.Steps = append(combo.Steps, steps...)
+	}
+	if len(combo.Steps) == 0 {
+		return nil, fmt.Errorf("empty combo %q", notation)
+	}
+	return combo, nil
+}
+
+func parseComboToken(token string) ([]ComboStep, error) {
+	var steps []ComboStep
+	rest := token
+	for len(rest) > 0 {
+		if rest[0] >= '1' && rest[0] <= '9' {
+			steps = append(steps, ComboStep{Heading: numpadHeadings[rest[0]-'0']})
+			rest = rest[1:]
+		} else if len(rest) >= 3 && rest[0] == '[' && rest[2] == ']' && rest[1] >= '1' && rest[1] <= '9' {
+			steps = append(steps, ComboStep{Heading: numpadHeadings[rest[1]-'0'], Charge: DefaultChargeTime})
+			rest = rest[3:]
+		} else {
+			break
+		}
+	}
+	rest = strings.TrimPrefix(rest, "+")
+	if rest == "" {
+		return steps, nil
+	}
+	var buttons []ControllerButton
+	for _, name := range strings.Split(rest, "+") {
+		button, ok := parseComboButton(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown button %q in %q", name, token)
+		}
+		buttons = append(buttons, button)
+	}
+	// The buttons go with the last direction, unless it's a charge
+	if n := len(steps); n > 0 && steps[n-1].Charge == 0 {
+		steps[n-1].Buttons = buttons
+	} else {
+		steps = append(steps, ComboStep{Heading: HEADING_ANY, Buttons: buttons})
+	}
+	return steps, nil
+}
+
+func parseComboButton(name string) (ControllerButton, bool) {
+	switch strings.ToLower(name) {
+	case "lb":
+		return BUTTON_LEFT_SHOULDER, true
+	case "rb":
+		return BUTTON_RIGHT_SHOULDER, true
+	}
+	for i, buttonName := range controllerButtonNames {
+		if strings.EqualFold(buttonName, name) || strings.EqualFold(buttonName, "Pad"+name) {
+			return ControllerButton(i), true
+		}
+	}
+	return 0, false
+}
+
+// String returns the combo in the notation of ParseCombo
+func (c *Combo) String() string {
+	var tokens []string
+	for _, step := range c.Steps {
+		token := ""
+		if step.Heading != HEADING_ANY {
+			token = fmt.Sprint(step.Heading.Numpad())
+			if step.Charge > 0 {
+				token = "[" + token + "]"
+			}
+		}
+		for i, button := range step.Buttons {
+			if i > 0 || token != "" {
+				token += "+"
+			}
+			token += strings.TrimPrefix(controllerButtonNames[button], "Pad")
+		}
+		tokens = append(tokens, token)
+	}
+	return strings.Join(tokens, " ")
+}
+
+// comboAtom is a part of a step matched by one or more events
+type comboAtom struct {
+	kind    BufferedInputType
+	heading Heading
+	buttons []ControllerButton
+	charge  float64
+	window  float64
+}
+
+func (c *Combo) atoms(facingLeft bool) []comboAtom {
+	var atoms []comboAtom
+	for _, step := range c.Steps {
+		window := step.Window
+		if window == 0 {
+			window = c.Window
+		}
+		if step.Heading != HEADING_ANY {
+			heading := step.Heading
+			if facingLeft {
+				heading = heading.Mirrored()
+			}
+			atoms = append(atoms, comboAtom{kind: BUFFERED_HEADING, heading: heading, charge: step.Charge, window: window})
+		}
+		if len(step.Buttons) > 0 {
+			atoms = append(atoms, comboAtom{kind: BUFFERED_BUTTON_PRESS, buttons: step.Buttons, window: window})
+		}
+	}
+	return atoms
+}
+
+// comboSearch is where the atom before a matched one is looked for
+type comboSearch struct {
+	earliest float64
+	latest   float64
+	// The events of the same kind are before this index
+	kind  BufferedInputType
+	index int
+	used  map[int]bool
+}
+
+func (s *comboSearch) valid(b *InputBuffer, i int) bool {
+	return b.events[i].Time >= s.earliest && s.unused(b, i)
+}
+
+// unused tells if an event can be used, even before the window
+func (s *comboSearch) unused(b *InputBuffer, i int) bool {
+	e := &b.events[i]
+	return e.Time <= s.latest && !s.used[i] && !e.Consumed && (e.Type != s.kind || i < s.index)
+}
+
+// Match returns true if the combo was just done, for a character facing
+// left the directions are mirrored. The last input of the combo is
+// consumed, so the combo matches once and the buttons don't do their own
+// action too. Check the longer combos first
+func (b *InputBuffer) Match(combo *Combo, facingLeft bool) bool {
+	atoms := combo.atoms(facingLeft)
+	search := comboSearch{
+		earliest: b.time - combo.Buffer,
+		latest:   b.time,
+		kind:     -1,
+		index:    len(b.events),
+		used:     make(map[int]bool),
+	}
+	var last []int
+	for i := len(atoms) - 1; i >= 0; i-- {
+		a := &atoms[i]
+		var time float64
+		var matched []int
+		var ok bool
+		switch {
+		case a.kind == BUFFERED_BUTTON_PRESS:
+			time, matched, ok = b.matchButtons(a, &search, combo.ButtonWindow)
+		case a.charge > 0:
+			time, matched, ok = b.matchCharge(a, &search)
+		default:
+			time, matched, ok = b.matchHeading(a, &search)
+		}
+		if !ok {
+			return false
+		}
+		if i == len(atoms)-1 {
+			last = matched
+		}
+		search.earliest = time - a.window
+		search.latest = time
+		search.kind = a.kind
+		search.index = len(b.events)
+		for _, m := range matched {
+			search.used[m] = true
+			if m < search.index {
+				search.index = m
+			}
+		}
+	}
+	for _, i := range last {
+		b.events[i].Consumed = true
+	}
+	return true
+}
+
+// matchHeading finds the last time the heading was entered
+func (b *InputBuffer) matchHeading(a *comboAtom, search *comboSearch) (float64, []int, bool) {
+	for i := len(b.events) - 1; i >= 0; i-- {
+		e := &b.events[i]
+		if e.Type == BUFFERED_HEADING && e.Heading == a.heading && search.valid(b, i) {
+			return e.Time, []int{i}, true
+		}
+	}
+	return 0, nil, false
+}
+
+// matchButtons finds the last time all the buttons were pressed, within
+// the button window
+func (b *InputBuffer) matchButtons(a *comboAtom, search *comboSearch, buttonWindow float64) (float64, []int, bool) {
+	for i := len(b.events) - 1; i >= 0; i-- {
+		e := &b.events[i]
+		if e.Type != BUFFERED_BUTTON_PRESS || !search.valid(b, i) || !containsButton(a.buttons, e.Button) {
+			continue
+		}
+		matched := []int{i}
+		for _, button := range a.buttons {
+			if button == e.Button {
+				continue
+			}
+			found := false
+			for j := len(b.events) - 1; j >= 0; j-- {
+				o := &b.events[j]
+				if j != i && o.Type == BUFFERED_BUTTON_PRESS && o.Button == button &&
+					o.Time <= e.Time && o.Time >= e.Time-buttonWindow && search.unused(b, j) {
+					matched = append(matched, j)
+					found = true
+					break
+				}
+			}
+			if !found {
+				matched = nil
+				break
+			}
+		}
+		if matched != nil {
+			return e.Time, matched, true
+		}
+	}
+	return 0, nil, false
+}
+
+func containsButton(buttons []ControllerButton, button ControllerButton) bool {
+	for _, b := range buttons {
+		if b == button {
+			return true
+		}
+	}
+	return false
+}
+
+// matchCharge finds the last time the charge was released after being held
+// long enough, or the charge still held. The release can be the heading of
+// the next atom
+func (b *InputBuffer) matchCharge(a *comboAtom, search *comboSearch) (float64, []int, bool) {
+	// Held until now
+	if b.time <= search.latest && b.time >= search.earliest && b.HeadingHeldFor(a.heading) >= a.charge {
+		return b.time, nil, true
+	}
+	for i := len(b.events) - 1; i >= 0; i-- {
+		e := &b.events[i]
+		if e.Type != BUFFERED_HEADING || e.Time > search.latest || e.Time < search.earliest ||
+			(search.kind == BUFFERED_HEADING && i > search.index) || a.heading.charges(e.Heading) {
+			continue
+		}
+		// The headings before the release, charging
+		start, charged := e.Time, false
+		for j := i - 1; j >= 0; j-- {
+			o := &b.events[j]
+			if o.Type != BUFFERED_HEADING {
+				continue
+			}
+			if !a.heading.charges(o.Heading) {
+				break
+			}
+			start, charged = o.Time, true
+		}
+		if charged && e.Time-start >= a.charge {
+			return e.Time, nil, true
+		}
+	}
+	return 0, nil, false
+}
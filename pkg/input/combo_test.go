@@ -0,0 +1,192 @@
+package input
+
+import (
+	"strings"
+	"testing"
+)
+
+// comboInput is a heading in the numpad notation held for frames, and the
+// buttons tapped in the first one, e.g. "6+X"
+type comboInput struct {
+	token  string
+	frames int
+}
+
+const comboTestFrame = 1.0 / 60
+
+// playCombo feeds inputs to a buffer through a fake controller, a frame at
+// a time from the time given. Returns the time of the next frame
+func playCombo(t *testing.T, pad *fakeController, buffer *InputBuffer, time float64, inputs ...comboInput) float64 {
+	for _, in := range inputs {
+		parts := strings.Split(in.token, "+")
+		heading := numpadHeadings[parts[0][0]-'0']
+		x, y := heading.vector()
+		pad.set(BUTTON_DIR_PAD_LEFT, x < 0)
+		pad.set(BUTTON_DIR_PAD_RIGHT, x > 0)
+		pad.set(BUTTON_DIR_PAD_UP, y < 0)
+		pad.set(BUTTON_DIR_PAD_DOWN, y > 0)
+		var buttons []ControllerButton
+		for _, name := range parts[1:] {
+			button, ok := parseComboButton(name)
+			if !ok {
+				t.Fatalf("Unknown button %s", name)
+			}
+			pad.set(button, true)
+			buttons = append(buttons, button)
+		}
+		for i := 0; i < in.frames; i++ {
+			pad.Update()
+			buffer.Update(time)
+			time += comboTestFrame
+			// The buttons are pressed in the first frame only
+			for _, button := range buttons {
+				pad.set(button, false)
+			}
+		}
+	}
+	return time
+}
+
+func TestInputBufferMatch(t *testing.T) {
+	var tests = []struct {
+		name       string
+		combo      string
+		facingLeft bool
+		inputs     []comboInput
+		match      bool
+	}{
+		{"Fireball", "236+X", false, []comboInput{{"2", 2}, {"3", 2}, {"6+X", 1}}, true},
+		{"Fireball late button", "236+X", false, []comboInput{{"2", 2}, {"3", 2}, {"6", 3}, {"6+X", 1}}, true},
+		{"Fireball slow", "236+X", false, []comboInput{{"2", 1}, {"3", 20}, {"6+X", 1}}, false},
+		{"Fireball missing down", "236+X", false, []comboInput{{"3", 2}, {"6+X", 1}}, false},
+		{"Fireball facing left", "236+X", true, []comboInput{{"2", 2}, {"1", 2}, {"4+X", 1}}, true},
+		{"Fireball facing left, forward", "236+X", true, []comboInput{{"2", 2}, {"3", 2}, {"6+X", 1}}, false},
+		{"Other button", "236+X", false, []comboInput{{"2", 2}, {"3", 2}, {"6+Y", 1}}, false},
+		{"Charge", "[4]6+X", false, []comboInput{{"4", 50}, {"6+X", 1}}, true},
+		{"Charge down back", "[4]6+X", false, []comboInput{{"1", 25}, {"4", 25}, {"6+X", 1}}, true},
+		{"Charge too short", "[4]6+X", false, []comboInput{{"4", 30}, {"6+X", 1}}, false},
+		{"Charge broken", "[4]6+X", false, []comboInput{{"4", 25}, {"5", 1}, {"4", 25}, {"6+X", 1}}, false},
+		{"Charge facing left", "[4]6+X", true, []comboInput{{"6", 50}, {"4+X", 1}}, true},
+		{"Dash", "6 6", false, []comboInput{{"6", 3}, {"5", 3}, {"6", 1}}, true},
+		{"Dash held", "6 6", false, []comboInput{{"6", 10}}, false},
+		{"Dash slow", "6 6", false, []comboInput{{"6", 3}, {"5", 20}, {"6", 1}}, false},
+		{"Dash facing left", "6 6", true, []comboInput{{"4", 3}, {"5", 3}, {"4", 1}}, true},
+		{"Buttons together", "A+B", false, []comboInput{{"5+A", 2}, {"5+B", 1}}, true},
+		{"Buttons apart", "A+B", false, []comboInput{{"5+A", 4}, {"5+B", 1}}, false},
+		{"Buttons in sequence", "X X Y", false, []comboInput{{"5+X", 3}, {"5+X", 3}, {"5+Y", 1}}, true},
+		{"Buttons out of order", "X X Y", false, []comboInput{{"5+X", 3}, {"5+Y", 3}, {"5+X", 1}}, false},
+	}
+	for _, test := range tests {
+		combo, err := ParseCombo(test.name, test.combo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pad := newFakeController()
+		buffer := NewInputBuffer(pad)
+		playCombo(t, pad, buffer, 10, test.inputs...)
+		if buffer.Match(combo, test.facingLeft) != test.match {
+			t.Errorf("%s: matched %v", test.name, !test.match)
+		}
+	}
+}
+
+func TestInputBufferMatchConsumes(t *testing.T) {
+	fireball, _ := ParseCombo("fireball", "236+X")
+	pad := newFakeController()
+	buffer := NewInputBuffer(pad)
+	time := playCombo(t, pad, buffer, 10, comboInput{"2", 2}, comboInput{"3", 2}, comboInput{"6+X", 1})
+	if !buffer.Match(fireball, false) {
+		t.Fatal("Fireball not matched")
+	}
+	if buffer.Match(fireball, false) || buffer.PressedWithin(BUTTON_X, 1) {
+		t.Error("Last input not consumed")
+	}
+
+	// Pressing X again does the fireball with the same motion
+	playCombo(t, pad, buffer, time, comboInput{"6", 1}, comboInput{"6+X", 1})
+	if !buffer.Match(fireball, false) {
+		t.Error("Fireball not matched again")
+	}
+
+	// The buffer keeps the combo for a while after its last input
+	fireball.Buffer = 0.1
+	time = playCombo(t, pad, buffer, time+1, comboInput{"2", 2}, comboInput{"3", 2}, comboInput{"6+X", 1})
+	playCombo(t, pad, buffer, time, comboInput{"6", 3})
+	if !buffer.Match(fireball, false) {
+		t.Error("Fireball not buffered")
+	}
+	fireball.Buffer = 0
+	playCombo(t, pad, buffer, time+1, comboInput{"2", 2}, comboInput{"3", 2}, comboInput{"6+X", 1}, comboInput{"6", 1})
+	if buffer.Match(fireball, false) {
+		t.Error("Fireball matched after its frame")
+	}
+}
+
+func TestInputBufferTrim(t *testing.T) {
+	charge, _ := ParseCombo("charge", "[4]6+X")
+	pad := newFakeController()
+	buffer := NewInputBuffer(pad)
+	// Longer than the buffer
+	time := playCombo(t, pad, buffer, 10, comboInput{"5+A", 1}, comboInput{"4", 180})
+	events := buffer.Events()
+	if len(events) != 1 || events[0].Type != BUFFERED_HEADING || events[0].Heading != HEADING_BACK {
+		t.Fatalf("Kept %+v, expecting the last heading", events)
+	}
+	if held := buffer.HeadingHeldFor(HEADING_BACK); held < 2.9 {
+		t.Errorf("Back held for %v", held)
+	}
+	if buffer.PressedWithin(BUTTON_A, 5) {
+		t.Error("Press older than the buffer kept")
+	}
+	playCombo(t, pad, buffer, time, comboInput{"6+X", 1})
+	if !buffer.Match(charge, false) {
+		t.Error("Charge longer than the buffer not matched")
+	}
+
+	// Neutral is held since the buffer was created or cleared
+	if held := NewInputBuffer(pad).HeadingHeldFor(HEADING_NEUTRAL); held != DefaultInputBufferLength {
+		t.Errorf("Neutral held for %v on a new buffer", held)
+	}
+	buffer.Clear()
+	if held := buffer.HeadingHeldFor(HEADING_NEUTRAL); held != DefaultInputBufferLength {
+		t.Errorf("Neutral held for %v after clearing", held)
+	}
+}
+
+func TestParseCombo(t *testing.T) {
+	var tests = []struct {
+		notation string
+		expected string
+		steps    int
+	}{
+		{"236+X", "2 3 6+X", 3},
+		{"2 3 6+X", "2 3 6+X", 3},
+		{"623+A+B", "6 2 3+A+B", 3},
+		{"[4]6+X", "[4] 6+X", 2},
+		{"[2]8+LB", "[2] 8+LeftShoulder", 2},
+		{"6 6", "6 6", 2},
+		{"X X Y", "X X Y", 3},
+		{"2X", "2+X", 1},
+		{"[4]+Start", "[4] Start", 2},
+	}
+	for _, test := range tests {
+		combo, err := ParseCombo("test", test.notation)
+		if err != nil {
+			t.Errorf("Error parsing %q: %v", test.notation, err)
+			continue
+		}
+		if combo.String() != test.expected || len(combo.Steps) != test.steps {
+			t.Errorf("Parsed %q as %q in %d steps, expecting %q in %d", test.notation,
+				combo.String(), len(combo.Steps), test.expected, test.steps)
+		}
+		if again, err := ParseCombo("test", combo.String()); err != nil || again.String() != combo.String() {
+			t.Errorf("Parsed %q again as %v", combo.String(), again)
+		}
+	}
+
+	for _, notation := range []string{"", "   ", "236+Z", "236+X+", "[4", "[0]6", "0", "6++X", "4]"} {
+		if _, err := ParseCombo("test", notation); err == nil {
+			t.Errorf("Parsed %q", notation)
+		}
+	}
+}
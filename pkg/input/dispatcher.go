@@ -0,0 +1,224 @@
+package input
+
+import (
+	"sort"
+
+	"github.com/markov/gojira2d/pkg/app"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+type EventType int
+
+const (
+	EVENT_KEY EventType = iota
+	EVENT_CHAR
+	EVENT_MOUSE_BUTTON
+	EVENT_CURSOR_POS
+	EVENT_CURSOR_ENTER
+	EVENT_SCROLL
+	EVENT_JOYSTICK
+)
+
+// Event is an input event of the window, or a joystick plugged in or out
+type Event struct {
+	Type EventType
+	// Seconds from glfw.Init
+	Time float64
+
+	// EVENT_KEY
+	Key      glfw.Key
+	ScanCode int
+	// EVENT_KEY and EVENT_MOUSE_BUTTON
+	Action    glfw.Action
+	Modifiers glfw.ModifierKey
+	// EVENT_CHAR
+	Char rune
+	// EVENT_MOUSE_BUTTON
+	MouseButton MouseButton
+	// The position of EVENT_CURSOR_POS or the offset of EVENT_SCROLL
+	X, Y float64
+	// EVENT_CURSOR_ENTER
+	Entered bool
+	// EVENT_JOYSTICK, plugged in or out
+	Joystick  int
+	Connected bool
+}
+
+// Listener gets the events of a Dispatcher, returning true it consumes them
+// and the listeners with a lower priority don't get them
+type Listener func(event *Event) bool
+
+type ListenerID int
+
+type listener struct {
+	id       ListenerID
+	priority int
+	types    []EventType
+	callback Listener
+	removed  bool
+}
+
+func (l *listener) wants(t EventType) bool {
+	if len(l.types) == 0 {
+		return true
+	}
+	for _, lt := range l.types {
+		if lt == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Dispatcher sends the input events of a window to any number of
+// listeners, from the highest priority down, so the keyboard can be shared
+// by the GUI and by several players:
+//
+//	input.GetDispatcher().AddListener(10, func(e *input.Event) bool {
+//		if e.Key == glfw.KeyEscape && e.Action == glfw.Press {
+//			pause()
+//			return true
+//		}
+//		return false
+//	}, input.EVENT_KEY)
+//
+// The callbacks of the window set before Attach get the events not
+// consumed. The ones set after, like a GUI attached later, get them first
+type Dispatcher struct {
+	window    *glfw.Window
+	attached  bool
+	listeners []*listener
+	nextID    ListenerID
+
+	previousKey         glfw.KeyCallback
+	previousChar        glfw.CharCallback
+	previousMouseButton glfw.MouseButtonCallback
+	previousCursorPos   glfw.CursorPosCallback
+	previousEnter       glfw.CursorEnterCallback
+	previousScroll      glfw.ScrollCallback
+}
+
+var defaultDispatcher *Dispatcher
+
+// GetDispatcher returns the dispatcher of the app window, attached when
+// first called. It gets the joystick events too
+func GetDispatcher() *Dispatcher {
+	if defaultDispatcher == nil {
+		defaultDispatcher = NewDispatcher(app.GetWindow())
+		defaultDispatcher.Attach()
+	}
+	return defaultDispatcher
+}
+
+// NewDispatcher creates a dispatcher of the events of a window, nil to
+// only get the events sent with Dispatch
+func NewDispatcher(window *glfw.Window) *Dispatcher {
+	return &Dispatcher{window: window}
+}
+
+// Attach installs the callbacks of the window
+func (d *Dispatcher) Attach() {
+	if d.attached || d.window == nil {
+		return
+	}
+	d.attached = true
+	d.previousKey = d.window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scanCode int, action glfw.Action, mods glfw.ModifierKey) {
+		e := &Event{Type: EVENT_KEY, Key: key, ScanCode: scanCode, Action: action, Modifiers: mods}
+		if !d.Dispatch(e) && d.previousKey != nil {
+			d.previousKey(w, key, scanCode, action, mods)
+		}
+	})
+	d.previousChar = d.window.SetCharCallback(func(w *glfw.Window, char rune) {
+		if !d.Dispatch(&Event{Type: EVENT_CHAR, Char: char}) && d.previousChar != nil {
+			d.previousChar(w, char)
+		}
+	})
+	d.previousMouseButton = d.window.SetMouseButtonCallback(func(w *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
+		e := &Event{Type: EVENT_MOUSE_BUTTON, MouseButton: MouseButton(button), Action: action, Modifiers: mods}
+		if !d.Dispatch(e) && d.previousMouseButton != nil {
+			d.previousMouseButton(w, button, action, mods)
+		}
+	})
+	d.previousCursorPos = d.window.SetCursorPosCallback(func(w *glfw.Window, x, y float64) {
+		if !d.Dispatch(&Event{Type: EVENT_CURSOR_POS, X: x, Y: y}) && d.previousCursorPos != nil {
+			d.previousCursorPos(w, x, y)
+		}
+	})
+	d.previousEnter = d.window.SetCursorEnterCallback(func(w *glfw.Window, entered bool) {
+		if !d.Dispatch(&Event{Type: EVENT_CURSOR_ENTER, Entered: entered}) && d.previousEnter != nil {
+			d.previousEnter(w, entered)
+		}
+	})
+	d.previousScroll = d.window.SetScrollCallback(func(w *glfw.Window, x, y float64) {
+		if !d.Dispatch(&Event{Type: EVENT_SCROLL, X: x, Y: y}) && d.previousScroll != nil {
+			d.previousScroll(w, x, y)
+		}
+	})
+}
+
+// Detach restores the callbacks the window had before Attach
+func (d *Dispatcher) Detach() {
+	if !d.attached {
+		return
+	}
+	d.window.SetKeyCallback(d.previousKey)
+	d.window.SetCharCallback(d.previousChar)
+	d.window.SetMouseButtonCallback(d.previousMouseButton)
+	d.window.SetCursorPosCallback(d.previousCursorPos)
+	d.window.SetCursorEnterCallback(d.previousEnter)
+	d.window.SetScrollCallback(d.previousScroll)
+	d.previousKey = nil
+	d.previousChar = nil
+	d.previousMouseButton = nil
+	d.previousCursorPos = nil
+	d.previousEnter = nil
+	d.previousScroll = nil
+	d.attached = false
+}
+
+// AddListener adds a listener of some types of events, all of them if none.
+// Among the same priority the listeners added first get the events first
+func (d *Dispatcher) AddListener(priority int, callback Listener, types ...EventType) ListenerID {
+	d.nextID++
+	l := &listener{
+		id:       d.nextID,
+		priority: priority,
+		types:    append([]EventType(nil), types...),
+		callback: callback,
+	}
+	d.listeners = append(d.listeners, l)
+	sort.SliceStable(d.listeners, func(i, j int) bool {
+		return d.listeners[i].priority > d.listeners[j].priority
+	})
+	return l.id
+}
+
+// RemoveListener removes a listener, it can be called by the listener. A
+// listener removed while dispatching doesn't get the event
+func (d *Dispatcher) RemoveListener(id ListenerID) {
+	for i, l := range d.listeners {
+		if l.id == id {
+			l.removed = true
+			d.listeners = append(d.listeners[:i:i], d.listeners[i+1:]...)
+			return
+		}
+	}
+}
+
+// Dispatch sends an event to the listeners, returns true if one consumed
+// it. The events of the window are sent by Attach, others can be sent to
+// simulate the input
+func (d *Dispatcher) Dispatch(event *Event) bool {
+	if event.Time == 0 {
+		event.Time = glfw.GetTime()
+	}
+	// A copy, the listeners can add and remove listeners
+	listeners := append([]*listener(nil), d.listeners...)
+	for _, l := range listeners {
+		if !l.removed && l.wants(event.Type) && l.callback(event) {
+			return true
+		}
+	}
+	return false
+}
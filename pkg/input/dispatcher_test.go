@@ -0,0 +1,81 @@
+package input
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+func TestDispatcherPriority(t *testing.T) {
+	d := NewDispatcher(nil)
+	var calls []string
+	listen := func(name string, consume bool) Listener {
+		return func(e *Event) bool {
+			calls = append(calls, name)
+			return consume
+		}
+	}
+	d.AddListener(0, listen("low", false))
+	d.AddListener(10, listen("high", false))
+	d.AddListener(0, listen("low after", false))
+	d.AddListener(5, listen("keys", false), EVENT_KEY)
+	d.AddListener(5, listen("chars", false), EVENT_CHAR)
+
+	if d.Dispatch(&Event{Type: EVENT_KEY, Key: glfw.KeyA, Time: 1}) {
+		t.Error("Consumed by no listener")
+	}
+	expected := []string{"high", "keys", "low", "low after"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Called %v, expecting %v", calls, expected)
+	}
+
+	calls = nil
+	d.AddListener(5, listen("consumer", true), EVENT_CHAR)
+	if !d.Dispatch(&Event{Type: EVENT_CHAR, Char: 'a', Time: 1}) {
+		t.Error("Not consumed")
+	}
+	expected = []string{"high", "chars", "consumer"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Called %v, expecting %v", calls, expected)
+	}
+}
+
+func TestDispatcherRemoveListener(t *testing.T) {
+	d := NewDispatcher(nil)
+	var calls []string
+	var once, removed ListenerID
+	once = d.AddListener(10, func(e *Event) bool {
+		calls = append(calls, "once")
+		d.RemoveListener(once)
+		d.RemoveListener(removed)
+		return false
+	})
+	removed = d.AddListener(5, func(e *Event) bool {
+		calls = append(calls, "removed")
+		return false
+	})
+	d.AddListener(0, func(e *Event) bool {
+		calls = append(calls, "last")
+		// Added while dispatching, it gets the next event
+		d.AddListener(20, func(e *Event) bool {
+			calls = append(calls, "added")
+			return true
+		})
+		return false
+	})
+
+	d.Dispatch(&Event{Type: EVENT_SCROLL, Time: 1})
+	d.Dispatch(&Event{Type: EVENT_SCROLL, Time: 2})
+	expected := []string{"once", "last", "added"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Called %v, expecting %v", calls, expected)
+	}
+	if len(d.listeners) != 2 {
+		t.Errorf("%d listeners left, expecting 2", len(d.listeners))
+	}
+	d.RemoveListener(once)
+	if len(d.listeners) != 2 {
+		t.Error("Removed a listener twice")
+	}
+}
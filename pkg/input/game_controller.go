@@ -1,6 +1,9 @@
 package input
 
 import (
+	"log"
+	"runtime"
+
 	"github.com/go-gl/glfw/v3.2/glfw"
 )
 
@@ -23,6 +26,11 @@ const (
 	BUTTON_DIR_PAD_DOWN
 	BUTTON_DIR_PAD_LEFT
 	BUTTON_DIR_PAD_RIGHT
+
+	NUM_CONTROLLER_BUTTONS = int(BUTTON_DIR_PAD_RIGHT) + 1
+)
+
+const (
 	AXIS_LEFT_X ControllerAxis = iota
 	AXIS_LEFT_Y
 	AXIS_RIGHT_X
@@ -30,6 +38,8 @@ const (
 	AXIS_TRIGGER_LEFT
 	AXIS_TRIGGER_RIGHT
 
+	NUM_CONTROLLER_AXES = int(AXIS_TRIGGER_RIGHT) + 1
+
 	MAX_NUM_JOYSTICKS = glfw.JoystickLast
 )
 
@@ -52,20 +62,59 @@ type GameController interface {
 	ButtonDown(button ControllerButton) bool
 	AxisValue(axis ControllerAxis) float32
 	AxisDigitalValue(axis ControllerAxis) int
+	StickPressed(stick Stick, direction Direction) bool
+	StickReleased(stick Stick, direction Direction) bool
+	SetAxisFilter(filter *AxisFilter)
 	SetMapping(mapping *GameControllerMapping)
 	Description() string
 }
 
+// GameControllerMapping tells which buttons and axes of a joystick are the
+// ones of the controller. The built-in mappings match the joystick names
+// with a regular expression and the numbers of buttons and axes, the ones
+// in the SDL format match the GUID or the name, see ParseGameControllerMapping
 type GameControllerMapping struct {
 	nameRegEx string
 	buttons   []int
 	axes      []int
+	guid      string
+	name      string
+	platform  string
+	// Number of hats used, glfw 3.2 reports them as buttons or axes
+	numHats       int
+	buttonSources [NUM_CONTROLLER_BUTTONS]mappingSource
+	axisSources   [NUM_CONTROLLER_AXES]axisSource
 }
 
 func (g *GameControllerMapping) set(nameRegEx string, buttons []int, axes []int) {
 	g.nameRegEx = nameRegEx
 	g.buttons = buttons
 	g.axes = axes
+	for i := 0; i < len(buttons) && i < NUM_CONTROLLER_BUTTONS; i++ {
+		g.buttonSources[i] = mappingSource{kind: SOURCE_BUTTON, index: buttons[i]}
+	}
+	for i := 0; i < len(axes) && i < NUM_CONTROLLER_AXES; i++ {
+		g.axisSources[i].full = mappingSource{kind: SOURCE_AXIS, index: axes[i]}
+	}
+}
+
+// GUID returns the SDL GUID of the joystick, empty for the built-in mappings
+func (g *GameControllerMapping) GUID() string {
+	return g.guid
+}
+
+// Name returns the name of the joystick, the regular expression matching it
+// for the built-in mappings
+func (g *GameControllerMapping) Name() string {
+	if g.name == "" {
+		return g.nameRegEx
+	}
+	return g.name
+}
+
+// Platform returns the platform of an SDL mapping, empty if for all of them
+func (g *GameControllerMapping) Platform() string {
+	return g.platform
 }
 
 func init() {
@@ -89,4 +138,19 @@ func init() {
 	// XBox 360 wired controller (MacOS)
 	MappingXBox360.set(".*Xbox 360.*", []int{11, 12, 13, 14, 5, 10, 4, 6, 7, 8, 9, 0, 1, 2, 3}, []int{0, 1, 2, 3, 4, 5})
 	GameControllerMappings = append(GameControllerMappings, &MappingXBox360)
+
+	// Common pads on Linux, matched by GUID. More are found in the SDL
+	// gamecontrollerdb.txt, see AddGameControllerMappingsFromFile
+	for _, line := range []string{
+		"030000005e0400008e02000010010000,Xbox 360 Controller,a:b0,b:b1,back:b6,dpdown:h0.4,dpleft:h0.8,dpright:h0.2,dpup:h0.1,guide:b8,leftshoulder:b4,leftstick:b9,lefttrigger:a2,leftx:a0,lefty:a1,rightshoulder:b5,rightstick:b10,righttrigger:a5,rightx:a3,righty:a4,start:b7,x:b2,y:b3,platform:Linux,",
+		"030000004c050000c405000011810000,PS4 Controller,a:b0,b:b1,back:b8,dpdown:h0.4,dpleft:h0.8,dpright:h0.2,dpup:h0.1,guide:b10,leftshoulder:b4,leftstick:b11,lefttrigger:a2,leftx:a0,lefty:a1,rightshoulder:b5,rightstick:b12,righttrigger:a5,rightx:a3,righty:a4,start:b9,x:b3,y:b2,platform:Linux,",
+	} {
+		mapping, err := ParseGameControllerMapping(line)
+		if err != nil {
+			log.Panicf("Error parsing built-in controller mapping %s: %v", line, err)
+		}
+		if runtime.GOOS == "linux" {
+			GameControllerMappings = append(GameControllerMappings, mapping)
+		}
+	}
 }
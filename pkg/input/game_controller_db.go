@@ -0,0 +1,405 @@
+package input
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+type MappingSourceType int
+
+const (
+	SOURCE_NONE MappingSourceType = iota
+	SOURCE_BUTTON
+	SOURCE_AXIS
+	SOURCE_HAT
+)
+
+// Bits of a hat in the SDL format
+const (
+	HAT_UP    = 1
+	HAT_RIGHT = 2
+	HAT_DOWN  = 4
+	HAT_LEFT  = 8
+)
+
+// mappingSource is the button, axis or hat direction of a joystick bound to
+// a button or an axis of the controller
+type mappingSource struct {
+	kind  MappingSourceType
+	index int
+	// Direction bit of a hat
+	hatMask int
+	// Half of an axis: 1 the positive one, -1 the negative one, 0 the whole
+	// axis
+	half     int
+	inverted bool
+}
+
+// axisSource is bound to a whole axis of the controller, or to each half
+type axisSource struct {
+	full     mappingSource
+	positive mappingSource
+	negative mappingSource
+}
+
+var (
+	sdlButtons = map[string]ControllerButton{
+		"a":             BUTTON_A,
+		"b":             BUTTON_B,
+		"x":             BUTTON_X,
+		"y":             BUTTON_Y,
+		"back":          BUTTON_BACK,
+		"guide":         BUTTON_GUIDE,
+		"start":         BUTTON_START,
+		"leftstick":     BUTTON_LEFT_STICK,
+		"rightstick":    BUTTON_RIGHT_STICK,
+		"leftshoulder":  BUTTON_LEFT_SHOULDER,
+		"rightshoulder": BUTTON_RIGHT_SHOULDER,
+		"dpup":          BUTTON_DIR_PAD_UP,
+		"dpdown":        BUTTON_DIR_PAD_DOWN,
+		"dpleft":        BUTTON_DIR_PAD_LEFT,
+		"dpright":       BUTTON_DIR_PAD_RIGHT,
+	}
+	sdlAxes = map[string]ControllerAxis{
+		"leftx":        AXIS_LEFT_X,
+		"lefty":        AXIS_LEFT_Y,
+		"rightx":       AXIS_RIGHT_X,
+		"righty":       AXIS_RIGHT_Y,
+		"lefttrigger":  AXIS_TRIGGER_LEFT,
+		"righttrigger": AXIS_TRIGGER_RIGHT,
+	}
+	// Platforms of the SDL mappings, by GOOS
+	sdlPlatforms = map[string]string{
+		"windows": "Windows",
+		"darwin":  "Mac OS X",
+		"linux":   "Linux",
+		"android": "Android",
+		"ios":     "iOS",
+	}
+	// glfw 3.2 has no hats: on Linux they are 2 axes each after the other
+	// axes, on the other platforms 4 buttons each, up, right, down and
+	// left, after the other buttons
+	hatsAsAxes = runtime.GOOS == "linux"
+)
+
+// ParseGameControllerMapping parses a mapping in the format of the SDL
+// gamecontrollerdb.txt, https://github.com/gabomdq/SDL_GameControllerDB:
+//
+//	030000005e0400008e02000010010000,Xbox 360 Controller,a:b0,b:b1,dpup:h0.1,leftx:a0,lefttrigger:a2,platform:Linux,
+//
+// Buttons and axes of the controller are bound to buttons (b0), axes (a0),
+// halves of axes (+a0, -a0), inverted axes (a0~) and hat directions (h0.1,
+// the bits are the HAT_ constants). Halves of the controller axes can be
+// bound separately (+leftx:b2). Unknown bindings, e.g. the paddles, are
+// ignored
+func ParseGameControllerMapping(line string) (*GameControllerMapping, error) {
+	fields := strings.Split(strings.TrimSpace(line), ",")
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("missing GUID or name")
+	}
+	m := &GameControllerMapping{guid: strings.ToLower(fields[0]), name: fields[1]}
+	if len(m.guid) != 32 {
+		return nil, fmt.Errorf("invalid GUID %q", fields[0])
+	}
+	if _, err := strconv.ParseUint(m.guid[:16], 16, 64); err != nil {
+		return nil, fmt.Errorf("invalid GUID %q", fields[0])
+	}
+	if _, err := strconv.ParseUint(m.guid[16:], 16, 64); err != nil {
+		return nil, fmt.Errorf("invalid GUID %q", fields[0])
+	}
+
+	for _, field := range fields[2:] {
+		if field == "" {
+			continue
+		}
+		parts := strings.SplitN(field, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid binding %q", field)
+		}
+		target, value := parts[0], parts[1]
+		if target == "platform" {
+			m.platform = value
+			continue
+		}
+		targetHalf := 0
+		if strings.HasPrefix(target, "+") {
+			targetHalf, target = 1, target[1:]
+		} else if strings.HasPrefix(target, "-") {
+			targetHalf, target = -1, target[1:]
+		}
+		button, isButton := sdlButtons[target]
+		axis, isAxis := sdlAxes[target]
+		if !isButton && !isAxis {
+			continue
+		}
+		source, err := parseMappingSource(value)
+		if err != nil {
+			return nil, fmt.Errorf("binding %s: %v", field, err)
+		}
+		if source.kind == SOURCE_HAT && source.index >= m.numHats {
+			m.numHats = source.index + 1
+		}
+		switch {
+		case isButton:
+			m.buttonSources[button] = source
+		case targetHalf > 0:
+			m.axisSources[axis].positive = source
+		case targetHalf < 0:
+			m.axisSources[axis].negative = source
+		default:
+			m.axisSources[axis].full = source
+		}
+	}
+	return m, nil
+}
+
+func parseMappingSource(value string) (mappingSource, error) {
+	var s mappingSource
+	if strings.HasPrefix(value, "+") {
+		s.half, value = 1, value[1:]
+	} else if strings.HasPrefix(value, "-") {
+		s.half, value = -1, value[1:]
+	}
+	if strings.HasSuffix(value, "~") {
+		s.inverted, value = true, value[:len(value)-1]
+	}
+	if len(value) < 2 {
+		return s, fmt.Errorf("invalid source %q", value)
+	}
+	var err error
+	switch value[0] {
+	case 'b':
+		s.kind = SOURCE_BUTTON
+		s.index, err = strconv.Atoi(value[1:])
+	case 'a':
+		s.kind = SOURCE_AXIS
+		s.index, err = strconv.Atoi(value[1:])
+	case 'h':
+		s.kind = SOURCE_HAT
+		parts := strings.SplitN(value[1:], ".", 2)
+		if len(parts) != 2 {
+			return s, fmt.Errorf("invalid hat %q", value)
+		}
+		if s.index, err = strconv.Atoi(parts[0]); err == nil {
+			s.hatMask, err = strconv.Atoi(parts[1])
+		}
+		if err == nil && s.hatMask != HAT_UP && s.hatMask != HAT_RIGHT && s.hatMask != HAT_DOWN && s.hatMask != HAT_LEFT {
+			err = fmt.Errorf("invalid hat direction %d", s.hatMask)
+		}
+	default:
+		return s, fmt.Errorf("invalid source %q", value)
+	}
+	if err == nil && s.index < 0 {
+		err = fmt.Errorf("negative index")
+	}
+	if s.kind != SOURCE_AXIS && (s.half != 0 || s.inverted) {
+		err = fmt.Errorf("only axes have halves or are inverted")
+	}
+	return s, err
+}
+
+// AddGameControllerMappings adds the mappings read from a file in the SDL
+// gamecontrollerdb.txt format, returning how many were added. Comments,
+// invalid lines and the mappings of other platforms are skipped. A mapping
+// replaces the one with the same GUID, the ones added later are matched
+// first
+func AddGameControllerMappings(reader io.Reader) (int, error) {
+	platform := sdlPlatforms[runtime.GOOS]
+	added := 0
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		mapping, err := ParseGameControllerMapping(text)
+		if err != nil {
+			log.Printf("Skipping game controller mapping at line %d: %v", line, err)
+			continue
+		}
+		if mapping.platform != "" && mapping.platform != platform {
+			continue
+		}
+		AddGameControllerMapping(mapping)
+		added++
+	}
+	return added, scanner.Err()
+}
+
+// AddGameControllerMappingsFromFile adds the mappings of a gamecontrollerdb.txt
+// file, see AddGameControllerMappings. Joysticks already open keep their
+// mapping until reopened
+func AddGameControllerMappingsFromFile(filePath string) int {
+	file, err := os.Open(filePath)
+	if err != nil {
+		log.Panicf("Loading game controller mappings. %s", err)
+		return 0
+	}
+	defer file.Close()
+
+	added, err := AddGameControllerMappings(file)
+	if err != nil {
+		log.Panicf("Error reading game controller mappings %s: %v", filePath, err)
+	}
+	return added
+}
+
+// AddGameControllerMapping adds a mapping, replacing the one with the same
+// GUID if any
+func AddGameControllerMapping(mapping *GameControllerMapping) {
+	if mapping.guid != "" {
+		for i, m := range GameControllerMappings {
+			if m.guid == mapping.guid {
+				GameControllerMappings = append(GameControllerMappings[:i], GameControllerMappings[i+1:]...)
+				break
+			}
+		}
+	}
+	GameControllerMappings = append(GameControllerMappings, mapping)
+}
+
+// FindGameControllerMapping returns the mapping of a joystick, nil if none.
+// The GUID is matched first, then the name; the mappings added later first
+func FindGameControllerMapping(guid string, name string, numButtons int, numAxes int) *GameControllerMapping {
+	if guid != "" {
+		guid = strings.ToLower(guid)
+		// SDL writes a CRC of the name in the second 16 bits of newer
+		// GUIDs, then tries without the version of the device
+		for _, ignoreVersion := range []bool{false, true} {
+			for i := len(GameControllerMappings) - 1; i >= 0; i-- {
+				m := GameControllerMappings[i]
+				if m.guid != "" && sameGUID(m.guid, guid, ignoreVersion) {
+					return m
+				}
+			}
+		}
+	}
+	for i := len(GameControllerMappings) - 1; i >= 0; i-- {
+		m := GameControllerMappings[i]
+		if m.nameRegEx != "" {
+			r, err := regexp.Compile(m.nameRegEx)
+			if err == nil && r.MatchString(name) && len(m.buttons) == numButtons && len(m.axes) == numAxes {
+				return m
+			}
+		} else if strings.EqualFold(m.name, name) {
+			return m
+		}
+	}
+	return nil
+}
+
+// sameGUID compares two lowercase SDL GUIDs, without the CRC
+func sameGUID(a, b string, ignoreVersion bool) bool {
+	if len(a) != 32 || len(b) != 32 || a[:4] != b[:4] {
+		return false
+	}
+	if ignoreVersion {
+		return a[8:24] == b[8:24]
+	}
+	return a[8:] == b[8:]
+}
+
+// sdlGUID returns the GUID SDL gives to a device from its bus, vendor,
+// product and version ids
+func sdlGUID(bus, vendor, product, version uint16) string {
+	var guid string
+	for _, id := range []uint16{bus, vendor, product, version} {
+		guid += fmt.Sprintf("%02x%02x0000", id&0xff, id>>8)
+	}
+	return guid
+}
+
+// buttonDown returns true if the button of the controller is down
+func (g *GameControllerMapping) buttonDown(button ControllerButton, buttons []byte, axes []float32) bool {
+	if int(button) >= NUM_CONTROLLER_BUTTONS {
+		return false
+	}
+	return g.sourceValue(g.buttonSources[button], buttons, axes) > 0.5
+}
+
+// axisValue returns the value of an axis of the controller, between -1 and
+// 1. Triggers are -1 when released
+func (g *GameControllerMapping) axisValue(axis ControllerAxis, buttons []byte, axes []float32) float32 {
+	if int(axis) >= NUM_CONTROLLER_AXES {
+		return 0
+	}
+	source := g.axisSources[axis]
+	trigger := axis == AXIS_TRIGGER_LEFT || axis == AXIS_TRIGGER_RIGHT
+	if source.full.kind != SOURCE_NONE {
+		value := g.sourceValue(source.full, buttons, axes)
+		if trigger && (source.full.kind != SOURCE_AXIS || source.full.half != 0) {
+			// Buttons and halves of axes go from 0 to 1
+			value = 2*value - 1
+		}
+		return value
+	}
+	value := g.sourceValue(source.positive, buttons, axes) - g.sourceValue(source.negative, buttons, axes)
+	if trigger && source.positive.kind == SOURCE_NONE && source.negative.kind == SOURCE_NONE {
+		return -1
+	}
+	return mgl32.Clamp(value, -1, 1)
+}
+
+// sourceValue returns the value of a source, between 0 and 1 for buttons,
+// hats and halves of axes, between -1 and 1 for whole axes
+func (g *GameControllerMapping) sourceValue(s mappingSource, buttons []byte, axes []float32) float32 {
+	switch s.kind {
+	case SOURCE_BUTTON:
+		if s.index < len(buttons) && buttons[s.index] > 0 {
+			return 1
+		}
+	case SOURCE_AXIS:
+		if s.index >= len(axes) {
+			return 0
+		}
+		value := axes[s.index]
+		if s.inverted {
+			value = -value
+		}
+		if s.half != 0 {
+			value *= float32(s.half)
+			if value < 0 {
+				value = 0
+			}
+		}
+		return value
+	case SOURCE_HAT:
+		if g.hatDown(s.index, s.hatMask, buttons, axes) {
+			return 1
+		}
+	}
+	return 0
+}
+
+// hatDown returns true if a hat is pushed in a direction, see hatsAsAxes
+func (g *GameControllerMapping) hatDown(hat int, mask int, buttons []byte, axes []float32) bool {
+	if hatsAsAxes {
+		x := len(axes) - 2*g.numHats + 2*hat
+		if x < 0 || x+1 >= len(axes) {
+			return false
+		}
+		switch mask {
+		case HAT_UP:
+			return axes[x+1] < -0.5
+		case HAT_RIGHT:
+			return axes[x] > 0.5
+		case HAT_DOWN:
+			return axes[x+1] > 0.5
+		case HAT_LEFT:
+			return axes[x] < -0.5
+		}
+		return false
+	}
+	offsets := map[int]int{HAT_UP: 0, HAT_RIGHT: 1, HAT_DOWN: 2, HAT_LEFT: 3}
+	index := len(buttons) - 4*g.numHats + 4*hat + offsets[mask]
+	return index >= 0 && index < len(buttons) && buttons[index] > 0
+}
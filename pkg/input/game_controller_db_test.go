@@ -0,0 +1,134 @@
+package input
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseGameControllerMapping(t *testing.T) {
+	defer func(asAxes bool) { hatsAsAxes = asAxes }(hatsAsAxes)
+	line := "030000005e0400008e02000010010000,Xbox 360 Controller,a:b0,b:b1,back:b6," +
+		"dpdown:h0.4,dpleft:h0.8,dpright:h0.2,dpup:h0.1,leftx:a0,lefty:a1,lefttrigger:a2," +
+		"rightx:+a3,righty:a4~,+righttrigger:b5,-leftx:b9,platform:Linux,"
+	m, err := ParseGameControllerMapping(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.guid != "030000005e0400008e02000010010000" || m.Name() != "Xbox 360 Controller" || m.platform != "Linux" || m.numHats != 1 {
+		t.Errorf("Got %s %q on %s with %d hats", m.guid, m.Name(), m.platform, m.numHats)
+	}
+
+	// 10 buttons, 5 axes and a hat
+	buttons := []byte{1, 0, 0, 0, 0, 1, 0, 0, 0, 0}
+	axes := []float32{0.5, -0.25, -1, -0.75, 0.5, 0, 1}
+	var buttonTests = []struct {
+		button ControllerButton
+		down   bool
+	}{{BUTTON_A, true}, {BUTTON_B, false}, {BUTTON_DIR_PAD_DOWN, true}, {BUTTON_DIR_PAD_UP, false}}
+	var axisTests = []struct {
+		axis  ControllerAxis
+		value float32
+	}{
+		{AXIS_LEFT_X, 0.5},
+		{AXIS_LEFT_Y, -0.25},
+		{AXIS_TRIGGER_LEFT, -1},
+		// Half, the negative side reads 0
+		{AXIS_RIGHT_X, 0},
+		// Inverted
+		{AXIS_RIGHT_Y, -0.5},
+		// Button to a trigger
+		{AXIS_TRIGGER_RIGHT, 1},
+	}
+	hatsAsAxes = true
+	for _, test := range buttonTests {
+		if m.buttonDown(test.button, buttons, axes) != test.down {
+			t.Errorf("Button %v down %v, hat as axes", test.button, !test.down)
+		}
+	}
+	for _, test := range axisTests {
+		if v := m.axisValue(test.axis, buttons, axes); v != test.value {
+			t.Errorf("Axis %v %v, expecting %v", test.axis, v, test.value)
+		}
+	}
+	// The positive half of the right x, the negative one of the left x
+	buttons[9] = 1
+	axes[3] = 0.75
+	if v := m.axisValue(AXIS_RIGHT_X, buttons, axes); v != 0.75 {
+		t.Errorf("Got %v on the positive half", v)
+	}
+	if v := m.axisValue(AXIS_LEFT_X, buttons, axes); v != 0.5 {
+		t.Errorf("Got %v, the whole axis first", v)
+	}
+
+	// The hat as 4 buttons after the others, up right down left
+	hatsAsAxes = false
+	buttons = []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}
+	if !m.buttonDown(BUTTON_DIR_PAD_LEFT, buttons, nil) || m.buttonDown(BUTTON_DIR_PAD_DOWN, buttons, nil) {
+		t.Error("Wrong hat as buttons")
+	}
+
+	for _, invalid := range []string{
+		"030000005e0400008e02000010010000",
+		"0300005e0400008e02000010010000,Short GUID,a:b0",
+		"030000005e0400008e020000100100zz,Bad GUID,a:b0",
+		"030000005e0400008e02000010010000,Bad button,a:b",
+		"030000005e0400008e02000010010000,Bad hat,dpup:h0.3",
+		"030000005e0400008e02000010010000,Half button,a:+b0",
+		"030000005e0400008e02000010010000,Inverted hat,dpup:h0.1~",
+		"030000005e0400008e02000010010000,No colon,a",
+	} {
+		if _, err := ParseGameControllerMapping(invalid); err == nil {
+			t.Errorf("Parsed %q", invalid)
+		}
+	}
+	if m, err := ParseGameControllerMapping("030000005e0400008e02000010010000,Paddles,paddle1:b11,a:b0"); err != nil || m.buttonSources[BUTTON_A].kind != SOURCE_BUTTON {
+		t.Errorf("Unknown binding not ignored: %v", err)
+	}
+}
+
+func TestFindGameControllerMapping(t *testing.T) {
+	defer func(mappings []*GameControllerMapping) { GameControllerMappings = mappings }(GameControllerMappings)
+	GameControllerMappings = nil
+
+	other := "Linux"
+	if runtime.GOOS == "linux" {
+		other = "Windows"
+	}
+	db := strings.Join([]string{
+		"# A comment",
+		"03000000de280000ff11000001000000,Steam Pad,a:b0,platform:" + sdlPlatforms[runtime.GOOS] + ",",
+		"03000000de280000ff11000002000000,Other Platform,a:b1,platform:" + other + ",",
+		"invalid",
+		"03000000de280000ff11000003000000,No Platform,a:b2,",
+	}, "\n")
+	added, err := AddGameControllerMappings(strings.NewReader(db))
+	if err != nil || added != 2 {
+		t.Fatalf("Added %d: %v", added, err)
+	}
+	var tests = []struct {
+		guid  string
+		name  string
+		found string
+	}{
+		{"03000000de280000ff11000001000000", "", "Steam Pad"},
+		// Another CRC of the name
+		{"03001234de280000ff11000001000000", "", "Steam Pad"},
+		// Another version
+		{"03000000de280000ff11000009000000", "", "No Platform"},
+		{"03000000de280000ff11000002000000", "", "No Platform"},
+		// Another bus
+		{"05000000de280000ff11000001000000", "no platform", "No Platform"},
+		{"", "Steam Pad", "Steam Pad"},
+		{"", "Unknown", ""},
+	}
+	for _, test := range tests {
+		name := ""
+		if m := FindGameControllerMapping(test.guid, test.name, 0, 0); m != nil {
+			name = m.Name()
+		}
+		if name != test.found {
+			t.Errorf("Found %q for %s %q, expecting %q", name, test.guid, test.name, test.found)
+		}
+	}
+}
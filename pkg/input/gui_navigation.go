@@ -0,0 +1,54 @@
+package input
+
+import (
+	"github.com/markov/gojira2d/pkg/ui"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+// NavigateGUI drives the focus of a GUI with a controller: the D-pad moves
+// the focus, A activates the focused widget and B cancels. Call it after
+// updating the controller
+func NavigateGUI(gui *ui.GUI, controller GameController) {
+	if !controller.Connected() {
+		return
+	}
+	directions := []struct {
+		button    ControllerButton
+		direction ui.NavDirection
+	}{
+		{BUTTON_DIR_PAD_UP, ui.NAV_UP},
+		{BUTTON_DIR_PAD_DOWN, ui.NAV_DOWN},
+		{BUTTON_DIR_PAD_LEFT, ui.NAV_LEFT},
+		{BUTTON_DIR_PAD_RIGHT, ui.NAV_RIGHT},
+	}
+	for _, d := range directions {
+		if controller.ButtonPressed(d.button) {
+			gui.Navigate(d.direction)
+		}
+	}
+	if controller.ButtonPressed(BUTTON_A) {
+		gui.Activate()
+	}
+	if controller.ButtonPressed(BUTTON_B) {
+		gui.Cancel()
+	}
+}
+
+// ListenTextInput sends the keys and the characters of a dispatcher to a
+// text input, instead of TextInput.Attach. They are consumed while the
+// input has the focus, so with a priority above the game the typing doesn't
+// move the players. The releases go on, no key is left down
+func ListenTextInput(dispatcher *Dispatcher, textInput *ui.TextInput, priority int) ListenerID {
+	return dispatcher.AddListener(priority, func(e *Event) bool {
+		if !textInput.Focused() {
+			return false
+		}
+		if e.Type == EVENT_CHAR {
+			textInput.HandleChar(e.Char)
+			return true
+		}
+		textInput.HandleKey(e.Key, e.Action, e.Modifiers)
+		return e.Action != glfw.Release
+	}, EVENT_KEY, EVENT_CHAR)
+}
@@ -0,0 +1,308 @@
+package input
+
+// Heading is one of the 8 directions of the stick and the D-pad, or none.
+// Forward is right, mirror it for a character facing left
+type Heading int
+
+const (
+	// For a combo step, the direction doesn't matter
+	HEADING_ANY Heading = iota - 1
+	HEADING_NEUTRAL
+	HEADING_UP
+	HEADING_UP_FORWARD
+	HEADING_FORWARD
+	HEADING_DOWN_FORWARD
+	HEADING_DOWN
+	HEADING_DOWN_BACK
+	HEADING_BACK
+	HEADING_UP_BACK
+)
+
+// headingsByVector are the headings by (y+1)*3 + x+1, y down and x forward
+var headingsByVector = [9]Heading{
+	HEADING_UP_BACK, HEADING_UP, HEADING_UP_FORWARD,
+	HEADING_BACK, HEADING_NEUTRAL, HEADING_FORWARD,
+	HEADING_DOWN_BACK, HEADING_DOWN, HEADING_DOWN_FORWARD,
+}
+
+// numpadHeadings are the headings in the numpad notation of the fighting
+// games, 1 down back to 9 up forward and 5 neutral
+var numpadHeadings = [10]Heading{
+	HEADING_ANY,
+	HEADING_DOWN_BACK, HEADING_DOWN, HEADING_DOWN_FORWARD,
+	HEADING_BACK, HEADING_NEUTRAL, HEADING_FORWARD,
+	HEADING_UP_BACK, HEADING_UP, HEADING_UP_FORWARD,
+}
+
+func headingOf(x, y int) Heading {
+	return headingsByVector[(y+1)*3+x+1]
+}
+
+// vector returns the x, forward, and the y, down, of a heading
+func (h Heading) vector() (int, int) {
+	for i, heading := range headingsByVector {
+		if heading == h {
+			return i%3 - 1, i/3 - 1
+		}
+	}
+	return 0, 0
+}
+
+// Mirrored returns the heading with forward and back swapped
+func (h Heading) Mirrored() Heading {
+	if h == HEADING_ANY {
+		return h
+	}
+	x, y := h.vector()
+	return headingOf(-x, y)
+}
+
+// Numpad returns the heading in the numpad notation, 0 for HEADING_ANY
+func (h Heading) Numpad() int {
+	for i, heading := range numpadHeadings {
+		if heading == h {
+			return i
+		}
+	}
+	return 0
+}
+
+// charges tells if a heading charges another one: the back charge is
+// held with any of the back diagonals too, the down charge with the down ones
+func (h Heading) charges(heading Heading) bool {
+	x, y := h.vector()
+	hx, hy := heading.vector()
+	if heading == HEADING_NEUTRAL || h == HEADING_NEUTRAL {
+		return heading == h
+	}
+	return (x == 0 || hx == x) && (y == 0 || hy == y)
+}
+
+type BufferedInputType int
+
+const (
+	BUFFERED_BUTTON_PRESS BufferedInputType = iota
+	BUFFERED_BUTTON_RELEASE
+	// The heading changed
+	BUFFERED_HEADING
+)
+
+// BufferedInput is an event kept by an InputBuffer
+type BufferedInput struct {
+	Type    BufferedInputType
+	Time    float64
+	Button  ControllerButton
+	Heading Heading
+	// Used by Consume or by a combo
+	Consumed bool
+}
+
+const (
+	// Seconds of history kept by an InputBuffer, more than the charges
+	DefaultInputBufferLength = 2
+	// Max seconds between the steps of a combo
+	DefaultComboWindow = 0.25
+	// Max seconds between the buttons of a step pressed together
+	DefaultComboButtonWindow = 0.05
+	// Seconds a charge is held, [4] in the notation of ParseCombo
+	DefaultChargeTime = 0.75
+)
+
+// InputBuffer keeps the presses, the releases and the directions of a
+// controller for a while, so an input is taken a bit before it's possible,
+// and the combos are matched:
+//
+//	buffer := input.NewInputBuffer(joystick)
+//	fireball, _ := input.ParseCombo("fireball", "236+X")
+//	...
+//	joystick.Update()
+//	buffer.Update(glfw.GetTime())
+//	if buffer.Match(fireball, fighter.facingLeft) {
+//		fighter.fireball()
+//	} else if fighter.onGround && buffer.PressedWithin(input.BUTTON_A, 0.12) {
+//		buffer.Consume(input.BUTTON_A)
+//		fighter.jump()
+//	}
+//
+// The direction is the D-pad, or the left stick if the D-pad is released
+type InputBuffer struct {
+	controller GameController
+	length     float64
+	events     []BufferedInput
+	heading    Heading
+	time       float64
+}
+
+// NewInputBuffer creates the buffer of a controller, keeping
+// DefaultInputBufferLength seconds
+func NewInputBuffer(controller GameController) *InputBuffer {
+	return &InputBuffer{controller: controller, length: DefaultInputBufferLength}
+}
+
+// SetLength changes the seconds of history kept, longer than the charges
+// of the combos matched
+func (b *InputBuffer) SetLength(seconds float64) {
+	b.length = seconds
+}
+
+// Update records the inputs of this frame, call it once per frame after
+// updating the controller. The time is in seconds, e.g. glfw.GetTime or
+// InputPlayback.Time
+func (b *InputBuffer) Update(time float64) {
+	b.time = time
+	heading := HEADING_NEUTRAL
+	if b.controller != nil && b.controller.Connected() {
+		for i := 0; i < NUM_CONTROLLER_BUTTONS; i++ {
+			button := ControllerButton(i)
+			if b.controller.ButtonPressed(button) {
+				b.events = append(b.events, BufferedInput{Type: BUFFERED_BUTTON_PRESS, Time: time, Button: button})
+			}
+			if b.controller.ButtonReleased(button) {
+				b.events = append(b.events, BufferedInput{Type: BUFFERED_BUTTON_RELEASE, Time: time, Button: button})
+			}
+		}
+		heading = b.readHeading()
+	}
+	if heading != b.heading {
+		b.heading = heading
+		b.events = append(b.events, BufferedInput{Type: BUFFERED_HEADING, Time: time, Heading: heading})
+	}
+	b.trim()
+}
+
+func (b *InputBuffer) readHeading() Heading {
+	c := b.controller
+	x, y := 0, 0
+	if c.ButtonDown(BUTTON_DIR_PAD_LEFT) {
+		x--
+	}
+	if c.ButtonDown(BUTTON_DIR_PAD_RIGHT) {
+		x++
+	}
+	if c.ButtonDown(BUTTON_DIR_PAD_UP) {
+		y--
+	}
+	if c.ButtonDown(BUTTON_DIR_PAD_DOWN) {
+		y++
+	}
+	if x == 0 && y == 0 {
+		x = c.AxisDigitalValue(AXIS_LEFT_X)
+		y = c.AxisDigitalValue(AXIS_LEFT_Y)
+	}
+	return headingOf(x, y)
+}
+
+// trim drops the events older than the length, but the heading held then,
+// the start of a charge still going on
+func (b *InputBuffer) trim() {
+	cutoff := b.time - b.length
+	if len(b.events) == 0 || b.events[0].Time >= cutoff {
+		return
+	}
+	heldHeading := -1
+	for i, e := range b.events {
+		if e.Time >= cutoff {
+			break
+		}
+		if e.Type == BUFFERED_HEADING {
+			heldHeading = i
+		}
+	}
+	events := b.events[:0]
+	for i, e := range b.events {
+		if e.Time >= cutoff || i == heldHeading {
+			events = append(events, e)
+		}
+	}
+	b.events = events
+}
+
+// Clear drops the history, e.g. when the round starts
+func (b *InputBuffer) Clear() {
+	b.events = nil
+	b.heading = HEADING_NEUTRAL
+}
+
+// Events returns a copy of the history, the oldest first
+func (b *InputBuffer) Events() []BufferedInput {
+	return append([]BufferedInput(nil), b.events...)
+}
+
+// Heading returns the direction held, forward is right
+func (b *InputBuffer) Heading() Heading {
+	return b.heading
+}
+
+// PressedWithin returns true if a button was pressed in the last seconds,
+// and the press was not consumed. 0 seconds is this frame only
+func (b *InputBuffer) PressedWithin(button ControllerButton, seconds float64) bool {
+	return b.within(BUFFERED_BUTTON_PRESS, button, seconds)
+}
+
+// ReleasedWithin returns true if a button was released in the last seconds
+func (b *InputBuffer) ReleasedWithin(button ControllerButton, seconds float64) bool {
+	return b.within(BUFFERED_BUTTON_RELEASE, button, seconds)
+}
+
+func (b *InputBuffer) within(kind BufferedInputType, button ControllerButton, seconds float64) bool {
+	for i := len(b.events) - 1; i >= 0 && b.events[i].Time >= b.time-seconds; i-- {
+		e := &b.events[i]
+		if e.Type == kind && e.Button == button && !e.Consumed {
+			return true
+		}
+	}
+	return false
+}
+
+// Consume marks the presses of a button used, so a buffered press does one
+// action only
+func (b *InputBuffer) Consume(button ControllerButton) {
+	for i := range b.events {
+		e := &b.events[i]
+		if e.Type == BUFFERED_BUTTON_PRESS && e.Button == button {
+			e.Consumed = true
+		}
+	}
+}
+
+// HeldFor returns the seconds a button has been down, 0 if up. Presses
+// older than the length of the buffer count from there
+func (b *InputBuffer) HeldFor(button ControllerButton) float64 {
+	if b.controller == nil || !b.controller.ButtonDown(button) {
+		return 0
+	}
+	for i := len(b.events) - 1; i >= 0; i-- {
+		e := &b.events[i]
+		if e.Type == BUFFERED_BUTTON_PRESS && e.Button == button {
+			return b.time - e.Time
+		}
+	}
+	return b.length
+}
+
+// HeadingHeldFor returns the seconds the heading has been held, counting
+// the headings charging it too: the diagonals back for HEADING_BACK. 0 if
+// not held. A heading unchanged since the buffer was created or cleared
+// counts from the length of the buffer
+func (b *InputBuffer) HeadingHeldFor(heading Heading) float64 {
+	if !heading.charges(b.heading) {
+		return 0
+	}
+	start := b.time
+	changed := false
+	for i := len(b.events) - 1; i >= 0; i-- {
+		e := &b.events[i]
+		if e.Type != BUFFERED_HEADING {
+			continue
+		}
+		if !heading.charges(e.Heading) {
+			break
+		}
+		start = e.Time
+		changed = true
+	}
+	if !changed {
+		return b.length
+	}
+	return b.time - start
+}
@@ -0,0 +1,273 @@
+package input
+
+import (
+	"fmt"
+
+	"github.com/markov/gojira2d/pkg/app"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+// InputPlayback plays a recording frame by frame through the same types the
+// game reads, for automated tests and attract mode demos:
+//
+//	playback := input.NewInputPlayback(input.NewInputRecordingFromFile("demo.rec"))
+//	player.controller = playback.Controller(0)
+//	actions.SetKeyState(playback)
+//	...
+//	playback.Update()
+//	player.controller.Update()
+//	actions.Update()
+//
+// The frames don't depend on the time the game takes, use Time instead of
+// the clock for a deterministic replay
+type InputPlayback struct {
+	recording   *InputRecording
+	frame       int
+	loop        bool
+	controllers []*ReplayController
+	mouse       *MouseController
+}
+
+// NewInputPlayback creates a playback of a recording, before its first frame
+func NewInputPlayback(recording *InputRecording) *InputPlayback {
+	p := &InputPlayback{recording: recording, frame: -1}
+	for i := 0; i < recording.numControllers; i++ {
+		p.controllers = append(p.controllers, &ReplayController{playback: p, index: i, open: true})
+	}
+	return p
+}
+
+// Update moves to the next frame, call it once per frame before updating the
+// controllers. Returns false once the recording is over, when nothing is
+// down anymore, unless looping
+func (p *InputPlayback) Update() bool {
+	p.frame++
+	if p.frame >= len(p.recording.frames) && p.loop && len(p.recording.frames) > 0 {
+		p.frame = 0
+	}
+	return !p.Finished()
+}
+
+// SetLoop makes the playback start over after the last frame
+func (p *InputPlayback) SetLoop(loop bool) {
+	p.loop = loop
+}
+
+// Restart goes back before the first frame
+func (p *InputPlayback) Restart() {
+	p.frame = -1
+}
+
+func (p *InputPlayback) Finished() bool {
+	return p.frame >= len(p.recording.frames)
+}
+
+// Frame returns the index of the frame played, -1 before the first update
+func (p *InputPlayback) Frame() int {
+	return p.frame
+}
+
+// Time returns the seconds of the frame played from the first one
+func (p *InputPlayback) Time() float64 {
+	if f := p.current(); f != nil {
+		return f.time
+	}
+	if p.frame >= 0 {
+		return p.recording.Duration()
+	}
+	return 0
+}
+
+func (p *InputPlayback) Recording() *InputRecording {
+	return p.recording
+}
+
+// current returns the frame played, nil before the first one and after the
+// last one
+func (p *InputPlayback) current() *inputFrame {
+	return p.frameAt(p.frame)
+}
+
+func (p *InputPlayback) frameAt(index int) *inputFrame {
+	if index < 0 || index >= len(p.recording.frames) {
+		return nil
+	}
+	return &p.recording.frames[index]
+}
+
+// Controller returns the controller recorded at an index of NewInputRecorder
+func (p *InputPlayback) Controller(index int) *ReplayController {
+	if index < 0 || index >= len(p.controllers) {
+		return nil
+	}
+	return p.controllers[index]
+}
+
+// Mouse returns a mouse controller playing the recorded mouse, already open.
+// Its cursor can't be changed
+func (p *InputPlayback) Mouse() *MouseController {
+	if p.mouse == nil {
+		p.mouse = &MouseController{
+			window:    app.GetWindow(),
+			connected: true,
+			replay:    p.updateMouse,
+		}
+	}
+	return p.mouse
+}
+
+func (p *InputPlayback) updateMouse(c *MouseController) {
+	var m mouseFrame
+	if f := p.current(); f != nil {
+		m = f.mouse
+	}
+	for i := 0; i < MAX_NUM_MOUSE_BUTTONS; i++ {
+		bit := uint8(0)
+		if i < 8 {
+			bit = uint8(1) << uint(i)
+		}
+		c.buttonsDown[i] = m.down&bit != 0
+		c.buttonsPressed[i] = m.pressed&bit != 0
+		c.buttonsReleased[i] = m.released&bit != 0
+		c.doubleClicked[i] = m.doubleClicked&bit != 0
+	}
+	c.inside = m.inside
+	c.position = m.position
+	c.rawPosition = m.position
+	c.delta = m.delta
+	c.scroll = m.scroll
+}
+
+// KeyDown returns true if a key was down in the frame played, so the
+// playback is a KeyState
+func (p *InputPlayback) KeyDown(key glfw.Key) bool {
+	if f := p.current(); f != nil {
+		for _, k := range f.keys {
+			if k == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MouseButtonDown returns true if a mouse button was down in the frame
+// played
+func (p *InputPlayback) MouseButtonDown(button MouseButton) bool {
+	if f := p.current(); f != nil && button >= 0 && button < 8 {
+		return f.mouse.down&(uint8(1)<<uint(button)) != 0
+	}
+	return false
+}
+
+// ReplayController is a controller recorded by an InputRecorder, played by
+// an InputPlayback. It reports the recorded values, axis filters and
+// mappings don't change them
+type ReplayController struct {
+	playback *InputPlayback
+	index    int
+	open     bool
+	frame    controllerFrame
+	previous [NUM_CONTROLLER_AXES]int8
+}
+
+// Open reopens the controller after Close, the device index is ignored
+func (c *ReplayController) Open(_ int) bool {
+	c.open = true
+	return true
+}
+
+func (c *ReplayController) Close() {
+	c.open = false
+	c.frame = controllerFrame{}
+	c.previous = [NUM_CONTROLLER_AXES]int8{}
+}
+
+// Update takes the state of the frame played
+func (c *ReplayController) Update() {
+	if !c.open {
+		return
+	}
+	c.frame = controllerFrame{}
+	c.previous = [NUM_CONTROLLER_AXES]int8{}
+	p := c.playback
+	if f := p.current(); f != nil {
+		c.frame = f.controllers[c.index]
+	}
+	if f := p.frameAt(p.frame - 1); f != nil {
+		c.previous = f.controllers[c.index].digital
+	}
+}
+
+func (c *ReplayController) Connected() bool {
+	return c.open && c.frame.connected
+}
+
+func (c *ReplayController) NumButtons() int {
+	return NUM_CONTROLLER_BUTTONS
+}
+
+func (c *ReplayController) NumAxes() int {
+	return NUM_CONTROLLER_AXES
+}
+
+func (c *ReplayController) buttonBit(button ControllerButton) uint16 {
+	if button < 0 || int(button) >= NUM_CONTROLLER_BUTTONS {
+		return 0
+	}
+	return uint16(1) << uint(button)
+}
+
+func (c *ReplayController) ButtonPressed(button ControllerButton) bool {
+	return c.frame.pressed&c.buttonBit(button) != 0
+}
+
+func (c *ReplayController) ButtonReleased(button ControllerButton) bool {
+	return c.frame.released&c.buttonBit(button) != 0
+}
+
+func (c *ReplayController) ButtonDown(button ControllerButton) bool {
+	return c.frame.down&c.buttonBit(button) != 0
+}
+
+func (c *ReplayController) AxisValue(axis ControllerAxis) float32 {
+	if axis < 0 || int(axis) >= NUM_CONTROLLER_AXES {
+		return 0
+	}
+	if !c.frame.connected && (axis == AXIS_TRIGGER_LEFT || axis == AXIS_TRIGGER_RIGHT) {
+		// Released triggers are -1
+		return -1
+	}
+	return c.frame.axes[axis]
+}
+
+func (c *ReplayController) AxisDigitalValue(axis ControllerAxis) int {
+	if axis < 0 || int(axis) >= NUM_CONTROLLER_AXES {
+		return 0
+	}
+	return int(c.frame.digital[axis])
+}
+
+func (c *ReplayController) StickPressed(stick Stick, direction Direction) bool {
+	axis, value := stickDirection(stick, direction)
+	return int(c.frame.digital[axis]) == value && int(c.previous[axis]) != value
+}
+
+func (c *ReplayController) StickReleased(stick Stick, direction Direction) bool {
+	axis, value := stickDirection(stick, direction)
+	return int(c.frame.digital[axis]) != value && int(c.previous[axis]) == value
+}
+
+// SetAxisFilter does nothing, the recorded axes are already filtered
+func (c *ReplayController) SetAxisFilter(_ *AxisFilter) {
+}
+
+// SetMapping does nothing, the recorded buttons are already mapped
+func (c *ReplayController) SetMapping(_ *GameControllerMapping) {
+}
+
+func (c *ReplayController) Description() string {
+	return fmt.Sprintf("Replay of controller #%d, frame %d of %d",
+		c.index, c.playback.frame+1, len(c.playback.recording.frames))
+}
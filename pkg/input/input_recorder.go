@@ -0,0 +1,382 @@
+package input
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	recordingMagic   = "GJIR"
+	recordingVersion = 1
+	// Max controllers of a recording
+	maxRecordedControllers = 0xFFFF
+)
+
+// controllerFrame is the state of a controller in a frame, the buttons are
+// bit masks
+type controllerFrame struct {
+	connected bool
+	down      uint16
+	pressed   uint16
+	released  uint16
+	axes      [NUM_CONTROLLER_AXES]float32
+	digital   [NUM_CONTROLLER_AXES]int8
+}
+
+// mouseFrame is the state of the mouse in a frame, the buttons are bit masks
+type mouseFrame struct {
+	down          uint8
+	pressed       uint8
+	released      uint8
+	doubleClicked uint8
+	inside        bool
+	position      mgl32.Vec2
+	delta         mgl32.Vec2
+	scroll        mgl32.Vec2
+}
+
+// inputFrame is the state of all the inputs in a frame
+type inputFrame struct {
+	time        float64
+	keys        []glfw.Key
+	mouse       mouseFrame
+	controllers []controllerFrame
+}
+
+// InputRecording is the state of the controllers, the keys and the mouse
+// frame by frame, made by an InputRecorder and played by an InputPlayback
+type InputRecording struct {
+	numControllers int
+	frames         []inputFrame
+}
+
+func (r *InputRecording) NumControllers() int {
+	return r.numControllers
+}
+
+func (r *InputRecording) NumFrames() int {
+	return len(r.frames)
+}
+
+// Duration returns the seconds from the first frame to the last one
+func (r *InputRecording) Duration() float64 {
+	if len(r.frames) == 0 {
+		return 0
+	}
+	return r.frames[len(r.frames)-1].time
+}
+
+// InputRecorder records the state of the controllers, the keys and the mouse
+// once per frame:
+//
+//	recorder := input.NewInputRecorder(mouse, keyboard, joystick)
+//	...
+//	keyboard.Update()
+//	joystick.Update()
+//	mouse.Update()
+//	recorder.Record(glfw.GetTime())
+//	...
+//	recorder.Recording().Save("demo.rec")
+//
+// The keys are read from the app window, or SetKeyState, the mouse is not
+// recorded if nil
+type InputRecorder struct {
+	controllers []GameController
+	mouse       *MouseController
+	keys        KeyState
+	start       float64
+	recording   *InputRecording
+}
+
+// NewInputRecorder creates a recorder of the mouse, nil for none, and the
+// controllers, played back in the same order
+func NewInputRecorder(mouse *MouseController, controllers ...GameController) *InputRecorder {
+	return &InputRecorder{
+		controllers: append([]GameController(nil), controllers...),
+		mouse:       mouse,
+		keys:        WindowKeyState(),
+		recording:   &InputRecording{numControllers: len(controllers)},
+	}
+}
+
+// SetKeyState changes where the keys are read from, nil not to record them
+func (r *InputRecorder) SetKeyState(keys KeyState) {
+	r.keys = keys
+}
+
+// Record adds a frame, call it once per frame after updating the inputs.
+// The times are saved from the first frame
+func (r *InputRecorder) Record(time float64) {
+	if len(r.recording.frames) == 0 {
+		r.start = time
+	}
+	frame := inputFrame{
+		time:        time - r.start,
+		controllers: make([]controllerFrame, len(r.controllers)),
+	}
+	if r.keys != nil {
+		for key := glfw.KeySpace; key <= glfw.KeyLast; key++ {
+			if r.keys.KeyDown(key) {
+				frame.keys = append(frame.keys, key)
+			}
+		}
+	}
+	if r.mouse != nil && r.mouse.Connected() {
+		frame.mouse = recordMouse(r.mouse)
+	}
+	for i, c := range r.controllers {
+		if c != nil && c.Connected() {
+			frame.controllers[i] = recordController(c)
+		}
+	}
+	r.recording.frames = append(r.recording.frames, frame)
+}
+
+// Recording returns the frames recorded so far
+func (r *InputRecorder) Recording() *InputRecording {
+	return r.recording
+}
+
+// Reset drops the frames recorded
+func (r *InputRecorder) Reset() {
+	r.recording = &InputRecording{numControllers: len(r.controllers)}
+}
+
+func recordController(c GameController) controllerFrame {
+	f := controllerFrame{connected: true}
+	for i := 0; i < NUM_CONTROLLER_BUTTONS; i++ {
+		button := ControllerButton(i)
+		bit := uint16(1) << uint(i)
+		if c.ButtonDown(button) {
+			f.down |= bit
+		}
+		if c.ButtonPressed(button) {
+			f.pressed |= bit
+		}
+		if c.ButtonReleased(button) {
+			f.released |= bit
+		}
+	}
+	for i := range f.axes {
+		f.axes[i] = c.AxisValue(ControllerAxis(i))
+		f.digital[i] = int8(c.AxisDigitalValue(ControllerAxis(i)))
+	}
+	return f
+}
+
+func recordMouse(m *MouseController) mouseFrame {
+	f := mouseFrame{
+		inside:   m.Inside(),
+		position: m.Position(),
+		delta:    m.Delta(),
+		scroll:   m.Scroll(),
+	}
+	// The bit masks hold the first 8 buttons, the others are hardly there
+	for i := 0; i < 8 && i < MAX_NUM_MOUSE_BUTTONS; i++ {
+		button := MouseButton(i)
+		bit := uint8(1) << uint(i)
+		if m.ButtonDown(button) {
+			f.down |= bit
+		}
+		if m.ButtonPressed(button) {
+			f.pressed |= bit
+		}
+		if m.ButtonReleased(button) {
+			f.released |= bit
+		}
+		if m.DoubleClicked(button) {
+			f.doubleClicked |= bit
+		}
+	}
+	return f
+}
+
+// recordingWriter writes binary values, keeping the first error
+type recordingWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *recordingWriter) write(value interface{}) {
+	if w.err == nil {
+		w.err = binary.Write(w.w, binary.LittleEndian, value)
+	}
+}
+
+type recordingReader struct {
+	r   io.Reader
+	err error
+}
+
+func (r *recordingReader) read(value interface{}) {
+	if r.err == nil {
+		r.err = binary.Read(r.r, binary.LittleEndian, value)
+	}
+}
+
+func boolByte(b bool) uint8 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Write writes the recording, gzipped binary frames
+func (r *InputRecording) Write(writer io.Writer) error {
+	if r.numControllers > maxRecordedControllers {
+		return fmt.Errorf("%d controllers recorded, up to %d are written", r.numControllers, maxRecordedControllers)
+	}
+	zw := gzip.NewWriter(writer)
+	w := &recordingWriter{w: zw}
+	w.write([]byte(recordingMagic))
+	w.write(uint16(recordingVersion))
+	w.write(uint16(r.numControllers))
+	w.write(uint32(len(r.frames)))
+	for i := range r.frames {
+		f := &r.frames[i]
+		w.write(f.time)
+
+		w.write(uint16(len(f.keys)))
+		for _, key := range f.keys {
+			w.write(uint16(key))
+		}
+
+		m := &f.mouse
+		w.write([]uint8{m.down, m.pressed, m.released, m.doubleClicked, boolByte(m.inside)})
+		w.write([]float32{m.position[0], m.position[1], m.delta[0], m.delta[1], m.scroll[0], m.scroll[1]})
+
+		for j := range f.controllers {
+			c := &f.controllers[j]
+			w.write(boolByte(c.connected))
+			if !c.connected {
+				continue
+			}
+			w.write([]uint16{c.down, c.pressed, c.released})
+			w.write(c.axes)
+			w.write(c.digital)
+		}
+	}
+	if w.err != nil {
+		return w.err
+	}
+	return zw.Close()
+}
+
+// Save writes the recording to a file
+func (r *InputRecording) Save(filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	buffered := bufio.NewWriter(file)
+	if err := r.Write(buffered); err != nil {
+		file.Close()
+		return err
+	}
+	if err := buffered.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// ReadInputRecording reads a recording written by InputRecording.Write
+func ReadInputRecording(reader io.Reader) (*InputRecording, error) {
+	zr, err := gzip.NewReader(reader)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	r := &recordingReader{r: bufio.NewReader(zr)}
+
+	magic := make([]byte, len(recordingMagic))
+	var version uint16
+	var numControllers uint16
+	var numFrames uint32
+	r.read(magic)
+	r.read(&version)
+	r.read(&numControllers)
+	r.read(&numFrames)
+	if r.err != nil {
+		return nil, r.err
+	}
+	if string(magic) != recordingMagic {
+		return nil, fmt.Errorf("not an input recording")
+	}
+	if version != recordingVersion {
+		return nil, fmt.Errorf("unsupported input recording version %d", version)
+	}
+
+	recording := &InputRecording{numControllers: int(numControllers)}
+	for i := uint32(0); i < numFrames && r.err == nil; i++ {
+		f := inputFrame{controllers: make([]controllerFrame, numControllers)}
+		r.read(&f.time)
+
+		var numKeys uint16
+		r.read(&numKeys)
+		if r.err == nil && numKeys > 0 {
+			keys := make([]uint16, numKeys)
+			r.read(keys)
+			for _, key := range keys {
+				f.keys = append(f.keys, glfw.Key(key))
+			}
+		}
+
+		var buttons [5]uint8
+		var vectors [6]float32
+		r.read(&buttons)
+		r.read(&vectors)
+		f.mouse = mouseFrame{
+			down:          buttons[0],
+			pressed:       buttons[1],
+			released:      buttons[2],
+			doubleClicked: buttons[3],
+			inside:        buttons[4] != 0,
+			position:      mgl32.Vec2{vectors[0], vectors[1]},
+			delta:         mgl32.Vec2{vectors[2], vectors[3]},
+			scroll:        mgl32.Vec2{vectors[4], vectors[5]},
+		}
+
+		for j := range f.controllers {
+			c := &f.controllers[j]
+			var connected uint8
+			r.read(&connected)
+			if connected == 0 {
+				continue
+			}
+			c.connected = true
+			var masks [3]uint16
+			r.read(&masks)
+			c.down, c.pressed, c.released = masks[0], masks[1], masks[2]
+			r.read(&c.axes)
+			r.read(&c.digital)
+		}
+		recording.frames = append(recording.frames, f)
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return recording, nil
+}
+
+// NewInputRecordingFromFile loads a recording saved with InputRecording.Save
+func NewInputRecordingFromFile(filePath string) *InputRecording {
+	file, err := os.Open(filePath)
+	if err != nil {
+		log.Panicf("Loading input recording. %s", err)
+	}
+	defer file.Close()
+	recording, err := ReadInputRecording(file)
+	if err != nil {
+		log.Panicf("Error parsing input recording %s: %v", filePath, err)
+	}
+	return recording
+}
@@ -0,0 +1,239 @@
+package input
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+// fakeKeys are the keys held by a test
+type fakeKeys map[glfw.Key]bool
+
+func (k fakeKeys) KeyDown(key glfw.Key) bool {
+	return k[key]
+}
+
+func (k fakeKeys) MouseButtonDown(_ MouseButton) bool {
+	return false
+}
+
+// fakeController is a controller set by the tests, the buttons and the axes
+// set are taken by Update like on a joystick
+type fakeController struct {
+	connected bool
+	raw       [NUM_CONTROLLER_BUTTONS]bool
+	down      [NUM_CONTROLLER_BUTTONS]bool
+	previous  [NUM_CONTROLLER_BUTTONS]bool
+	axes      [NUM_CONTROLLER_AXES]float32
+	analog    axisState
+}
+
+func newFakeController() *fakeController {
+	c := &fakeController{connected: true}
+	c.axes[AXIS_TRIGGER_LEFT] = -1
+	c.axes[AXIS_TRIGGER_RIGHT] = -1
+	c.analog.reset()
+	return c
+}
+
+func (c *fakeController) set(button ControllerButton, down bool) {
+	c.raw[button] = down
+}
+
+// tap presses a button for a frame
+func (c *fakeController) tap(button ControllerButton) {
+	c.raw[button] = true
+	c.Update()
+	c.raw[button] = false
+}
+
+func (c *fakeController) Connected() bool {
+	return c.connected
+}
+
+func (c *fakeController) Open(_ int) bool {
+	c.connected = true
+	return true
+}
+
+func (c *fakeController) Close() {
+	c.connected = false
+}
+
+func (c *fakeController) Update() {
+	if !c.connected {
+		return
+	}
+	c.previous = c.down
+	c.down = c.raw
+	c.analog.update(c.axes)
+}
+
+func (c *fakeController) NumButtons() int {
+	return NUM_CONTROLLER_BUTTONS
+}
+
+func (c *fakeController) NumAxes() int {
+	return NUM_CONTROLLER_AXES
+}
+
+func (c *fakeController) ButtonPressed(button ControllerButton) bool {
+	return c.connected && c.down[button] && !c.previous[button]
+}
+
+func (c *fakeController) ButtonReleased(button ControllerButton) bool {
+	return c.connected && !c.down[button] && c.previous[button]
+}
+
+func (c *fakeController) ButtonDown(button ControllerButton) bool {
+	return c.connected && c.down[button]
+}
+
+func (c *fakeController) AxisValue(axis ControllerAxis) float32 {
+	return c.analog.value(axis)
+}
+
+func (c *fakeController) AxisDigitalValue(axis ControllerAxis) int {
+	return c.analog.digitalValue(axis)
+}
+
+func (c *fakeController) StickPressed(stick Stick, direction Direction) bool {
+	return c.connected && c.analog.stickPressed(stick, direction)
+}
+
+func (c *fakeController) StickReleased(stick Stick, direction Direction) bool {
+	return c.connected && c.analog.stickReleased(stick, direction)
+}
+
+func (c *fakeController) SetAxisFilter(filter *AxisFilter) {
+	c.analog.filter = filter
+}
+
+func (c *fakeController) SetMapping(_ *GameControllerMapping) {
+}
+
+func (c *fakeController) Description() string {
+	return "fake"
+}
+
+// recordTestFrames records a controller and the keys: A pressed for a
+// frame, the stick pushed right, an unnamed key held
+func recordTestFrames() *InputRecording {
+	pad := newFakeController()
+	keys := fakeKeys{}
+	recorder := NewInputRecorder(nil, pad, nil)
+	recorder.SetKeyState(keys)
+	for i := 0; i < 5; i++ {
+		switch i {
+		case 1:
+			pad.set(BUTTON_A, true)
+			keys[glfw.Key(200)] = true
+		case 2:
+			pad.set(BUTTON_A, false)
+			pad.axes[AXIS_LEFT_X] = 1
+		case 3:
+			keys[glfw.KeyW] = true
+		}
+		pad.Update()
+		recorder.Record(10 + float64(i)*0.25)
+	}
+	return recorder.Recording()
+}
+
+func TestInputRecordingWrite(t *testing.T) {
+	recording := recordTestFrames()
+	if recording.NumFrames() != 5 || recording.NumControllers() != 2 || recording.Duration() != 1 {
+		t.Fatalf("Recorded %d frames of %d controllers in %v seconds",
+			recording.NumFrames(), recording.NumControllers(), recording.Duration())
+	}
+
+	var buffer bytes.Buffer
+	if err := recording.Write(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadInputRecording(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read, recording) {
+		t.Errorf("Read %+v, expecting %+v", read, recording)
+	}
+
+	if _, err := ReadInputRecording(bytes.NewReader([]byte("GJIR"))); err == nil {
+		t.Error("Read a recording not gzipped")
+	}
+	if err := (&InputRecording{numControllers: 0x10000}).Write(&buffer); err == nil {
+		t.Error("Wrote 65536 controllers")
+	}
+}
+
+func TestInputPlayback(t *testing.T) {
+	playback := NewInputPlayback(recordTestFrames())
+	pad := playback.Controller(0)
+	if playback.Controller(1) == nil || playback.Controller(2) != nil {
+		t.Error("Wrong controllers")
+	}
+	var tests = []struct {
+		time     float64
+		pressed  bool
+		down     bool
+		released bool
+		right    bool
+		keys     []glfw.Key
+	}{
+		{0, false, false, false, false, nil},
+		{0.25, true, true, false, false, []glfw.Key{200}},
+		{0.5, false, false, true, true, []glfw.Key{200}},
+		{0.75, false, false, false, false, []glfw.Key{200, glfw.KeyW}},
+		{1, false, false, false, false, []glfw.Key{200, glfw.KeyW}},
+	}
+	for i, test := range tests {
+		if !playback.Update() {
+			t.Fatalf("Finished at frame %d", i)
+		}
+		pad.Update()
+		if playback.Frame() != i || playback.Time() != test.time {
+			t.Errorf("Frame %d at %v, expecting %d at %v", playback.Frame(), playback.Time(), i, test.time)
+		}
+		if pad.ButtonPressed(BUTTON_A) != test.pressed || pad.ButtonDown(BUTTON_A) != test.down ||
+			pad.ButtonReleased(BUTTON_A) != test.released {
+			t.Errorf("Frame %d: A pressed %v down %v released %v", i,
+				pad.ButtonPressed(BUTTON_A), pad.ButtonDown(BUTTON_A), pad.ButtonReleased(BUTTON_A))
+		}
+		// The stick is pressed in the frame it's pushed only
+		if pad.StickPressed(STICK_LEFT, DIRECTION_RIGHT) != test.right {
+			t.Errorf("Frame %d: stick pressed %v", i, !test.right)
+		}
+		for _, key := range []glfw.Key{200, glfw.KeyW} {
+			held := false
+			for _, k := range test.keys {
+				held = held || k == key
+			}
+			if playback.KeyDown(key) != held {
+				t.Errorf("Frame %d: key %d down %v", i, key, !held)
+			}
+		}
+	}
+	if pad.AxisValue(AXIS_LEFT_X) <= 0.5 || pad.AxisDigitalValue(AXIS_LEFT_X) != 1 {
+		t.Errorf("Stick at %v", pad.AxisValue(AXIS_LEFT_X))
+	}
+
+	if playback.Update() || !playback.Finished() || playback.Time() != 1 {
+		t.Error("Not finished after the last frame")
+	}
+	pad.Update()
+	if pad.ButtonDown(BUTTON_A) || pad.AxisDigitalValue(AXIS_LEFT_X) != 0 || playback.KeyDown(glfw.KeyW) {
+		t.Error("Inputs down after the last frame")
+	}
+
+	playback.SetLoop(true)
+	playback.Restart()
+	for i := 0; i < 7; i++ {
+		playback.Update()
+	}
+	if playback.Frame() != 1 || playback.Finished() {
+		t.Errorf("Looping at frame %d", playback.Frame())
+	}
+}
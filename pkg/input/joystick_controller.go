@@ -3,8 +3,6 @@ package input
 import (
 	"fmt"
 
-	"regexp"
-
 	"log"
 
 	"github.com/go-gl/glfw/v3.2/glfw"
@@ -15,9 +13,12 @@ type JoystickController struct {
 	connected       bool
 	joystick        glfw.Joystick
 	name            string
+	guid            string
 	numButtons      int
 	numAxes         int
 	axes            []float32
+	buttons         []byte
+	analog          axisState
 	buttonsPressed  []bool
 	buttonsReleased []bool
 	buttonsDown     []bool
@@ -47,6 +48,13 @@ func init() {
 				JoystickControllers[joy].pluggedOut()
 			}
 		}
+		if defaultDispatcher != nil {
+			defaultDispatcher.Dispatch(&Event{
+				Type:      EVENT_JOYSTICK,
+				Joystick:  joy,
+				Connected: glfw.MonitorEvent(event) == glfw.Connected,
+			})
+		}
 	})
 }
 
@@ -75,10 +83,11 @@ func (c *JoystickController) Open(deviceIndex int) bool {
 	// Get the num of buttons and axes
 	c.numButtons = len(glfw.GetJoystickButtons(c.joystick))
 	c.numAxes = len(glfw.GetJoystickAxes(c.joystick))
-	// Build the slices
-	c.buttonsDown = make([]bool, c.numButtons)
-	c.buttonsPressed = make([]bool, c.numButtons)
-	c.buttonsReleased = make([]bool, c.numButtons)
+	// Build the slices, one entry per button of the controller
+	c.buttonsDown = make([]bool, NUM_CONTROLLER_BUTTONS)
+	c.buttonsPressed = make([]bool, NUM_CONTROLLER_BUTTONS)
+	c.buttonsReleased = make([]bool, NUM_CONTROLLER_BUTTONS)
+	c.guid = joystickGUID(c.name)
 
 	log.Printf("Joystick #%d: opened. %s", c.joystick, c.Description())
 	c.findMapping()
@@ -96,6 +105,10 @@ func (c *JoystickController) pluggedOut() {
 	c.connected = false
 	c.joystick = -1
 	c.name = ""
+	c.guid = ""
+	c.axes = nil
+	c.buttons = nil
+	c.analog.reset()
 	c.buttonsDown = nil
 	c.buttonsPressed = nil
 	c.buttonsReleased = nil
@@ -106,29 +119,57 @@ func (c *JoystickController) Update() {
 		return
 	}
 
-	// Buttons
-	for i, button := range glfw.GetJoystickButtons(c.joystick) {
-		isDown := button > 0
+	c.buttons = glfw.GetJoystickButtons(c.joystick)
+	c.axes = glfw.GetJoystickAxes(c.joystick)
+
+	// Buttons of the controller, from the buttons, axes or hats of the
+	// joystick
+	for i := range c.buttonsDown {
+		isDown := c.mapping.buttonDown(ControllerButton(i), c.buttons, c.axes)
 		c.buttonsPressed[i] = isDown && !c.buttonsDown[i]
 		c.buttonsReleased[i] = !isDown && c.buttonsDown[i]
 		c.buttonsDown[i] = isDown
 	}
 
-	// Axes
-	c.axes = glfw.GetJoystickAxes(c.joystick)
+	// Axes of the controller, filtered
+	var raw [NUM_CONTROLLER_AXES]float32
+	for i := range raw {
+		raw[i] = c.mapping.axisValue(ControllerAxis(i), c.buttons, c.axes)
+	}
+	c.analog.update(raw)
 }
 
 func (c *JoystickController) AxisValue(axis ControllerAxis) float32 {
-	if int(axis) >= c.numAxes {
+	if !c.connected {
 		return 0
 	}
-	axisIndex := c.axisFromMapping(axis)
-	return c.axes[axisIndex]
+	return c.analog.value(axis)
 }
 
+// AxisDigitalValue returns -1, 0 or 1 when the axis is pushed, see
+// AxisFilter for the thresholds
 func (c *JoystickController) AxisDigitalValue(axis ControllerAxis) int {
-	// TODO: Define dead zone...
-	return 0
+	if !c.connected {
+		return 0
+	}
+	return c.analog.digitalValue(axis)
+}
+
+// StickPressed returns true if the stick was pushed in a direction this frame
+func (c *JoystickController) StickPressed(stick Stick, direction Direction) bool {
+	return c.connected && c.analog.stickPressed(stick, direction)
+}
+
+// StickReleased returns true if the stick stopped being pushed in a
+// direction this frame
+func (c *JoystickController) StickReleased(stick Stick, direction Direction) bool {
+	return c.connected && c.analog.stickReleased(stick, direction)
+}
+
+// SetAxisFilter changes the dead zones, the response curves and the
+// thresholds of the digital values, DefaultAxisFilter if nil
+func (c *JoystickController) SetAxisFilter(filter *AxisFilter) {
+	c.analog.filter = filter
 }
 
 func (c *JoystickController) Connected() bool {
@@ -143,64 +184,59 @@ func (c *JoystickController) NumAxis() int {
 	return c.numAxes
 }
 
+func (c *JoystickController) NumAxes() int {
+	return c.numAxes
+}
+
 func (c *JoystickController) ButtonPressed(button ControllerButton) bool {
-	if !c.connected {
+	if !c.connected || int(button) >= NUM_CONTROLLER_BUTTONS {
 		return false
 	}
-	buttonIndex := c.buttonFromMapping(button)
-	return c.buttonsPressed[buttonIndex]
+	return c.buttonsPressed[button]
 }
 
 func (c *JoystickController) ButtonReleased(button ControllerButton) bool {
-	if !c.connected {
+	if !c.connected || int(button) >= NUM_CONTROLLER_BUTTONS {
 		return false
 	}
-	buttonIndex := c.buttonFromMapping(button)
-	return c.buttonsReleased[buttonIndex]
+	return c.buttonsReleased[button]
 }
 
 func (c *JoystickController) ButtonDown(button ControllerButton) bool {
-	if !c.connected {
+	if !c.connected || int(button) >= NUM_CONTROLLER_BUTTONS {
 		return false
 	}
-	buttonIndex := c.buttonFromMapping(button)
-	if buttonIndex >= c.numButtons {
-		return false
-	}
-	return c.buttonsDown[buttonIndex]
+	return c.buttonsDown[button]
 }
 
 func (c *JoystickController) Description() string {
-	return fmt.Sprintf("name:'%s' buttons:%d axes:%d", c.name, c.numButtons, c.numAxes)
+	return fmt.Sprintf("name:'%s' guid:%s buttons:%d axes:%d", c.name, c.guid, c.numButtons, c.numAxes)
 }
 
 func (c *JoystickController) SetMapping(mapping *GameControllerMapping) {
 	c.mapping = mapping
 }
 
+// findMapping picks the mapping of the joystick by GUID, then by name. See
+// AddGameControllerMappingsFromFile to load more mappings
 func (c *JoystickController) findMapping() {
-	for _, mapping := range GameControllerMappings {
-		r, _ := regexp.Compile(mapping.nameRegEx)
-		if r.MatchString(c.name) == true && len(mapping.buttons) == c.numButtons && len(mapping.axes) == c.numAxes {
-			c.SetMapping(mapping)
-			log.Printf("Joystick #%d: mapping found", c.joystick)
-			return
-		}
+	if mapping := FindGameControllerMapping(c.guid, c.name, c.numButtons, c.numAxes); mapping != nil {
+		c.SetMapping(mapping)
+		log.Printf("Joystick #%d: mapping found, %s", c.joystick, mapping.Name())
+		return
 	}
 	//	Couldn't find a mapping, pick the XBox 360 one
+	log.Printf("Joystick #%d: no mapping for %s '%s', using %s", c.joystick, c.guid, c.name, MappingXBox360.Name())
 	c.SetMapping(&MappingXBox360)
 }
 
-func (c *JoystickController) buttonFromMapping(index ControllerButton) int {
-	if int(index) >= c.numButtons {
-		return 0
-	}
-	return c.mapping.buttons[int(index)]
+// Name returns the name of the joystick, empty while disconnected
+func (c *JoystickController) Name() string {
+	return c.name
 }
 
-func (c *JoystickController) axisFromMapping(index ControllerAxis) int {
-	if int(index) >= c.numAxes {
-		return 0
-	}
-	return c.mapping.axes[int(index)]
+// GUID returns the SDL GUID of the joystick, empty if unknown. glfw 3.2 has
+// no API for it, it's found on Linux only
+func (c *JoystickController) GUID() string {
+	return c.guid
 }
@@ -0,0 +1,34 @@
+package input
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// joystickGUID returns the SDL GUID of a joystick. glfw 3.2 has no API for
+// it, the ids are read from the input device with the same name
+func joystickGUID(name string) string {
+	devices, _ := filepath.Glob("/sys/class/input/event*/device")
+	for _, device := range devices {
+		deviceName, err := ioutil.ReadFile(filepath.Join(device, "name"))
+		if err != nil || strings.TrimSpace(string(deviceName)) != name {
+			continue
+		}
+		var ids [4]uint16
+		for i, id := range []string{"bustype", "vendor", "product", "version"} {
+			data, err := ioutil.ReadFile(filepath.Join(device, "id", id))
+			if err != nil {
+				return ""
+			}
+			value, err := strconv.ParseUint(strings.TrimSpace(string(data)), 16, 16)
+			if err != nil {
+				return ""
+			}
+			ids[i] = uint16(value)
+		}
+		return sdlGUID(ids[0], ids[1], ids[2], ids[3])
+	}
+	return ""
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package input
+
+// joystickGUID returns the SDL GUID of a joystick. glfw 3.2 has no API for
+// it, outside of Linux the joysticks are matched by name only
+func joystickGUID(name string) string {
+	return ""
+}
@@ -4,9 +4,48 @@ import (
 	"fmt"
 
 	"github.com/go-gl/glfw/v3.2/glfw"
-	"github.com/markov/gojira2d/pkg/app"
 )
 
+var (
+	// Left hand layout: WASD moves, F G R T are A B X Y
+	MappingKeyboardWASD = NewKeyboardMapping(map[ControllerButton]glfw.Key{
+		BUTTON_A: glfw.KeyF, BUTTON_B: glfw.KeyG, BUTTON_X: glfw.KeyR, BUTTON_Y: glfw.KeyT,
+		BUTTON_BACK: glfw.Key2, BUTTON_START: glfw.Key1,
+		BUTTON_LEFT_SHOULDER: glfw.KeyQ, BUTTON_RIGHT_SHOULDER: glfw.KeyE,
+		BUTTON_DIR_PAD_UP: glfw.KeyW, BUTTON_DIR_PAD_DOWN: glfw.KeyS,
+		BUTTON_DIR_PAD_LEFT: glfw.KeyA, BUTTON_DIR_PAD_RIGHT: glfw.KeyD,
+	})
+	// Right hand layout: the arrows move, the keypad has the buttons
+	MappingKeyboardArrows = NewKeyboardMapping(map[ControllerButton]glfw.Key{
+		BUTTON_A: glfw.KeyKP1, BUTTON_B: glfw.KeyKP2, BUTTON_X: glfw.KeyKP4, BUTTON_Y: glfw.KeyKP5,
+		BUTTON_BACK: glfw.KeyKPAdd, BUTTON_START: glfw.KeyKPEnter,
+		BUTTON_LEFT_SHOULDER: glfw.KeyKP7, BUTTON_RIGHT_SHOULDER: glfw.KeyKP8,
+		BUTTON_DIR_PAD_UP: glfw.KeyUp, BUTTON_DIR_PAD_DOWN: glfw.KeyDown,
+		BUTTON_DIR_PAD_LEFT: glfw.KeyLeft, BUTTON_DIR_PAD_RIGHT: glfw.KeyRight,
+	})
+)
+
+// NewKeyboardMapping creates a layout of a KeyboardController, the key of
+// each button. The buttons missing have no key
+func NewKeyboardMapping(keys map[ControllerButton]glfw.Key) *GameControllerMapping {
+	buttons := make([]int, NUM_CONTROLLER_BUTTONS)
+	for i := range buttons {
+		buttons[i] = int(glfw.KeyUnknown)
+	}
+	for button, key := range keys {
+		if button >= 0 && int(button) < NUM_CONTROLLER_BUTTONS {
+			buttons[button] = int(key)
+		}
+	}
+	mapping := &GameControllerMapping{}
+	mapping.set("<None>", buttons, []int{})
+	return mapping
+}
+
+// KeyboardController is a controller played with keys, the ones of
+// MappingKeyboard or of a layout set with SetMapping before Open. Several
+// of them share the keyboard, e.g. MappingKeyboardWASD for a player and
+// MappingKeyboardArrows for another one
 type KeyboardController struct {
 	GameController
 	connected       bool
@@ -17,15 +56,23 @@ type KeyboardController struct {
 	buttonsDown     []bool
 	buttonsRaw      []bool
 	axes            []float32
+	analog          axisState
 	mapping         *GameControllerMapping
 	keyMapping      map[glfw.Key]int
+	listener        ListenerID
 }
 
+// Open starts listening to the keys with the dispatcher of the app window
 func (c *KeyboardController) Open(_ int) bool {
+	if c.connected {
+		return true
+	}
 	c.connected = true
 	c.numButtons = 15 // Xbox360
 	c.numAxes = 2     // Only the left stick
-	c.SetMapping(&MappingKeyboard)
+	if c.mapping == nil {
+		c.SetMapping(&MappingKeyboard)
+	}
 
 	// Build the slices
 	c.buttonsDown = make([]bool, c.numButtons)
@@ -34,21 +81,26 @@ func (c *KeyboardController) Open(_ int) bool {
 	c.buttonsRaw = make([]bool, c.numButtons)
 	c.axes = make([]float32, c.numAxes)
 
-	app.GetWindow().SetKeyCallback(func(w *glfw.Window, key glfw.Key, scanCode int, action glfw.Action, mods glfw.ModifierKey) {
-		if index, ok := c.keyMapping[key]; ok {
-			if action == glfw.Press {
+	c.listener = GetDispatcher().AddListener(0, func(e *Event) bool {
+		if index, ok := c.keyMapping[e.Key]; ok {
+			if e.Action == glfw.Press {
 				c.buttonsRaw[index] = true
-			} else if action == glfw.Release {
+			} else if e.Action == glfw.Release {
 				c.buttonsRaw[index] = false
 			}
 		}
-	})
+		return false
+	}, EVENT_KEY)
 
 	return true
 }
 
 func (c *KeyboardController) Close() {
-	app.GetWindow().SetKeyCallback(nil)
+	if !c.connected {
+		return
+	}
+	GetDispatcher().RemoveListener(c.listener)
+	c.analog.reset()
 	c.connected = false
 }
 
@@ -80,17 +132,38 @@ func (c *KeyboardController) Update() {
 	} else {
 		c.axes[1] = 0
 	}
+	raw := [NUM_CONTROLLER_AXES]float32{c.axes[0], c.axes[1], 0, 0, -1, -1}
+	c.analog.update(raw)
 }
 
 func (c *KeyboardController) AxisValue(axis ControllerAxis) float32 {
 	if int(axis) >= c.numAxes {
+		// Released like the triggers of the joysticks
+		if axis == AXIS_TRIGGER_LEFT || axis == AXIS_TRIGGER_RIGHT {
+			return -1
+		}
 		return 0
 	}
-	return c.axes[axis]
+	return c.analog.value(axis)
 }
 
 func (c *KeyboardController) AxisDigitalValue(axis ControllerAxis) int {
-	return 0
+	if int(axis) >= c.numAxes {
+		return 0
+	}
+	return c.analog.digitalValue(axis)
+}
+
+func (c *KeyboardController) StickPressed(stick Stick, direction Direction) bool {
+	return c.connected && c.analog.stickPressed(stick, direction)
+}
+
+func (c *KeyboardController) StickReleased(stick Stick, direction Direction) bool {
+	return c.connected && c.analog.stickReleased(stick, direction)
+}
+
+func (c *KeyboardController) SetAxisFilter(filter *AxisFilter) {
+	c.analog.filter = filter
 }
 
 func (c *KeyboardController) Connected() bool {
@@ -105,6 +178,10 @@ func (c *KeyboardController) NumAxis() int {
 	return c.numAxes
 }
 
+func (c *KeyboardController) NumAxes() int {
+	return c.numAxes
+}
+
 func (c *KeyboardController) ButtonPressed(button ControllerButton) bool {
 	if !c.connected {
 		return false
@@ -134,6 +211,8 @@ func (c *KeyboardController) SetMapping(mapping *GameControllerMapping) {
 	c.mapping = mapping
 	c.keyMapping = make(map[glfw.Key]int)
 	for i, key := range c.mapping.buttons {
-		c.keyMapping[glfw.Key(key)] = i
+		if glfw.Key(key) != glfw.KeyUnknown {
+			c.keyMapping[glfw.Key(key)] = i
+		}
 	}
 }
@@ -0,0 +1,204 @@
+package input
+
+import (
+	"strings"
+)
+
+// MergedController is several controllers as one, e.g. the keyboard or a
+// pad playing the same player. A button is down while down on any of them,
+// each axis and its digital value are the ones pushed the most. It updates
+// the controllers, don't update them elsewhere
+type MergedController struct {
+	controllers     []GameController
+	buttonsDown     [NUM_CONTROLLER_BUTTONS]bool
+	buttonsPressed  [NUM_CONTROLLER_BUTTONS]bool
+	buttonsReleased [NUM_CONTROLLER_BUTTONS]bool
+	values          [NUM_CONTROLLER_AXES]float32
+	digital         [NUM_CONTROLLER_AXES]int
+	previousDigital [NUM_CONTROLLER_AXES]int
+}
+
+// NewMergedController merges controllers, already open or opened later
+func NewMergedController(controllers ...GameController) *MergedController {
+	c := &MergedController{controllers: append([]GameController(nil), controllers...)}
+	c.reset()
+	return c
+}
+
+// Add merges another controller
+func (c *MergedController) Add(controller GameController) {
+	c.controllers = append(c.controllers, controller)
+}
+
+// Remove stops merging a controller, it isn't closed
+func (c *MergedController) Remove(controller GameController) {
+	for i, o := range c.controllers {
+		if o == controller {
+			c.controllers = append(c.controllers[:i], c.controllers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Controllers returns a copy of the controllers merged
+func (c *MergedController) Controllers() []GameController {
+	return append([]GameController(nil), c.controllers...)
+}
+
+func (c *MergedController) reset() {
+	c.buttonsDown = [NUM_CONTROLLER_BUTTONS]bool{}
+	c.buttonsPressed = [NUM_CONTROLLER_BUTTONS]bool{}
+	c.buttonsReleased = [NUM_CONTROLLER_BUTTONS]bool{}
+	c.values = [NUM_CONTROLLER_AXES]float32{}
+	c.values[AXIS_TRIGGER_LEFT] = -1
+	c.values[AXIS_TRIGGER_RIGHT] = -1
+	c.digital = [NUM_CONTROLLER_AXES]int{}
+	c.previousDigital = [NUM_CONTROLLER_AXES]int{}
+}
+
+// Open opens the controllers, the device index is passed to all of them
+func (c *MergedController) Open(deviceIndex int) bool {
+	opened := false
+	for _, o := range c.controllers {
+		if o.Connected() || o.Open(deviceIndex) {
+			opened = true
+		}
+	}
+	return opened
+}
+
+// Close closes the controllers
+func (c *MergedController) Close() {
+	for _, o := range c.controllers {
+		o.Close()
+	}
+	c.reset()
+}
+
+// Update updates the controllers and merges them. The edges are the ones
+// of the merged buttons: holding a button on both and releasing one of
+// them doesn't release it
+func (c *MergedController) Update() {
+	var down [NUM_CONTROLLER_BUTTONS]bool
+	var values [NUM_CONTROLLER_AXES]float32
+	var digital [NUM_CONTROLLER_AXES]int
+	values[AXIS_TRIGGER_LEFT] = -1
+	values[AXIS_TRIGGER_RIGHT] = -1
+	for _, o := range c.controllers {
+		o.Update()
+		if !o.Connected() {
+			continue
+		}
+		for i := range down {
+			down[i] = down[i] || o.ButtonDown(ControllerButton(i))
+		}
+		// The keyboard has the left stick only, its other axes are 0
+		for i := 0; i < len(values) && i < o.NumAxes(); i++ {
+			axis := ControllerAxis(i)
+			value := o.AxisValue(axis)
+			trigger := axis == AXIS_TRIGGER_LEFT || axis == AXIS_TRIGGER_RIGHT
+			// The digital value goes with the axis taken
+			if (trigger && value > values[i]) || (!trigger && abs32(value) > abs32(values[i])) {
+				values[i] = value
+				digital[i] = o.AxisDigitalValue(axis)
+			}
+		}
+	}
+	for i, isDown := range down {
+		c.buttonsPressed[i] = isDown && !c.buttonsDown[i]
+		c.buttonsReleased[i] = !isDown && c.buttonsDown[i]
+		c.buttonsDown[i] = isDown
+	}
+	c.values = values
+	c.previousDigital = c.digital
+	c.digital = digital
+}
+
+func abs32(value float32) float32 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+// Connected returns true if any of the controllers is connected
+func (c *MergedController) Connected() bool {
+	for _, o := range c.controllers {
+		if o.Connected() {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *MergedController) NumButtons() int {
+	return NUM_CONTROLLER_BUTTONS
+}
+
+func (c *MergedController) NumAxes() int {
+	return NUM_CONTROLLER_AXES
+}
+
+func (c *MergedController) ButtonPressed(button ControllerButton) bool {
+	if button < 0 || int(button) >= NUM_CONTROLLER_BUTTONS {
+		return false
+	}
+	return c.buttonsPressed[button]
+}
+
+func (c *MergedController) ButtonReleased(button ControllerButton) bool {
+	if button < 0 || int(button) >= NUM_CONTROLLER_BUTTONS {
+		return false
+	}
+	return c.buttonsReleased[button]
+}
+
+func (c *MergedController) ButtonDown(button ControllerButton) bool {
+	if button < 0 || int(button) >= NUM_CONTROLLER_BUTTONS {
+		return false
+	}
+	return c.buttonsDown[button]
+}
+
+func (c *MergedController) AxisValue(axis ControllerAxis) float32 {
+	if axis < 0 || int(axis) >= NUM_CONTROLLER_AXES {
+		return 0
+	}
+	return c.values[axis]
+}
+
+func (c *MergedController) AxisDigitalValue(axis ControllerAxis) int {
+	if axis < 0 || int(axis) >= NUM_CONTROLLER_AXES {
+		return 0
+	}
+	return c.digital[axis]
+}
+
+func (c *MergedController) StickPressed(stick Stick, direction Direction) bool {
+	axis, value := stickDirection(stick, direction)
+	return c.digital[axis] == value && c.previousDigital[axis] != value
+}
+
+func (c *MergedController) StickReleased(stick Stick, direction Direction) bool {
+	axis, value := stickDirection(stick, direction)
+	return c.digital[axis] != value && c.previousDigital[axis] == value
+}
+
+// SetAxisFilter changes the filter of all the controllers
+func (c *MergedController) SetAxisFilter(filter *AxisFilter) {
+	for _, o := range c.controllers {
+		o.SetAxisFilter(filter)
+	}
+}
+
+// SetMapping does nothing, each controller has its own mapping
+func (c *MergedController) SetMapping(_ *GameControllerMapping) {
+}
+
+func (c *MergedController) Description() string {
+	descriptions := make([]string, len(c.controllers))
+	for i, o := range c.controllers {
+		descriptions[i] = o.Description()
+	}
+	return "merged:[" + strings.Join(descriptions, ", ") + "]"
+}
@@ -0,0 +1,112 @@
+package input
+
+import (
+	"testing"
+)
+
+func TestMergedControllerButtons(t *testing.T) {
+	pad, keyboard := NewVirtualController("pad"), NewVirtualController("keyboard")
+	c := NewMergedController(pad, keyboard)
+
+	pad.Press(BUTTON_A)
+	c.Update()
+	if edges := buttonEdges(c, BUTTON_A); edges != edgePressed {
+		t.Errorf("Pressed on one: %v", edges)
+	}
+	keyboard.Press(BUTTON_A)
+	c.Update()
+	if edges := buttonEdges(c, BUTTON_A); edges != edgeHeld {
+		t.Errorf("Pressed on both: %v", edges)
+	}
+	// Still held on the keyboard
+	pad.Release(BUTTON_A)
+	c.Update()
+	if edges := buttonEdges(c, BUTTON_A); edges != edgeHeld {
+		t.Errorf("Released on one: %v", edges)
+	}
+	keyboard.Release(BUTTON_A)
+	c.Update()
+	if edges := buttonEdges(c, BUTTON_A); edges != edgeReleased {
+		t.Errorf("Released on both: %v", edges)
+	}
+
+	// The buttons of a disconnected controller are up
+	keyboard.Press(BUTTON_B)
+	c.Update()
+	keyboard.Close()
+	c.Update()
+	if edges := buttonEdges(c, BUTTON_B); edges != edgeReleased || !c.Connected() {
+		t.Errorf("Disconnected: %v", edges)
+	}
+	c.Remove(pad)
+	if c.Connected() || len(c.Controllers()) != 1 {
+		t.Error("Controller not removed")
+	}
+	c.Add(pad)
+	if !c.Connected() || len(c.Controllers()) != 2 {
+		t.Error("Controller not added")
+	}
+}
+
+func TestMergedControllerTriggers(t *testing.T) {
+	pad, other := NewVirtualController("pad"), NewVirtualController("other")
+	c := NewMergedController(pad, other)
+	if c.AxisValue(AXIS_TRIGGER_LEFT) != -1 {
+		t.Error("Trigger not released before Update")
+	}
+	c.Update()
+	if c.AxisValue(AXIS_TRIGGER_LEFT) != -1 || c.AxisValue(AXIS_TRIGGER_RIGHT) != -1 {
+		t.Error("Trigger not released")
+	}
+	// The trigger pulled the most, -1 being released
+	pad.SetAxis(AXIS_TRIGGER_LEFT, -0.5)
+	other.SetAxis(AXIS_TRIGGER_LEFT, 0.5)
+	c.Update()
+	if !nearly(c.AxisValue(AXIS_TRIGGER_LEFT), other.AxisValue(AXIS_TRIGGER_LEFT)) ||
+		c.AxisValue(AXIS_TRIGGER_LEFT) <= pad.AxisValue(AXIS_TRIGGER_LEFT) {
+		t.Errorf("Left trigger at %v", c.AxisValue(AXIS_TRIGGER_LEFT))
+	}
+	other.SetAxis(AXIS_TRIGGER_LEFT, -1)
+	c.Update()
+	if !nearly(c.AxisValue(AXIS_TRIGGER_LEFT), pad.AxisValue(AXIS_TRIGGER_LEFT)) {
+		t.Errorf("Left trigger at %v", c.AxisValue(AXIS_TRIGGER_LEFT))
+	}
+}
+
+func TestMergedControllerSticks(t *testing.T) {
+	pad, other := NewVirtualController("pad"), NewVirtualController("other")
+	c := NewMergedController(pad, other)
+	pad.SetStick(STICK_LEFT, 0.6, 0)
+	other.SetStick(STICK_LEFT, -1, 0.3)
+	c.Update()
+	// Each axis is the one pushed the most
+	if !nearly(c.AxisValue(AXIS_LEFT_X), other.AxisValue(AXIS_LEFT_X)) || !nearly(c.AxisValue(AXIS_LEFT_Y), other.AxisValue(AXIS_LEFT_Y)) {
+		t.Errorf("Left stick at %v, %v", c.AxisValue(AXIS_LEFT_X), c.AxisValue(AXIS_LEFT_Y))
+	}
+	if c.AxisDigitalValue(AXIS_LEFT_X) != -1 || !c.StickPressed(STICK_LEFT, DIRECTION_LEFT) ||
+		c.StickPressed(STICK_LEFT, DIRECTION_RIGHT) {
+		t.Errorf("Digital value %d of the stick pushed left", c.AxisDigitalValue(AXIS_LEFT_X))
+	}
+
+	other.SetStick(STICK_LEFT, 0, 0)
+	c.Update()
+	if !nearly(c.AxisValue(AXIS_LEFT_X), pad.AxisValue(AXIS_LEFT_X)) || c.AxisDigitalValue(AXIS_LEFT_X) != 1 ||
+		!c.StickPressed(STICK_LEFT, DIRECTION_RIGHT) || !c.StickReleased(STICK_LEFT, DIRECTION_LEFT) {
+		t.Errorf("Left stick at %v, digital %d", c.AxisValue(AXIS_LEFT_X), c.AxisDigitalValue(AXIS_LEFT_X))
+	}
+	c.Update()
+	if c.StickPressed(STICK_LEFT, DIRECTION_RIGHT) || c.AxisDigitalValue(AXIS_LEFT_X) != 1 {
+		t.Error("Stick pressed for two frames")
+	}
+
+	pad.SetStick(STICK_LEFT, 0, 0)
+	c.Update()
+	if c.AxisValue(AXIS_LEFT_X) != 0 || !c.StickReleased(STICK_LEFT, DIRECTION_RIGHT) {
+		t.Error("Stick not released")
+	}
+
+	c.Close()
+	if c.Connected() || c.AxisValue(AXIS_TRIGGER_RIGHT) != -1 {
+		t.Error("Not reset when closed")
+	}
+}
@@ -0,0 +1,323 @@
+package input
+
+import (
+	"github.com/markov/gojira2d/pkg/app"
+	"github.com/markov/gojira2d/pkg/graphics"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+type MouseButton int
+
+const (
+	MOUSE_BUTTON_LEFT MouseButton = iota
+	MOUSE_BUTTON_RIGHT
+	MOUSE_BUTTON_MIDDLE
+	MOUSE_BUTTON_4
+	MOUSE_BUTTON_5
+	MOUSE_BUTTON_6
+	MOUSE_BUTTON_7
+	MOUSE_BUTTON_8
+
+	MAX_NUM_MOUSE_BUTTONS = int(glfw.MouseButtonLast) + 1
+)
+
+type CursorMode int
+
+const (
+	// The system cursor, the default
+	CURSOR_NORMAL CursorMode = iota
+	// No cursor over the window
+	CURSOR_HIDDEN
+	// Hidden and locked to the window, for relative motion only. Use Delta
+	CURSOR_CAPTURED
+)
+
+const (
+	// Max seconds between the presses of a double click
+	DefaultDoubleClickTime = 0.3
+	// Max pixels between the presses of a double click
+	DefaultDoubleClickDistance = 4
+)
+
+// MouseController keeps the state of the mouse, updated once per frame like
+// the other controllers:
+//
+//	mouse := &input.MouseController{}
+//	mouse.Open()
+//	...
+//	mouse.Update()
+//	if mouse.ButtonPressed(input.MOUSE_BUTTON_LEFT) {
+//		shoot(mouse.WorldPosition(app.Context))
+//	}
+//
+// The events come from the dispatcher of the app window, see GetDispatcher.
+// A ui.ImmediateGUI attached after it gets the events first, so it can keep
+// its clicks from the controller
+type MouseController struct {
+	window    *glfw.Window
+	connected bool
+
+	buttonsPressed  [MAX_NUM_MOUSE_BUTTONS]bool
+	buttonsReleased [MAX_NUM_MOUSE_BUTTONS]bool
+	buttonsDown     [MAX_NUM_MOUSE_BUTTONS]bool
+	doubleClicked   [MAX_NUM_MOUSE_BUTTONS]bool
+	// Events received since the last update, a press and a release within
+	// a frame are both seen
+	buttonsRaw      [MAX_NUM_MOUSE_BUTTONS]bool
+	pendingPressed  [MAX_NUM_MOUSE_BUTTONS]bool
+	pendingReleased [MAX_NUM_MOUSE_BUTTONS]bool
+	pressTime       [MAX_NUM_MOUSE_BUTTONS]float64
+	pressPosition   [MAX_NUM_MOUSE_BUTTONS]mgl32.Vec2
+
+	// Time and position of the last press, for the double clicks
+	lastClickTime     [MAX_NUM_MOUSE_BUTTONS]float64
+	lastClickPosition [MAX_NUM_MOUSE_BUTTONS]mgl32.Vec2
+	doubleClickTime   float64
+	doubleClickDist   float32
+
+	position    mgl32.Vec2
+	rawPosition mgl32.Vec2
+	delta       mgl32.Vec2
+	scroll      mgl32.Vec2
+	rawScroll   mgl32.Vec2
+	inside      bool
+
+	cursorMode CursorMode
+	cursor     *glfw.Cursor
+
+	listener ListenerID
+
+	// Set by InputPlayback.Mouse, fills the state on update instead of the
+	// window
+	replay func(c *MouseController)
+}
+
+// Open starts listening to the mouse with the dispatcher of the app window
+func (c *MouseController) Open() bool {
+	if c.connected {
+		return true
+	}
+	c.window = app.GetWindow()
+	c.connected = true
+	if c.doubleClickTime == 0 {
+		c.doubleClickTime = DefaultDoubleClickTime
+	}
+	if c.doubleClickDist == 0 {
+		c.doubleClickDist = DefaultDoubleClickDistance
+	}
+	for i := range c.lastClickTime {
+		c.lastClickTime[i] = -1
+	}
+
+	x, y := c.window.GetCursorPos()
+	c.rawPosition = mgl32.Vec2{float32(x), float32(y)}
+	c.position = c.rawPosition
+
+	c.listener = GetDispatcher().AddListener(0, func(e *Event) bool {
+		switch e.Type {
+		case EVENT_CURSOR_POS:
+			c.rawPosition = mgl32.Vec2{float32(e.X), float32(e.Y)}
+		case EVENT_MOUSE_BUTTON:
+			c.buttonEvent(int(e.MouseButton), e.Action == glfw.Press, e.Time)
+		case EVENT_SCROLL:
+			c.rawScroll = c.rawScroll.Add(mgl32.Vec2{float32(e.X), float32(e.Y)})
+		case EVENT_CURSOR_ENTER:
+			c.inside = e.Entered
+		}
+		return false
+	}, EVENT_CURSOR_POS, EVENT_MOUSE_BUTTON, EVENT_SCROLL, EVENT_CURSOR_ENTER)
+	return true
+}
+
+// Close stops listening to the mouse, restoring the cursor
+func (c *MouseController) Close() {
+	if !c.connected {
+		return
+	}
+	if c.replay != nil {
+		c.connected = false
+		return
+	}
+	c.SetCursorMode(CURSOR_NORMAL)
+	c.ResetCursor()
+	GetDispatcher().RemoveListener(c.listener)
+	c.connected = false
+}
+
+// buttonEvent records a press or a release until the next update
+func (c *MouseController) buttonEvent(button int, pressed bool, time float64) {
+	if button < 0 || button >= MAX_NUM_MOUSE_BUTTONS {
+		return
+	}
+	c.buttonsRaw[button] = pressed
+	if pressed {
+		c.pendingPressed[button] = true
+		c.pressTime[button] = time
+		c.pressPosition[button] = c.rawPosition
+	} else {
+		c.pendingReleased[button] = true
+	}
+}
+
+// Update takes the events received since the last update, call it once per
+// frame
+func (c *MouseController) Update() {
+	if !c.connected {
+		return
+	}
+	if c.replay != nil {
+		c.replay(c)
+		return
+	}
+	for i := range c.buttonsRaw {
+		c.buttonsPressed[i] = c.pendingPressed[i]
+		c.buttonsReleased[i] = c.pendingReleased[i]
+		c.buttonsDown[i] = c.buttonsRaw[i]
+		c.pendingPressed[i] = false
+		c.pendingReleased[i] = false
+
+		c.doubleClicked[i] = false
+		if !c.buttonsPressed[i] {
+			continue
+		}
+		if c.lastClickTime[i] >= 0 &&
+			c.pressTime[i]-c.lastClickTime[i] <= c.doubleClickTime &&
+			c.pressPosition[i].Sub(c.lastClickPosition[i]).Len() <= c.doubleClickDist {
+			c.doubleClicked[i] = true
+			// A third click starts a new double click
+			c.lastClickTime[i] = -1
+		} else {
+			c.lastClickTime[i] = c.pressTime[i]
+			c.lastClickPosition[i] = c.pressPosition[i]
+		}
+	}
+
+	c.delta = c.rawPosition.Sub(c.position)
+	c.position = c.rawPosition
+	c.scroll = c.rawScroll
+	c.rawScroll = mgl32.Vec2{}
+}
+
+func (c *MouseController) Connected() bool {
+	return c.connected
+}
+
+func (c *MouseController) ButtonPressed(button MouseButton) bool {
+	if !c.connected || button < 0 || int(button) >= MAX_NUM_MOUSE_BUTTONS {
+		return false
+	}
+	return c.buttonsPressed[button]
+}
+
+func (c *MouseController) ButtonReleased(button MouseButton) bool {
+	if !c.connected || button < 0 || int(button) >= MAX_NUM_MOUSE_BUTTONS {
+		return false
+	}
+	return c.buttonsReleased[button]
+}
+
+func (c *MouseController) ButtonDown(button MouseButton) bool {
+	if !c.connected || button < 0 || int(button) >= MAX_NUM_MOUSE_BUTTONS {
+		return false
+	}
+	return c.buttonsDown[button]
+}
+
+// DoubleClicked returns true if the button was pressed twice in a short time
+// and close enough, on the second press
+func (c *MouseController) DoubleClicked(button MouseButton) bool {
+	if !c.connected || button < 0 || int(button) >= MAX_NUM_MOUSE_BUTTONS {
+		return false
+	}
+	return c.doubleClicked[button]
+}
+
+// SetDoubleClick changes the max seconds and pixels between the presses of
+// a double click
+func (c *MouseController) SetDoubleClick(time float64, distance float32) {
+	c.doubleClickTime = time
+	c.doubleClickDist = distance
+}
+
+// Position returns the position of the cursor in window pixels, with the
+// origin at the top left
+func (c *MouseController) Position() mgl32.Vec2 {
+	return c.position
+}
+
+// WorldPosition returns the position of the cursor in the coordinates of a
+// context, e.g. app.Context for the scene or app.UIContext for the GUI
+func (c *MouseController) WorldPosition(context *graphics.Context) mgl32.Vec2 {
+	if !c.connected || c.window == nil {
+		return mgl32.Vec2{}
+	}
+	width, height := c.window.GetSize()
+	return context.ScreenToWorld(c.position, width, height)
+}
+
+// Delta returns the motion in pixels since the last update
+func (c *MouseController) Delta() mgl32.Vec2 {
+	return c.delta
+}
+
+// Scroll returns the wheel and trackpad scrolling since the last update,
+// y is positive scrolling up
+func (c *MouseController) Scroll() mgl32.Vec2 {
+	return c.scroll
+}
+
+// Inside returns true if the cursor is over the window
+func (c *MouseController) Inside() bool {
+	return c.inside
+}
+
+// SetCursorMode shows, hides or captures the cursor
+func (c *MouseController) SetCursorMode(mode CursorMode) {
+	if !c.connected || c.replay != nil {
+		return
+	}
+	switch mode {
+	case CURSOR_HIDDEN:
+		c.window.SetInputMode(glfw.CursorMode, glfw.CursorHidden)
+	case CURSOR_CAPTURED:
+		c.window.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+	default:
+		c.window.SetInputMode(glfw.CursorMode, glfw.CursorNormal)
+	}
+	c.cursorMode = mode
+	// The captured cursor jumps, no motion is reported for it
+	x, y := c.window.GetCursorPos()
+	c.rawPosition = mgl32.Vec2{float32(x), float32(y)}
+	c.position = c.rawPosition
+}
+
+// CursorMode returns the mode set with SetCursorMode
+func (c *MouseController) CursorMode() CursorMode {
+	return c.cursorMode
+}
+
+// SetCursorImage replaces the system cursor with the content of a texture,
+// hotSpot is the pixel of the texture at the pointer position
+func (c *MouseController) SetCursorImage(texture *graphics.Texture, hotSpot mgl32.Vec2) {
+	if !c.connected || c.replay != nil {
+		return
+	}
+	cursor := glfw.CreateCursor(texture.Image(), int(hotSpot[0]), int(hotSpot[1]))
+	c.window.SetCursor(cursor)
+	if c.cursor != nil {
+		c.cursor.Destroy()
+	}
+	c.cursor = cursor
+}
+
+// ResetCursor restores the system cursor
+func (c *MouseController) ResetCursor() {
+	if !c.connected || c.cursor == nil {
+		return
+	}
+	c.window.SetCursor(nil)
+	c.cursor.Destroy()
+	c.cursor = nil
+}
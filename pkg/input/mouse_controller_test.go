@@ -0,0 +1,68 @@
+package input
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// newTestMouse creates a mouse fed by buttonEvent, without a window
+func newTestMouse() *MouseController {
+	c := &MouseController{connected: true}
+	c.SetDoubleClick(DefaultDoubleClickTime, DefaultDoubleClickDistance)
+	for i := range c.lastClickTime {
+		c.lastClickTime[i] = -1
+	}
+	return c
+}
+
+func TestMouseDoubleClick(t *testing.T) {
+	var tests = []struct {
+		name     string
+		time     float64
+		position mgl32.Vec2
+		double   bool
+	}{
+		{"first", 1, mgl32.Vec2{10, 10}, false},
+		{"second in time", 1.2, mgl32.Vec2{12, 11}, true},
+		{"third starts over", 1.35, mgl32.Vec2{12, 11}, false},
+		{"too late", 1.7, mgl32.Vec2{12, 11}, false},
+		{"too far", 1.8, mgl32.Vec2{20, 11}, false},
+		{"close again", 2.05, mgl32.Vec2{22, 13}, true},
+	}
+	c := newTestMouse()
+	for _, test := range tests {
+		c.rawPosition = test.position
+		c.buttonEvent(int(MOUSE_BUTTON_LEFT), true, test.time)
+		c.buttonEvent(int(MOUSE_BUTTON_LEFT), false, test.time+0.05)
+		c.Update()
+		if c.DoubleClicked(MOUSE_BUTTON_LEFT) != test.double {
+			t.Errorf("%s: double click %v", test.name, !test.double)
+		}
+		// A press and a release in a frame are both seen
+		if !c.ButtonPressed(MOUSE_BUTTON_LEFT) || !c.ButtonReleased(MOUSE_BUTTON_LEFT) || c.ButtonDown(MOUSE_BUTTON_LEFT) {
+			t.Errorf("%s: missed the click", test.name)
+		}
+		c.Update()
+		if c.DoubleClicked(MOUSE_BUTTON_LEFT) || c.ButtonPressed(MOUSE_BUTTON_LEFT) {
+			t.Errorf("%s: clicked on the next update", test.name)
+		}
+	}
+
+	// The buttons are apart
+	c = newTestMouse()
+	c.buttonEvent(int(MOUSE_BUTTON_LEFT), true, 1)
+	c.Update()
+	c.buttonEvent(int(MOUSE_BUTTON_RIGHT), true, 1.1)
+	c.Update()
+	if c.DoubleClicked(MOUSE_BUTTON_RIGHT) || !c.ButtonDown(MOUSE_BUTTON_LEFT) || !c.ButtonDown(MOUSE_BUTTON_RIGHT) {
+		t.Error("Right after left is a double click")
+	}
+
+	// Buttons out of range are never down
+	for _, button := range []MouseButton{-1, MouseButton(MAX_NUM_MOUSE_BUTTONS)} {
+		if c.ButtonPressed(button) || c.ButtonReleased(button) || c.ButtonDown(button) || c.DoubleClicked(button) {
+			t.Errorf("Button %d down", button)
+		}
+	}
+}
@@ -0,0 +1,463 @@
+package input
+
+import (
+	"fmt"
+	"log"
+)
+
+// PlayerSlot is a player of a PlayerInput. It's the controller of the
+// player: it reads the controller the player joined with, nothing is down
+// before joining or while the controller is disconnected. Open, Close and
+// Update do nothing, PlayerInput updates the controllers
+type PlayerSlot struct {
+	index      int
+	controller GameController
+	// The joystick the player joined with, to find it when plugged in again
+	guid         string
+	name         string
+	disconnected bool
+	filter       *AxisFilter
+}
+
+// Index returns the number of the player, from 0
+func (s *PlayerSlot) Index() int {
+	return s.index
+}
+
+// Joined returns true if the player has a controller, even disconnected
+func (s *PlayerSlot) Joined() bool {
+	return s.controller != nil
+}
+
+// Disconnected returns true while the controller of the player is unplugged
+func (s *PlayerSlot) Disconnected() bool {
+	return s.disconnected
+}
+
+// Controller returns the controller the player joined with, nil if none
+func (s *PlayerSlot) Controller() GameController {
+	return s.controller
+}
+
+func (s *PlayerSlot) active() bool {
+	return s.controller != nil && !s.disconnected
+}
+
+func (s *PlayerSlot) Connected() bool {
+	return s.active() && s.controller.Connected()
+}
+
+func (s *PlayerSlot) Open(_ int) bool {
+	return true
+}
+
+func (s *PlayerSlot) Close() {
+}
+
+func (s *PlayerSlot) Update() {
+}
+
+func (s *PlayerSlot) NumButtons() int {
+	if !s.active() {
+		return 0
+	}
+	return s.controller.NumButtons()
+}
+
+func (s *PlayerSlot) NumAxes() int {
+	if !s.active() {
+		return 0
+	}
+	return s.controller.NumAxes()
+}
+
+func (s *PlayerSlot) ButtonPressed(button ControllerButton) bool {
+	return s.active() && s.controller.ButtonPressed(button)
+}
+
+func (s *PlayerSlot) ButtonReleased(button ControllerButton) bool {
+	return s.active() && s.controller.ButtonReleased(button)
+}
+
+func (s *PlayerSlot) ButtonDown(button ControllerButton) bool {
+	return s.active() && s.controller.ButtonDown(button)
+}
+
+func (s *PlayerSlot) AxisValue(axis ControllerAxis) float32 {
+	if !s.active() {
+		if axis == AXIS_TRIGGER_LEFT || axis == AXIS_TRIGGER_RIGHT {
+			return -1
+		}
+		return 0
+	}
+	return s.controller.AxisValue(axis)
+}
+
+func (s *PlayerSlot) AxisDigitalValue(axis ControllerAxis) int {
+	if !s.active() {
+		return 0
+	}
+	return s.controller.AxisDigitalValue(axis)
+}
+
+func (s *PlayerSlot) StickPressed(stick Stick, direction Direction) bool {
+	return s.active() && s.controller.StickPressed(stick, direction)
+}
+
+func (s *PlayerSlot) StickReleased(stick Stick, direction Direction) bool {
+	return s.active() && s.controller.StickReleased(stick, direction)
+}
+
+// SetAxisFilter changes the filter of the controller of the player, kept
+// for the controllers joining later
+func (s *PlayerSlot) SetAxisFilter(filter *AxisFilter) {
+	s.filter = filter
+	if s.controller != nil {
+		s.controller.SetAxisFilter(filter)
+	}
+}
+
+func (s *PlayerSlot) SetMapping(mapping *GameControllerMapping) {
+	if s.controller != nil {
+		s.controller.SetMapping(mapping)
+	}
+}
+
+func (s *PlayerSlot) Description() string {
+	if s.controller == nil {
+		return fmt.Sprintf("Player %d: no controller", s.index+1)
+	}
+	return fmt.Sprintf("Player %d: %s", s.index+1, s.controller.Description())
+}
+
+// PlayerInput gives the controllers to the players: a player joins pressing
+// start on a joystick or a keyboard layout added with AddKeyboard, and gets
+// the same slot back when the joystick is plugged in again, found by GUID:
+//
+//	players := input.NewPlayerInput(4)
+//	players.AddKeyboard(input.MappingKeyboardWASD)
+//	players.SetOnDisconnect(func(slot *input.PlayerSlot) { pauseMenu.Show() })
+//	...
+//	players.Update()
+//	if !players.Paused() {
+//		for i, p := range heroes {
+//			p.Update(players.Slot(i))
+//		}
+//	}
+//
+// The joysticks are opened for all the device indices, the ones already in
+// JoystickControllers are used as they are
+type PlayerInput struct {
+	slots      []*PlayerSlot
+	keyboards  []*KeyboardController
+	joysticks  []*JoystickController
+	others     []GameController
+	joinButton ControllerButton
+	joining    bool
+
+	onJoin       func(slot *PlayerSlot)
+	onLeave      func(slot *PlayerSlot)
+	onDisconnect func(slot *PlayerSlot)
+	onReconnect  func(slot *PlayerSlot)
+}
+
+// NewPlayerInput creates the slots of a number of players, joining with
+// BUTTON_START
+func NewPlayerInput(numPlayers int) *PlayerInput {
+	p := &PlayerInput{joinButton: BUTTON_START, joining: true}
+	for i := 0; i < numPlayers; i++ {
+		p.slots = append(p.slots, &PlayerSlot{index: i})
+	}
+	for i := 0; i <= int(MAX_NUM_JOYSTICKS); i++ {
+		joystick := JoystickControllers[i]
+		if joystick == nil {
+			joystick = &JoystickController{}
+			if !joystick.Open(i) {
+				continue
+			}
+		}
+		p.joysticks = append(p.joysticks, joystick)
+	}
+	return p
+}
+
+// AddKeyboard adds a keyboard layout players can join with
+func (p *PlayerInput) AddKeyboard(layout *GameControllerMapping) *KeyboardController {
+	keyboard := &KeyboardController{}
+	keyboard.SetMapping(layout)
+	keyboard.Open(0)
+	p.keyboards = append(p.keyboards, keyboard)
+	return keyboard
+}
+
+// SetJoinButton changes the button pressed to join, BUTTON_START by default
+func (p *PlayerInput) SetJoinButton(button ControllerButton) {
+	p.joinButton = button
+}
+
+// SetJoining lets the players join or not, e.g. only in the lobby. The
+// players reconnect anyway
+func (p *PlayerInput) SetJoining(joining bool) {
+	p.joining = joining
+}
+
+func (p *PlayerInput) SetOnJoin(onJoin func(slot *PlayerSlot)) {
+	p.onJoin = onJoin
+}
+
+func (p *PlayerInput) SetOnLeave(onLeave func(slot *PlayerSlot)) {
+	p.onLeave = onLeave
+}
+
+// SetOnDisconnect sets the function called when the controller of a player
+// is unplugged, the game pauses until it's back
+func (p *PlayerInput) SetOnDisconnect(onDisconnect func(slot *PlayerSlot)) {
+	p.onDisconnect = onDisconnect
+}
+
+func (p *PlayerInput) SetOnReconnect(onReconnect func(slot *PlayerSlot)) {
+	p.onReconnect = onReconnect
+}
+
+func (p *PlayerInput) NumPlayers() int {
+	return len(p.slots)
+}
+
+// NumJoined returns the number of players with a controller
+func (p *PlayerInput) NumJoined() int {
+	n := 0
+	for _, s := range p.slots {
+		if s.Joined() {
+			n++
+		}
+	}
+	return n
+}
+
+// Slot returns a player, nil if out of range
+func (p *PlayerInput) Slot(index int) *PlayerSlot {
+	if index < 0 || index >= len(p.slots) {
+		return nil
+	}
+	return p.slots[index]
+}
+
+// Paused returns true while a player has the controller unplugged
+func (p *PlayerInput) Paused() bool {
+	for _, s := range p.slots {
+		if s.disconnected {
+			return true
+		}
+	}
+	return false
+}
+
+// Disconnected returns the players with the controller unplugged
+func (p *PlayerInput) Disconnected() []*PlayerSlot {
+	var slots []*PlayerSlot
+	for _, s := range p.slots {
+		if s.disconnected {
+			slots = append(slots, s)
+		}
+	}
+	return slots
+}
+
+// Assign gives a controller to a player, opened if needed unless it's a
+// joystick, e.g. for a game with fixed players. A controller assigned to
+// another player leaves it
+func (p *PlayerInput) Assign(index int, controller GameController) {
+	slot := p.Slot(index)
+	if slot == nil || controller == nil {
+		return
+	}
+	if other := p.owner(controller); other != nil && other != slot {
+		p.Leave(other.index)
+	}
+	// A joystick is opened again at its index when plugged in
+	if _, joystick := controller.(*JoystickController); !joystick && !controller.Connected() {
+		controller.Open(0)
+	}
+	if !p.known(controller) {
+		p.others = append(p.others, controller)
+	}
+	p.join(slot, controller)
+}
+
+// Leave removes the controller of a player, the slot is free to join
+func (p *PlayerInput) Leave(index int) {
+	slot := p.Slot(index)
+	if slot == nil || slot.controller == nil {
+		return
+	}
+	slot.controller = nil
+	slot.guid = ""
+	slot.name = ""
+	slot.disconnected = false
+	if p.onLeave != nil {
+		p.onLeave(slot)
+	}
+}
+
+// Close closes the keyboards added and the assigned controllers, the
+// joysticks stay open in JoystickControllers
+func (p *PlayerInput) Close() {
+	for _, k := range p.keyboards {
+		k.Close()
+	}
+	for _, c := range p.others {
+		c.Close()
+	}
+	for _, s := range p.slots {
+		s.controller = nil
+		s.disconnected = false
+	}
+}
+
+func (p *PlayerInput) known(controller GameController) bool {
+	for _, j := range p.joysticks {
+		if GameController(j) == controller {
+			return true
+		}
+	}
+	for _, k := range p.keyboards {
+		if GameController(k) == controller {
+			return true
+		}
+	}
+	for _, c := range p.others {
+		if c == controller {
+			return true
+		}
+	}
+	return false
+}
+
+// owner returns the player using a controller, nil if none. A joystick of
+// a disconnected player is free, another pad can be plugged in its place
+func (p *PlayerInput) owner(controller GameController) *PlayerSlot {
+	for _, s := range p.slots {
+		if s.controller == controller && !s.disconnected {
+			return s
+		}
+	}
+	return nil
+}
+
+// setController gives a controller to a player, remembering the joystick
+func (p *PlayerInput) setController(slot *PlayerSlot, controller GameController) {
+	slot.controller = controller
+	slot.disconnected = false
+	slot.guid = ""
+	slot.name = ""
+	if joystick, ok := controller.(*JoystickController); ok {
+		slot.guid = joystick.GUID()
+		slot.name = joystick.Name()
+	}
+	if slot.filter != nil {
+		controller.SetAxisFilter(slot.filter)
+	}
+}
+
+func (p *PlayerInput) join(slot *PlayerSlot, controller GameController) {
+	p.setController(slot, controller)
+	log.Printf("Player %d: joined. %s", slot.index+1, controller.Description())
+	if p.onJoin != nil {
+		p.onJoin(slot)
+	}
+}
+
+func (p *PlayerInput) reconnect(slot *PlayerSlot, controller GameController) {
+	p.setController(slot, controller)
+	log.Printf("Player %d: controller reconnected. %s", slot.index+1, controller.Description())
+	if p.onReconnect != nil {
+		p.onReconnect(slot)
+	}
+}
+
+// Update updates the controllers, then joins the players pressing the join
+// button and reconnects the ones plugged in again. Call it once per frame
+// before reading the slots
+func (p *PlayerInput) Update() {
+	for _, j := range p.joysticks {
+		j.Update()
+	}
+	for _, k := range p.keyboards {
+		k.Update()
+	}
+	for _, c := range p.others {
+		c.Update()
+	}
+
+	// Players losing or getting back their controller. The joysticks are
+	// found by GUID below, another pad can be plugged in at the same index
+	for _, s := range p.slots {
+		if s.controller == nil {
+			continue
+		}
+		connected := s.controller.Connected()
+		if !s.disconnected && !connected {
+			s.disconnected = true
+			log.Printf("Player %d: controller disconnected", s.index+1)
+			if p.onDisconnect != nil {
+				p.onDisconnect(s)
+			}
+		} else if _, joystick := s.controller.(*JoystickController); s.disconnected && connected && !joystick {
+			p.reconnect(s, s.controller)
+		}
+	}
+
+	for _, j := range p.joysticks {
+		if !j.Connected() || p.owner(j) != nil {
+			continue
+		}
+		// The joystick of a player plugged in again, maybe at another index
+		if s := p.waiting(j); s != nil {
+			p.reconnect(s, j)
+			continue
+		}
+		p.tryJoin(j)
+	}
+	for _, k := range p.keyboards {
+		if p.owner(k) == nil {
+			p.tryJoin(k)
+		}
+	}
+}
+
+// waiting returns the disconnected player of a joystick
+func (p *PlayerInput) waiting(joystick *JoystickController) *PlayerSlot {
+	for _, s := range p.slots {
+		if !s.disconnected {
+			continue
+		}
+		if s.guid != "" && s.guid == joystick.GUID() {
+			return s
+		}
+		// No GUID off Linux, the name is the best guess
+		if s.guid == "" && s.name != "" && s.name == joystick.Name() {
+			return s
+		}
+	}
+	return nil
+}
+
+// tryJoin joins a controller pressing the join button to a free slot, or to
+// the one of a disconnected player taking another controller
+func (p *PlayerInput) tryJoin(controller GameController) {
+	if !p.joining || !controller.ButtonPressed(p.joinButton) {
+		return
+	}
+	for _, s := range p.slots {
+		if s.controller == nil {
+			p.join(s, controller)
+			return
+		}
+	}
+	for _, s := range p.slots {
+		if s.disconnected {
+			p.reconnect(s, controller)
+			return
+		}
+	}
+}
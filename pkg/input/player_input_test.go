@@ -0,0 +1,203 @@
+package input
+
+import (
+	"testing"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+// newTestPlayerInput creates a PlayerInput of the joysticks given, without
+// opening the ones plugged in
+func newTestPlayerInput(numPlayers int, joysticks ...*JoystickController) (*PlayerInput, *[]string) {
+	p := &PlayerInput{joinButton: BUTTON_START, joining: true, joysticks: joysticks}
+	for i := 0; i < numPlayers; i++ {
+		p.slots = append(p.slots, &PlayerSlot{index: i})
+	}
+	var events []string
+	p.SetOnJoin(func(s *PlayerSlot) { events = append(events, "join") })
+	p.SetOnLeave(func(s *PlayerSlot) { events = append(events, "leave") })
+	p.SetOnDisconnect(func(s *PlayerSlot) { events = append(events, "disconnect") })
+	p.SetOnReconnect(func(s *PlayerSlot) { events = append(events, "reconnect") })
+	return p, &events
+}
+
+// fakeJoystick returns a joystick plugged in at an index no test opens
+func fakeJoystick(guid, name string) *JoystickController {
+	return &JoystickController{
+		connected: true,
+		joystick:  glfw.JoystickLast,
+		guid:      guid,
+		name:      name,
+		mapping:   &MappingXBox360,
+
+		buttonsDown:     make([]bool, NUM_CONTROLLER_BUTTONS),
+		buttonsPressed:  make([]bool, NUM_CONTROLLER_BUTTONS),
+		buttonsReleased: make([]bool, NUM_CONTROLLER_BUTTONS),
+	}
+}
+
+func pressStart(pad *fakeController) {
+	pad.tap(BUTTON_START)
+}
+
+func checkEvents(t *testing.T, events *[]string, expected ...string) {
+	t.Helper()
+	if len(*events) != len(expected) {
+		t.Errorf("Events %v, expecting %v", *events, expected)
+	} else {
+		for i := range expected {
+			if (*events)[i] != expected[i] {
+				t.Errorf("Events %v, expecting %v", *events, expected)
+				break
+			}
+		}
+	}
+	*events = nil
+}
+
+func TestPlayerInputJoin(t *testing.T) {
+	p, events := newTestPlayerInput(2)
+	first, second, third := newFakeController(), newFakeController(), newFakeController()
+
+	p.tryJoin(first)
+	if p.NumJoined() != 0 {
+		t.Error("Joined without pressing start")
+	}
+	pressStart(first)
+	p.tryJoin(first)
+	pressStart(second)
+	p.tryJoin(second)
+	pressStart(third)
+	p.tryJoin(third)
+	checkEvents(t, events, "join", "join")
+	if p.Slot(0).Controller() != first || p.Slot(1).Controller() != second || p.NumJoined() != 2 {
+		t.Error("Wrong players joined")
+	}
+
+	first.set(BUTTON_A, true)
+	first.Update()
+	if !p.Slot(0).ButtonDown(BUTTON_A) || p.Slot(1).ButtonDown(BUTTON_A) {
+		t.Error("Players don't read their controller")
+	}
+
+	p.Leave(0)
+	p.Leave(0)
+	checkEvents(t, events, "leave")
+	if p.Slot(0).Joined() || p.Slot(0).ButtonDown(BUTTON_A) || p.NumJoined() != 1 {
+		t.Error("Joined after leaving")
+	}
+
+	p.SetJoining(false)
+	pressStart(third)
+	p.tryJoin(third)
+	p.SetJoining(true)
+	p.SetJoinButton(BUTTON_A)
+	pressStart(third)
+	p.tryJoin(third)
+	checkEvents(t, events)
+	third.tap(BUTTON_A)
+	p.tryJoin(third)
+	checkEvents(t, events, "join")
+	if p.Slot(0).Controller() != third {
+		t.Error("Not joined in the free slot")
+	}
+
+	// Assigning the controller of another player moves it
+	p.Assign(1, third)
+	checkEvents(t, events, "leave", "join")
+	if p.Slot(0).Joined() || p.Slot(1).Controller() != third {
+		t.Error("Controller not moved")
+	}
+}
+
+func TestPlayerInputDisconnect(t *testing.T) {
+	p, events := newTestPlayerInput(2)
+	first, second := newFakeController(), newFakeController()
+	first.Close()
+	p.Assign(0, first)
+	p.Assign(1, second)
+	checkEvents(t, events, "join", "join")
+	if !first.Connected() {
+		t.Error("Assigned controller not opened")
+	}
+
+	first.Close()
+	p.Update()
+	p.Update()
+	checkEvents(t, events, "disconnect")
+	if !p.Paused() || !p.Slot(0).Disconnected() || !p.Slot(0).Joined() || len(p.Disconnected()) != 1 {
+		t.Error("Not paused")
+	}
+	first.set(BUTTON_A, true)
+	first.Update()
+	if p.Slot(0).ButtonDown(BUTTON_A) || p.Slot(0).Connected() {
+		t.Error("Disconnected player reads the controller")
+	}
+
+	first.Open(0)
+	p.Update()
+	checkEvents(t, events, "reconnect")
+	if p.Paused() || p.Slot(0).Controller() != first || !p.Slot(0).ButtonDown(BUTTON_A) {
+		t.Error("Not reconnected")
+	}
+
+	// Another controller pressing start takes the place of the disconnected
+	third := newFakeController()
+	second.Close()
+	p.Update()
+	pressStart(third)
+	p.tryJoin(third)
+	checkEvents(t, events, "disconnect", "reconnect")
+	if p.Paused() || p.Slot(1).Controller() != third || p.NumJoined() != 2 {
+		t.Error("Disconnected slot not taken")
+	}
+}
+
+func TestPlayerInputJoystickReconnect(t *testing.T) {
+	var tests = []struct {
+		name        string
+		guid, other string
+		reconnected bool
+	}{
+		{"Same GUID", "030000005e0400008e02000010010000", "030000005e0400008e02000010010000", true},
+		{"Other GUID", "030000005e0400008e02000010010000", "030000004c050000c405000011010000", false},
+		{"Same name", "", "", true},
+	}
+	for _, test := range tests {
+		joystick := fakeJoystick(test.guid, "Pad")
+		// Plugged in again at another index
+		other := fakeJoystick(test.other, "Pad")
+		other.connected = false
+		p, events := newTestPlayerInput(1, joystick, other)
+		p.Assign(0, joystick)
+		checkEvents(t, events, "join")
+
+		joystick.pluggedOut()
+		p.Update()
+		checkEvents(t, events, "disconnect")
+
+		other.connected = true
+		p.Update()
+		if test.reconnected {
+			checkEvents(t, events, "reconnect")
+			if p.Paused() || p.Slot(0).Controller() != other {
+				t.Errorf("%s: not reconnected", test.name)
+			}
+		} else {
+			checkEvents(t, events)
+			if !p.Paused() || p.Slot(0).Controller() != joystick {
+				t.Errorf("%s: reconnected another joystick", test.name)
+			}
+		}
+	}
+}
+
+func TestPlayerInputAssignJoystick(t *testing.T) {
+	joystick := &JoystickController{joystick: glfw.JoystickLast}
+	p, events := newTestPlayerInput(1)
+	p.Assign(0, joystick)
+	checkEvents(t, events, "join")
+	if joystick.Connected() || JoystickControllers[0] == joystick {
+		t.Error("Unplugged joystick opened at index 0")
+	}
+}
@@ -0,0 +1,191 @@
+package input
+
+import (
+	"fmt"
+)
+
+// VirtualController is a controller driven by code, for the tests, the bots
+// and the touch screens. The buttons and the axes set take effect on
+// Update, pressed and released for a frame like the ones of a joystick:
+//
+//	bot := input.NewVirtualController("bot")
+//	bot.SetStick(input.STICK_LEFT, 1, 0)
+//	bot.Tap(input.BUTTON_A)
+//	bot.Update()
+//	bot.ButtonPressed(input.BUTTON_A) // true, released on the next update
+type VirtualController struct {
+	name            string
+	connected       bool
+	buttonsRaw      [NUM_CONTROLLER_BUTTONS]bool
+	buttonsDown     [NUM_CONTROLLER_BUTTONS]bool
+	buttonsPressed  [NUM_CONTROLLER_BUTTONS]bool
+	buttonsReleased [NUM_CONTROLLER_BUTTONS]bool
+	// Updates left before releasing a button, 0 if held
+	holdFrames [NUM_CONTROLLER_BUTTONS]int
+	axesRaw    [NUM_CONTROLLER_AXES]float32
+	analog     axisState
+}
+
+// NewVirtualController creates a connected controller, nothing pushed
+func NewVirtualController(name string) *VirtualController {
+	c := &VirtualController{name: name, connected: true}
+	c.axesRaw[AXIS_TRIGGER_LEFT] = -1
+	c.axesRaw[AXIS_TRIGGER_RIGHT] = -1
+	c.analog.reset()
+	return c
+}
+
+// Open connects the controller, like plugging it in
+func (c *VirtualController) Open(_ int) bool {
+	c.connected = true
+	return true
+}
+
+// Close disconnects the controller, releasing everything
+func (c *VirtualController) Close() {
+	c.connected = false
+	c.ReleaseAll()
+	c.buttonsDown = [NUM_CONTROLLER_BUTTONS]bool{}
+	c.buttonsPressed = [NUM_CONTROLLER_BUTTONS]bool{}
+	c.buttonsReleased = [NUM_CONTROLLER_BUTTONS]bool{}
+	c.analog.reset()
+}
+
+// SetButton holds or releases a button
+func (c *VirtualController) SetButton(button ControllerButton, down bool) {
+	if button < 0 || int(button) >= NUM_CONTROLLER_BUTTONS {
+		return
+	}
+	c.buttonsRaw[button] = down
+	c.holdFrames[button] = 0
+}
+
+// Press holds a button until released
+func (c *VirtualController) Press(button ControllerButton) {
+	c.SetButton(button, true)
+}
+
+func (c *VirtualController) Release(button ControllerButton) {
+	c.SetButton(button, false)
+}
+
+// HoldFor holds a button for a number of updates, then releases it
+func (c *VirtualController) HoldFor(button ControllerButton, frames int) {
+	if button < 0 || int(button) >= NUM_CONTROLLER_BUTTONS || frames <= 0 {
+		return
+	}
+	c.buttonsRaw[button] = true
+	c.holdFrames[button] = frames
+}
+
+// Tap presses a button for one update
+func (c *VirtualController) Tap(button ControllerButton) {
+	c.HoldFor(button, 1)
+}
+
+// SetAxis sets the raw value of an axis, between -1 and 1. The triggers are
+// -1 released. The axis filter is applied on update
+func (c *VirtualController) SetAxis(axis ControllerAxis, value float32) {
+	if axis < 0 || int(axis) >= NUM_CONTROLLER_AXES {
+		return
+	}
+	c.axesRaw[axis] = value
+}
+
+// SetStick sets both the axes of a stick, y is down
+func (c *VirtualController) SetStick(stick Stick, x, y float32) {
+	xAxis, yAxis := stickAxes(stick)
+	c.axesRaw[xAxis] = x
+	c.axesRaw[yAxis] = y
+}
+
+// ReleaseAll releases the buttons, the sticks and the triggers
+func (c *VirtualController) ReleaseAll() {
+	c.buttonsRaw = [NUM_CONTROLLER_BUTTONS]bool{}
+	c.holdFrames = [NUM_CONTROLLER_BUTTONS]int{}
+	c.axesRaw = [NUM_CONTROLLER_AXES]float32{}
+	c.axesRaw[AXIS_TRIGGER_LEFT] = -1
+	c.axesRaw[AXIS_TRIGGER_RIGHT] = -1
+}
+
+// Update takes the buttons and the axes set since the last update
+func (c *VirtualController) Update() {
+	if !c.connected {
+		return
+	}
+	for i, isDown := range c.buttonsRaw {
+		c.buttonsPressed[i] = isDown && !c.buttonsDown[i]
+		c.buttonsReleased[i] = !isDown && c.buttonsDown[i]
+		c.buttonsDown[i] = isDown
+		if c.holdFrames[i] > 0 {
+			c.holdFrames[i]--
+			if c.holdFrames[i] == 0 {
+				c.buttonsRaw[i] = false
+			}
+		}
+	}
+	c.analog.update(c.axesRaw)
+}
+
+func (c *VirtualController) Connected() bool {
+	return c.connected
+}
+
+func (c *VirtualController) NumButtons() int {
+	return NUM_CONTROLLER_BUTTONS
+}
+
+func (c *VirtualController) NumAxes() int {
+	return NUM_CONTROLLER_AXES
+}
+
+func (c *VirtualController) ButtonPressed(button ControllerButton) bool {
+	if !c.connected || button < 0 || int(button) >= NUM_CONTROLLER_BUTTONS {
+		return false
+	}
+	return c.buttonsPressed[button]
+}
+
+func (c *VirtualController) ButtonReleased(button ControllerButton) bool {
+	if !c.connected || button < 0 || int(button) >= NUM_CONTROLLER_BUTTONS {
+		return false
+	}
+	return c.buttonsReleased[button]
+}
+
+func (c *VirtualController) ButtonDown(button ControllerButton) bool {
+	if !c.connected || button < 0 || int(button) >= NUM_CONTROLLER_BUTTONS {
+		return false
+	}
+	return c.buttonsDown[button]
+}
+
+func (c *VirtualController) AxisValue(axis ControllerAxis) float32 {
+	return c.analog.value(axis)
+}
+
+func (c *VirtualController) AxisDigitalValue(axis ControllerAxis) int {
+	return c.analog.digitalValue(axis)
+}
+
+func (c *VirtualController) StickPressed(stick Stick, direction Direction) bool {
+	return c.connected && c.analog.stickPressed(stick, direction)
+}
+
+func (c *VirtualController) StickReleased(stick Stick, direction Direction) bool {
+	return c.connected && c.analog.stickReleased(stick, direction)
+}
+
+// SetAxisFilter changes the filter of the axes set, DefaultAxisFilter if nil
+func (c *VirtualController) SetAxisFilter(filter *AxisFilter) {
+	c.analog.filter = filter
+}
+
+// SetMapping does nothing, the buttons set are already the ones of the
+// controller
+func (c *VirtualController) SetMapping(_ *GameControllerMapping) {
+}
+
+func (c *VirtualController) Description() string {
+	return fmt.Sprintf("virtual:'%s' buttons:%d axes:%d", c.name, NUM_CONTROLLER_BUTTONS, NUM_CONTROLLER_AXES)
+}
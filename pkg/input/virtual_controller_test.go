@@ -0,0 +1,118 @@
+package input
+
+import (
+	"testing"
+)
+
+// buttonEdges returns the pressed, down and released of a button
+func buttonEdges(c GameController, button ControllerButton) [3]bool {
+	return [3]bool{c.ButtonPressed(button), c.ButtonDown(button), c.ButtonReleased(button)}
+}
+
+var (
+	edgeUp       = [3]bool{false, false, false}
+	edgePressed  = [3]bool{true, true, false}
+	edgeHeld     = [3]bool{false, true, false}
+	edgeReleased = [3]bool{false, false, true}
+)
+
+func TestVirtualControllerButtons(t *testing.T) {
+	var tests = []struct {
+		name   string
+		input  func(c *VirtualController)
+		frames [][3]bool
+	}{
+		{"Tap", func(c *VirtualController) { c.Tap(BUTTON_A) },
+			[][3]bool{edgePressed, edgeReleased, edgeUp}},
+		{"HoldFor", func(c *VirtualController) { c.HoldFor(BUTTON_A, 3) },
+			[][3]bool{edgePressed, edgeHeld, edgeHeld, edgeReleased, edgeUp}},
+		{"HoldFor no frames", func(c *VirtualController) { c.HoldFor(BUTTON_A, 0) },
+			[][3]bool{edgeUp, edgeUp}},
+		{"Press", func(c *VirtualController) { c.Press(BUTTON_A) },
+			[][3]bool{edgePressed, edgeHeld, edgeHeld}},
+		{"Press twice", func(c *VirtualController) { c.Press(BUTTON_A); c.Press(BUTTON_A) },
+			[][3]bool{edgePressed, edgeHeld}},
+		{"Press and release", func(c *VirtualController) { c.Press(BUTTON_A); c.Release(BUTTON_A) },
+			[][3]bool{edgeUp, edgeUp}},
+		{"Press hold", func(c *VirtualController) { c.HoldFor(BUTTON_A, 1); c.Press(BUTTON_A) },
+			[][3]bool{edgePressed, edgeHeld, edgeHeld}},
+		{"Other button", func(c *VirtualController) { c.Tap(BUTTON_B) },
+			[][3]bool{edgeUp, edgeUp}},
+	}
+	for _, test := range tests {
+		c := NewVirtualController("test")
+		test.input(c)
+		for i, expected := range test.frames {
+			c.Update()
+			if edges := buttonEdges(c, BUTTON_A); edges != expected {
+				t.Errorf("%s: frame %d pressed, down, released %v, expecting %v", test.name, i, edges, expected)
+			}
+		}
+	}
+}
+
+func TestVirtualControllerRelease(t *testing.T) {
+	c := NewVirtualController("test")
+	c.Press(BUTTON_A)
+	if c.ButtonDown(BUTTON_A) {
+		t.Error("Down before Update")
+	}
+	c.Update()
+	c.Update()
+	c.Release(BUTTON_A)
+	if edges := buttonEdges(c, BUTTON_A); edges != edgeHeld {
+		t.Errorf("Released before Update: %v", edges)
+	}
+	c.Update()
+	if edges := buttonEdges(c, BUTTON_A); edges != edgeReleased {
+		t.Errorf("Not released: %v", edges)
+	}
+
+	c.Press(BUTTON_A)
+	c.SetStick(STICK_LEFT, 1, 0)
+	c.Update()
+	c.ReleaseAll()
+	c.Update()
+	if edges := buttonEdges(c, BUTTON_A); edges != edgeReleased || c.AxisValue(AXIS_LEFT_X) != 0 ||
+		!c.StickReleased(STICK_LEFT, DIRECTION_RIGHT) || c.AxisValue(AXIS_TRIGGER_LEFT) != -1 {
+		t.Error("Not all released")
+	}
+
+	c.Press(BUTTON_A)
+	c.Update()
+	c.Close()
+	c.Update()
+	if c.Connected() || c.ButtonDown(BUTTON_A) || c.ButtonReleased(BUTTON_A) {
+		t.Error("Closed controller down")
+	}
+	c.Open(0)
+	c.Update()
+	if c.ButtonDown(BUTTON_A) {
+		t.Error("Press kept after Close")
+	}
+}
+
+func TestVirtualControllerAxes(t *testing.T) {
+	c := NewVirtualController("test")
+	c.Update()
+	if c.AxisValue(AXIS_TRIGGER_RIGHT) != -1 || c.AxisValue(AXIS_LEFT_Y) != 0 {
+		t.Error("Axes not at rest")
+	}
+	c.SetStick(STICK_RIGHT, 0, -1)
+	c.SetAxis(AXIS_TRIGGER_RIGHT, 1)
+	c.SetAxis(ControllerAxis(NUM_CONTROLLER_AXES), 1)
+	if c.AxisValue(AXIS_RIGHT_Y) != 0 {
+		t.Error("Axis moved before Update")
+	}
+	c.Update()
+	if c.AxisValue(AXIS_RIGHT_Y) != -1 || c.AxisDigitalValue(AXIS_RIGHT_Y) != -1 || c.AxisValue(AXIS_TRIGGER_RIGHT) != 1 {
+		t.Errorf("Right stick at %v, trigger at %v", c.AxisValue(AXIS_RIGHT_Y), c.AxisValue(AXIS_TRIGGER_RIGHT))
+	}
+	if !c.StickPressed(STICK_RIGHT, DIRECTION_UP) || c.StickPressed(STICK_LEFT, DIRECTION_UP) {
+		t.Error("Stick not pressed up")
+	}
+	c.Update()
+	if c.StickPressed(STICK_RIGHT, DIRECTION_UP) || c.AxisDigitalValue(AXIS_RIGHT_Y) != -1 {
+		t.Error("Stick pressed for two frames")
+	}
+}
@@ -0,0 +1,124 @@
+package ui
+
+import (
+	"image"
+
+	g "github.com/markov/gojira2d/pkg/graphics"
+)
+
+// Empty pixels between the glyphs of an atlas
+const atlasGutter = 1
+
+// glyphAtlas packs glyph images in rows (shelves) of texture pages. A page
+// starts small and doubles its height up to maxHeight, then a new page is
+// added. Textures are uploaded lazily, when the page is used for drawing
+type glyphAtlas struct {
+	width         int
+	initialHeight int
+	maxHeight     int
+	pages         []*atlasPage
+}
+
+type atlasPage struct {
+	image       *image.RGBA
+	texture     *g.Texture
+	dirty       bool
+	shelfX      int
+	shelfY      int
+	shelfHeight int
+}
+
+func newGlyphAtlas(width, initialHeight, maxHeight int) *glyphAtlas {
+	if initialHeight > maxHeight {
+		initialHeight = maxHeight
+	}
+	return &glyphAtlas{width: width, initialHeight: initialHeight, maxHeight: maxHeight}
+}
+
+func (a *glyphAtlas) addPage() *atlasPage {
+	p := &atlasPage{image: image.NewRGBA(image.Rect(0, 0, a.width, a.initialHeight))}
+	a.pages = append(a.pages, p)
+	return p
+}
+
+// pageHeight returns the current height of a page
+func (a *glyphAtlas) pageHeight(page int) int {
+	return a.pages[page].image.Bounds().Dy()
+}
+
+// insert reserves a width x height area. grown is true if the page had to
+// be resized, which invalidates the texture coordinates of its glyphs.
+// ok is false if the area doesn't fit in an empty page
+func (a *glyphAtlas) insert(width, height int) (page, x, y int, grown, ok bool) {
+	if width > a.width || height > a.maxHeight {
+		return 0, 0, 0, false, false
+	}
+	if len(a.pages) == 0 {
+		a.addPage()
+	}
+	p := a.pages[len(a.pages)-1]
+	if p.shelfX+width > a.width {
+		// Start a new shelf
+		p.shelfY += p.shelfHeight
+		p.shelfX = 0
+		p.shelfHeight = 0
+	}
+	if p.shelfY+height > a.maxHeight {
+		p = a.addPage()
+	}
+	for p.shelfY+height > p.image.Bounds().Dy() {
+		a.grow(p)
+		grown = true
+	}
+
+	page = len(a.pages) - 1
+	x, y = p.shelfX, p.shelfY
+	p.shelfX += width + atlasGutter
+	if height+atlasGutter > p.shelfHeight {
+		p.shelfHeight = height + atlasGutter
+	}
+	return page, x, y, grown, true
+}
+
+// grow doubles the height of a page, up to maxHeight
+func (a *glyphAtlas) grow(p *atlasPage) {
+	height := p.image.Bounds().Dy() * 2
+	if height > a.maxHeight {
+		height = a.maxHeight
+	}
+	grown := image.NewRGBA(image.Rect(0, 0, a.width, height))
+	copy(grown.Pix, p.image.Pix)
+	p.image = grown
+	p.dirty = true
+}
+
+// draw copies a single channel image in the alpha channel of a page
+func (a *glyphAtlas) draw(page, x, y, width, height int, alpha []uint8) {
+	p := a.pages[page]
+	for row := 0; row < height; row++ {
+		offset := p.image.PixOffset(x, y+row)
+		for column := 0; column < width; column++ {
+			pixel := p.image.Pix[offset+column*4 : offset+column*4+4]
+			pixel[0], pixel[1], pixel[2] = 255, 255, 255
+			pixel[3] = alpha[row*width+column]
+		}
+	}
+	p.dirty = true
+}
+
+// texture returns the texture of a page, uploading the changes since the
+// last call
+func (a *glyphAtlas) texture(page int) *g.Texture {
+	if page < 0 || page >= len(a.pages) {
+		return nil
+	}
+	p := a.pages[page]
+	if p.texture == nil {
+		p.texture = g.NewTextureFromImage(p.image)
+		p.dirty = false
+	} else if p.dirty {
+		p.texture.SetImage(p.image)
+		p.dirty = false
+	}
+	return p.texture
+}
@@ -1,9 +1,11 @@
 package ui
 
-// Bitmap font loader. The format is the one described here
+// Bitmap font loader. The formats are the ones described here
 // http://www.angelcode.com/products/bmfont/doc/file_format.html
 
 import (
+	"bytes"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"regexp"
@@ -76,35 +78,104 @@ type BmFont struct {
 	f32scaleH    float32
 }
 
-// NewBmFontFromFile parse the font data out of a file
+// NewBmFontFromFile parse the font data out of a file. The text, XML, JSON
+// and binary (version 3) formats are detected from the content
 func NewBmFontFromFile(fileName string) *BmFont {
-	f := &BmFont{}
+	fileContent, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	f, err := parseBmFont(fileContent)
+	if err != nil {
+		log.Panicf("Error parsing font %s: %v", fileName, err)
+	}
+	return f
+}
 
+func parseBmFont(data []byte) (*BmFont, error) {
+	f := &BmFont{}
 	f.pageFiles = make(map[int]string)
 	f.Characters = make(map[int32]*BmChar)
 
-	fileContent, err := ioutil.ReadFile(fileName)
+	trimmed := bytes.TrimSpace(data)
+	var err error
+	switch {
+	case bytes.HasPrefix(data, []byte("BMF")):
+		err = f.parseBinary(data)
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		err = f.parseXML(trimmed)
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		err = f.parseJSON(trimmed)
+	default:
+		f.parseText(string(data))
+	}
 	if err != nil {
-		log.Fatalln(err)
+		return nil, err
+	}
+	if f.lineHeight <= 0 || f.pageWidth <= 0 || f.pageHeight <= 0 {
+		return nil, fmt.Errorf("missing or invalid common section")
 	}
-	lines := strings.Split(string(fileContent), "\n")
+	return f, nil
+}
+
+func (f *BmFont) parseText(content string) {
+	lines := strings.Split(content, "\n")
 	for _, line := range lines {
-		section, keyValues := f.tokenizeLine(line)
-		switch section {
-		case "info":
-			f.parseInfoSection(keyValues)
-		case "common":
-			f.parseCommonSection(keyValues)
-		case "page":
-			f.parsePageSection(keyValues)
-		case "char":
-			f.parseCharSection(keyValues)
-		case "kerning":
-			f.parseKerningSection(keyValues)
-		}
+		section, keyValues := f.tokenizeLine(strings.TrimRight(line, "\r"))
+		f.parseSection(section, keyValues)
 	}
+}
 
-	return f
+// parseSection handles a block of key/value pairs. All the formats are
+// converted to the key names of the text format
+func (f *BmFont) parseSection(section string, keyValues map[string]string) {
+	switch section {
+	case "info":
+		f.parseInfoSection(keyValues)
+	case "common":
+		f.parseCommonSection(keyValues)
+	case "page":
+		f.parsePageSection(keyValues)
+	case "char":
+		f.parseCharSection(keyValues)
+	case "kerning":
+		f.parseKerningSection(keyValues)
+	}
+}
+
+// Face returns the name of the true type font
+func (f *BmFont) Face() string {
+	return f.face
+}
+
+// LineHeight returns the distance in pixels between lines
+func (f *BmFont) LineHeight() int {
+	return f.lineHeight
+}
+
+// Base returns the distance in pixels from the top of the line to the baseline
+func (f *BmFont) Base() int {
+	return f.base
+}
+
+// Padding returns the padding of the characters: up, right, down, left
+func (f *BmFont) Padding() [4]int {
+	return f.padding
+}
+
+// Spacing returns the horizontal and vertical spacing of the characters
+func (f *BmFont) Spacing() [2]int {
+	return f.spacing
+}
+
+// NumPages returns the number of texture pages
+func (f *BmFont) NumPages() int {
+	return f.numPages
+}
+
+// PageFile returns the texture file name of a page
+func (f *BmFont) PageFile(id int) string {
+	return f.pageFiles[id]
 }
 
 func (f *BmFont) parseInfoSection(keyValues map[string]string) {
@@ -116,8 +187,28 @@ func (f *BmFont) parseInfoSection(keyValues map[string]string) {
 	f.stretchH, _ = strconv.Atoi(keyValues["stretchH"])
 	f.smooth, _ = strconv.ParseBool(keyValues["smooth"])
 	f.superSampling, _ = strconv.Atoi(keyValues["aa"])
-	//f.padding = keyValues["padding"]
-	//f.spacing = keyValues["spacing"]
+	for i, v := range parseBmInts(keyValues["padding"], len(f.padding)) {
+		f.padding[i] = v
+	}
+	for i, v := range parseBmInts(keyValues["spacing"], len(f.spacing)) {
+		f.spacing[i] = v
+	}
+}
+
+// parseBmInts parses up to n comma separated integers
+func parseBmInts(value string, n int) []int {
+	ints := make([]int, 0, n)
+	for _, v := range strings.Split(value, ",") {
+		if len(ints) == n {
+			break
+		}
+		i, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			break
+		}
+		ints = append(ints, i)
+	}
+	return ints
 }
 
 func (f *BmFont) parseCommonSection(keyValues map[string]string) {
@@ -128,6 +219,10 @@ func (f *BmFont) parseCommonSection(keyValues map[string]string) {
 	f.packed, _ = strconv.ParseBool(keyValues["packed"])
 	f.numPages, _ = strconv.Atoi(keyValues["pages"])
 
+	if f.numPages == 0 {
+		f.numPages = 1
+	}
+
 	f.f32scaleLine = 1.0 / float32(f.lineHeight)
 	f.f32scaleH = 1.0 / float32(f.pageHeight)
 	f.f32scaleW = 1.0 / float32(f.pageWidth)
@@ -152,17 +247,7 @@ func (f *BmFont) parseCharSection(keyValues map[string]string) {
 	c.pageIndex, _ = strconv.Atoi(keyValues["page"])
 	c.textureChannel, _ = strconv.Atoi(keyValues["chnl"])
 
-	c.f32x = float32(c.x) * f.f32scaleW
-	c.f32y = float32(c.y) * f.f32scaleH
-	c.f32width = float32(c.width) * f.f32scaleW
-	c.f32height = float32(c.height) * f.f32scaleH
-
-	c.f32lineWidth = float32(c.width) * f.f32scaleLine
-	c.f32lineHeight = float32(c.height) * f.f32scaleLine
-	c.f32offsetX = float32(c.offsetX) * f.f32scaleLine
-	c.f32offsetY = float32(c.offsetY) * f.f32scaleLine
-	c.f32advanceX = float32(c.advanceX) * f.f32scaleLine
-
+	f.scaleChar(c)
 	c.kernings = make(map[int32]int)
 	c.f32kernings = make(map[int32]float32)
 
@@ -178,10 +263,28 @@ func (f *BmFont) parseCharSection(keyValues map[string]string) {
 	f.Characters[c.id] = c
 }
 
+// scaleChar computes the page-size and line-height scaled values
+func (f *BmFont) scaleChar(c *BmChar) {
+	c.f32x = float32(c.x) * f.f32scaleW
+	c.f32y = float32(c.y) * f.f32scaleH
+	c.f32width = float32(c.width) * f.f32scaleW
+	c.f32height = float32(c.height) * f.f32scaleH
+
+	c.f32lineWidth = float32(c.width) * f.f32scaleLine
+	c.f32lineHeight = float32(c.height) * f.f32scaleLine
+	c.f32offsetX = float32(c.offsetX) * f.f32scaleLine
+	c.f32offsetY = float32(c.offsetY) * f.f32scaleLine
+	c.f32advanceX = float32(c.advanceX) * f.f32scaleLine
+}
+
 func (f *BmFont) parseKerningSection(keyValues map[string]string) {
-	first, err := strconv.Atoi(keyValues["first"])
-	second, err := strconv.Atoi(keyValues["second"])
-	amount, err := strconv.Atoi(keyValues["amount"])
+	var first, second, amount int
+	var err error
+	if first, err = strconv.Atoi(keyValues["first"]); err == nil {
+		if second, err = strconv.Atoi(keyValues["second"]); err == nil {
+			amount, err = strconv.Atoi(keyValues["amount"])
+		}
+	}
 	if err != nil {
 		log.Printf("Error parsing kerning: %v", err)
 		return
@@ -190,6 +293,7 @@ func (f *BmFont) parseKerningSection(keyValues map[string]string) {
 	char, ok := f.Characters[int32(first)]
 	if !ok {
 		log.Printf("Kerning parse error: char %v not found", first)
+		return
 	}
 	char.kernings[int32(second)] = amount
 	char.f32kernings[int32(second)] = float32(amount) * f.f32scaleLine
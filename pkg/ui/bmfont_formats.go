@@ -0,0 +1,258 @@
+package ui
+
+// Binary, XML and JSON variants of the BMFont format. Every block is
+// converted to the key/value pairs of the text format and handed to the same
+// section parsers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	bmBlockInfo = iota + 1
+	bmBlockCommon
+	bmBlockPages
+	bmBlockChars
+	bmBlockKerning
+)
+
+const (
+	bmBinaryCharSize    = 20
+	bmBinaryKerningSize = 10
+)
+
+// parseBinary parses the version 3 of the binary format
+func (f *BmFont) parseBinary(data []byte) error {
+	if len(data) < 4 || data[3] != 3 {
+		return fmt.Errorf("unsupported binary font version")
+	}
+	data = data[4:]
+	for len(data) > 0 {
+		if len(data) < 5 {
+			return fmt.Errorf("truncated block header")
+		}
+		blockType := data[0]
+		size := int(binary.LittleEndian.Uint32(data[1:5]))
+		data = data[5:]
+		if size > len(data) {
+			return fmt.Errorf("block %d: truncated, %d bytes left of %d", blockType, len(data), size)
+		}
+		block := data[:size]
+		data = data[size:]
+
+		var err error
+		switch blockType {
+		case bmBlockInfo:
+			err = f.parseBinaryInfo(block)
+		case bmBlockCommon:
+			err = f.parseBinaryCommon(block)
+		case bmBlockPages:
+			f.parseBinaryPages(block)
+		case bmBlockChars:
+			f.parseBinaryChars(block)
+		case bmBlockKerning:
+			f.parseBinaryKernings(block)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *BmFont) parseBinaryInfo(block []byte) error {
+	if len(block) < 14 {
+		return fmt.Errorf("info block too short")
+	}
+	fontSize := int16(binary.LittleEndian.Uint16(block[0:]))
+	// Bit 0 is the most significant one
+	bits := block[2]
+	name := block[14:]
+	if end := bytes.IndexByte(name, 0); end >= 0 {
+		name = name[:end]
+	}
+	f.parseSection("info", map[string]string{
+		"face":     string(name),
+		"size":     strconv.Itoa(int(fontSize)),
+		"smooth":   bmBit(bits, 7),
+		"unicode":  bmBit(bits, 6),
+		"italic":   bmBit(bits, 5),
+		"bold":     bmBit(bits, 4),
+		"charset":  strconv.Itoa(int(block[3])),
+		"stretchH": strconv.Itoa(int(binary.LittleEndian.Uint16(block[4:]))),
+		"aa":       strconv.Itoa(int(block[6])),
+		"padding":  fmt.Sprintf("%d,%d,%d,%d", block[7], block[8], block[9], block[10]),
+		"spacing":  fmt.Sprintf("%d,%d", block[11], block[12]),
+	})
+	return nil
+}
+
+func (f *BmFont) parseBinaryCommon(block []byte) error {
+	if len(block) < 15 {
+		return fmt.Errorf("common block too short")
+	}
+	u16 := func(offset int) string {
+		return strconv.Itoa(int(binary.LittleEndian.Uint16(block[offset:])))
+	}
+	f.parseSection("common", map[string]string{
+		"lineHeight": u16(0),
+		"base":       u16(2),
+		"scaleW":     u16(4),
+		"scaleH":     u16(6),
+		"pages":      u16(8),
+		"packed":     bmBit(block[10], 0),
+	})
+	return nil
+}
+
+func (f *BmFont) parseBinaryPages(block []byte) {
+	id := 0
+	for _, name := range bytes.Split(block, []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+		f.parseSection("page", map[string]string{"id": strconv.Itoa(id), "file": string(name)})
+		id++
+	}
+}
+
+func (f *BmFont) parseBinaryChars(block []byte) {
+	for ; len(block) >= bmBinaryCharSize; block = block[bmBinaryCharSize:] {
+		u16 := func(offset int) string {
+			return strconv.Itoa(int(binary.LittleEndian.Uint16(block[offset:])))
+		}
+		i16 := func(offset int) string {
+			return strconv.Itoa(int(int16(binary.LittleEndian.Uint16(block[offset:]))))
+		}
+		f.parseSection("char", map[string]string{
+			"id":       strconv.FormatUint(uint64(binary.LittleEndian.Uint32(block)), 10),
+			"x":        u16(4),
+			"y":        u16(6),
+			"width":    u16(8),
+			"height":   u16(10),
+			"xoffset":  i16(12),
+			"yoffset":  i16(14),
+			"xadvance": i16(16),
+			"page":     strconv.Itoa(int(block[18])),
+			"chnl":     strconv.Itoa(int(block[19])),
+		})
+	}
+}
+
+func (f *BmFont) parseBinaryKernings(block []byte) {
+	for ; len(block) >= bmBinaryKerningSize; block = block[bmBinaryKerningSize:] {
+		f.parseSection("kerning", map[string]string{
+			"first":  strconv.FormatUint(uint64(binary.LittleEndian.Uint32(block)), 10),
+			"second": strconv.FormatUint(uint64(binary.LittleEndian.Uint32(block[4:])), 10),
+			"amount": strconv.Itoa(int(int16(binary.LittleEndian.Uint16(block[8:])))),
+		})
+	}
+}
+
+// bmBit returns "1" if the bit is set, bits are counted from the least
+// significant one
+func bmBit(b byte, bit uint) string {
+	if b&(1<<bit) != 0 {
+		return "1"
+	}
+	return "0"
+}
+
+// parseXML parses the XML format, every element is a section and its
+// attributes are the key/value pairs
+func (f *BmFont) parseXML(data []byte) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		element, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		keyValues := make(map[string]string, len(element.Attr))
+		for _, attr := range element.Attr {
+			keyValues[attr.Name.Local] = attr.Value
+		}
+		f.parseSection(element.Name.Local, keyValues)
+	}
+}
+
+// bmFontJSON is the layout of the JSON format used by most exporters, e.g.
+// https://github.com/soimy/msdf-bmfont-xml
+type bmFontJSON struct {
+	Pages    []string                 `json:"pages"`
+	Info     map[string]interface{}   `json:"info"`
+	Common   map[string]interface{}   `json:"common"`
+	Chars    []map[string]interface{} `json:"chars"`
+	Kernings []map[string]interface{} `json:"kernings"`
+}
+
+func (f *BmFont) parseJSON(data []byte) error {
+	var font bmFontJSON
+	if err := json.Unmarshal(data, &font); err != nil {
+		return err
+	}
+	// The sections are parsed in the order of the text format, chars need
+	// the common section
+	f.parseSection("info", bmJSONKeyValues(font.Info))
+	f.parseSection("common", bmJSONKeyValues(font.Common))
+	for id, file := range font.Pages {
+		f.parseSection("page", map[string]string{"id": strconv.Itoa(id), "file": file})
+	}
+	for _, char := range font.Chars {
+		keyValues := bmJSONKeyValues(char)
+		if _, ok := keyValues["letter"]; !ok {
+			if letter, ok := keyValues["char"]; ok {
+				keyValues["letter"] = letter
+			}
+		}
+		f.parseSection("char", keyValues)
+	}
+	for _, kerning := range font.Kernings {
+		f.parseSection("kerning", bmJSONKeyValues(kerning))
+	}
+	return nil
+}
+
+// bmJSONKeyValues formats JSON values like the text format does: booleans
+// are 0 or 1, arrays are comma separated
+func bmJSONKeyValues(object map[string]interface{}) map[string]string {
+	keyValues := make(map[string]string, len(object))
+	for k, v := range object {
+		keyValues[k] = bmJSONValue(v)
+	}
+	return keyValues
+}
+
+func bmJSONValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case []interface{}:
+		values := make([]string, len(v))
+		for i := range v {
+			values[i] = bmJSONValue(v[i])
+		}
+		return strings.Join(values, ",")
+	}
+	return ""
+}
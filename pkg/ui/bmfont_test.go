@@ -0,0 +1,126 @@
+package ui
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/markov/gojira2d/pkg/graphics"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestBmFontFormats(t *testing.T) {
+	expected := NewBmFontFromFile("../../examples/assets/fonts/roboto-regular.fnt")
+	if expected.Face() != "Roboto" || expected.LineHeight() != 88 || expected.Base() != 57 {
+		t.Fatalf("Wrong text font header %+v", expected)
+	}
+	if expected.Padding() != [4]int{8, 8, 8, 8} || expected.Spacing() != [2]int{0, 0} {
+		t.Errorf("Wrong padding %v or spacing %v", expected.Padding(), expected.Spacing())
+	}
+	if expected.NumPages() != 1 || expected.PageFile(0) != "roboto-regular.png" {
+		t.Errorf("Wrong pages %v", expected.pageFiles)
+	}
+
+	files := []string{
+		"../../examples/assets/fonts/roboto-regular-binary.fnt",
+		"../../examples/assets/fonts/roboto-regular.xml",
+		"../../examples/assets/fonts/roboto-regular.json",
+	}
+	for _, file := range files {
+		f := NewBmFontFromFile(file)
+		if f.face != expected.face || f.size != expected.size || f.smooth != expected.smooth ||
+			f.padding != expected.padding || f.spacing != expected.spacing {
+			t.Errorf("%s: wrong info section %+v", file, f)
+		}
+		if f.lineHeight != expected.lineHeight || f.base != expected.base ||
+			f.pageWidth != expected.pageWidth || f.numPages != expected.numPages {
+			t.Errorf("%s: wrong common section %+v", file, f)
+		}
+		if !reflect.DeepEqual(f.pageFiles, expected.pageFiles) {
+			t.Errorf("%s: got pages %v, expecting %v", file, f.pageFiles, expected.pageFiles)
+		}
+		if len(f.Characters) != len(expected.Characters) {
+			t.Errorf("%s: got %d chars, expecting %d", file, len(f.Characters), len(expected.Characters))
+		}
+		for id, c := range expected.Characters {
+			if !reflect.DeepEqual(f.Characters[id], c) {
+				t.Errorf("%s: got char %+v, expecting %+v", file, f.Characters[id], c)
+				break
+			}
+		}
+	}
+}
+
+const testMultiPageFont = `info face="Packed" size=32 padding=1,2,3,4 spacing=1,1
+common lineHeight=32 base=26 scaleW=256 scaleH=256 pages=2 packed=1
+page id=0 file="packed_0.png"
+page id=1 file="packed_1.png"
+chars count=3
+char id=97 x=0 y=0 width=16 height=20 xoffset=0 yoffset=6 xadvance=16 page=0 chnl=4
+char id=98 x=0 y=0 width=16 height=26 xoffset=0 yoffset=0 xadvance=16 page=0 chnl=2
+char id=99 x=0 y=0 width=16 height=20 xoffset=0 yoffset=6 xadvance=16 page=1 chnl=15
+kernings count=1
+kerning first=97 second=98 amount=-2
+`
+
+func TestBmFontMultiPage(t *testing.T) {
+	bm, err := parseBmFont([]byte(testMultiPageFont))
+	if err != nil {
+		t.Fatalf("Not expecting error %v", err)
+	}
+	if bm.Padding() != [4]int{1, 2, 3, 4} || bm.NumPages() != 2 || bm.PageFile(1) != "packed_1.png" {
+		t.Errorf("Wrong font header %+v", bm)
+	}
+	if c := bm.Characters['c']; c.pageIndex != 1 || c.textureChannel != 15 {
+		t.Errorf("Wrong page or channel %+v", c)
+	}
+	if bm.Characters['a'].kernings['b'] != -2 {
+		t.Errorf("Wrong kerning %v", bm.Characters['a'].kernings)
+	}
+
+	// Glyphs are grouped by page and channel
+	font := &Font{bm: bm, pages: []*graphics.Texture{{}, {}}}
+	text := &Text{text: "abcab", font: font, size: mgl32.Vec2{32, 32}}
+	quads := text.makeNewQuads()
+	expected := []struct {
+		texture *graphics.Texture
+		channel mgl32.Vec4
+		glyphs  int
+	}{
+		{font.pages[0], mgl32.Vec4{1, 0, 0, 0}, 2},
+		{font.pages[0], mgl32.Vec4{0, 1, 0, 0}, 2},
+		{font.pages[1], mgl32.Vec4{0, 0, 0, 1}, 1},
+	}
+	if len(quads) != len(expected) {
+		t.Fatalf("Got %d batches, expecting %d", len(quads), len(expected))
+	}
+	for i, e := range expected {
+		q := quads[i]
+		if q.texture != e.texture || q.channel != e.channel || len(q.vertices) != e.glyphs*charVertices {
+			t.Errorf("Batch %d: got %v %v %d vertices", i, q.texture, q.channel, len(q.vertices))
+		}
+	}
+}
+
+func TestBmFontErrors(t *testing.T) {
+	valid, err := ioutil.ReadFile("../../examples/assets/fonts/roboto-regular-binary.fnt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests = []struct {
+		name string
+		data []byte
+	}{
+		{"binary version", []byte("BMF\x02")},
+		{"truncated binary", valid[:len(valid)-5]},
+		{"invalid xml", []byte("<font><info face=\"x\"></font>")},
+		{"invalid json", []byte("{\"pages\": 1}")},
+		{"missing common", []byte("info face=\"x\"\n")},
+	}
+	for _, test := range tests {
+		if _, err := parseBmFont(test.data); err == nil {
+			t.Errorf("%s: expecting error", test.name)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"github.com/markov/gojira2d/pkg/graphics"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Button is a clickable box with a centered label
+type Button struct {
+	WidgetBase
+	background *box
+	label      *Label
+	onClick    func()
+}
+
+// NewButton creates a button sized to fit its label and the padding
+func NewButton(text string, style *WidgetStyle) *Button {
+	b := &Button{}
+	b.init(b)
+	b.style = style
+	b.styleName = "button"
+	b.background = newBackgroundBox(&b.WidgetBase)
+	b.interactive = true
+	b.focusable = true
+	b.label = NewLabel(text, style)
+	b.label.stateSource = b
+	b.label.styleName = ""
+	b.Add(b.label)
+	b.SetSize(b.label.Size().Add(style.paddingSize()))
+	return b
+}
+
+// SetText changes the label, the button is not resized
+func (b *Button) SetText(text string) {
+	b.label.SetText(text)
+	b.Invalidate()
+}
+
+// Label returns the label of the button
+func (b *Button) Label() *Label {
+	return b.label
+}
+
+// SetStyle changes the look of the button and resizes it to fit the label
+func (b *Button) SetStyle(style *WidgetStyle) {
+	b.WidgetBase.SetStyle(style)
+	b.label.SetStyle(style)
+	b.SetSize(b.label.Size().Add(style.paddingSize()))
+}
+
+// SetOnClick sets the function called when the button is clicked
+func (b *Button) SetOnClick(onClick func()) {
+	b.onClick = onClick
+}
+
+// HandleEvent see Widget.HandleEvent
+func (b *Button) HandleEvent(event *Event) {
+	if event.Type == EVENT_CLICK && event.Target == Widget(b) {
+		if b.onClick != nil {
+			b.onClick()
+		}
+	}
+}
+
+// Arrange see Widget.Arrange
+func (b *Button) Arrange() {
+	b.background.set(b.AbsolutePosition(), b.layerDepth(0), b.size)
+	b.label.SetPosition(b.size.Sub(b.label.Size()).Mul(0.5))
+}
+
+// EnqueueForDrawing see Widget.EnqueueForDrawing
+func (b *Button) EnqueueForDrawing(context *graphics.Context) {
+	b.background.setColor(b.style.Background.Color(b.State()))
+	b.background.enqueue(context)
+	b.WidgetBase.EnqueueForDrawing(context)
+}
+
+// centerVertically returns the y placing a child of a given height in the
+// middle of a widget
+func centerVertically(size mgl32.Vec2, height float32) float32 {
+	return (size[1] - height) / 2
+}
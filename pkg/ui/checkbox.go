@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"github.com/markov/gojira2d/pkg/graphics"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Checkbox is a box toggled by clicks, followed by a label
+type Checkbox struct {
+	WidgetBase
+	box      *box
+	mark     *box
+	label    *Label
+	checked  bool
+	onChange func(checked bool)
+}
+
+// NewCheckbox creates an unchecked checkbox
+func NewCheckbox(text string, style *WidgetStyle) *Checkbox {
+	c := &Checkbox{}
+	c.init(c)
+	c.style = style
+	c.styleName = "checkbox"
+	c.box = newBackgroundBox(&c.WidgetBase)
+	c.mark = newBox(&c.WidgetBase)
+	c.interactive = true
+	c.focusable = true
+	c.label = NewLabel(text, style)
+	c.label.stateSource = c
+	c.label.styleName = ""
+	c.Add(c.label)
+	c.fit()
+	return c
+}
+
+// SetStyle changes the look of the checkbox and resizes it to fit the label
+func (c *Checkbox) SetStyle(style *WidgetStyle) {
+	c.WidgetBase.SetStyle(style)
+	c.label.SetStyle(style)
+	c.fit()
+}
+
+// fit resizes the checkbox to the box and the label
+func (c *Checkbox) fit() {
+	labelSize := c.label.Size()
+	c.SetSize(mgl32.Vec2{c.style.FontSize + c.style.Padding[2] + labelSize[0], labelSize[1]})
+}
+
+// SetChecked changes the state without emitting EVENT_CHANGE
+func (c *Checkbox) SetChecked(checked bool) {
+	c.checked = checked
+}
+
+// Checked returns true if the box is checked
+func (c *Checkbox) Checked() bool {
+	return c.checked
+}
+
+// SetOnChange sets the function called when the user toggles the box
+func (c *Checkbox) SetOnChange(onChange func(checked bool)) {
+	c.onChange = onChange
+}
+
+// Label returns the label of the checkbox
+func (c *Checkbox) Label() *Label {
+	return c.label
+}
+
+// HandleEvent see Widget.HandleEvent
+func (c *Checkbox) HandleEvent(event *Event) {
+	if event.Type == EVENT_CLICK && event.Target == Widget(c) {
+		c.checked = !c.checked
+		c.changed()
+		if c.onChange != nil {
+			c.onChange(c.checked)
+		}
+	}
+}
+
+// Arrange see Widget.Arrange
+func (c *Checkbox) Arrange() {
+	side := c.style.FontSize
+	position := c.AbsolutePosition()
+	position[1] += centerVertically(c.size, side)
+	c.box.set(position, c.layerDepth(0), mgl32.Vec2{side, side})
+	inset := side / 4
+	c.mark.set(position.Add(mgl32.Vec2{inset, inset}), c.layerDepth(1), mgl32.Vec2{side - 2*inset, side - 2*inset})
+	c.label.SetPosition(mgl32.Vec2{side + c.style.Padding[2], centerVertically(c.size, c.label.Size()[1])})
+}
+
+// EnqueueForDrawing see Widget.EnqueueForDrawing
+func (c *Checkbox) EnqueueForDrawing(context *graphics.Context) {
+	state := c.State()
+	c.box.setColor(c.style.Background.Color(state))
+	c.box.enqueue(context)
+	if c.checked {
+		c.mark.setColor(c.style.Accent.Color(state))
+		c.mark.enqueue(context)
+	}
+	c.WidgetBase.EnqueueForDrawing(context)
+}
@@ -0,0 +1,216 @@
+package ui
+
+import (
+	"github.com/markov/gojira2d/pkg/graphics"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Dropdown shows the selected option and opens the list of the options
+// below itself when clicked
+type Dropdown struct {
+	WidgetBase
+	background *box
+	arrow      *box
+	label      *Label
+	options    []string
+	selected   int
+	list       *Panel
+	onChange   func(index int, option string)
+}
+
+// NewDropdown creates a dropdown selecting the first option
+func NewDropdown(options []string, style *WidgetStyle) *Dropdown {
+	d := &Dropdown{}
+	d.init(d)
+	d.style = style
+	d.styleName = "dropdown"
+	d.background = newBackgroundBox(&d.WidgetBase)
+	d.arrow = newBox(&d.WidgetBase)
+	d.interactive = true
+	d.focusable = true
+	d.label = NewLabel("", style)
+	d.label.stateSource = d
+	d.label.styleName = ""
+	d.Add(d.label)
+	d.SetOptions(options)
+	return d
+}
+
+// SetOptions replaces the options, selecting the first one, and resizes
+// the dropdown to fit the longest
+func (d *Dropdown) SetOptions(options []string) {
+	d.close()
+	d.options = append([]string{}, options...)
+	d.fit()
+	d.selected = -1
+	d.SetSelected(0)
+}
+
+// SetStyle changes the look of the dropdown and resizes it to fit the
+// longest option
+func (d *Dropdown) SetStyle(style *WidgetStyle) {
+	d.close()
+	d.WidgetBase.SetStyle(style)
+	d.label.SetStyle(style)
+	d.fit()
+}
+
+// fit resizes the dropdown to the longest option and the arrow
+func (d *Dropdown) fit() {
+	var width float32
+	for _, option := range d.options {
+		if w := measureText(d.style, option)[0]; w > width {
+			width = w
+		}
+	}
+	padding := d.style.paddingSize()
+	d.SetSize(mgl32.Vec2{width + padding[0] + d.style.FontSize, d.style.FontSize + padding[1]})
+}
+
+// Options returns the options
+func (d *Dropdown) Options() []string {
+	return d.options
+}
+
+// SetSelected selects an option without emitting EVENT_CHANGE, -1 selects
+// nothing
+func (d *Dropdown) SetSelected(index int) {
+	if index < -1 || index >= len(d.options) {
+		index = -1
+	}
+	d.selected = index
+	if index >= 0 {
+		d.label.SetText(d.options[index])
+	} else {
+		d.label.SetText("")
+	}
+	d.Invalidate()
+}
+
+// Selected returns the index of the selected option, -1 if none
+func (d *Dropdown) Selected() int {
+	return d.selected
+}
+
+// SelectedOption returns the selected option, empty if none
+func (d *Dropdown) SelectedOption() string {
+	if d.selected < 0 {
+		return ""
+	}
+	return d.options[d.selected]
+}
+
+// SetOnChange sets the function called when the user picks an option
+func (d *Dropdown) SetOnChange(onChange func(index int, option string)) {
+	d.onChange = onChange
+}
+
+// Open returns true while the list is shown
+func (d *Dropdown) Open() bool {
+	return d.list != nil
+}
+
+// open shows the options in an overlay and focuses the selected one
+func (d *Dropdown) open() {
+	if d.list != nil || d.gui == nil || len(d.options) == 0 {
+		return
+	}
+	d.list = NewPanel(d.style)
+	d.list.styleName = ""
+	d.list.SetPosition(d.AbsolutePosition().Add(mgl32.Vec2{0, d.size[1]}))
+	var focus Widget
+	y := float32(0)
+	for i, option := range d.options {
+		index := i
+		item := NewButton(option, d.style)
+		item.styleName = ""
+		item.SetSize(mgl32.Vec2{d.size[0], item.Size()[1]})
+		item.SetPosition(mgl32.Vec2{0, y})
+		item.SetOnClick(func() {
+			d.pick(index)
+		})
+		y += item.Size()[1]
+		d.list.Add(item)
+		if i == d.selected || focus == nil {
+			focus = item
+		}
+	}
+	d.list.SetSize(mgl32.Vec2{d.size[0], y})
+	list := d.list
+	d.gui.ShowOverlay(list, d, func() {
+		if d.list == list {
+			d.list = nil
+		}
+	})
+	if d.focused {
+		d.gui.Focus(focus)
+	}
+}
+
+// close hides the list of the options
+func (d *Dropdown) close() {
+	if d.list != nil && d.gui != nil {
+		d.gui.HideOverlay(d.list)
+	}
+	d.list = nil
+}
+
+// pick selects an option chosen by the user and closes the list
+func (d *Dropdown) pick(index int) {
+	changed := index != d.selected
+	d.SetSelected(index)
+	d.close()
+	if d.gui != nil {
+		d.gui.Focus(d)
+	}
+	if changed {
+		d.changed()
+		if d.onChange != nil {
+			d.onChange(index, d.options[index])
+		}
+	}
+}
+
+// HandleEvent see Widget.HandleEvent
+func (d *Dropdown) HandleEvent(event *Event) {
+	if event.Type == EVENT_CLICK && event.Target == Widget(d) {
+		if d.list != nil {
+			d.close()
+		} else {
+			d.open()
+		}
+	}
+}
+
+// Arrange see Widget.Arrange
+func (d *Dropdown) Arrange() {
+	position := d.AbsolutePosition()
+	d.background.set(position, d.layerDepth(0), d.size)
+	arrow := d.style.FontSize / 3
+	d.arrow.set(
+		position.Add(mgl32.Vec2{d.size[0] - d.style.Padding[3] - arrow, centerVertically(d.size, arrow)}),
+		d.layerDepth(1),
+		mgl32.Vec2{arrow, arrow},
+	)
+	d.label.SetPosition(mgl32.Vec2{d.style.Padding[2], centerVertically(d.size, d.label.Size()[1])})
+	if d.list != nil {
+		d.list.SetPosition(position.Add(mgl32.Vec2{0, d.size[1]}))
+	}
+}
+
+// EnqueueForDrawing see Widget.EnqueueForDrawing
+func (d *Dropdown) EnqueueForDrawing(context *graphics.Context) {
+	state := d.State()
+	d.background.setColor(d.style.Background.Color(state))
+	d.arrow.setColor(d.style.Accent.Color(state))
+	d.background.enqueue(context)
+	d.arrow.enqueue(context)
+	d.WidgetBase.EnqueueForDrawing(context)
+}
+
+// measureText returns the size in pixels of a single line of text
+func measureText(style *WidgetStyle, text string) mgl32.Vec2 {
+	layout := style.Font.Layout(text, LayoutOptions{})
+	return mgl32.Vec2{layout.Width * style.FontSize, layout.Height * style.FontSize}
+}
@@ -1,19 +1,29 @@
 package ui
 
 import (
+	"log"
+	"path/filepath"
+
 	g "github.com/markov/gojira2d/pkg/graphics"
 )
 
-// Font structure contains BmFont metadata and the texture
+// Font structure contains BmFont metadata and the texture pages
 type Font struct {
-	bm *BmFont
-	tx *g.Texture
+	bm            *BmFont
+	pages         []*g.Texture
+	fallbacks     []*Font
+	fallbackRune  rune
+	missingLogged map[rune]bool
+	// Glyphs of fonts loaded from TrueType files are rasterized on demand
+	ttf *ttfSource
 }
 
 // FontRegistry is a dictionary of loaded fonts
 var FontRegistry = make(map[string]*Font)
 
-// NewFontFromFiles create Font structure from metadata and texture files
+// NewFontFromFiles create Font structure from metadata and texture files.
+// texpath is the first page, the other ones are loaded from the files named
+// in the metadata, relative to its directory
 func NewFontFromFiles(name, bmpath, texpath string) *Font {
 	if f, ok := FontRegistry[name]; ok {
 		return f
@@ -21,7 +31,156 @@ func NewFontFromFiles(name, bmpath, texpath string) *Font {
 
 	f := &Font{}
 	f.bm = NewBmFontFromFile(bmpath)
-	f.tx = g.NewTextureFromFile(texpath)
+	f.pages = make([]*g.Texture, f.bm.numPages)
+	f.pages[0] = g.NewTextureFromFile(texpath)
+	for i := 1; i < len(f.pages); i++ {
+		f.pages[i] = g.NewTextureFromFile(f.pagePath(bmpath, i))
+	}
+	FontRegistry[name] = f
+	return f
+}
+
+// NewFontFromFile create Font structure loading all the pages named in the
+// metadata, relative to its directory
+func NewFontFromFile(name, bmpath string) *Font {
+	if f, ok := FontRegistry[name]; ok {
+		return f
+	}
+
+	f := &Font{}
+	f.bm = NewBmFontFromFile(bmpath)
+	f.pages = make([]*g.Texture, f.bm.numPages)
+	for i := range f.pages {
+		f.pages[i] = g.NewTextureFromFile(f.pagePath(bmpath, i))
+	}
 	FontRegistry[name] = f
 	return f
 }
+
+func (f *Font) pagePath(bmpath string, page int) string {
+	file, ok := f.bm.pageFiles[page]
+	if !ok {
+		log.Panicf("Font %s: page %d not found", bmpath, page)
+	}
+	return filepath.Join(filepath.Dir(bmpath), file)
+}
+
+// BmFont returns the font metadata
+func (f *Font) BmFont() *BmFont {
+	return f.bm
+}
+
+// Page returns the texture of a page, nil if the page doesn't exist
+func (f *Font) Page(page int) *g.Texture {
+	if f.ttf != nil {
+		return f.ttf.atlas.texture(page)
+	}
+	if page < 0 || page >= len(f.pages) {
+		return nil
+	}
+	return f.pages[page]
+}
+
+// SetFallbackFonts sets the fonts searched, in order, for characters missing
+// in this font. Glyphs of fallback fonts are scaled to the same line height
+func (f *Font) SetFallbackFonts(fonts ...*Font) {
+	f.fallbacks = fonts
+}
+
+// SetFallbackRune sets the character drawn in place of the ones missing in
+// the whole fallback chain, e.g. '?' or '�'. 0 means nothing is drawn
+func (f *Font) SetFallbackRune(r rune) {
+	f.fallbackRune = r
+}
+
+// HasRune returns true if the font itself has a glyph for r
+func (f *Font) HasRune(r rune) bool {
+	if _, ok := f.bm.Characters[r]; ok {
+		return true
+	}
+	return f.ttf != nil && f.ttf.hasRune(r)
+}
+
+// MissingRunes returns, without duplicates, the runes of txt that neither the
+// font nor its fallbacks can draw. Useful to check translations
+func (f *Font) MissingRunes(txt string) []rune {
+	missing := make([]rune, 0)
+	seen := make(map[rune]bool)
+	for _, r := range txt {
+		if seen[r] || r == '\n' {
+			continue
+		}
+		seen[r] = true
+		if font, _ := f.lookup(r, make(map[*Font]bool)); font == nil {
+			missing = append(missing, r)
+		}
+	}
+	return missing
+}
+
+// lookup searches r in the font and recursively in its fallbacks
+func (f *Font) lookup(r rune, visited map[*Font]bool) (*Font, *BmChar) {
+	if visited[f] {
+		return nil, nil
+	}
+	visited[f] = true
+	if bmc, ok := f.bm.Characters[r]; ok {
+		return f, bmc
+	}
+	if f.ttf != nil {
+		if bmc := f.ttf.addGlyph(f.bm, r); bmc != nil {
+			return f, bmc
+		}
+	}
+	for _, fallback := range f.fallbacks {
+		if font, bmc := fallback.lookup(r, visited); font != nil {
+			return font, bmc
+		}
+	}
+	return nil, nil
+}
+
+// glyph returns the font and the character used to draw r. Missing
+// characters are replaced by the fallback rune and logged once
+func (f *Font) glyph(r rune) (*Font, *BmChar) {
+	if font, bmc := f.lookup(r, make(map[*Font]bool)); font != nil {
+		return font, bmc
+	}
+	if f.missingLogged == nil {
+		f.missingLogged = make(map[rune]bool)
+	}
+	if !f.missingLogged[r] {
+		f.missingLogged[r] = true
+		log.Printf("ERR: char %v (%U) not found in font map", string(r), r)
+	}
+	if f.fallbackRune != 0 && f.fallbackRune != r {
+		return f.lookup(f.fallbackRune, make(map[*Font]bool))
+	}
+	return nil, nil
+}
+
+// baselineShift returns how much the glyphs of font have to be moved down to
+// share the baseline of f, in line height units
+func (f *Font) baselineShift(font *Font) float32 {
+	if font == f {
+		return 0
+	}
+	return float32(f.bm.base)*f.bm.f32scaleLine - float32(font.bm.base)*font.bm.f32scaleLine
+}
+
+// revision changes when the texture coordinates of the glyphs of the font or
+// of its fallbacks change, e.g. when an atlas page grows
+func (f *Font) revision(visited map[*Font]bool) int {
+	if visited[f] {
+		return 0
+	}
+	visited[f] = true
+	revision := 0
+	if f.ttf != nil {
+		revision = f.ttf.revision
+	}
+	for _, fallback := range f.fallbacks {
+		revision += fallback.revision(visited)
+	}
+	return revision
+}
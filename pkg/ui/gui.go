@@ -0,0 +1,546 @@
+package ui
+
+import (
+	"github.com/markov/gojira2d/pkg/graphics"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Widgets are drawn with decreasing z, in tree order, starting from
+// guiDepthStart. Each widget can draw guiDepthLayers primitives on top of
+// each other
+const (
+	guiDepthStart  = 0.9
+	guiDepthStep   = 0.0001
+	guiDepthLayers = 4
+)
+
+// overlay is a widget drawn above the tree, like the list of a Dropdown.
+// While overlays are open, navigation is limited to the topmost one
+type overlay struct {
+	widget  Widget
+	owner   Widget
+	onClose func()
+}
+
+// GUI is the root of a widget tree. It dispatches the mouse and navigation
+// events, tracks hover, press and focus, and arranges the widgets. Draw it
+// with the UI context:
+//
+//	gui.Update(deltaTime)
+//	gui.EnqueueForDrawing(app.UIContext)
+type GUI struct {
+	root     *Panel
+	overlays []overlay
+
+	hovered Widget
+	pressed Widget
+	focused Widget
+	pointer mgl32.Vec2
+	order   int
+	theme   *Theme
+	// Seconds since the creation, for the style transitions
+	time float64
+
+	window              *glfw.Window
+	previousCursorPos   glfw.CursorPosCallback
+	previousMouseButton glfw.MouseButtonCallback
+	previousScroll      glfw.ScrollCallback
+	previousSize        glfw.SizeCallback
+}
+
+// NewGUI creates an empty GUI covering width x height pixels
+func NewGUI(width, height float32) *GUI {
+	g := &GUI{root: NewPanel(nil)}
+	g.root.styleName = ""
+	g.root.gui = g
+	g.root.SetSize(mgl32.Vec2{width, height})
+	return g
+}
+
+// Root returns the panel containing all the widgets
+func (g *GUI) Root() *Panel {
+	return g.root
+}
+
+// Add adds a widget to the root panel
+func (g *GUI) Add(widget Widget) {
+	g.root.Add(widget)
+}
+
+// SetSize resizes the root panel, its layout places the widgets again.
+// Attach calls it when the window is resized
+func (g *GUI) SetSize(width, height float32) {
+	g.root.SetSize(mgl32.Vec2{width, height})
+}
+
+// Attach installs the cursor, mouse button, scroll and size callbacks of
+// the window. Callbacks set before are still called
+func (g *GUI) Attach(window *glfw.Window) {
+	if g.window != nil {
+		g.Detach()
+	}
+	g.window = window
+	g.previousCursorPos = window.SetCursorPosCallback(func(w *glfw.Window, x, y float64) {
+		if g.previousCursorPos != nil {
+			g.previousCursorPos(w, x, y)
+		}
+		g.MouseMove(mgl32.Vec2{float32(x), float32(y)})
+	})
+	g.previousMouseButton = window.SetMouseButtonCallback(func(w *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
+		if g.previousMouseButton != nil {
+			g.previousMouseButton(w, button, action, mods)
+		}
+		if button == glfw.MouseButtonLeft && action != glfw.Repeat {
+			g.MouseButton(action == glfw.Press)
+		}
+	})
+	g.previousScroll = window.SetScrollCallback(func(w *glfw.Window, x, y float64) {
+		if g.previousScroll != nil {
+			g.previousScroll(w, x, y)
+		}
+		g.Scroll(mgl32.Vec2{float32(x), float32(y)})
+	})
+	g.previousSize = window.SetSizeCallback(func(w *glfw.Window, width, height int) {
+		if g.previousSize != nil {
+			g.previousSize(w, width, height)
+		}
+		g.SetSize(float32(width), float32(height))
+	})
+}
+
+// Detach restores the callbacks the window had before Attach
+func (g *GUI) Detach() {
+	if g.window == nil {
+		return
+	}
+	g.window.SetCursorPosCallback(g.previousCursorPos)
+	g.window.SetMouseButtonCallback(g.previousMouseButton)
+	g.window.SetScrollCallback(g.previousScroll)
+	g.window.SetSizeCallback(g.previousSize)
+	g.window = nil
+	g.previousCursorPos = nil
+	g.previousMouseButton = nil
+	g.previousScroll = nil
+	g.previousSize = nil
+}
+
+// Update arranges the widgets that moved or changed and updates them
+func (g *GUI) Update(deltaTime float64) {
+	g.time += deltaTime
+	g.order = 0
+	g.arrange(g.root)
+	for _, o := range g.overlays {
+		g.arrange(o.widget)
+	}
+
+	// Forget the widgets removed or hidden since the last update
+	if g.hovered != nil && !g.reachable(g.hovered) {
+		g.hovered.Base().hovered = false
+		g.hovered = nil
+	}
+	if g.pressed != nil && !g.reachable(g.pressed) {
+		g.pressed.Base().pressed = false
+		g.pressed = nil
+	}
+	if g.focused != nil && !g.reachable(g.focused) {
+		g.Focus(nil)
+	}
+
+	g.root.Update(deltaTime)
+	for _, o := range g.overlays {
+		o.widget.Update(deltaTime)
+	}
+}
+
+// arrange assigns the depths in drawing order and arranges the widgets
+// whose bounds or depth changed
+func (g *GUI) arrange(w Widget) {
+	b := w.Base()
+	if b.hidden {
+		return
+	}
+	b.gui = g
+	b.depth = guiDepthStart - float32(g.order)*guiDepthStep
+	g.order++
+
+	position := b.AbsolutePosition()
+	bounds := mgl32.Vec4{position[0], position[1], b.size[0], b.size[1]}
+	if b.dirty || bounds != b.arranged || b.depth != b.arrangedDepth {
+		w.Arrange()
+		position = b.AbsolutePosition()
+		b.arranged = mgl32.Vec4{position[0], position[1], b.size[0], b.size[1]}
+		b.arrangedDepth = b.depth
+		b.dirty = false
+	}
+	for _, child := range b.children {
+		g.arrange(child)
+	}
+}
+
+// EnqueueForDrawing enqueues the visible widgets and the overlays
+func (g *GUI) EnqueueForDrawing(context *graphics.Context) {
+	if !g.root.hidden {
+		g.root.EnqueueForDrawing(context)
+	}
+	for _, o := range g.overlays {
+		if !o.widget.Base().hidden {
+			o.widget.EnqueueForDrawing(context)
+		}
+	}
+}
+
+// reachable returns true if w is visible, enabled and part of the tree or
+// of an overlay
+func (g *GUI) reachable(w Widget) bool {
+	for {
+		b := w.Base()
+		if b.hidden || b.disabled {
+			return false
+		}
+		if b.parent == nil {
+			break
+		}
+		w = b.parent
+	}
+	if w == Widget(g.root) {
+		return true
+	}
+	for _, o := range g.overlays {
+		if o.widget == w {
+			return true
+		}
+	}
+	return false
+}
+
+// WidgetAt returns the topmost interactive widget under an absolute point
+func (g *GUI) WidgetAt(point mgl32.Vec2) Widget {
+	for i := len(g.overlays) - 1; i >= 0; i-- {
+		if w := g.hit(g.overlays[i].widget, point); w != nil {
+			return w
+		}
+	}
+	return g.hit(g.root, point)
+}
+
+func (g *GUI) hit(w Widget, point mgl32.Vec2) Widget {
+	b := w.Base()
+	if b.hidden || b.disabled || (b.clipChildren && !b.Contains(point)) {
+		return nil
+	}
+	for i := len(b.children) - 1; i >= 0; i-- {
+		if hit := g.hit(b.children[i], point); hit != nil {
+			return hit
+		}
+	}
+	if b.interactive && b.Contains(point) {
+		return w
+	}
+	return nil
+}
+
+// dispatch sends an event to the target and, if it bubbles, to its parents
+// until handled
+func (g *GUI) dispatch(target Widget, event *Event) {
+	event.Target = target
+	for w := target; w != nil; w = w.Base().parent {
+		w.Base().emit(event)
+		if event.Handled || !event.Type.bubbles() {
+			return
+		}
+	}
+}
+
+// MouseMove moves the pointer to an absolute position in pixels, updating
+// the hovered widget and dragging the pressed one
+func (g *GUI) MouseMove(point mgl32.Vec2) {
+	delta := point.Sub(g.pointer)
+	g.pointer = point
+	if g.pressed != nil {
+		g.dispatch(g.pressed, &Event{Type: EVENT_DRAG, Position: point, Delta: delta})
+	}
+	g.setHovered(g.WidgetAt(point))
+}
+
+func (g *GUI) setHovered(w Widget) {
+	if w == g.hovered {
+		return
+	}
+	if g.hovered != nil {
+		g.hovered.Base().hovered = false
+		g.dispatch(g.hovered, &Event{Type: EVENT_LEAVE, Position: g.pointer})
+	}
+	g.hovered = w
+	if w != nil {
+		w.Base().hovered = true
+		g.dispatch(w, &Event{Type: EVENT_HOVER, Position: g.pointer})
+	}
+}
+
+// MouseButton presses or releases the pointer. Pressing focuses the widget,
+// or its closest focusable parent, and closes the overlays it's not part of
+func (g *GUI) MouseButton(pressed bool) {
+	if pressed {
+		target := g.WidgetAt(g.pointer)
+		for i := len(g.overlays) - 1; i >= 0; i-- {
+			o := g.overlays[i]
+			if target == nil || (!o.widget.Base().isAncestorOf(target) && (o.owner == nil || !o.owner.Base().isAncestorOf(target))) {
+				g.closeOverlay(i)
+			}
+		}
+		if target == nil {
+			g.Focus(nil)
+			return
+		}
+		var focus Widget
+		for w := target; w != nil && focus == nil; w = w.Base().parent {
+			if w.Base().focusable {
+				focus = w
+			}
+		}
+		g.Focus(focus)
+		g.pressed = target
+		target.Base().pressed = true
+		g.dispatch(target, &Event{Type: EVENT_PRESS, Position: g.pointer})
+		return
+	}
+
+	if g.pressed == nil {
+		return
+	}
+	released := g.pressed
+	released.Base().pressed = false
+	g.pressed = nil
+	g.dispatch(released, &Event{Type: EVENT_RELEASE, Position: g.pointer})
+	if target := g.WidgetAt(g.pointer); target != nil && released.Base().isAncestorOf(target) {
+		g.dispatch(released, &Event{Type: EVENT_CLICK, Position: g.pointer})
+	}
+}
+
+// Scroll sends a scroll event to the widget under the pointer, delta is in
+// lines as reported by glfw
+func (g *GUI) Scroll(delta mgl32.Vec2) {
+	if target := g.WidgetAt(g.pointer); target != nil {
+		g.dispatch(target, &Event{Type: EVENT_SCROLL, Position: g.pointer, Delta: delta})
+	}
+}
+
+// Focus gives the focus to a widget, nil removes it. Scroll panels
+// containing the widget scroll to show it
+func (g *GUI) Focus(w Widget) {
+	if w == g.focused {
+		return
+	}
+	if g.focused != nil {
+		g.focused.Base().focused = false
+		g.dispatch(g.focused, &Event{Type: EVENT_BLUR})
+	}
+	g.focused = w
+	if w == nil {
+		return
+	}
+	w.Base().focused = true
+	g.dispatch(w, &Event{Type: EVENT_FOCUS})
+	for p := w.Base().parent; p != nil; p = p.Base().parent {
+		if s, ok := p.(*ScrollPanel); ok {
+			s.ScrollIntoView(w)
+		}
+	}
+}
+
+// Focused returns the widget with the focus
+func (g *GUI) Focused() Widget {
+	return g.focused
+}
+
+// Hovered returns the widget under the pointer
+func (g *GUI) Hovered() Widget {
+	return g.hovered
+}
+
+// Pointer returns the last pointer position
+func (g *GUI) Pointer() mgl32.Vec2 {
+	return g.pointer
+}
+
+// Navigate moves the focus to the closest focusable widget in a direction,
+// unless the focused widget handles the navigation itself. Without a focused
+// widget the first one gets the focus
+func (g *GUI) Navigate(direction NavDirection) {
+	if g.focused != nil {
+		event := &Event{Type: EVENT_NAVIGATE, Direction: direction}
+		g.dispatch(g.focused, event)
+		if event.Handled {
+			return
+		}
+	}
+
+	candidates := make([]Widget, 0)
+	if len(g.overlays) > 0 {
+		candidates = g.focusables(g.overlays[len(g.overlays)-1].widget, candidates)
+	} else {
+		candidates = g.focusables(g.root, candidates)
+	}
+	if len(candidates) == 0 {
+		return
+	}
+	current := -1
+	for i, c := range candidates {
+		if c == g.focused {
+			current = i
+		}
+	}
+	if current < 0 {
+		g.Focus(candidates[0])
+		return
+	}
+
+	from := g.focused.Base().Center()
+	var best Widget
+	var bestScore float32
+	for _, c := range candidates {
+		if c == g.focused {
+			continue
+		}
+		d := c.Base().Center().Sub(from)
+		var primary, secondary float32
+		switch direction {
+		case NAV_UP:
+			primary, secondary = -d[1], d[0]
+		case NAV_DOWN:
+			primary, secondary = d[1], d[0]
+		case NAV_LEFT:
+			primary, secondary = -d[0], d[1]
+		case NAV_RIGHT:
+			primary, secondary = d[0], d[1]
+		}
+		if primary <= 0 {
+			continue
+		}
+		if secondary < 0 {
+			secondary = -secondary
+		}
+		// Widgets aligned with the current one are preferred
+		score := primary + 2*secondary
+		if best == nil || score < bestScore {
+			best, bestScore = c, score
+		}
+	}
+	if best != nil {
+		g.Focus(best)
+	}
+}
+
+// focusables appends the visible, enabled and focusable widgets in tree order
+func (g *GUI) focusables(w Widget, list []Widget) []Widget {
+	b := w.Base()
+	if b.hidden || b.disabled {
+		return list
+	}
+	if b.focusable {
+		list = append(list, w)
+	}
+	for _, child := range b.children {
+		list = g.focusables(child, list)
+	}
+	return list
+}
+
+// Activate presses and clicks the focused widget, like the mouse would
+func (g *GUI) Activate() {
+	w := g.focused
+	if w == nil {
+		return
+	}
+	w.Base().pressed = true
+	g.dispatch(w, &Event{Type: EVENT_PRESS, Position: w.Base().Center()})
+	w.Base().pressed = false
+	g.dispatch(w, &Event{Type: EVENT_RELEASE, Position: w.Base().Center()})
+	g.dispatch(w, &Event{Type: EVENT_CLICK, Position: w.Base().Center()})
+}
+
+// Cancel sends a cancel event to the focused widget. If nobody handles it
+// the topmost overlay is closed
+func (g *GUI) Cancel() {
+	if g.focused != nil {
+		event := &Event{Type: EVENT_CANCEL}
+		g.dispatch(g.focused, event)
+		if event.Handled {
+			return
+		}
+	}
+	if len(g.overlays) > 0 {
+		g.closeOverlay(len(g.overlays) - 1)
+	}
+}
+
+// ShowOverlay draws a widget above all the others, at its position in the
+// GUI. Pressing outside of it and of its owner closes it, calling onClose
+func (g *GUI) ShowOverlay(widget, owner Widget, onClose func()) {
+	widget.Base().gui = g
+	widget.Base().dirty = true
+	g.applyTheme(widget)
+	g.overlays = append(g.overlays, overlay{widget, owner, onClose})
+}
+
+// HideOverlay closes an overlay opened with ShowOverlay
+func (g *GUI) HideOverlay(widget Widget) {
+	for i, o := range g.overlays {
+		if o.widget == widget {
+			g.closeOverlay(i)
+			return
+		}
+	}
+}
+
+func (g *GUI) closeOverlay(i int) {
+	o := g.overlays[i]
+	g.overlays = append(g.overlays[:i], g.overlays[i+1:]...)
+	o.widget.Base().gui = nil
+	if g.focused != nil && o.widget.Base().isAncestorOf(g.focused) {
+		if o.owner != nil && o.owner.Base().focusable {
+			g.Focus(o.owner)
+		} else {
+			g.Focus(nil)
+		}
+	}
+	if o.onClose != nil {
+		o.onClose()
+	}
+}
+
+// SetTheme restyles all the widgets with the styles of a theme, and the
+// ones added later. A nil theme keeps the current styles
+func (g *GUI) SetTheme(theme *Theme) {
+	g.theme = theme
+	g.applyTheme(g.root)
+	for _, o := range g.overlays {
+		g.applyTheme(o.widget)
+	}
+}
+
+// Theme returns the theme of the GUI, nil if none
+func (g *GUI) Theme() *Theme {
+	return g.theme
+}
+
+// applyTheme gives a widget and its children the styles named after them
+func (g *GUI) applyTheme(w Widget) {
+	if g.theme == nil {
+		return
+	}
+	b := w.Base()
+	if b.styleName != "" {
+		styled, ok := w.(interface {
+			SetStyle(style *WidgetStyle)
+		})
+		if style := g.theme.Style(b.styleName); ok && style != nil && style != b.style {
+			styled.SetStyle(style)
+		}
+	}
+	for _, child := range b.children {
+		g.applyTheme(child)
+	}
+}
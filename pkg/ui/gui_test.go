@@ -0,0 +1,215 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// testWidget records the events it receives, it draws nothing
+type testWidget struct {
+	WidgetBase
+	events   []EventType
+	handle   EventType
+	arranged int
+}
+
+func newTestWidget(x, y, width, height float32) *testWidget {
+	w := &testWidget{handle: -1}
+	w.init(w)
+	w.interactive = true
+	w.focusable = true
+	w.SetPosition(mgl32.Vec2{x, y})
+	w.SetSize(mgl32.Vec2{width, height})
+	return w
+}
+
+func (w *testWidget) Arrange() {
+	w.arranged++
+}
+
+func (w *testWidget) HandleEvent(event *Event) {
+	w.events = append(w.events, event.Type)
+	if event.Type == w.handle {
+		event.Handled = true
+	}
+}
+
+func (w *testWidget) received(eventType EventType) int {
+	count := 0
+	for _, e := range w.events {
+		if e == eventType {
+			count++
+		}
+	}
+	return count
+}
+
+func TestGUIPointerEvents(t *testing.T) {
+	gui := NewGUI(800, 600)
+	parent := newTestWidget(100, 100, 200, 200)
+	child := newTestWidget(10, 10, 50, 50)
+	parent.Add(child)
+	gui.Add(parent)
+	gui.Update(0)
+
+	if child.AbsolutePosition() != (mgl32.Vec2{110, 110}) {
+		t.Errorf("Wrong absolute position %v", child.AbsolutePosition())
+	}
+	if gui.WidgetAt(mgl32.Vec2{120, 120}) != Widget(child) || gui.WidgetAt(mgl32.Vec2{250, 250}) != Widget(parent) {
+		t.Errorf("Wrong hit test")
+	}
+	if gui.WidgetAt(mgl32.Vec2{50, 50}) != nil {
+		t.Errorf("The root panel should not be hit")
+	}
+
+	gui.MouseMove(mgl32.Vec2{120, 120})
+	if !child.Hovered() || parent.Hovered() || child.received(EVENT_HOVER) != 1 {
+		t.Errorf("Wrong hover, child %v parent %v", child.Hovered(), parent.Hovered())
+	}
+	gui.MouseButton(true)
+	gui.MouseButton(false)
+	if child.received(EVENT_CLICK) != 1 || !child.Focused() {
+		t.Errorf("Child events %v", child.events)
+	}
+	// Clicks bubble to the parent, press/release too
+	if parent.received(EVENT_CLICK) != 1 || parent.received(EVENT_PRESS) != 1 {
+		t.Errorf("Parent events %v", parent.events)
+	}
+
+	// Handled events stop bubbling
+	child.handle = EVENT_CLICK
+	gui.MouseButton(true)
+	gui.MouseButton(false)
+	if parent.received(EVENT_CLICK) != 1 {
+		t.Errorf("Handled click reached the parent")
+	}
+
+	// Releasing outside doesn't click, dragging is reported to the pressed
+	gui.MouseButton(true)
+	gui.MouseMove(mgl32.Vec2{250, 250})
+	gui.MouseButton(false)
+	if child.received(EVENT_CLICK) != 2 || child.received(EVENT_DRAG) != 1 || child.received(EVENT_LEAVE) != 1 {
+		t.Errorf("Child events %v", child.events)
+	}
+
+	// Hidden widgets are not hit and lose the hover
+	gui.MouseMove(mgl32.Vec2{120, 120})
+	child.SetVisible(false)
+	gui.Update(0)
+	if child.Hovered() || gui.WidgetAt(mgl32.Vec2{120, 120}) != Widget(parent) {
+		t.Errorf("Hidden child still hovered or hit")
+	}
+}
+
+func TestGUIArrange(t *testing.T) {
+	gui := NewGUI(800, 600)
+	parent := newTestWidget(0, 0, 100, 100)
+	child := newTestWidget(10, 10, 10, 10)
+	parent.Add(child)
+	gui.Add(parent)
+
+	gui.Update(0)
+	gui.Update(0)
+	if parent.arranged != 1 || child.arranged != 1 {
+		t.Errorf("Arranged %d %d times, expecting once", parent.arranged, child.arranged)
+	}
+	// Moving the parent moves the child
+	parent.SetPosition(mgl32.Vec2{5, 5})
+	gui.Update(0)
+	if parent.arranged != 2 || child.arranged != 2 {
+		t.Errorf("Arranged %d %d times, expecting twice", parent.arranged, child.arranged)
+	}
+	// Children are drawn in front of their parents
+	if child.Depth() >= parent.Depth() {
+		t.Errorf("Wrong depths %f %f", child.Depth(), parent.Depth())
+	}
+}
+
+func TestGUINavigation(t *testing.T) {
+	gui := NewGUI(800, 600)
+	// A 2x2 grid
+	topLeft := newTestWidget(0, 0, 100, 50)
+	topRight := newTestWidget(200, 0, 100, 50)
+	bottomLeft := newTestWidget(0, 100, 100, 50)
+	bottomRight := newTestWidget(200, 100, 100, 50)
+	disabled := newTestWidget(0, 200, 100, 50)
+	disabled.SetEnabled(false)
+	for _, w := range []Widget{topLeft, topRight, bottomLeft, bottomRight, disabled} {
+		gui.Add(w)
+	}
+	gui.Update(0)
+
+	var tests = []struct {
+		direction NavDirection
+		expected  Widget
+	}{
+		// The first focusable widget gets the focus
+		{NAV_DOWN, topLeft},
+		{NAV_RIGHT, topRight},
+		{NAV_DOWN, bottomRight},
+		{NAV_LEFT, bottomLeft},
+		// Disabled widgets are skipped, nothing below
+		{NAV_DOWN, bottomLeft},
+		{NAV_UP, topLeft},
+	}
+	for i, test := range tests {
+		gui.Navigate(test.direction)
+		if gui.Focused() != test.expected {
+			t.Errorf("%d: wrong focus", i)
+		}
+	}
+
+	// The focused widget can keep the navigation
+	topLeft.handle = EVENT_NAVIGATE
+	gui.Navigate(NAV_RIGHT)
+	if gui.Focused() != Widget(topLeft) || topLeft.received(EVENT_NAVIGATE) == 0 {
+		t.Errorf("Navigation not handled by the focused widget")
+	}
+
+	gui.Activate()
+	if topLeft.received(EVENT_PRESS) != 1 || topLeft.received(EVENT_CLICK) != 1 {
+		t.Errorf("Activate events %v", topLeft.events)
+	}
+	if topRight.received(EVENT_BLUR) != 1 {
+		t.Errorf("Blur events %v", topRight.events)
+	}
+}
+
+func TestGUIOverlay(t *testing.T) {
+	gui := NewGUI(800, 600)
+	owner := newTestWidget(0, 0, 100, 50)
+	below := newTestWidget(0, 50, 100, 100)
+	gui.Add(owner)
+	gui.Add(below)
+	list := newTestWidget(0, 50, 100, 100)
+	item := newTestWidget(0, 0, 100, 20)
+	list.Add(item)
+	closed := 0
+	gui.ShowOverlay(list, owner, func() { closed++ })
+	gui.Update(0)
+
+	// Overlays are above the tree and limit the navigation
+	if gui.WidgetAt(mgl32.Vec2{10, 60}) != Widget(item) {
+		t.Errorf("Overlay not hit first")
+	}
+	gui.Navigate(NAV_DOWN)
+	gui.Navigate(NAV_DOWN)
+	if gui.Focused() != Widget(list) && gui.Focused() != Widget(item) {
+		t.Errorf("Focus left the overlay")
+	}
+
+	// Pressing the owner keeps the overlay, pressing elsewhere closes it
+	gui.MouseMove(mgl32.Vec2{10, 10})
+	gui.MouseButton(true)
+	gui.MouseButton(false)
+	if closed != 0 {
+		t.Errorf("Overlay closed pressing its owner")
+	}
+	gui.MouseMove(mgl32.Vec2{500, 500})
+	gui.MouseButton(true)
+	gui.MouseButton(false)
+	if closed != 1 || gui.WidgetAt(mgl32.Vec2{10, 60}) != Widget(below) {
+		t.Errorf("Overlay not closed")
+	}
+}
@@ -0,0 +1,18 @@
+package ui
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// vecNear compares two vectors with a tolerance
+func vecNear(a, b []float32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if mgl32.Abs(a[i]-b[i]) > 0.0001 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"github.com/markov/gojira2d/pkg/graphics"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+var imageShaderProgram *graphics.ShaderProgram
+
+// Image is a non interactive texture, tinted by a color
+type Image struct {
+	WidgetBase
+	primitive *graphics.Primitive2D
+	color     graphics.Color
+}
+
+// NewImage creates an image as large as the texture
+func NewImage(texture *graphics.Texture) *Image {
+	if imageShaderProgram == nil {
+		imageShaderProgram = graphics.NewShaderProgram(
+			graphics.VertexShaderPrimitive2D, "", graphics.FragmentShaderTextureColor,
+		)
+	}
+	i := &Image{color: graphics.Color{1, 1, 1, 1}}
+	i.init(i)
+	quad := []float32{0, 0, 0, 1, 1, 1, 0, 0, 1, 1, 1, 0}
+	i.primitive = graphics.NewTriangles(quad, quad, texture, mgl32.Vec3{}, mgl32.Vec2{}, imageShaderProgram)
+	i.SetTexture(texture)
+	return i
+}
+
+// SetTexture changes the texture and resizes the image to it
+func (i *Image) SetTexture(texture *graphics.Texture) {
+	i.primitive.SetTexture(texture)
+	i.SetSize(mgl32.Vec2{float32(texture.Width()), float32(texture.Height())})
+}
+
+// Texture returns the texture of the image
+func (i *Image) Texture() *graphics.Texture {
+	return i.primitive.Texture()
+}
+
+// SetColor sets the tint of the image
+func (i *Image) SetColor(color graphics.Color) {
+	i.color = color
+}
+
+// Arrange see Widget.Arrange
+func (i *Image) Arrange() {
+	position := i.AbsolutePosition()
+	i.primitive.SetPosition(mgl32.Vec3{position[0], position[1], i.layerDepth(0)})
+	i.primitive.SetSize(i.size)
+}
+
+// EnqueueForDrawing see Widget.EnqueueForDrawing
+func (i *Image) EnqueueForDrawing(context *graphics.Context) {
+	i.primitive.SetColor(i.color)
+	context.EnqueueForDrawing(i.primitive)
+	i.WidgetBase.EnqueueForDrawing(context)
+}
@@ -0,0 +1,533 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/markov/gojira2d/pkg/graphics"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	imDefaultWidth = 320
+	// Pixels the pointer moves before pressing a title bar drags the window
+	imDragThreshold = 3
+	// Windows are drawn in front of the retained GUI, each one in front of
+	// the previous. Shapes of a window are layered by imDepthLayer
+	imDepthStart = 0.5
+	imDepthStep  = 0.01
+	imDepthLayer = 0.001
+)
+
+type imCommandKind int
+
+const (
+	imRect imCommandKind = iota
+	imText
+	imLine
+)
+
+// imCommand is a shape of a window, recorded during the frame and drawn by
+// EnqueueForDrawing. Positions are absolute
+type imCommand struct {
+	kind     imCommandKind
+	position mgl32.Vec2
+	size     mgl32.Vec2
+	color    graphics.Color
+	layer    int
+	text     string
+	// Vertices of lines, relative to the position and scaled by the size
+	points []float32
+}
+
+// imWindow is the state of a window kept between the frames
+type imWindow struct {
+	title     string
+	position  mgl32.Vec2
+	size      mgl32.Vec2
+	collapsed bool
+	// Frame of the last Begin, windows not begun in a frame are not drawn
+	frame int
+	// Top of the next line, left of the content and indentation of the
+	// tree nodes
+	cursorY float32
+	indent  float32
+	// Bounds of the last item, for SameLine
+	lastPosition mgl32.Vec2
+	lastSize     mgl32.Vec2
+	sameLine     bool
+	commands     []imCommand
+	// Position when the title bar was pressed, for dragging
+	dragStart mgl32.Vec2
+	dragged   bool
+}
+
+// ImmediateGUI is an immediate mode GUI for tools and debug panels. Widgets
+// are functions called every frame between Begin and End, returning what
+// the user did:
+//
+//	debug.NewFrame()
+//	if debug.Begin("Player") {
+//		debug.SliderFloat("Speed", &speed, 0, 10)
+//		if debug.Button("Respawn") {
+//			respawn()
+//		}
+//	}
+//	debug.End()
+//	debug.EnqueueForDrawing(app.UIContext)
+//
+// Windows are dragged by the title bar and collapsed by clicking it. The
+// state of windows and tree nodes is kept by title and label; labels can
+// be followed by "##" and a suffix hidden on screen, to tell apart widgets
+// with the same text
+type ImmediateGUI struct {
+	style   *WidgetStyle
+	windows map[string]*imWindow
+	// Drawing order of the windows, the last one on top
+	order []*imWindow
+	// Windows drawn in the last frame, in order
+	visible []*imWindow
+	current *imWindow
+	ids     []string
+	open    map[string]bool
+	frame   int
+
+	nextPosition *mgl32.Vec2
+	nextWidth    float32
+
+	mouse          mgl32.Vec2
+	down           bool
+	pressed        bool
+	released       bool
+	pendingPress   bool
+	pendingRelease bool
+	// Item pressed and not released yet, it gets the input until then
+	active        string
+	hoveredWindow *imWindow
+
+	rects     []*graphics.Primitive2D
+	texts     []*Text
+	lines     []*graphics.Primitive2D
+	usedRects int
+	usedTexts int
+	usedLines int
+
+	window              *glfw.Window
+	captured            bool
+	previousCursorPos   glfw.CursorPosCallback
+	previousMouseButton glfw.MouseButtonCallback
+	previousScroll      glfw.ScrollCallback
+}
+
+// NewImmediateGUI creates an immediate mode GUI. Windows use the darkest
+// background of the style, the pressed one, and items the other ones
+func NewImmediateGUI(style *WidgetStyle) *ImmediateGUI {
+	return &ImmediateGUI{
+		style:   style,
+		windows: make(map[string]*imWindow),
+		open:    make(map[string]bool),
+	}
+}
+
+// SetStyle changes the look of the windows
+func (g *ImmediateGUI) SetStyle(style *WidgetStyle) {
+	g.style = style
+}
+
+// Attach installs the cursor, mouse button and scroll callbacks of the
+// window. Callbacks set before are still called, but not for the clicks
+// and scrolls over the GUI windows
+func (g *ImmediateGUI) Attach(window *glfw.Window) {
+	if g.window != nil {
+		g.Detach()
+	}
+	g.window = window
+	g.previousCursorPos = window.SetCursorPosCallback(func(w *glfw.Window, x, y float64) {
+		if g.previousCursorPos != nil {
+			g.previousCursorPos(w, x, y)
+		}
+		g.MouseMove(mgl32.Vec2{float32(x), float32(y)})
+	})
+	g.previousMouseButton = window.SetMouseButtonCallback(func(w *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
+		if button == glfw.MouseButtonLeft && action != glfw.Repeat {
+			pressed := action == glfw.Press
+			if pressed {
+				g.captured = g.windowAt(g.mouse) != nil
+			}
+			captured := g.captured
+			if !pressed {
+				g.captured = false
+			}
+			g.MouseButton(pressed)
+			if captured {
+				return
+			}
+		} else if g.windowAt(g.mouse) != nil {
+			return
+		}
+		if g.previousMouseButton != nil {
+			g.previousMouseButton(w, button, action, mods)
+		}
+	})
+	g.previousScroll = window.SetScrollCallback(func(w *glfw.Window, x, y float64) {
+		if g.previousScroll != nil && g.windowAt(g.mouse) == nil {
+			g.previousScroll(w, x, y)
+		}
+	})
+}
+
+// Detach restores the callbacks the window had before Attach
+func (g *ImmediateGUI) Detach() {
+	if g.window == nil {
+		return
+	}
+	g.window.SetCursorPosCallback(g.previousCursorPos)
+	g.window.SetMouseButtonCallback(g.previousMouseButton)
+	g.window.SetScrollCallback(g.previousScroll)
+	g.window = nil
+	g.previousCursorPos = nil
+	g.previousMouseButton = nil
+	g.previousScroll = nil
+}
+
+// MouseMove moves the pointer, in pixels
+func (g *ImmediateGUI) MouseMove(point mgl32.Vec2) {
+	g.mouse = point
+}
+
+// MouseButton presses or releases the button, seen by the next frame
+func (g *ImmediateGUI) MouseButton(pressed bool) {
+	g.down = pressed
+	if pressed {
+		g.pendingPress = true
+	} else {
+		g.pendingRelease = true
+	}
+}
+
+// WantsMouse returns true when the pointer is over a window or an item is
+// being dragged: the game should ignore the mouse
+func (g *ImmediateGUI) WantsMouse() bool {
+	return g.active != "" || g.windowAt(g.mouse) != nil
+}
+
+// windowAt returns the topmost window drawn in the last frame containing a
+// point
+func (g *ImmediateGUI) windowAt(point mgl32.Vec2) *imWindow {
+	for i := len(g.visible) - 1; i >= 0; i-- {
+		w := g.visible[i]
+		if imContains(w.position, w.size, point) {
+			return w
+		}
+	}
+	return nil
+}
+
+func imContains(position, size, point mgl32.Vec2) bool {
+	return point[0] >= position[0] && point[0] < position[0]+size[0] &&
+		point[1] >= position[1] && point[1] < position[1]+size[1]
+}
+
+// NewFrame starts a frame, call it before the windows
+func (g *ImmediateGUI) NewFrame() {
+	// The release was seen by the last frame
+	if g.released {
+		g.active = ""
+	}
+	g.frame++
+	g.pressed, g.released = g.pendingPress, g.pendingRelease
+	g.pendingPress, g.pendingRelease = false, false
+	g.hoveredWindow = g.windowAt(g.mouse)
+	if g.pressed && g.hoveredWindow != nil {
+		g.bringToFront(g.hoveredWindow)
+	}
+}
+
+func (g *ImmediateGUI) bringToFront(w *imWindow) {
+	for i, o := range g.order {
+		if o == w {
+			g.order = append(append(g.order[:i], g.order[i+1:]...), w)
+			return
+		}
+	}
+}
+
+// SetNextWindowPosition places the next window created by Begin
+func (g *ImmediateGUI) SetNextWindowPosition(position mgl32.Vec2) {
+	g.nextPosition = &position
+}
+
+// SetNextWindowWidth sets the width of the next window created by Begin
+func (g *ImmediateGUI) SetNextWindowWidth(width float32) {
+	g.nextWidth = width
+}
+
+// lineHeight returns the height of the items
+func (g *ImmediateGUI) lineHeight() float32 {
+	return g.style.FontSize + g.style.Padding[0] + g.style.Padding[1]
+}
+
+// spacing returns the space between the items
+func (g *ImmediateGUI) spacing() float32 {
+	return g.style.Padding[0]
+}
+
+// Begin starts a window, returning false if it's collapsed. End must be
+// called in both cases
+func (g *ImmediateGUI) Begin(title string) bool {
+	w, ok := g.windows[title]
+	if !ok {
+		offset := float32(20 + 30*len(g.windows))
+		w = &imWindow{title: title, position: mgl32.Vec2{offset, offset}, size: mgl32.Vec2{imDefaultWidth, 0}}
+		if g.nextPosition != nil {
+			w.position = *g.nextPosition
+		}
+		if g.nextWidth > 0 {
+			w.size[0] = g.nextWidth
+		}
+		g.windows[title] = w
+		g.order = append(g.order, w)
+	}
+	g.nextPosition = nil
+	g.nextWidth = 0
+	g.current = w
+	g.ids = g.ids[:0]
+	w.frame = g.frame
+	w.commands = w.commands[:0]
+	w.indent = 0
+	w.sameLine = false
+
+	// Title bar, dragged or clicked to collapse
+	titleID := title + "##title"
+	lineHeight := g.lineHeight()
+	hovered, held, clicked := g.interact(titleID, w.position, mgl32.Vec2{w.size[0], lineHeight})
+	if hovered && g.pressed {
+		w.dragStart = w.position.Sub(g.mouse)
+		w.dragged = false
+	}
+	if held {
+		position := g.mouse.Add(w.dragStart)
+		if position.Sub(w.position).Len() >= imDragThreshold || w.dragged {
+			w.dragged = true
+			w.position = position
+		}
+	}
+	if clicked && !w.dragged {
+		w.collapsed = !w.collapsed
+	}
+
+	// The background is resized by End
+	g.addRect(w.position, w.size, g.style.Background.Pressed, 0)
+	g.addRect(w.position, mgl32.Vec2{w.size[0], lineHeight}, g.style.Accent.Color(imState(hovered, held)), 1)
+	arrow := "v "
+	if w.collapsed {
+		arrow = "> "
+	}
+	g.addText(arrow+imDisplay(title), mgl32.Vec2{w.position[0] + g.style.Padding[2], w.position[1]}, lineHeight, g.style.Text.Normal)
+
+	w.cursorY = w.position[1] + lineHeight + g.spacing()
+	return !w.collapsed
+}
+
+// End finishes the window started by Begin, fitting it to the content
+func (g *ImmediateGUI) End() {
+	w := g.current
+	if w == nil {
+		return
+	}
+	if w.collapsed {
+		w.size[1] = g.lineHeight()
+	} else {
+		w.size[1] = w.cursorY - g.spacing() - w.position[1] + g.style.Padding[1]
+	}
+	w.commands[0].size = w.size
+	g.current = nil
+}
+
+// PushID makes the following labels unique, e.g. inside loops
+func (g *ImmediateGUI) PushID(id string) {
+	g.ids = append(g.ids, id)
+}
+
+// PopID removes the last id pushed
+func (g *ImmediateGUI) PopID() {
+	if len(g.ids) > 0 {
+		g.ids = g.ids[:len(g.ids)-1]
+	}
+}
+
+// id returns the unique id of an item of the current window
+func (g *ImmediateGUI) id(label string) string {
+	return g.current.title + "/" + strings.Join(append(g.ids, label), "/")
+}
+
+// imDisplay returns the part of a label shown on screen
+func imDisplay(label string) string {
+	if i := strings.Index(label, "##"); i >= 0 {
+		return label[:i]
+	}
+	return label
+}
+
+// SameLine places the next item to the right of the previous one
+func (g *ImmediateGUI) SameLine() {
+	if g.current != nil {
+		g.current.sameLine = true
+	}
+}
+
+// contentWidth returns the width available to the items of the current
+// window
+func (g *ImmediateGUI) contentWidth() float32 {
+	return g.current.size[0] - g.style.Padding[2] - g.style.Padding[3] - g.current.indent
+}
+
+// item reserves space for an item in the current window, returning its
+// position
+func (g *ImmediateGUI) item(size mgl32.Vec2) mgl32.Vec2 {
+	w := g.current
+	var position mgl32.Vec2
+	if w.sameLine {
+		position = mgl32.Vec2{w.lastPosition[0] + w.lastSize[0] + g.spacing(), w.lastPosition[1]}
+		w.sameLine = false
+	} else {
+		position = mgl32.Vec2{w.position[0] + g.style.Padding[2] + w.indent, w.cursorY}
+	}
+	if bottom := position[1] + size[1] + g.spacing(); bottom > w.cursorY {
+		w.cursorY = bottom
+	}
+	w.lastPosition = position
+	w.lastSize = size
+	return position
+}
+
+// interact returns if an item is hovered, held down and clicked this frame
+func (g *ImmediateGUI) interact(id string, position, size mgl32.Vec2) (hovered, held, clicked bool) {
+	hovered = g.hoveredWindow == g.current && imContains(position, size, g.mouse) &&
+		(g.active == "" || g.active == id)
+	if hovered && g.pressed {
+		g.active = id
+	}
+	held = g.active == id && g.down
+	clicked = g.active == id && g.released && hovered
+	return
+}
+
+// imState returns the state of an item for its colors
+func imState(hovered, held bool) WidgetState {
+	switch {
+	case held:
+		return STATE_PRESSED
+	case hovered:
+		return STATE_HOVER
+	}
+	return STATE_NORMAL
+}
+
+func (g *ImmediateGUI) addRect(position, size mgl32.Vec2, color graphics.Color, layer int) {
+	g.current.commands = append(g.current.commands, imCommand{
+		kind: imRect, position: position, size: size, color: color, layer: layer,
+	})
+}
+
+// addText adds a line of text centered vertically in height
+func (g *ImmediateGUI) addText(text string, position mgl32.Vec2, height float32, color graphics.Color) {
+	if text == "" {
+		return
+	}
+	position[1] += (height - g.style.FontSize) / 2
+	g.current.commands = append(g.current.commands, imCommand{
+		kind: imText, position: position, color: color, layer: 3, text: text,
+	})
+}
+
+func (g *ImmediateGUI) addLine(position, size mgl32.Vec2, points []float32, color graphics.Color, layer int) {
+	g.current.commands = append(g.current.commands, imCommand{
+		kind: imLine, position: position, size: size, color: color, layer: layer, points: points,
+	})
+}
+
+// textWidth returns the width in pixels of a line of text
+func (g *ImmediateGUI) textWidth(text string) float32 {
+	if text == "" {
+		return 0
+	}
+	return measureText(g.style, text)[0]
+}
+
+// EnqueueForDrawing draws the windows begun in this frame, call it after
+// the last End
+func (g *ImmediateGUI) EnqueueForDrawing(context *graphics.Context) {
+	g.updateVisible()
+	g.usedRects, g.usedTexts, g.usedLines = 0, 0, 0
+	for i, w := range g.visible {
+		depth := float32(imDepthStart - float64(i)*imDepthStep)
+		for _, c := range w.commands {
+			position := mgl32.Vec3{c.position[0], c.position[1], depth - float32(c.layer)*imDepthLayer}
+			switch c.kind {
+			case imRect:
+				g.rect(position, c.size, c.color).EnqueueForDrawing(context)
+			case imText:
+				g.text(position, c.text, c.color).EnqueueForDrawing(context)
+			case imLine:
+				g.line(position, c.size, c.points, c.color).EnqueueForDrawing(context)
+			}
+		}
+	}
+}
+
+// updateVisible lists the windows begun in this frame, the ones getting
+// the input until the next frame
+func (g *ImmediateGUI) updateVisible() {
+	g.visible = g.visible[:0]
+	for _, w := range g.order {
+		if w.frame == g.frame {
+			g.visible = append(g.visible, w)
+		}
+	}
+}
+
+// rect returns a rectangle of the pool
+func (g *ImmediateGUI) rect(position mgl32.Vec3, size mgl32.Vec2, color graphics.Color) *graphics.Primitive2D {
+	if g.usedRects == len(g.rects) {
+		g.rects = append(g.rects, graphics.NewRectanglePrimitive(position, size))
+	}
+	r := g.rects[g.usedRects]
+	g.usedRects++
+	r.SetPosition(position)
+	r.SetSize(size)
+	r.SetColor(color)
+	return r
+}
+
+// text returns a text of the pool, texts are reused in the same order
+// every frame so they are rarely laid out again
+func (g *ImmediateGUI) text(position mgl32.Vec3, text string, color graphics.Color) *Text {
+	size := mgl32.Vec2{g.style.FontSize, g.style.FontSize}
+	if g.usedTexts == len(g.texts) {
+		g.texts = append(g.texts, NewText(text, g.style.Font, position, size, color, mgl32.Vec4{}))
+	}
+	t := g.texts[g.usedTexts]
+	g.usedTexts++
+	t.SetFont(g.style.Font)
+	t.SetSize(size)
+	t.SetText(text)
+	t.SetPosition(position)
+	t.SetColor(color)
+	return t
+}
+
+// line returns a line strip of the pool
+func (g *ImmediateGUI) line(position mgl32.Vec3, size mgl32.Vec2, points []float32, color graphics.Color) *graphics.Primitive2D {
+	if g.usedLines == len(g.lines) {
+		g.lines = append(g.lines, graphics.NewLineStripPrimitive(position, size))
+	}
+	l := g.lines[g.usedLines]
+	g.usedLines++
+	l.SetPosition(position)
+	l.SetSize(size)
+	l.SetVertices(points)
+	l.SetColor(color)
+	return l
+}
@@ -0,0 +1,266 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// newTestImmediateGUI creates a GUI with items 30 pixels high and 5 apart.
+// Windows are 300 pixels wide, the first item is 35 pixels below the top
+func newTestImmediateGUI() *ImmediateGUI {
+	return NewImmediateGUI(&WidgetStyle{
+		Font:     newTestMonoFont(),
+		FontSize: 20,
+		Padding:  mgl32.Vec4{5, 5, 10, 10},
+	})
+}
+
+// imFrame runs a frame with a window at 100, 100, without drawing
+func imFrame(g *ImmediateGUI, items func()) bool {
+	g.NewFrame()
+	g.SetNextWindowPosition(mgl32.Vec2{100, 100})
+	g.SetNextWindowWidth(300)
+	open := g.Begin("Test")
+	if open {
+		items()
+	}
+	g.End()
+	g.updateVisible()
+	return open
+}
+
+func imClick(g *ImmediateGUI, point mgl32.Vec2, items func()) {
+	g.MouseMove(point)
+	g.MouseButton(true)
+	imFrame(g, items)
+	g.MouseButton(false)
+	imFrame(g, items)
+}
+
+func TestImmediateButton(t *testing.T) {
+	g := newTestImmediateGUI()
+	clicks := map[string]int{}
+	items := func() {
+		for _, label := range []string{"OK##first", "OK##second"} {
+			if g.Button(label) {
+				clicks[label]++
+			}
+		}
+	}
+	imFrame(g, items)
+
+	// Second button, below the first one
+	g.MouseMove(mgl32.Vec2{115, 175})
+	g.MouseButton(true)
+	imFrame(g, items)
+	if len(clicks) != 0 || !g.WantsMouse() {
+		t.Errorf("Clicked on press %v", clicks)
+	}
+	g.MouseButton(false)
+	imFrame(g, items)
+	if clicks["OK##second"] != 1 || clicks["OK##first"] != 0 {
+		t.Errorf("Wrong clicks %v", clicks)
+	}
+	imFrame(g, items)
+	if clicks["OK##second"] != 1 {
+		t.Errorf("Clicked twice")
+	}
+
+	// Pressing outside and releasing over the button does nothing
+	g.MouseMove(mgl32.Vec2{50, 50})
+	if g.WantsMouse() {
+		t.Errorf("Mouse wanted outside of the windows")
+	}
+	g.MouseButton(true)
+	imFrame(g, items)
+	g.MouseMove(mgl32.Vec2{115, 140})
+	g.MouseButton(false)
+	imFrame(g, items)
+	if clicks["OK##first"] != 0 {
+		t.Errorf("Clicked by a drag from outside")
+	}
+
+	// Pressing and releasing within a frame clicks
+	g.MouseButton(true)
+	g.MouseButton(false)
+	imFrame(g, items)
+	if clicks["OK##first"] != 1 {
+		t.Errorf("Quick click lost")
+	}
+}
+
+func TestImmediateCheckboxAndSlider(t *testing.T) {
+	g := newTestImmediateGUI()
+	checked := false
+	speed := float32(0)
+	count := 0
+	items := func() {
+		g.Checkbox("Enabled", &checked)
+		g.SliderFloat("Speed", &speed, 0, 1)
+		g.SliderInt("Count", &count, 0, 10)
+	}
+	imFrame(g, items)
+
+	imClick(g, mgl32.Vec2{115, 140}, items)
+	if !checked {
+		t.Errorf("Checkbox not toggled")
+	}
+	imClick(g, mgl32.Vec2{115, 140}, items)
+	if checked {
+		t.Errorf("Checkbox not toggled back")
+	}
+
+	// The slider is 182 pixels wide with a grab of 15
+	g.MouseMove(mgl32.Vec2{110 + 7.5 + 0.5*167, 175})
+	g.MouseButton(true)
+	imFrame(g, items)
+	if speed != 0.5 {
+		t.Errorf("Expected speed 0.5, got %v", speed)
+	}
+	// Dragged beyond the window
+	g.MouseMove(mgl32.Vec2{600, 300})
+	imFrame(g, items)
+	if speed != 1 {
+		t.Errorf("Expected speed 1, got %v", speed)
+	}
+	g.MouseButton(false)
+	imFrame(g, items)
+
+	imClick(g, mgl32.Vec2{110 + 7.5 + 0.32*167, 210}, items)
+	if count != 3 {
+		t.Errorf("Expected count 3, got %v", count)
+	}
+}
+
+func TestImmediateWindow(t *testing.T) {
+	g := newTestImmediateGUI()
+	items := func() {
+		g.Text("Hello")
+		g.Text("World")
+	}
+	imFrame(g, items)
+	w := g.windows["Test"]
+	if w.size != (mgl32.Vec2{300, 105}) {
+		t.Errorf("Wrong window size %v", w.size)
+	}
+
+	// Dragged by the title bar
+	g.MouseMove(mgl32.Vec2{150, 110})
+	g.MouseButton(true)
+	imFrame(g, items)
+	g.MouseMove(mgl32.Vec2{200, 160})
+	imFrame(g, items)
+	if w.position != (mgl32.Vec2{150, 150}) {
+		t.Errorf("Window not dragged, at %v", w.position)
+	}
+	g.MouseButton(false)
+	imFrame(g, items)
+	if w.collapsed {
+		t.Errorf("Collapsed by dragging")
+	}
+
+	// Collapsed by a click
+	imClick(g, mgl32.Vec2{200, 160}, items)
+	if imFrame(g, items) || w.size[1] != 30 {
+		t.Errorf("Window not collapsed, %v", w.size)
+	}
+	imClick(g, mgl32.Vec2{200, 160}, items)
+	if !imFrame(g, items) {
+		t.Errorf("Window not expanded")
+	}
+
+	// Windows not begun are hidden and don't take the mouse
+	g.NewFrame()
+	g.updateVisible()
+	if g.WantsMouse() {
+		t.Errorf("Hidden window wants the mouse")
+	}
+}
+
+func TestImmediateWindowOrder(t *testing.T) {
+	g := newTestImmediateGUI()
+	clicked := ""
+	frame := func() {
+		g.NewFrame()
+		for _, title := range []string{"A", "B"} {
+			g.SetNextWindowPosition(mgl32.Vec2{100, 100})
+			if g.Begin(title) && g.Button("Go") {
+				clicked = title
+			}
+			g.End()
+		}
+		g.updateVisible()
+	}
+	frame()
+	// B is on top
+	g.MouseMove(mgl32.Vec2{115, 140})
+	g.MouseButton(true)
+	frame()
+	g.MouseButton(false)
+	frame()
+	if clicked != "B" {
+		t.Errorf("Expected B clicked, got %q", clicked)
+	}
+
+	g.bringToFront(g.windows["A"])
+	frame()
+	g.MouseButton(true)
+	frame()
+	g.MouseButton(false)
+	frame()
+	if clicked != "A" {
+		t.Errorf("Expected A clicked, got %q", clicked)
+	}
+}
+
+func TestImmediateTreeNode(t *testing.T) {
+	g := newTestImmediateGUI()
+	var opened bool
+	var buttonX float32
+	items := func() {
+		opened = g.TreeNode("Node")
+		if opened {
+			g.Button("Inside")
+			buttonX = g.current.lastPosition[0]
+			g.TreePop()
+		}
+	}
+	imFrame(g, items)
+	imClick(g, mgl32.Vec2{200, 140}, items)
+	if !opened {
+		t.Fatalf("Node not opened")
+	}
+	if buttonX != 140 {
+		t.Errorf("Items not indented, at %v", buttonX)
+	}
+	if g.current != nil || g.windows["Test"].indent != 0 {
+		t.Errorf("Indentation not restored")
+	}
+	imClick(g, mgl32.Vec2{200, 140}, items)
+	if opened {
+		t.Errorf("Node not closed")
+	}
+}
+
+func TestTimeSeries(t *testing.T) {
+	s := NewTimeSeries(3)
+	if len(s.Values()) != 0 {
+		t.Errorf("New series not empty")
+	}
+	s.Add(1)
+	s.Add(2)
+	if !vecNear(s.Values(), []float32{1, 2}) || s.Len() != 2 {
+		t.Errorf("Wrong values %v", s.Values())
+	}
+	s.Add(3)
+	s.Add(4)
+	if !vecNear(s.Values(), []float32{2, 3, 4}) || s.Len() != 3 {
+		t.Errorf("Wrong values %v", s.Values())
+	}
+
+	points := plotPoints([]float32{0, 5, 10}, 0, 10)
+	if !vecNear(points, []float32{0, 1, 0.5, 0.5, 1, 0}) {
+		t.Errorf("Wrong points %v", points)
+	}
+}
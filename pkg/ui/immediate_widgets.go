@@ -0,0 +1,284 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/markov/gojira2d/pkg/graphics"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Part of the content width taken by the frames of sliders and plots, the
+// label is on the right
+const imFrameRatio = 0.65
+
+// Text adds a line of formatted text
+func (g *ImmediateGUI) Text(format string, args ...interface{}) {
+	text := fmt.Sprintf(format, args...)
+	position := g.item(mgl32.Vec2{g.textWidth(text), g.lineHeight()})
+	g.addText(text, position, g.lineHeight(), g.style.Text.Normal)
+}
+
+// Separator adds a horizontal line
+func (g *ImmediateGUI) Separator() {
+	position := g.item(mgl32.Vec2{g.contentWidth(), 1})
+	g.addRect(position, mgl32.Vec2{g.contentWidth(), 1}, g.style.Text.Disabled, 1)
+}
+
+// Button adds a button fitting its label, returning true when clicked
+func (g *ImmediateGUI) Button(label string) bool {
+	text := imDisplay(label)
+	size := mgl32.Vec2{g.textWidth(text) + g.style.Padding[2] + g.style.Padding[3], g.lineHeight()}
+	position := g.item(size)
+	hovered, held, clicked := g.interact(g.id(label), position, size)
+	g.addRect(position, size, g.style.Background.Color(imState(hovered, held)), 1)
+	g.addText(text, position.Add(mgl32.Vec2{g.style.Padding[2], 0}), size[1], g.style.Text.Normal)
+	return clicked
+}
+
+// Checkbox adds a box toggling value when clicked with its label, returning
+// true when changed
+func (g *ImmediateGUI) Checkbox(label string, value *bool) bool {
+	text := imDisplay(label)
+	box := g.lineHeight()
+	size := mgl32.Vec2{box + g.spacing() + g.textWidth(text), box}
+	position := g.item(size)
+	hovered, held, clicked := g.interact(g.id(label), position, size)
+	if clicked {
+		*value = !*value
+	}
+	g.addRect(position, mgl32.Vec2{box, box}, g.style.Background.Color(imState(hovered, held)), 1)
+	if *value {
+		inset := box / 4
+		g.addRect(position.Add(mgl32.Vec2{inset, inset}), mgl32.Vec2{box - 2*inset, box - 2*inset}, g.style.Accent.Normal, 2)
+	}
+	g.addText(text, position.Add(mgl32.Vec2{box + g.spacing(), 0}), box, g.style.Text.Normal)
+	return clicked
+}
+
+// SliderFloat adds a slider dragging value between min and max, returning
+// true when changed
+func (g *ImmediateGUI) SliderFloat(label string, value *float32, min, max float32) bool {
+	width := g.contentWidth() * imFrameRatio
+	changed := g.slider(label, value, min, max, width, func(v float32) string {
+		return fmt.Sprintf("%.3f", v)
+	})
+	g.label(label)
+	return changed
+}
+
+// SliderInt adds a slider dragging value between min and max, returning true
+// when changed
+func (g *ImmediateGUI) SliderInt(label string, value *int, min, max int) bool {
+	v := float32(*value)
+	width := g.contentWidth() * imFrameRatio
+	g.slider(label, &v, float32(min), float32(max), width, func(v float32) string {
+		return fmt.Sprintf("%d", roundInt(v))
+	})
+	g.label(label)
+	rounded := roundInt(v)
+	if rounded == *value {
+		return false
+	}
+	*value = rounded
+	return true
+}
+
+// ColorEdit adds a slider for each channel of color and a preview of it,
+// returning true when changed
+func (g *ImmediateGUI) ColorEdit(label string, color *graphics.Color) bool {
+	spacing := g.spacing()
+	preview := g.lineHeight()
+	width := (g.contentWidth()*imFrameRatio - preview - 4*spacing) / 4
+	changed := false
+	g.PushID(label)
+	for i, channel := range []string{"R", "G", "B", "A"} {
+		if i > 0 {
+			g.SameLine()
+		}
+		format := func(v float32) string {
+			return fmt.Sprintf("%s:%.2f", channel, v)
+		}
+		if g.slider(channel, &color[i], 0, 1, width, format) {
+			changed = true
+		}
+	}
+	g.PopID()
+	g.SameLine()
+	position := g.item(mgl32.Vec2{preview, preview})
+	g.addRect(position, mgl32.Vec2{preview, preview}, *color, 1)
+	g.label(label)
+	return changed
+}
+
+// slider adds the frame of a slider, showing the value formatted over it
+func (g *ImmediateGUI) slider(
+	label string,
+	value *float32,
+	min, max, width float32,
+	format func(float32) string,
+) bool {
+	size := mgl32.Vec2{width, g.lineHeight()}
+	position := g.item(size)
+	hovered, held, _ := g.interact(g.id(label), position, size)
+	grab := size[1] / 2
+	changed := false
+	if held && max > min {
+		ratio := (g.mouse[0] - position[0] - grab/2) / (width - grab)
+		v := min + mgl32.Clamp(ratio, 0, 1)*(max-min)
+		changed = v != *value
+		*value = v
+	}
+	ratio := float32(0)
+	if max > min {
+		ratio = mgl32.Clamp((*value-min)/(max-min), 0, 1)
+	}
+	g.addRect(position, size, g.style.Background.Color(imState(hovered, held)), 1)
+	g.addRect(
+		position.Add(mgl32.Vec2{ratio * (width - grab), 0}),
+		mgl32.Vec2{grab, size[1]},
+		g.style.Accent.Color(imState(hovered, held)),
+		2,
+	)
+	text := format(*value)
+	textX := (width - g.textWidth(text)) / 2
+	g.addText(text, position.Add(mgl32.Vec2{textX, 0}), size[1], g.style.Text.Normal)
+	return changed
+}
+
+func roundInt(v float32) int {
+	return int(math.Floor(float64(v) + 0.5))
+}
+
+// label adds the label of a widget to the right of its frame
+func (g *ImmediateGUI) label(label string) {
+	text := imDisplay(label)
+	if text == "" {
+		return
+	}
+	g.SameLine()
+	g.Text("%s", text)
+}
+
+// TreeNode adds a node opened and closed by clicking it, returning true when
+// open. Items up to TreePop are indented, TreePop must be called only when
+// open
+func (g *ImmediateGUI) TreeNode(label string) bool {
+	id := g.id(label)
+	text := imDisplay(label)
+	size := mgl32.Vec2{g.contentWidth(), g.lineHeight()}
+	position := g.item(size)
+	hovered, held, clicked := g.interact(id, position, size)
+	if clicked {
+		g.open[id] = !g.open[id]
+	}
+	open := g.open[id]
+	if hovered || held {
+		g.addRect(position, size, g.style.Background.Color(imState(hovered, held)), 1)
+	}
+	arrow := "> "
+	if open {
+		arrow = "v "
+	}
+	g.addText(arrow+text, position, size[1], g.style.Text.Normal)
+	if open {
+		g.PushID(label)
+		g.current.indent += g.lineHeight()
+	}
+	return open
+}
+
+// TreePop ends the items of an open TreeNode
+func (g *ImmediateGUI) TreePop() {
+	g.PopID()
+	g.current.indent -= g.lineHeight()
+	if g.current.indent < 0 {
+		g.current.indent = 0
+	}
+}
+
+// PlotLines adds a plot of values, e.g. from a TimeSeries, with the last
+// value shown. The values are scaled between min and max, or between the
+// lowest and highest one if min is not lower than max
+func (g *ImmediateGUI) PlotLines(label string, values []float32, min, max float32) {
+	size := mgl32.Vec2{g.contentWidth() * imFrameRatio, g.lineHeight() * 3}
+	position := g.item(size)
+	g.addRect(position, size, g.style.Background.Normal, 1)
+	if len(values) > 0 {
+		if min >= max {
+			min, max = values[0], values[0]
+			for _, v := range values {
+				if v < min {
+					min = v
+				}
+				if v > max {
+					max = v
+				}
+			}
+		}
+		g.addLine(position, size, plotPoints(values, min, max), g.style.Accent.Normal, 2)
+		text := fmt.Sprintf("%.3f", values[len(values)-1])
+		g.addText(text, position.Add(mgl32.Vec2{g.style.Padding[2], 0}), g.lineHeight(), g.style.Text.Normal)
+	}
+	g.label(label)
+}
+
+// plotPoints returns the vertices of a line through values, between 0 and 1
+// with the y axis down
+func plotPoints(values []float32, min, max float32) []float32 {
+	points := make([]float32, 0, 2*len(values))
+	for i, v := range values {
+		x := float32(0.5)
+		if len(values) > 1 {
+			x = float32(i) / float32(len(values)-1)
+		}
+		y := float32(0.5)
+		if max > min {
+			y = 1 - mgl32.Clamp((v-min)/(max-min), 0, 1)
+		}
+		points = append(points, x, y)
+	}
+	return points
+}
+
+// TimeSeries keeps the last values of a quantity sampled over time, e.g.
+// the frame times, for PlotLines
+type TimeSeries struct {
+	values []float32
+	next   int
+	full   bool
+}
+
+// NewTimeSeries creates a series keeping up to capacity values
+func NewTimeSeries(capacity int) *TimeSeries {
+	return &TimeSeries{values: make([]float32, capacity)}
+}
+
+// Add adds a value, dropping the oldest one when full
+func (s *TimeSeries) Add(value float32) {
+	if len(s.values) == 0 {
+		return
+	}
+	s.values[s.next] = value
+	s.next = (s.next + 1) % len(s.values)
+	if s.next == 0 {
+		s.full = true
+	}
+}
+
+// Values returns the values from the oldest to the newest
+func (s *TimeSeries) Values() []float32 {
+	if !s.full {
+		return append([]float32(nil), s.values[:s.next]...)
+	}
+	return append(append([]float32(nil), s.values[s.next:]...), s.values[:s.next]...)
+}
+
+// Len returns the number of values
+func (s *TimeSeries) Len() int {
+	if s.full {
+		return len(s.values)
+	}
+	return s.next
+}
@@ -0,0 +1,95 @@
+package ui
+
+import (
+	"github.com/markov/gojira2d/pkg/graphics"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Label is a non interactive text, sized to fit it
+type Label struct {
+	WidgetBase
+	text  *Text
+	color colorTransition
+	// Widget whose state colors the text, the label itself by default
+	stateSource Widget
+}
+
+// NewLabel creates a label with the font, size and text colors of style
+func NewLabel(text string, style *WidgetStyle) *Label {
+	l := &Label{}
+	l.init(l)
+	l.style = style
+	l.styleName = "label"
+	l.stateSource = l
+	l.text = NewText(
+		text,
+		style.Font,
+		mgl32.Vec3{},
+		mgl32.Vec2{style.FontSize, style.FontSize},
+		style.Text.Normal,
+		mgl32.Vec4{},
+	)
+	l.fit()
+	return l
+}
+
+// SetText changes the text and resizes the label
+func (l *Label) SetText(text string) {
+	l.text.SetText(text)
+	l.fit()
+}
+
+// SetMarkup changes the text to a rich text, see ParseMarkup
+func (l *Label) SetMarkup(markup string) {
+	l.text.SetMarkup(markup)
+	l.fit()
+}
+
+// Text returns the text without markup
+func (l *Label) Text() string {
+	return l.text.Text()
+}
+
+// TextElement returns the Text drawing the label, to change its layout or
+// its effects
+func (l *Label) TextElement() *Text {
+	return l.text
+}
+
+// SetStyle changes font, size and colors
+func (l *Label) SetStyle(style *WidgetStyle) {
+	l.WidgetBase.SetStyle(style)
+	l.text.SetFont(style.Font)
+	l.text.SetSize(mgl32.Vec2{style.FontSize, style.FontSize})
+	l.fit()
+}
+
+// fit resizes the label to the text, at least one line tall
+func (l *Label) fit() {
+	_, size := l.text.Bounds()
+	if size[1] < l.style.FontSize {
+		size[1] = l.style.FontSize
+	}
+	l.SetSize(size)
+}
+
+// Arrange see Widget.Arrange
+func (l *Label) Arrange() {
+	position := l.AbsolutePosition()
+	l.text.SetPosition(mgl32.Vec3{position[0], position[1], l.layerDepth(0)})
+}
+
+// Update see Widget.Update
+func (l *Label) Update(deltaTime float64) {
+	l.text.Update(deltaTime)
+	l.WidgetBase.Update(deltaTime)
+}
+
+// EnqueueForDrawing see Widget.EnqueueForDrawing
+func (l *Label) EnqueueForDrawing(context *graphics.Context) {
+	l.color.set(l.style.Text.Color(l.stateSource.Base().State()), l.style.Transition, l.now())
+	l.text.SetColor(l.color.value(l.now()))
+	l.text.EnqueueForDrawing(context)
+	l.WidgetBase.EnqueueForDrawing(context)
+}
@@ -0,0 +1,530 @@
+package ui
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// HorizontalAlignment aligns the lines of a text inside the layout box
+type HorizontalAlignment int
+
+const (
+	ALIGN_LEFT HorizontalAlignment = iota
+	ALIGN_CENTER
+	ALIGN_RIGHT
+	ALIGN_JUSTIFY
+)
+
+// VerticalAlignment aligns the block of lines inside the layout box
+type VerticalAlignment int
+
+const (
+	ALIGN_TOP VerticalAlignment = iota
+	ALIGN_MIDDLE
+	ALIGN_BOTTOM
+	// The baseline of the first line is placed at the top of the box
+	ALIGN_BASELINE
+)
+
+// LayoutOptions controls how a string is laid out. All the measures are
+// relative to the font line height, like the Text paddings
+type LayoutOptions struct {
+	// Width of the layout box, 0 means unbounded. Lines are aligned inside it
+	MaxWidth float32
+	// Height of the layout box, the block of lines is aligned inside it
+	MaxHeight float32
+	// Break lines at word boundaries when they exceed MaxWidth
+	WordWrap bool
+	// Maximum number of lines, 0 means unlimited
+	MaxLines int
+	// Appended to truncated lines, e.g. "..."
+	Ellipsis string
+	HAlign   HorizontalAlignment
+	VAlign   VerticalAlignment
+	// Padding order: top, bottom, left, right. See Text.SetPaddings
+	Paddings mgl32.Vec4
+}
+
+// GlyphLayout is the position of a single rune of the string
+type GlyphLayout struct {
+	Rune rune
+	// Byte offset in the string, -1 for the runes of the ellipsis
+	Index int
+	// Pen position, top of the line
+	X, Y    float32
+	Advance float32
+	Line    int
+	// Size relative to the font line height, see TextRun.Size
+	Scale float32
+	// Index of the TextRun of the glyph, -1 for plain text and ellipsis
+	Run int
+	// True for combining marks, joiners and variation selectors that belong
+	// to the grapheme cluster of the previous rune
+	Extends bool
+	char    *BmChar
+	font    *Font
+	// Drawing offset of the glyph from the pen position
+	offset mgl32.Vec2
+}
+
+// LineLayout holds the metrics of a line. Glyphs are Glyphs[First:Last]
+type LineLayout struct {
+	First    int
+	Last     int
+	X        float32
+	Y        float32
+	Width    float32
+	Height   float32
+	Baseline float32
+}
+
+// TextLayout is the result of laying out a string with a font
+type TextLayout struct {
+	Glyphs    []GlyphLayout
+	Lines     []LineLayout
+	Width     float32
+	Height    float32
+	Truncated bool
+}
+
+// layoutRune is a rune of the string with its font metrics
+type layoutRune struct {
+	r       rune
+	index   int
+	char    *BmChar
+	font    *Font
+	advance float32
+	offset  mgl32.Vec2
+	extends bool
+	scale   float32
+	run     int
+}
+
+// Layout positions the characters of txt according to the options
+func (f *Font) Layout(txt string, options LayoutOptions) *TextLayout {
+	return f.layout(f.layoutRunes(txt, options), options)
+}
+
+// layout breaks the runes in lines and positions them. Lines are as tall as
+// their largest rune and glyphs of all sizes share the line baseline
+func (f *Font) layout(runes []layoutRune, options LayoutOptions) *TextLayout {
+	lines := f.breakLines(runes, options)
+
+	layout := &TextLayout{}
+	if options.MaxLines > 0 && len(lines) > options.MaxLines {
+		lines = lines[:options.MaxLines]
+		lines[len(lines)-1].truncate = true
+		layout.Truncated = true
+	}
+	if !options.WordWrap && options.MaxWidth > 0 {
+		for i := range lines {
+			if f.measure(runes[lines[i].start:lines[i].end], options) > options.MaxWidth {
+				lines[i].truncate = true
+				layout.Truncated = true
+			}
+		}
+	}
+
+	baseline := float32(f.bm.base) * f.bm.f32scaleLine
+	var y float32
+	for i, line := range lines {
+		lineRunes := runes[line.start:line.end]
+		var ellipsis []layoutRune
+		if line.truncate {
+			lineRunes, ellipsis = f.truncate(lineRunes, options)
+		}
+
+		height := float32(1)
+		for _, r := range lineRunes {
+			if r.scale > height {
+				height = r.scale
+			}
+		}
+		first := len(layout.Glyphs)
+		width := f.placeGlyphs(layout, lineRunes, ellipsis, i, y, height*baseline, options)
+		layout.Lines = append(layout.Lines, LineLayout{
+			First:    first,
+			Last:     len(layout.Glyphs),
+			Y:        y,
+			Width:    width,
+			Height:   height,
+			Baseline: y + options.Paddings[0] + height*baseline,
+		})
+		if width > layout.Width {
+			layout.Width = width
+		}
+		y += height + options.Paddings[1]
+	}
+	if len(layout.Lines) > 0 {
+		layout.Height = options.Paddings[0] + y - options.Paddings[1]
+	}
+
+	layout.align(lines, options)
+	return layout
+}
+
+func (f *Font) layoutRunes(txt string, options LayoutOptions) []layoutRune {
+	return f.appendRunes(make([]layoutRune, 0, len(txt)), txt, f, 1, -1, 0, options)
+}
+
+// appendRunes appends the runes of txt drawn with font (and its fallbacks)
+// at the given scale. index is the byte offset of txt in the whole string
+func (f *Font) appendRunes(
+	runes []layoutRune,
+	txt string,
+	font *Font,
+	scale float32,
+	run int,
+	index int,
+	options LayoutOptions,
+) []layoutRune {
+	// The base of the current grapheme cluster, possibly in a previous run
+	base := len(runes) - 1
+	for base >= 0 && runes[base].extends {
+		base--
+	}
+	if base >= 0 && runes[base].r == '\n' {
+		base = -1
+	}
+	for offset, r := range txt {
+		lr := layoutRune{r: r, index: index + offset, scale: scale, run: run}
+		if r == '\n' {
+			runes = append(runes, lr)
+			base = -1
+			continue
+		}
+		if len(runes) > 0 && base >= 0 {
+			lr.extends = extendsCluster(r) || runes[len(runes)-1].r == zeroWidthJoiner
+		}
+		if isInvisible(r) {
+			// Joiners and selectors are never drawn nor reported as missing
+			runes = append(runes, lr)
+			continue
+		}
+
+		glyphFont, bmc := font.glyph(r)
+		if bmc != nil {
+			lr.char = bmc
+			lr.font = glyphFont
+			lr.offset = mgl32.Vec2{0, f.baselineShift(glyphFont) * scale}
+			lr.advance = bmc.f32advanceX*scale + options.Paddings[3]
+		}
+		if lr.extends && isMark(r) {
+			// Combining marks don't move the pen. Fonts usually design them
+			// with a zero advance and a negative offset, the ones with an
+			// advance are centered over the base character
+			if bmc != nil && bmc.f32advanceX > 0 && runes[base].char != nil {
+				baseAdvance := runes[base].char.f32advanceX * runes[base].scale
+				lr.offset[0] = baseAdvance/2 - runes[base].advance - bmc.f32advanceX*scale/2
+			}
+			lr.advance = 0
+		}
+		if !lr.extends {
+			base = len(runes)
+		}
+		runes = append(runes, lr)
+	}
+	return runes
+}
+
+// kerning returns the adjustment between two consecutive characters
+func (f *Font) kerning(previous, current *layoutRune) float32 {
+	if previous == nil || previous.char == nil || current.char == nil ||
+		previous.font != current.font || previous.scale != current.scale {
+		return 0
+	}
+	// Kerning pairs are stored in the first character of the pair
+	return previous.char.f32kernings[current.r] * current.scale
+}
+
+// measure returns the advance of a run of characters, trailing spaces excluded
+func (f *Font) measure(runes []layoutRune, options LayoutOptions) float32 {
+	end := len(runes)
+	for end > 0 && isLayoutSpace(runes[end-1].r) {
+		end--
+	}
+	var width float32
+	for i := 0; i < end; i++ {
+		if i > 0 {
+			width += f.kerning(&runes[i-1], &runes[i])
+		}
+		width += runes[i].advance
+	}
+	return width
+}
+
+func isLayoutSpace(r rune) bool {
+	return r == ' ' || r == '\t'
+}
+
+type layoutLine struct {
+	start, end int
+	// Last line of a paragraph, not stretched when justified
+	paragraphEnd bool
+	truncate     bool
+}
+
+// breakLines splits the runes at new lines and, if word wrap is enabled, at
+// the last space before exceeding MaxWidth. Words longer than a line are
+// split between characters
+func (f *Font) breakLines(runes []layoutRune, options LayoutOptions) []layoutLine {
+	lines := make([]layoutLine, 0)
+	wrap := options.WordWrap && options.MaxWidth > 0
+	lineStart := 0
+	lastSpace := -1
+	var width float32
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i].r
+		if r == '\n' {
+			// The new line is kept in the line so that every rune has a glyph
+			lines = append(lines, layoutLine{start: lineStart, end: i + 1, paragraphEnd: true})
+			lineStart = i + 1
+			lastSpace = -1
+			width = 0
+			continue
+		}
+
+		advance := runes[i].advance
+		if i > lineStart {
+			advance += f.kerning(&runes[i-1], &runes[i])
+		}
+		if wrap && i > lineStart && !isLayoutSpace(r) && !runes[i].extends && width+advance > options.MaxWidth {
+			breakAt := i
+			if lastSpace >= lineStart {
+				breakAt = lastSpace + 1
+			}
+			lines = append(lines, layoutLine{start: lineStart, end: breakAt})
+			lineStart = breakAt
+			lastSpace = -1
+			width = f.measure(runes[lineStart:i+1], options)
+			continue
+		}
+		if isLayoutSpace(r) {
+			lastSpace = i
+		}
+		width += advance
+	}
+	if lineStart < len(runes) || len(lines) == 0 || runes[len(runes)-1].r == '\n' {
+		lines = append(lines, layoutLine{start: lineStart, end: len(runes), paragraphEnd: true})
+	}
+	return lines
+}
+
+// truncate removes characters from the end of a line until the line and the
+// ellipsis fit in MaxWidth
+func (f *Font) truncate(runes []layoutRune, options LayoutOptions) ([]layoutRune, []layoutRune) {
+	ellipsis := f.layoutRunes(options.Ellipsis, options)
+	for i := range ellipsis {
+		ellipsis[i].index = -1
+	}
+	ellipsisWidth := f.measure(ellipsis, options)
+
+	end := len(runes)
+	for end > 0 && (runes[end-1].r == '\n' || isLayoutSpace(runes[end-1].r)) {
+		end--
+	}
+	if options.MaxWidth > 0 {
+		for end > 0 && f.measure(runes[:end], options)+ellipsisWidth > options.MaxWidth {
+			end--
+			// Grapheme clusters are removed as a whole
+			for end > 0 && runes[end].extends {
+				end--
+			}
+		}
+		for end > 0 && isLayoutSpace(runes[end-1].r) {
+			end--
+		}
+	}
+	return runes[:end], ellipsis
+}
+
+// placeGlyphs appends the glyphs of a line and returns its width. Glyphs
+// smaller than the line are moved down to the line baseline
+func (f *Font) placeGlyphs(
+	layout *TextLayout,
+	runes, ellipsis []layoutRune,
+	line int,
+	y float32,
+	baseline float32,
+	options LayoutOptions,
+) float32 {
+	all := append(append([]layoutRune{}, runes...), ellipsis...)
+	fontBaseline := float32(f.bm.base) * f.bm.f32scaleLine
+	var pen float32
+	for i := range all {
+		if i > 0 {
+			pen += f.kerning(&all[i-1], &all[i])
+		}
+		offset := all[i].offset
+		offset[1] += baseline - fontBaseline*all[i].scale
+		layout.Glyphs = append(layout.Glyphs, GlyphLayout{
+			Rune:    all[i].r,
+			Index:   all[i].index,
+			X:       pen,
+			Y:       y,
+			Advance: all[i].advance,
+			Line:    line,
+			Scale:   all[i].scale,
+			Run:     all[i].run,
+			Extends: all[i].extends,
+			char:    all[i].char,
+			font:    all[i].font,
+			offset:  offset,
+		})
+		pen += all[i].advance
+	}
+	return f.measure(all, options)
+}
+
+// align moves lines and glyphs according to the alignment options
+func (l *TextLayout) align(lines []layoutLine, options LayoutOptions) {
+	boxWidth := options.MaxWidth
+	if boxWidth <= 0 {
+		boxWidth = l.Width
+	}
+
+	var offsetY float32
+	switch options.VAlign {
+	case ALIGN_MIDDLE:
+		offsetY = (options.MaxHeight - l.Height) / 2
+	case ALIGN_BOTTOM:
+		offsetY = options.MaxHeight - l.Height
+	case ALIGN_BASELINE:
+		offsetY = -l.Lines[0].Baseline
+	}
+
+	for i := range l.Lines {
+		line := &l.Lines[i]
+		var offsetX, extraSpace float32
+		switch options.HAlign {
+		case ALIGN_CENTER:
+			offsetX = (boxWidth - line.Width) / 2
+		case ALIGN_RIGHT:
+			offsetX = boxWidth - line.Width
+		case ALIGN_JUSTIFY:
+			if !lines[i].paragraphEnd && !lines[i].truncate {
+				spaces := 0
+				for _, g := range l.Glyphs[line.First:line.Last] {
+					if isLayoutSpace(g.Rune) && g.X+g.Advance < line.Width {
+						spaces++
+					}
+				}
+				if spaces > 0 {
+					extraSpace = (boxWidth - line.Width) / float32(spaces)
+				}
+			}
+		}
+
+		line.X = offsetX
+		line.Y += offsetY
+		line.Baseline += offsetY
+		var shift float32
+		for j := line.First; j < line.Last; j++ {
+			g := &l.Glyphs[j]
+			inside := g.X+g.Advance < line.Width
+			g.X += offsetX + shift
+			g.Y += offsetY
+			if extraSpace > 0 && isLayoutSpace(g.Rune) && inside {
+				g.Advance += extraSpace
+				shift += extraSpace
+			}
+		}
+		if extraSpace > 0 {
+			line.Width = boxWidth
+		}
+	}
+}
+
+// Bounds returns the top left corner and the size of the box enclosing all
+// the lines after alignment
+func (l *TextLayout) Bounds() (mgl32.Vec2, mgl32.Vec2) {
+	if len(l.Lines) == 0 {
+		return mgl32.Vec2{}, mgl32.Vec2{}
+	}
+	first := l.Lines[0]
+	last := l.Lines[len(l.Lines)-1]
+	minX := first.X
+	maxX := first.X + first.Width
+	for _, line := range l.Lines {
+		if line.X < minX {
+			minX = line.X
+		}
+		if line.X+line.Width > maxX {
+			maxX = line.X + line.Width
+		}
+	}
+	topLeft := mgl32.Vec2{minX, first.Y}
+	return topLeft, mgl32.Vec2{maxX - minX, last.Y + last.Height - first.Y}
+}
+
+// CursorPosition returns the pen position (top of the line) before the
+// grapheme cluster starting at byte offset index. The end of the text is
+// returned for an index past the last rune
+func (l *TextLayout) CursorPosition(index int) mgl32.Vec2 {
+	for _, g := range l.Glyphs {
+		if g.Index >= index && !g.Extends {
+			return mgl32.Vec2{g.X, g.Y}
+		}
+	}
+	if len(l.Lines) == 0 {
+		return mgl32.Vec2{}
+	}
+	line := l.Lines[len(l.Lines)-1]
+	if line.Last > line.First {
+		g := l.Glyphs[line.Last-1]
+		if g.Rune == '\n' {
+			// After a trailing new line the cursor is at the start of the next line
+			return mgl32.Vec2{0, line.Y + line.Height}
+		}
+		return mgl32.Vec2{g.X + g.Advance, g.Y}
+	}
+	return mgl32.Vec2{line.X, line.Y}
+}
+
+// IndexAt returns the byte offset of the cursor position closest to point.
+// The cursor is never placed inside a grapheme cluster
+func (l *TextLayout) IndexAt(point mgl32.Vec2) int {
+	if len(l.Lines) == 0 {
+		return 0
+	}
+	// Pick the line first
+	lineIndex := len(l.Lines) - 1
+	for i, line := range l.Lines {
+		if point.Y() < line.Y+line.Height {
+			lineIndex = i
+			break
+		}
+	}
+	line := l.Lines[lineIndex]
+	end := -1
+	for i := line.First; i < line.Last; i++ {
+		g := l.Glyphs[i]
+		if g.Index < 0 {
+			continue
+		}
+		if g.Extends {
+			end = i
+			continue
+		}
+		if g.Rune == '\n' {
+			return g.Index
+		}
+		if point.X() < g.X+g.Advance/2 {
+			return g.Index
+		}
+		end = i
+	}
+	if end >= 0 {
+		// After the last glyph of the line
+		g := l.Glyphs[end]
+		return g.Index + len(string(g.Rune))
+	}
+	if lineIndex > 0 {
+		prev := l.Lines[lineIndex-1]
+		if prev.Last > prev.First {
+			g := l.Glyphs[prev.Last-1]
+			return g.Index + len(string(g.Rune))
+		}
+	}
+	return 0
+}
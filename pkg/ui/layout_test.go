@@ -0,0 +1,254 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// All the characters of the mono font advance by 53/90 of the line height
+const monoAdvance = float32(53) / 90
+
+func newTestMonoFont() *Font {
+	return &Font{bm: NewBmFontFromFile("../../examples/assets/fonts/roboto-mono-regular.fnt")}
+}
+
+func lineStrings(txt string, layout *TextLayout) []string {
+	lines := make([]string, 0, len(layout.Lines))
+	for _, line := range layout.Lines {
+		runes := make([]rune, 0)
+		for _, g := range layout.Glyphs[line.First:line.Last] {
+			runes = append(runes, g.Rune)
+		}
+		lines = append(lines, string(runes))
+	}
+	return lines
+}
+
+func TestLayoutWordWrap(t *testing.T) {
+	font := newTestMonoFont()
+	var tests = []struct {
+		text     string
+		maxChars float32
+		maxLines int
+		lines    []string
+	}{
+		{"hello world", 0, 0, []string{"hello world"}},
+		{"hello world", 8, 0, []string{"hello ", "world"}},
+		{"hello\nworld", 0, 0, []string{"hello\n", "world"}},
+		{"abcdefghij", 4, 0, []string{"abcd", "efgh", "ij"}},
+		{"one two three four", 9, 0, []string{"one two ", "three ", "four"}},
+		{"one two three four", 9, 2, []string{"one two ", "three..."}},
+		{"text\n", 0, 0, []string{"text\n", ""}},
+		{"", 0, 0, []string{""}},
+	}
+
+	for _, test := range tests {
+		layout := font.Layout(test.text, LayoutOptions{
+			MaxWidth: test.maxChars * monoAdvance,
+			WordWrap: true,
+			MaxLines: test.maxLines,
+			Ellipsis: "...",
+		})
+		lines := lineStrings(test.text, layout)
+		if len(lines) != len(test.lines) {
+			t.Errorf("%q: got lines %q, expecting %q", test.text, lines, test.lines)
+			continue
+		}
+		for i := range lines {
+			if lines[i] != test.lines[i] {
+				t.Errorf("%q: got lines %q, expecting %q", test.text, lines, test.lines)
+				break
+			}
+		}
+		if layout.Truncated != (test.maxLines > 0) {
+			t.Errorf("%q: wrong truncation flag", test.text)
+		}
+	}
+}
+
+func TestLayoutTruncate(t *testing.T) {
+	font := newTestMonoFont()
+	layout := font.Layout("a very long line", LayoutOptions{MaxWidth: 8 * monoAdvance, Ellipsis: "..."})
+	lines := lineStrings("", layout)
+	if len(lines) != 1 || lines[0] != "a ver..." {
+		t.Errorf("Got %q", lines)
+	}
+	if layout.Width > 8*monoAdvance+0.0001 {
+		t.Errorf("Truncated line is too wide: %v", layout.Width)
+	}
+}
+
+func TestLayoutAlignment(t *testing.T) {
+	font := newTestMonoFont()
+	var tests = []struct {
+		align HorizontalAlignment
+		// X of the first glyph of the second line, in characters
+		secondLineX float32
+	}{
+		{ALIGN_LEFT, 0},
+		{ALIGN_CENTER, 2},
+		{ALIGN_RIGHT, 4},
+	}
+	for _, test := range tests {
+		layout := font.Layout("abcdefgh\nabcd", LayoutOptions{MaxWidth: 8 * monoAdvance, HAlign: test.align})
+		x := layout.Glyphs[layout.Lines[1].First].X / monoAdvance
+		if mgl32.Abs(x-test.secondLineX) > 0.001 {
+			t.Errorf("Align %v: got x %v, expecting %v", test.align, x, test.secondLineX)
+		}
+	}
+
+	// Spaces are stretched to fill the line, the last line of the paragraph is not
+	layout := font.Layout("a b c d e", LayoutOptions{MaxWidth: 6 * monoAdvance, WordWrap: true, HAlign: ALIGN_JUSTIFY})
+	if len(layout.Lines) != 2 || mgl32.Abs(layout.Lines[0].Width-6*monoAdvance) > 0.001 {
+		t.Fatalf("Wrong justified lines %+v", layout.Lines)
+	}
+	last := layout.Glyphs[layout.Lines[0].Last-2]
+	if last.Rune != 'c' || mgl32.Abs(last.X-5*monoAdvance) > 0.001 {
+		t.Errorf("Wrong justified glyph %+v", last)
+	}
+	if layout.Lines[1].Width > 3*monoAdvance+0.001 {
+		t.Errorf("Last line should not be justified %+v", layout.Lines[1])
+	}
+
+	layout = font.Layout("a\nb", LayoutOptions{VAlign: ALIGN_BASELINE})
+	if layout.Lines[0].Baseline != 0 || layout.Lines[1].Y != 1-float32(58)/90 {
+		t.Errorf("Wrong baseline alignment %+v", layout.Lines)
+	}
+}
+
+func TestLayoutCursor(t *testing.T) {
+	font := newTestMonoFont()
+	layout := font.Layout("ab\ncd", LayoutOptions{})
+	var tests = []struct {
+		index    int
+		position mgl32.Vec2
+	}{
+		{0, mgl32.Vec2{0, 0}},
+		{1, mgl32.Vec2{monoAdvance, 0}},
+		{2, mgl32.Vec2{2 * monoAdvance, 0}},
+		{3, mgl32.Vec2{0, 1}},
+		{5, mgl32.Vec2{2 * monoAdvance, 1}},
+	}
+	for _, test := range tests {
+		if p := layout.CursorPosition(test.index); p != test.position {
+			t.Errorf("Index %d: got %v, expecting %v", test.index, p, test.position)
+		}
+		// Clicking slightly after the cursor position returns the same index
+		point := test.position.Add(mgl32.Vec2{0.1, 0.5})
+		if index := layout.IndexAt(point); index != test.index {
+			t.Errorf("Point %v: got index %d, expecting %d", point, index, test.index)
+		}
+	}
+}
+
+func TestLayoutKerning(t *testing.T) {
+	font := &Font{bm: NewBmFontFromFile("../../examples/assets/fonts/roboto-regular.fnt")}
+	// The font has the pair T,o (-3) but not o,T
+	var tests = []struct {
+		text  string
+		width float32
+	}{
+		{"To", float32(52+51-3) / 88},
+		{"oT", float32(51+52) / 88},
+	}
+	for _, test := range tests {
+		layout := font.Layout(test.text, LayoutOptions{})
+		if mgl32.Abs(layout.Width-test.width) > 0.0001 {
+			t.Errorf("%q: got width %v, expecting %v", test.text, layout.Width, test.width)
+		}
+	}
+}
+
+func TestLayoutGraphemes(t *testing.T) {
+	font := newTestMonoFont()
+	// A combining grave accent with an advance, like the spacing one
+	grave := *font.bm.Characters['`']
+	font.bm.Characters[0x300] = &grave
+
+	txt := "èa"
+	layout := font.Layout(txt, LayoutOptions{})
+	if len(layout.Glyphs) != 3 || mgl32.Abs(layout.Width-2*monoAdvance) > 0.0001 {
+		t.Fatalf("Combining mark should not advance: %+v", layout)
+	}
+	mark := layout.Glyphs[1]
+	if !mark.Extends || mark.Advance != 0 || mark.X+mark.offset.X() != 0 {
+		t.Errorf("Mark should be centered over the base char %+v", mark)
+	}
+	if p := layout.CursorPosition(1); p.X() != monoAdvance {
+		t.Errorf("Cursor should skip the mark, got %v", p)
+	}
+	if index := layout.IndexAt(mgl32.Vec2{monoAdvance * 0.9, 0.5}); index != len("è") {
+		t.Errorf("Got index %d inside the cluster", index)
+	}
+
+	// Clusters are not split when wrapping
+	layout = font.Layout("eè", LayoutOptions{MaxWidth: 1.5 * monoAdvance, WordWrap: true})
+	if lines := lineStrings("", layout); len(lines) != 2 || lines[1] != "è" {
+		t.Errorf("Wrong lines %q", lines)
+	}
+
+	var graphemes = []struct {
+		text  string
+		count int
+		next  int
+	}{
+		{"", 0, 0},
+		{"abc", 3, 1},
+		{"è́x", 2, 5},
+		{"👍🏽!", 2, 8},
+		{"👨‍👩‍👧", 1, 18},
+		{"ä", 1, 2},
+	}
+	for _, test := range graphemes {
+		if count := GraphemeCount(test.text); count != test.count {
+			t.Errorf("%q: got %d graphemes, expecting %d", test.text, count, test.count)
+		}
+		next := NextGrapheme(test.text, 0)
+		if next != test.next {
+			t.Errorf("%q: got next grapheme at %d, expecting %d", test.text, next, test.next)
+		}
+		if prev := PrevGrapheme(test.text, next); prev != 0 {
+			t.Errorf("%q: got previous grapheme at %d, expecting 0", test.text, prev)
+		}
+	}
+}
+
+func TestFontFallback(t *testing.T) {
+	font := newTestMonoFont()
+	fallback := &Font{bm: NewBmFontFromFile("../../examples/assets/fonts/roboto-regular.fnt")}
+	// Pretend the primary font lacks some characters
+	delete(font.bm.Characters, 'T')
+	delete(font.bm.Characters, 'o')
+	delete(fallback.bm.Characters, 'o')
+
+	if font.HasRune('T') || !font.HasRune('a') {
+		t.Errorf("Wrong HasRune")
+	}
+	if missing := font.MissingRunes("ToTo"); len(missing) != 2 || missing[0] != 'T' {
+		t.Errorf("Got missing runes %q", missing)
+	}
+
+	font.SetFallbackFonts(fallback)
+	font.SetFallbackRune('?')
+	if missing := font.MissingRunes("ToTo\n"); len(missing) != 1 || missing[0] != 'o' {
+		t.Errorf("Got missing runes %q", missing)
+	}
+
+	layout := font.Layout("To", LayoutOptions{})
+	tGlyph, oGlyph := layout.Glyphs[0], layout.Glyphs[1]
+	if tGlyph.font != fallback || tGlyph.char != fallback.bm.Characters['T'] {
+		t.Errorf("T should come from the fallback font")
+	}
+	// Fallback glyphs share the baseline of the primary font
+	if shift := float32(58)/90 - float32(57)/88; mgl32.Abs(tGlyph.offset.Y()-shift) > 0.0001 {
+		t.Errorf("Got baseline shift %v, expecting %v", tGlyph.offset.Y(), shift)
+	}
+	if oGlyph.font != font || oGlyph.char != font.bm.Characters['?'] {
+		t.Errorf("o should be replaced by the fallback rune")
+	}
+	// No kerning between glyphs of different fonts
+	if oGlyph.X != tGlyph.Advance {
+		t.Errorf("Got x %v, expecting %v", oGlyph.X, tGlyph.Advance)
+	}
+}
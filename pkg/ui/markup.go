@@ -0,0 +1,334 @@
+package ui
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/markov/gojira2d/pkg/graphics"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Inline icons are represented by the object replacement character
+const iconRune = '\uFFFC'
+
+const (
+	defaultWaveAmplitude  = 0.1
+	defaultShakeAmplitude = 0.05
+)
+
+// TextRun is a piece of text sharing the same style
+type TextRun struct {
+	Text string
+	// Multiplied by the text color
+	Color graphics.Color
+	// nil draws the run with the font of the text
+	Font *Font
+	// Size relative to the text size
+	Size float32
+	// Amplitudes, in line heights, of the animated effects
+	Wave  float32
+	Shake float32
+	// Name of an icon of the text IconSet, the text of the run is iconRune
+	Icon string
+}
+
+func defaultTextRun() TextRun {
+	return TextRun{Color: graphics.Color{1, 1, 1, 1}, Size: 1}
+}
+
+func (r *TextRun) sameStyle(other *TextRun) bool {
+	return r.Color == other.Color && r.Font == other.Font && r.Size == other.Size &&
+		r.Wave == other.Wave && r.Shake == other.Shake && r.Icon == "" && other.Icon == ""
+}
+
+// markupTag is an open tag and the style change it applies
+type markupTag struct {
+	name  string
+	apply func(run *TextRun)
+}
+
+// ParseMarkup splits a string with inline tags in styled runs. Supported tags:
+//
+//	[color=#f00]red[/color]  colors as #rgb, #rgba, #rrggbb, #rrggbbaa or names
+//	[font=mono]...[/font]    fonts of the FontRegistry
+//	[size=1.5]...[/size]     size relative to the text size
+//	[wave]...[/wave]         animated wave, [wave=0.2] sets the amplitude
+//	[shake]...[/shake]       animated shake, [shake=0.1] sets the amplitude
+//	[img=coin]               inline icon of the text IconSet
+//
+// "[[" is a literal bracket, unknown tags are left in the text
+func ParseMarkup(markup string) []TextRun {
+	runs := make([]TextRun, 0)
+	stack := make([]markupTag, 0)
+	var text strings.Builder
+
+	style := func() TextRun {
+		run := defaultTextRun()
+		for _, tag := range stack {
+			tag.apply(&run)
+		}
+		return run
+	}
+	flush := func() {
+		if text.Len() == 0 {
+			return
+		}
+		run := style()
+		run.Text = text.String()
+		text.Reset()
+		if last := len(runs) - 1; last >= 0 && runs[last].sameStyle(&run) {
+			runs[last].Text += run.Text
+			return
+		}
+		runs = append(runs, run)
+	}
+
+	for i := 0; i < len(markup); {
+		if markup[i] != '[' {
+			next := strings.IndexByte(markup[i:], '[')
+			if next < 0 {
+				next = len(markup) - i
+			}
+			text.WriteString(markup[i : i+next])
+			i += next
+			continue
+		}
+		if strings.HasPrefix(markup[i:], "[[") {
+			text.WriteByte('[')
+			i += 2
+			continue
+		}
+		end := strings.IndexByte(markup[i:], ']')
+		if end < 0 {
+			text.WriteString(markup[i:])
+			break
+		}
+		tag := markup[i+1 : i+end]
+
+		if strings.HasPrefix(tag, "/") {
+			name := tag[1:]
+			closed := false
+			for j := len(stack) - 1; j >= 0; j-- {
+				if stack[j].name == name {
+					flush()
+					stack = append(stack[:j], stack[j+1:]...)
+					closed = true
+					break
+				}
+			}
+			if !closed {
+				text.WriteString(markup[i : i+end+1])
+			}
+			i += end + 1
+			continue
+		}
+
+		name, value := tag, ""
+		if eq := strings.IndexByte(tag, '='); eq >= 0 {
+			name, value = tag[:eq], tag[eq+1:]
+		}
+		if name == "img" {
+			flush()
+			run := style()
+			run.Text = string(iconRune)
+			run.Icon = value
+			runs = append(runs, run)
+			i += end + 1
+			continue
+		}
+		apply, ok := markupStyle(name, value)
+		if !ok {
+			text.WriteString(markup[i : i+end+1])
+			i += end + 1
+			continue
+		}
+		flush()
+		stack = append(stack, markupTag{name, apply})
+		i += end + 1
+	}
+	flush()
+	return runs
+}
+
+// markupStyle returns the style change of a tag, false if the tag is unknown
+// or its value is invalid
+func markupStyle(name, value string) (func(run *TextRun), bool) {
+	switch name {
+	case "color":
+		color, ok := ParseColor(value)
+		if !ok {
+			return nil, false
+		}
+		return func(run *TextRun) { run.Color = color }, true
+	case "font":
+		font, ok := FontRegistry[value]
+		if !ok {
+			log.Printf("ERR: markup font %q not found in the registry", value)
+			return func(run *TextRun) {}, true
+		}
+		return func(run *TextRun) { run.Font = font }, true
+	case "size":
+		size, err := strconv.ParseFloat(value, 32)
+		if err != nil || size <= 0 {
+			return nil, false
+		}
+		return func(run *TextRun) { run.Size *= float32(size) }, true
+	case "wave", "shake":
+		amplitude := float32(defaultWaveAmplitude)
+		if name == "shake" {
+			amplitude = defaultShakeAmplitude
+		}
+		if value != "" {
+			v, err := strconv.ParseFloat(value, 32)
+			if err != nil {
+				return nil, false
+			}
+			amplitude = float32(v)
+		}
+		if name == "wave" {
+			return func(run *TextRun) { run.Wave = amplitude }, true
+		}
+		return func(run *TextRun) { run.Shake = amplitude }, true
+	}
+	return nil, false
+}
+
+var namedColors = map[string]graphics.Color{
+	"white":       {1, 1, 1, 1},
+	"black":       {0, 0, 0, 1},
+	"red":         {1, 0, 0, 1},
+	"green":       {0, 1, 0, 1},
+	"blue":        {0, 0, 1, 1},
+	"yellow":      {1, 1, 0, 1},
+	"cyan":        {0, 1, 1, 1},
+	"magenta":     {1, 0, 1, 1},
+	"orange":      {1, 0.5, 0, 1},
+	"gray":        {0.5, 0.5, 0.5, 1},
+	"transparent": {0, 0, 0, 0},
+}
+
+// ParseColor parses #rgb, #rgba, #rrggbb, #rrggbbaa and a few color names
+func ParseColor(value string) (graphics.Color, bool) {
+	if color, ok := namedColors[strings.ToLower(value)]; ok {
+		return color, true
+	}
+	if !strings.HasPrefix(value, "#") {
+		return graphics.Color{}, false
+	}
+	hex := value[1:]
+	if len(hex) == 3 || len(hex) == 4 {
+		// Short form, every digit is repeated
+		long := make([]byte, 0, 8)
+		for i := range hex {
+			long = append(long, hex[i], hex[i])
+		}
+		hex = string(long)
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return graphics.Color{}, false
+	}
+	rgba, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return graphics.Color{}, false
+	}
+	return graphics.Color{
+		float32(rgba>>24&0xff) / 255,
+		float32(rgba>>16&0xff) / 255,
+		float32(rgba>>8&0xff) / 255,
+		float32(rgba&0xff) / 255,
+	}, true
+}
+
+// IconSet is a texture atlas of images placed inline in texts with [img=name]
+type IconSet struct {
+	texture *graphics.Texture
+	regions map[string]mgl32.Vec4
+}
+
+// NewIconSet creates an empty icon set using texture as atlas
+func NewIconSet(texture *graphics.Texture) *IconSet {
+	return &IconSet{texture: texture, regions: make(map[string]mgl32.Vec4)}
+}
+
+// Add names a region of the texture: x, y, width, height in pixels
+func (s *IconSet) Add(name string, region mgl32.Vec4) {
+	s.regions[name] = region
+}
+
+// AddGrid names the cells of a grid of icons, row by row. Empty names are
+// skipped
+func (s *IconSet) AddGrid(names []string, cellWidth, cellHeight int) {
+	columns := s.texture.Width() / cellWidth
+	if columns == 0 {
+		return
+	}
+	for i, name := range names {
+		if name == "" {
+			continue
+		}
+		s.Add(name, mgl32.Vec4{
+			float32(i % columns * cellWidth), float32(i / columns * cellHeight),
+			float32(cellWidth), float32(cellHeight),
+		})
+	}
+}
+
+// Texture returns the atlas texture
+func (s *IconSet) Texture() *graphics.Texture {
+	return s.texture
+}
+
+// Region returns the region of an icon in pixels
+func (s *IconSet) Region(name string) (mgl32.Vec4, bool) {
+	region, ok := s.regions[name]
+	return region, ok
+}
+
+// LayoutRuns positions styled runs like Layout does for plain strings.
+// Glyph indices refer to the concatenation of the run texts. Icons are as
+// tall as the ascent of the font, scaled by the run size
+func (f *Font) LayoutRuns(runs []TextRun, icons *IconSet, options LayoutOptions) *TextLayout {
+	runes := make([]layoutRune, 0)
+	index := 0
+	for i, run := range runs {
+		size := run.Size
+		if size <= 0 {
+			size = 1
+		}
+		if run.Icon != "" {
+			lr := layoutRune{r: iconRune, index: index, scale: size, run: i}
+			if width, _, ok := f.iconSize(icons, run.Icon, size); ok {
+				lr.advance = width + options.Paddings[3]
+			} else {
+				log.Printf("ERR: icon %q not found", run.Icon)
+			}
+			runes = append(runes, lr)
+		} else {
+			font := run.Font
+			if font == nil {
+				font = f
+			}
+			runes = f.appendRunes(runes, run.Text, font, size, i, index, options)
+		}
+		index += len(run.Text)
+	}
+	return f.layout(runes, options)
+}
+
+// iconSize returns the size in line heights of an icon
+func (f *Font) iconSize(icons *IconSet, name string, scale float32) (float32, float32, bool) {
+	if icons == nil {
+		return 0, 0, false
+	}
+	region, ok := icons.Region(name)
+	if !ok || region[3] <= 0 {
+		return 0, 0, false
+	}
+	height := float32(f.bm.base) * f.bm.f32scaleLine * scale
+	return height * region[2] / region[3], height, true
+}
@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/markov/gojira2d/pkg/graphics"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestParseMarkup(t *testing.T) {
+	runs := ParseMarkup("Hi [color=#f00]red [wave]up[/wave][/color][[x] [img=coin][bogus]!")
+	expected := []TextRun{
+		{Text: "Hi ", Color: graphics.Color{1, 1, 1, 1}, Size: 1},
+		{Text: "red ", Color: graphics.Color{1, 0, 0, 1}, Size: 1},
+		{Text: "up", Color: graphics.Color{1, 0, 0, 1}, Size: 1, Wave: defaultWaveAmplitude},
+		{Text: "[x] ", Color: graphics.Color{1, 1, 1, 1}, Size: 1},
+		{Text: string(iconRune), Color: graphics.Color{1, 1, 1, 1}, Size: 1, Icon: "coin"},
+		{Text: "[bogus]!", Color: graphics.Color{1, 1, 1, 1}, Size: 1},
+	}
+	if len(runs) != len(expected) {
+		t.Fatalf("Got %d runs %+v, expecting %d", len(runs), runs, len(expected))
+	}
+	for i := range runs {
+		if runs[i] != expected[i] {
+			t.Errorf("Run %d: got %+v, expecting %+v", i, runs[i], expected[i])
+		}
+	}
+
+	// Nested sizes multiply, unmatched closing tags are text
+	runs = ParseMarkup("[size=2]a[size=1.5]b[/size][/size][/shake]")
+	if len(runs) != 3 || runs[0].Size != 2 || runs[1].Size != 3 || runs[2].Text != "[/shake]" {
+		t.Errorf("Wrong runs %+v", runs)
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	var tests = []struct {
+		value    string
+		expected graphics.Color
+		ok       bool
+	}{
+		{"#fff", graphics.Color{1, 1, 1, 1}, true},
+		{"#0f08", graphics.Color{0, 1, 0, float32(0x88) / 255}, true},
+		{"#ff000080", graphics.Color{1, 0, 0, float32(0x80) / 255}, true},
+		{"Blue", graphics.Color{0, 0, 1, 1}, true},
+		{"#12345", graphics.Color{}, false},
+		{"#gggggg", graphics.Color{}, false},
+		{"nope", graphics.Color{}, false},
+	}
+	for _, test := range tests {
+		color, ok := ParseColor(test.value)
+		if ok != test.ok || color != test.expected {
+			t.Errorf("%s: got %v %v, expecting %v %v", test.value, color, ok, test.expected, test.ok)
+		}
+	}
+}
+
+func TestLayoutRuns(t *testing.T) {
+	font := newTestMonoFont()
+	runs := []TextRun{
+		{Text: "ab", Size: 1},
+		{Text: "cd", Size: 2},
+	}
+	layout := font.LayoutRuns(runs, nil, LayoutOptions{})
+	if len(layout.Glyphs) != 4 || len(layout.Lines) != 1 {
+		t.Fatalf("Got %d glyphs in %d lines", len(layout.Glyphs), len(layout.Lines))
+	}
+	if g := layout.Glyphs[2]; g.Index != 2 || g.Run != 1 || g.Scale != 2 {
+		t.Errorf("Wrong glyph %+v", g)
+	}
+	if !vecNear([]float32{layout.Width, layout.Height}, []float32{6 * monoAdvance, 2}) {
+		t.Errorf("Got size %f x %f, expecting %f x 2", layout.Width, layout.Height, 6*monoAdvance)
+	}
+	// Smaller glyphs share the baseline of the larger ones
+	small, large := layout.Glyphs[0], layout.Glyphs[2]
+	baseline := float32(font.bm.base) * font.bm.f32scaleLine
+	if !vecNear([]float32{small.offset.Y() + baseline}, []float32{large.offset.Y() + 2*baseline}) {
+		t.Errorf("Baselines differ: %f %f", small.offset.Y()+baseline, large.offset.Y()+2*baseline)
+	}
+
+	// Icons are as tall as the ascent
+	icons := &IconSet{regions: map[string]mgl32.Vec4{"coin": {0, 0, 32, 16}}}
+	layout = font.LayoutRuns(ParseMarkup("[img=coin]"), icons, LayoutOptions{})
+	if len(layout.Glyphs) != 1 || !vecNear([]float32{layout.Glyphs[0].Advance}, []float32{2 * baseline}) {
+		t.Errorf("Wrong icon layout %+v", layout.Glyphs)
+	}
+}
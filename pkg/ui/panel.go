@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"github.com/markov/gojira2d/pkg/graphics"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Panel is a container, with the background of its style if any. A layout
+// places its children, otherwise they keep their positions
+type Panel struct {
+	WidgetBase
+	background *box
+	layout     WidgetLayout
+	fit        bool
+}
+
+// NewPanel creates an empty panel, a nil style has no background
+func NewPanel(style *WidgetStyle) *Panel {
+	p := &Panel{}
+	p.init(p)
+	p.style = style
+	p.styleName = "panel"
+	p.background = newBackgroundBox(&p.WidgetBase)
+	return p
+}
+
+// SetLayout sets the layout placing the children, nil keeps their positions
+func (p *Panel) SetLayout(layout WidgetLayout) {
+	if owned, ok := layout.(ownedLayout); ok {
+		owned.setOwner(p)
+	}
+	p.layout = layout
+	p.layoutChanged()
+}
+
+// Layout returns the layout of the panel, nil if none
+func (p *Panel) Layout() WidgetLayout {
+	return p.layout
+}
+
+// SetFitContent makes the preferred size of the panel the one measured by
+// its layout. Outside of a layout the panel resizes itself to it
+func (p *Panel) SetFitContent(fit bool) {
+	p.fit = fit
+	p.layoutChanged()
+}
+
+// fitsContent returns true if the panel is sized by its layout
+func (p *Panel) fitsContent() bool {
+	return p.fit && p.layout != nil
+}
+
+// layoutChanged arranges the panel again after its layout changed
+func (p *Panel) layoutChanged() {
+	p.dirty = true
+	if p.fitsContent() {
+		p.invalidateLayout()
+	}
+}
+
+// measure returns the preferred size of the panel
+func (p *Panel) measure() mgl32.Vec2 {
+	if p.fitsContent() {
+		return p.layout.Measure(p.children)
+	}
+	return p.preferred
+}
+
+// Arrange see Widget.Arrange
+func (p *Panel) Arrange() {
+	if p.fitsContent() {
+		if parent, ok := p.parent.(*Panel); !ok || parent.layout == nil {
+			p.setLayoutSize(p.measure())
+		}
+	}
+	p.background.set(p.AbsolutePosition(), p.layerDepth(0), p.size)
+	if p.layout != nil {
+		p.layout.Apply(p.children, p.size)
+	}
+}
+
+// EnqueueForDrawing see Widget.EnqueueForDrawing
+func (p *Panel) EnqueueForDrawing(context *graphics.Context) {
+	if p.style != nil {
+		p.background.setColor(p.style.Background.Color(p.State()))
+		p.background.enqueue(context)
+	}
+	p.WidgetBase.EnqueueForDrawing(context)
+}
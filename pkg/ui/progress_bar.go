@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"github.com/markov/gojira2d/pkg/graphics"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// ProgressBar shows a value between 0 and 1 as a filled bar
+type ProgressBar struct {
+	WidgetBase
+	background *box
+	fill       *box
+	value      float32
+}
+
+// NewProgressBar creates an empty progress bar
+func NewProgressBar(style *WidgetStyle) *ProgressBar {
+	p := &ProgressBar{}
+	p.init(p)
+	p.style = style
+	p.styleName = "progress_bar"
+	p.background = newBackgroundBox(&p.WidgetBase)
+	p.fill = newBox(&p.WidgetBase)
+	p.SetSize(mgl32.Vec2{8 * style.FontSize, style.FontSize / 2})
+	return p
+}
+
+// SetValue sets the progress, clamped between 0 and 1
+func (p *ProgressBar) SetValue(value float32) {
+	p.value = mgl32.Clamp(value, 0, 1)
+	p.Invalidate()
+}
+
+// Value returns the progress
+func (p *ProgressBar) Value() float32 {
+	return p.value
+}
+
+// Arrange see Widget.Arrange
+func (p *ProgressBar) Arrange() {
+	position := p.AbsolutePosition()
+	p.background.set(position, p.layerDepth(0), p.size)
+	p.fill.set(position, p.layerDepth(1), mgl32.Vec2{p.size[0] * p.value, p.size[1]})
+}
+
+// EnqueueForDrawing see Widget.EnqueueForDrawing
+func (p *ProgressBar) EnqueueForDrawing(context *graphics.Context) {
+	state := p.State()
+	p.background.setColor(p.style.Background.Color(state))
+	p.fill.setColor(p.style.Accent.Color(state))
+	p.background.enqueue(context)
+	if p.value > 0 {
+		p.fill.enqueue(context)
+	}
+	p.WidgetBase.EnqueueForDrawing(context)
+}
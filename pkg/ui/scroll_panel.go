@@ -0,0 +1,140 @@
+package ui
+
+import (
+	"github.com/markov/gojira2d/pkg/graphics"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// ScrollPanel shows a part of its children, scrolled with the mouse wheel
+// or by focusing them. Children partly outside the panel are drawn whole,
+// the ones completely outside are skipped
+type ScrollPanel struct {
+	WidgetBase
+	background *box
+	scrollbar  *box
+	// Pixels scrolled by a mouse wheel step
+	scrollSpeed float32
+}
+
+// NewScrollPanel creates an empty scroll panel
+func NewScrollPanel(style *WidgetStyle) *ScrollPanel {
+	s := &ScrollPanel{}
+	s.init(s)
+	s.style = style
+	s.styleName = "scroll_panel"
+	s.background = newBackgroundBox(&s.WidgetBase)
+	s.scrollbar = newBox(&s.WidgetBase)
+	s.interactive = true
+	s.clipChildren = true
+	s.scrollSpeed = 2 * style.FontSize
+	return s
+}
+
+// SetScrollSpeed sets the pixels scrolled by a mouse wheel step
+func (s *ScrollPanel) SetScrollSpeed(speed float32) {
+	s.scrollSpeed = speed
+}
+
+// ContentSize returns the size of the box containing all the children
+func (s *ScrollPanel) ContentSize() mgl32.Vec2 {
+	var size mgl32.Vec2
+	for _, child := range s.children {
+		b := child.Base()
+		if b.hidden {
+			continue
+		}
+		end := b.position.Add(b.size)
+		if end[0] > size[0] {
+			size[0] = end[0]
+		}
+		if end[1] > size[1] {
+			size[1] = end[1]
+		}
+	}
+	return size
+}
+
+// SetScroll sets the scroll offset, clamped to the content
+func (s *ScrollPanel) SetScroll(scroll mgl32.Vec2) {
+	max := s.ContentSize().Sub(s.size)
+	for i := range scroll {
+		if scroll[i] > max[i] {
+			scroll[i] = max[i]
+		}
+		if scroll[i] < 0 {
+			scroll[i] = 0
+		}
+	}
+	if scroll != s.contentOffset {
+		s.contentOffset = scroll
+		s.Invalidate()
+	}
+}
+
+// Scroll returns the scroll offset in pixels
+func (s *ScrollPanel) Scroll() mgl32.Vec2 {
+	return s.contentOffset
+}
+
+// ScrollIntoView scrolls the least needed to show a descendant
+func (s *ScrollPanel) ScrollIntoView(w Widget) {
+	position := w.Base().AbsolutePosition().Sub(s.AbsolutePosition()).Add(s.contentOffset)
+	size := w.Base().size
+	scroll := s.contentOffset
+	for i := range scroll {
+		if position[i]+size[i] > scroll[i]+s.size[i] {
+			scroll[i] = position[i] + size[i] - s.size[i]
+		}
+		if position[i] < scroll[i] {
+			scroll[i] = position[i]
+		}
+	}
+	s.SetScroll(scroll)
+}
+
+// HandleEvent see Widget.HandleEvent
+func (s *ScrollPanel) HandleEvent(event *Event) {
+	if event.Type != EVENT_SCROLL {
+		return
+	}
+	previous := s.contentOffset
+	s.SetScroll(s.contentOffset.Sub(event.Delta.Mul(s.scrollSpeed)))
+	// Nested panels pass the scroll on when at their limit
+	event.Handled = s.contentOffset != previous
+}
+
+// Arrange see Widget.Arrange
+func (s *ScrollPanel) Arrange() {
+	position := s.AbsolutePosition()
+	s.background.set(position, s.layerDepth(0), s.size)
+	content := s.ContentSize()
+	if content[1] > s.size[1] && content[1] > 0 {
+		width := s.style.FontSize / 4
+		height := s.size[1] * s.size[1] / content[1]
+		y := s.contentOffset[1] / content[1] * s.size[1]
+		s.scrollbar.set(position.Add(mgl32.Vec2{s.size[0] - width, y}), s.layerDepth(1), mgl32.Vec2{width, height})
+	}
+}
+
+// Update see Widget.Update. The scroll is clamped again since the content
+// can change without the panel knowing
+func (s *ScrollPanel) Update(deltaTime float64) {
+	s.SetScroll(s.contentOffset)
+	s.WidgetBase.Update(deltaTime)
+}
+
+// EnqueueForDrawing see Widget.EnqueueForDrawing
+func (s *ScrollPanel) EnqueueForDrawing(context *graphics.Context) {
+	state := STATE_NORMAL
+	if !s.Enabled() {
+		state = STATE_DISABLED
+	}
+	s.background.setColor(s.style.Background.Color(state))
+	s.background.enqueue(context)
+	if s.ContentSize()[1] > s.size[1] {
+		s.scrollbar.setColor(s.style.Accent.Color(state))
+		s.scrollbar.enqueue(context)
+	}
+	s.WidgetBase.EnqueueForDrawing(context)
+}
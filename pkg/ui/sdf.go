@@ -0,0 +1,132 @@
+package ui
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// sdfCurveSteps is the number of lines a bezier curve is flattened to
+const sdfCurveSteps = 8
+
+// sdfOutline is a glyph outline flattened to closed polygons, in pixels with
+// y pointing down
+type sdfOutline struct {
+	edges [][2]mgl32.Vec2
+	start mgl32.Vec2
+	pen   mgl32.Vec2
+}
+
+func (o *sdfOutline) moveTo(p mgl32.Vec2) {
+	o.close()
+	o.start = p
+	o.pen = p
+}
+
+func (o *sdfOutline) lineTo(p mgl32.Vec2) {
+	if p != o.pen {
+		o.edges = append(o.edges, [2]mgl32.Vec2{o.pen, p})
+	}
+	o.pen = p
+}
+
+func (o *sdfOutline) quadTo(c, p mgl32.Vec2) {
+	p0 := o.pen
+	for i := 1; i <= sdfCurveSteps; i++ {
+		t := float32(i) / sdfCurveSteps
+		u := 1 - t
+		o.lineTo(p0.Mul(u * u).Add(c.Mul(2 * u * t)).Add(p.Mul(t * t)))
+	}
+}
+
+func (o *sdfOutline) cubeTo(c1, c2, p mgl32.Vec2) {
+	p0 := o.pen
+	for i := 1; i <= sdfCurveSteps; i++ {
+		t := float32(i) / sdfCurveSteps
+		u := 1 - t
+		o.lineTo(p0.Mul(u * u * u).Add(c1.Mul(3 * u * u * t)).Add(c2.Mul(3 * u * t * t)).Add(p.Mul(t * t * t)))
+	}
+}
+
+// close adds the edge back to the start of the current contour
+func (o *sdfOutline) close() {
+	o.lineTo(o.start)
+}
+
+// bounds returns the integer pixel box enclosing the outline
+func (o *sdfOutline) bounds() (x0, y0, x1, y1 int) {
+	if len(o.edges) == 0 {
+		return 0, 0, 0, 0
+	}
+	min := o.edges[0][0]
+	max := min
+	for _, e := range o.edges {
+		for _, p := range e {
+			for i := range p {
+				if p[i] < min[i] {
+					min[i] = p[i]
+				}
+				if p[i] > max[i] {
+					max[i] = p[i]
+				}
+			}
+		}
+	}
+	return int(math.Floor(float64(min[0]))), int(math.Floor(float64(min[1]))),
+		int(math.Ceil(float64(max[0]))), int(math.Ceil(float64(max[1])))
+}
+
+// render computes the signed distance field of the outline in a width x
+// height box whose top left corner is at origin. Values are 0.5 on the
+// outline, grow inside and reach 0 or 1 at spread pixels from it
+func (o *sdfOutline) render(origin mgl32.Vec2, width, height int, spread float32) []uint8 {
+	o.close()
+	field := make([]uint8, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			p := origin.Add(mgl32.Vec2{float32(x) + 0.5, float32(y) + 0.5})
+			distance := o.distance(p)
+			if !o.inside(p) {
+				distance = -distance
+			}
+			value := 0.5 + distance/(2*spread)
+			field[y*width+x] = uint8(mgl32.Clamp(value, 0, 1)*255 + 0.5)
+		}
+	}
+	return field
+}
+
+// distance returns the distance from p to the closest edge
+func (o *sdfOutline) distance(p mgl32.Vec2) float32 {
+	min := float32(math.MaxFloat32)
+	for _, e := range o.edges {
+		ab := e[1].Sub(e[0])
+		t := mgl32.Clamp(p.Sub(e[0]).Dot(ab)/ab.Dot(ab), 0, 1)
+		if d := p.Sub(e[0].Add(ab.Mul(t))).Len(); d < min {
+			min = d
+		}
+	}
+	return min
+}
+
+// inside uses the non-zero winding rule, like TrueType and CFF outlines
+func (o *sdfOutline) inside(p mgl32.Vec2) bool {
+	winding := 0
+	for _, e := range o.edges {
+		a, b := e[0], e[1]
+		if a[1] <= p[1] {
+			if b[1] > p[1] && sdfCross(a, b, p) > 0 {
+				winding++
+			}
+		} else if b[1] <= p[1] && sdfCross(a, b, p) < 0 {
+			winding--
+		}
+	}
+	return winding != 0
+}
+
+// sdfCross returns the z of the cross product of a->b and a->p, its sign
+// tells on which side of the line p is
+func sdfCross(a, b, p mgl32.Vec2) float32 {
+	return (b[0]-a[0])*(p[1]-a[1]) - (p[0]-a[0])*(b[1]-a[1])
+}
@@ -0,0 +1,115 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// square adds a clockwise (y down) or counter clockwise square contour
+func square(o *sdfOutline, x0, y0, x1, y1 float32, clockwise bool) {
+	o.moveTo(mgl32.Vec2{x0, y0})
+	if clockwise {
+		o.lineTo(mgl32.Vec2{x1, y0})
+		o.lineTo(mgl32.Vec2{x1, y1})
+		o.lineTo(mgl32.Vec2{x0, y1})
+	} else {
+		o.lineTo(mgl32.Vec2{x0, y1})
+		o.lineTo(mgl32.Vec2{x1, y1})
+		o.lineTo(mgl32.Vec2{x1, y0})
+	}
+	o.close()
+}
+
+func TestSDFOutline(t *testing.T) {
+	// A 20x20 square with a 10x10 hole in the middle
+	o := &sdfOutline{}
+	square(o, 0, 0, 20, 20, true)
+	square(o, 5, 5, 15, 15, false)
+
+	if x0, y0, x1, y1 := o.bounds(); x0 != 0 || y0 != 0 || x1 != 20 || y1 != 20 {
+		t.Errorf("Wrong bounds %d,%d %d,%d", x0, y0, x1, y1)
+	}
+
+	const spread = 4
+	field := o.render(mgl32.Vec2{-spread, -spread}, 20+2*spread, 20+2*spread, spread)
+	width := 20 + 2*spread
+	value := func(x, y int) uint8 {
+		return field[(y+spread)*width+x+spread]
+	}
+	var tests = []struct {
+		x, y     int
+		min, max uint8
+	}{
+		// Far outside and in the middle of the hole
+		{-4, -4, 0, 0},
+		{10, 10, 0, 0},
+		// Right inside and outside the outline
+		{0, 10, 128, 160},
+		{-1, 10, 96, 127},
+		// Inside the ring, 2.5 pixels from both edges
+		{2, 10, 200, 220},
+	}
+	for _, test := range tests {
+		if v := value(test.x, test.y); v < test.min || v > test.max {
+			t.Errorf("Pixel %d,%d: got %d, expecting [%d, %d]", test.x, test.y, v, test.min, test.max)
+		}
+	}
+}
+
+func TestSDFCurves(t *testing.T) {
+	o := &sdfOutline{}
+	o.moveTo(mgl32.Vec2{0, 0})
+	o.quadTo(mgl32.Vec2{10, -10}, mgl32.Vec2{20, 0})
+	o.cubeTo(mgl32.Vec2{20, 10}, mgl32.Vec2{0, 10}, mgl32.Vec2{0, 0})
+	if len(o.edges) != 2*sdfCurveSteps {
+		t.Errorf("Got %d edges, expecting %d", len(o.edges), 2*sdfCurveSteps)
+	}
+	// The quadratic curve peaks at half of the control point
+	if _, y0, _, y1 := o.bounds(); y0 != -5 || y1 != 8 {
+		t.Errorf("Wrong vertical bounds %d %d", y0, y1)
+	}
+	if !o.inside(mgl32.Vec2{10, 0}) || o.inside(mgl32.Vec2{10, -6}) {
+		t.Errorf("Wrong inside test")
+	}
+}
+
+func TestGlyphAtlas(t *testing.T) {
+	a := newGlyphAtlas(64, 16, 64)
+	var tests = []struct {
+		width, height int
+		page, x, y    int
+		grown, ok     bool
+	}{
+		{30, 10, 0, 0, 0, false, true},
+		{30, 12, 0, 31, 0, false, true},
+		// Doesn't fit in the first shelf, the page grows to 32 pixels
+		{20, 10, 0, 0, 13, true, true},
+		// A taller one grows the page to 64 pixels
+		{20, 40, 0, 21, 13, true, true},
+		// Doesn't fit in the page, a new one is added and grows
+		{30, 30, 1, 0, 0, true, true},
+		{65, 10, 0, 0, 0, false, false},
+	}
+	for i, test := range tests {
+		page, x, y, grown, ok := a.insert(test.width, test.height)
+		if !test.ok {
+			if ok {
+				t.Errorf("%d: area should not fit", i)
+			}
+			continue
+		}
+		if page != test.page || x != test.x || y != test.y || grown != test.grown {
+			t.Errorf("%d: got page %d at %d,%d grown %v, expecting page %d at %d,%d grown %v",
+				i, page, x, y, grown, test.page, test.x, test.y, test.grown)
+		}
+	}
+	if a.pageHeight(0) != 64 || a.pageHeight(1) != 32 {
+		t.Errorf("Wrong page heights %d %d", a.pageHeight(0), a.pageHeight(1))
+	}
+
+	a.draw(1, 2, 3, 2, 1, []uint8{10, 20})
+	if p := a.pages[1].image.RGBAAt(3, 3); p.A != 20 || !a.pages[1].dirty {
+		t.Errorf("Wrong pixel %v", p)
+	}
+}
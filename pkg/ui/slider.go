@@ -0,0 +1,163 @@
+package ui
+
+import (
+	"math"
+
+	"github.com/markov/gojira2d/pkg/graphics"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Number of left/right navigation steps between min and max when the
+// slider has no step
+const sliderNavigationSteps = 20
+
+// Slider picks a value in a range by dragging a thumb, or with left and
+// right while focused
+type Slider struct {
+	WidgetBase
+	track    *box
+	fill     *box
+	thumb    *box
+	min      float32
+	max      float32
+	step     float32
+	value    float32
+	onChange func(value float32)
+}
+
+// NewSlider creates a horizontal slider set to min
+func NewSlider(min, max float32, style *WidgetStyle) *Slider {
+	s := &Slider{min: min, max: max, value: min}
+	s.init(s)
+	s.style = style
+	s.styleName = "slider"
+	s.track = newBackgroundBox(&s.WidgetBase)
+	s.fill = newBox(&s.WidgetBase)
+	s.thumb = newBox(&s.WidgetBase)
+	s.interactive = true
+	s.focusable = true
+	s.SetSize(mgl32.Vec2{8 * style.FontSize, style.FontSize})
+	return s
+}
+
+// SetStep makes the value a multiple of step from min, 0 is continuous
+func (s *Slider) SetStep(step float32) {
+	s.step = step
+	s.SetValue(s.value)
+}
+
+// SetValue sets the value, clamped and snapped to the step, without
+// emitting EVENT_CHANGE
+func (s *Slider) SetValue(value float32) {
+	s.value = s.snap(value)
+	s.Invalidate()
+}
+
+// Value returns the current value
+func (s *Slider) Value() float32 {
+	return s.value
+}
+
+// SetOnChange sets the function called when the user changes the value
+func (s *Slider) SetOnChange(onChange func(value float32)) {
+	s.onChange = onChange
+}
+
+func (s *Slider) snap(value float32) float32 {
+	if s.step > 0 {
+		value = s.min + float32(math.Floor(float64((value-s.min)/s.step)+0.5))*s.step
+	}
+	return mgl32.Clamp(value, s.min, s.max)
+}
+
+// setFromUser changes the value and notifies it
+func (s *Slider) setFromUser(value float32) {
+	value = s.snap(value)
+	if value == s.value {
+		return
+	}
+	s.value = value
+	s.Invalidate()
+	s.changed()
+	if s.onChange != nil {
+		s.onChange(value)
+	}
+}
+
+// ratio returns the position of the value between min and max
+func (s *Slider) ratio() float32 {
+	if s.max <= s.min {
+		return 0
+	}
+	return (s.value - s.min) / (s.max - s.min)
+}
+
+// thumbWidth returns the width in pixels of the thumb
+func (s *Slider) thumbWidth() float32 {
+	return s.size[1] / 2
+}
+
+// valueAt returns the value for an absolute pointer position
+func (s *Slider) valueAt(point mgl32.Vec2) float32 {
+	thumb := s.thumbWidth()
+	usable := s.size[0] - thumb
+	if usable <= 0 {
+		return s.min
+	}
+	ratio := (point[0] - s.AbsolutePosition()[0] - thumb/2) / usable
+	return s.min + mgl32.Clamp(ratio, 0, 1)*(s.max-s.min)
+}
+
+// HandleEvent see Widget.HandleEvent
+func (s *Slider) HandleEvent(event *Event) {
+	if event.Target != Widget(s) {
+		return
+	}
+	switch event.Type {
+	case EVENT_PRESS, EVENT_DRAG:
+		s.setFromUser(s.valueAt(event.Position))
+		event.Handled = true
+	case EVENT_NAVIGATE:
+		step := s.step
+		if step <= 0 {
+			step = (s.max - s.min) / sliderNavigationSteps
+		}
+		switch event.Direction {
+		case NAV_LEFT:
+			s.setFromUser(s.value - step)
+			event.Handled = true
+		case NAV_RIGHT:
+			s.setFromUser(s.value + step)
+			event.Handled = true
+		}
+	}
+}
+
+// Arrange see Widget.Arrange
+func (s *Slider) Arrange() {
+	position := s.AbsolutePosition()
+	thumb := s.thumbWidth()
+	trackHeight := s.size[1] / 4
+	trackPosition := position.Add(mgl32.Vec2{0, centerVertically(s.size, trackHeight)})
+	thumbX := s.ratio() * (s.size[0] - thumb)
+	s.track.set(trackPosition, s.layerDepth(0), mgl32.Vec2{s.size[0], trackHeight})
+	s.fill.set(trackPosition, s.layerDepth(1), mgl32.Vec2{thumbX + thumb/2, trackHeight})
+	s.thumb.set(position.Add(mgl32.Vec2{thumbX, 0}), s.layerDepth(2), mgl32.Vec2{thumb, s.size[1]})
+}
+
+// EnqueueForDrawing see Widget.EnqueueForDrawing
+func (s *Slider) EnqueueForDrawing(context *graphics.Context) {
+	state := s.State()
+	s.track.setColor(s.style.Background.Color(state))
+	if state == STATE_DISABLED {
+		s.fill.setColor(s.style.Accent.Color(state))
+	} else {
+		s.fill.setColor(s.style.Accent.Color(STATE_NORMAL))
+	}
+	s.thumb.setColor(s.style.Accent.Color(state))
+	s.track.enqueue(context)
+	s.fill.enqueue(context)
+	s.thumb.enqueue(context)
+	s.WidgetBase.EnqueueForDrawing(context)
+}
@@ -1,7 +1,7 @@
 package ui
 
 import (
-	"log"
+	"strings"
 
 	"github.com/markov/gojira2d/pkg/graphics"
 
@@ -11,17 +11,31 @@ import (
 
 // Text is a UI element that just renders a string
 type Text struct {
-	drawable *graphics.Primitive2D
-	position mgl32.Vec3
-	size     mgl32.Vec2
-	color    graphics.Color
-	text     string
-	font     *Font
-	paddings mgl32.Vec4
+	batches       []*textBatch
+	position      mgl32.Vec3
+	size          mgl32.Vec2
+	color         graphics.Color
+	text          string
+	font          *Font
+	paddings      mgl32.Vec4
+	layoutOptions LayoutOptions
+	layout        *TextLayout
+	anchor        mgl32.Vec2
+	fontRevision  int
+	style         TextStyle
+	runs          []TextRun
+	icons         *IconSet
+	time          float32
 }
 
 const charVertices = 12
 
+// Vertex attribute locations of the per glyph color and effects
+const (
+	textColorLocation  = 2
+	textEffectLocation = 3
+)
+
 func charQuad(offsetX, offsetY, width, height float32) []float32 {
 	q := [charVertices]float32{
 		offsetX, offsetY + height, // bl
@@ -34,63 +48,179 @@ func charQuad(offsetX, offsetY, width, height float32) []float32 {
 	return q[:]
 }
 
-func (t *Text) makeNewQuads() ([]float32, []float32) {
-	var (
-		vnum     = len(t.text) * charVertices
-		idx      = 0
-		cursorX  float32
-		cursorY  float32
-		lastChar int32
-	)
+// textQuadsKey identifies the glyphs drawn together: same texture page and
+// same channel of the texture
+type textQuadsKey struct {
+	texture *graphics.Texture
+	channel mgl32.Vec4
+}
 
-	vertices := make([]float32, vnum)
-	uvCoords := make([]float32, vnum)
+// textQuads are the character quads sharing the same texture and channel
+type textQuads struct {
+	textQuadsKey
+	// Texture coordinates size of a line height
+	uvScale  mgl32.Vec2
+	vertices []float32
+	uvCoords []float32
+	// Per vertex color and effects: wave, shake, phase and image flag
+	colors  []float32
+	effects []float32
+}
+
+// appendGlyph adds the quad of a glyph, the color and effects are repeated
+// for each of its vertices
+func (q *textQuads) appendGlyph(vertices, uvCoords []float32, color graphics.Color, effect mgl32.Vec4) {
+	q.vertices = append(q.vertices, vertices...)
+	q.uvCoords = append(q.uvCoords, uvCoords...)
+	for i := 0; i < charVertices/2; i++ {
+		q.colors = append(q.colors, color[:]...)
+		q.effects = append(q.effects, effect[:]...)
+	}
+}
 
-	for _, char := range t.text {
-		if char == 0x0a {
-			cursorX = 0
-			cursorY += 1 + t.paddings[1]
-			lastChar = 0
+// channelMask returns the texture channel holding the glyphs of a BMFont
+// chnl value. Glyphs in all the channels or in alpha are read from alpha
+func channelMask(channel int) mgl32.Vec4 {
+	switch channel {
+	case 1:
+		return mgl32.Vec4{0, 0, 1, 0}
+	case 2:
+		return mgl32.Vec4{0, 1, 0, 0}
+	case 4:
+		return mgl32.Vec4{1, 0, 0, 0}
+	}
+	return mgl32.Vec4{0, 0, 0, 1}
+}
+
+// makeNewQuads lays out the text and returns its quads grouped by texture
+// page and channel, in order of first appearance. Missing characters are
+// skipped, they are logged once by the font
+func (t *Text) makeNewQuads() []*textQuads {
+	options := t.layoutOptions
+	options.Paddings = t.paddings
+	if t.runs != nil {
+		t.layout = t.font.LayoutRuns(t.runs, t.icons, options)
+	} else {
+		t.layout = t.font.Layout(t.text, options)
+	}
+	t.fontRevision = t.font.revision(make(map[*Font]bool))
+	offset := t.anchorOffset()
+
+	quads := make([]*textQuads, 0, 1)
+	byKey := make(map[textQuadsKey]*textQuads)
+
+	for i, glyph := range t.layout.Glyphs {
+		color := graphics.Color{1, 1, 1, 1}
+		effect := mgl32.Vec4{0, 0, float32(i), 0}
+		if glyph.Run >= 0 && glyph.Run < len(t.runs) {
+			run := &t.runs[glyph.Run]
+			color = run.Color
+			effect[0], effect[1] = run.Wave, run.Shake
+			if run.Icon != "" {
+				t.appendIcon(&quads, byKey, glyph, run.Icon, color, effect, offset)
+				continue
+			}
+		}
+
+		bmc := glyph.char
+		if bmc == nil {
 			continue
 		}
-		bmc, ok := t.font.bm.Characters[char]
-		if !ok {
-			log.Printf(
-				"ERR: char %v (%v) not found in font map",
-				string(char), char,
-			)
+		texture := glyph.font.Page(bmc.pageIndex)
+		if texture == nil {
 			continue
 		}
 
-		kerning, ok := bmc.f32kernings[lastChar]
+		key := textQuadsKey{texture, channelMask(bmc.textureChannel)}
+		q, ok := byKey[key]
 		if !ok {
-			kerning = 0
+			q = &textQuads{textQuadsKey: key}
+			if texture.Width() > 0 && texture.Height() > 0 {
+				lineHeight := float32(glyph.font.bm.lineHeight)
+				q.uvScale = mgl32.Vec2{lineHeight / float32(texture.Width()), lineHeight / float32(texture.Height())}
+			}
+			byKey[key] = q
+			quads = append(quads, q)
 		}
-
-		copy(
-			vertices[idx:],
+		q.appendGlyph(
 			charQuad(
-				cursorX+bmc.f32offsetX+kerning+t.paddings[2],
-				cursorY+bmc.f32offsetY+t.paddings[0],
-				bmc.f32lineWidth,
-				bmc.f32lineHeight,
+				glyph.X+glyph.offset.X()+bmc.f32offsetX*glyph.Scale+t.paddings[2]+offset.X(),
+				glyph.Y+glyph.offset.Y()+bmc.f32offsetY*glyph.Scale+t.paddings[0]+offset.Y(),
+				bmc.f32lineWidth*glyph.Scale,
+				bmc.f32lineHeight*glyph.Scale,
 			),
-		)
-		copy(
-			uvCoords[idx:],
 			charQuad(
 				bmc.f32x,
 				bmc.f32y,
 				bmc.f32width,
 				bmc.f32height,
 			),
+			color,
+			effect,
 		)
-		idx += charVertices
-		cursorX += bmc.f32advanceX + t.paddings[3]
-		lastChar = char
 	}
 
-	return vertices, uvCoords
+	return quads
+}
+
+// appendIcon adds the quad of an inline icon, batched with the other icons
+// of the IconSet texture. The top of the icon is at the ascent of the line
+func (t *Text) appendIcon(
+	quads *[]*textQuads,
+	byKey map[textQuadsKey]*textQuads,
+	glyph GlyphLayout,
+	name string,
+	color graphics.Color,
+	effect mgl32.Vec4,
+	offset mgl32.Vec2,
+) {
+	width, height, ok := t.font.iconSize(t.icons, name, glyph.Scale)
+	if !ok {
+		return
+	}
+	texture := t.icons.Texture()
+	if texture == nil || texture.Width() == 0 || texture.Height() == 0 {
+		return
+	}
+	key := textQuadsKey{texture: texture}
+	q, ok := byKey[key]
+	if !ok {
+		q = &textQuads{textQuadsKey: key}
+		byKey[key] = q
+		*quads = append(*quads, q)
+	}
+	region, _ := t.icons.Region(name)
+	textureWidth, textureHeight := float32(texture.Width()), float32(texture.Height())
+	effect[3] = 1
+	q.appendGlyph(
+		charQuad(
+			glyph.X+glyph.offset.X()+t.paddings[2]+offset.X(),
+			glyph.Y+glyph.offset.Y()+t.paddings[0]+offset.Y(),
+			width,
+			height,
+		),
+		charQuad(
+			region[0]/textureWidth,
+			region[1]/textureHeight,
+			region[2]/textureWidth,
+			region[3]/textureHeight,
+		),
+		color,
+		effect,
+	)
+}
+
+// anchorOffset returns the translation moving the anchor point of the layout
+// box to the text position
+func (t *Text) anchorOffset() mgl32.Vec2 {
+	box := mgl32.Vec2{t.layoutOptions.MaxWidth, t.layoutOptions.MaxHeight}
+	if box[0] <= 0 {
+		box[0] = t.layout.Width
+	}
+	if box[1] <= 0 {
+		box[1] = t.layout.Height
+	}
+	return mgl32.Vec2{-t.anchor[0] * box[0], -t.anchor[1] * box[1]}
 }
 
 var textShaderProgram *graphics.ShaderProgram
@@ -106,7 +236,7 @@ func NewText(
 ) *Text {
 	if textShaderProgram == nil {
 		textShaderProgram = graphics.NewShaderProgram(
-			graphics.VertexShaderPrimitive2D, "", fragmentDistanceFieldFont,
+			vertexShaderText, "", fragmentDistanceFieldFont,
 		)
 	}
 
@@ -119,27 +249,144 @@ func NewText(
 	t.font = font
 	t.paddings = paddings
 
-	charVertices, charUVCoords := t.makeNewQuads()
-	t.drawable = graphics.NewTriangles(
-		charVertices, charUVCoords, font.tx, position, size, textShaderProgram)
+	t.uploadNewQuads()
 
 	return t
 }
 
+// uploadNewQuads rebuilds the quads and uploads them to one primitive per
+// texture, reusing the existing primitives when possible
 func (t *Text) uploadNewQuads() {
-	charVertices, charUVCoords := t.makeNewQuads()
-	t.drawable.SetVertices(charVertices)
-	t.drawable.SetUVCoords(charUVCoords)
+	quads := t.makeNewQuads()
+	for i, q := range quads {
+		if i < len(t.batches) && t.batches[i].primitive.Texture() == q.texture {
+			t.batches[i].channel = q.channel
+			t.batches[i].uvScale = q.uvScale
+			t.batches[i].primitive.SetVertices(q.vertices)
+			t.batches[i].primitive.SetUVCoords(q.uvCoords)
+			t.batches[i].primitive.SetVertexAttribute(textColorLocation, 4, q.colors)
+			t.batches[i].primitive.SetVertexAttribute(textEffectLocation, 4, q.effects)
+			continue
+		}
+		batch := &textBatch{
+			text:    t,
+			channel: q.channel,
+			uvScale: q.uvScale,
+			primitive: graphics.NewTriangles(
+				q.vertices, q.uvCoords, q.texture, t.position, t.size, textShaderProgram),
+		}
+		batch.primitive.SetVertexAttribute(textColorLocation, 4, q.colors)
+		batch.primitive.SetVertexAttribute(textEffectLocation, 4, q.effects)
+		if i < len(t.batches) {
+			t.batches[i].primitive.Release()
+			t.batches[i] = batch
+		} else {
+			t.batches = append(t.batches, batch)
+		}
+	}
+	for _, batch := range t.batches[len(quads):] {
+		batch.primitive.Release()
+	}
+	t.batches = t.batches[:len(quads)]
+}
+
+// refresh rebuilds the quads if the texture coordinates of the font changed
+// since the last time, e.g. a dynamic atlas page grew
+func (t *Text) refresh() {
+	if t.font.revision(make(map[*Font]bool)) != t.fontRevision {
+		t.uploadNewQuads()
+	}
 }
 
 // SetText changes the rendered string and uploads new vertices/coordinates
 func (t *Text) SetText(txt string) {
-	if t.text != txt {
+	if t.text != txt || t.runs != nil {
 		t.text = txt
+		t.runs = nil
 		t.uploadNewQuads()
 	}
 }
 
+// SetMarkup changes the rendered string to a rich text, see ParseMarkup for
+// the supported tags. Text() returns the string without the tags
+func (t *Text) SetMarkup(markup string) {
+	t.SetRuns(ParseMarkup(markup))
+}
+
+// SetRuns changes the rendered string to a list of styled runs
+func (t *Text) SetRuns(runs []TextRun) {
+	var text strings.Builder
+	for _, run := range runs {
+		text.WriteString(run.Text)
+	}
+	t.text = text.String()
+	t.runs = append(make([]TextRun, 0, len(runs)), runs...)
+	t.uploadNewQuads()
+}
+
+// Runs returns the styled runs of a rich text, nil for plain text
+func (t *Text) Runs() []TextRun {
+	return t.runs
+}
+
+// Text returns the rendered string, without markup tags
+func (t *Text) Text() string {
+	return t.text
+}
+
+// SetIconSet sets the images placed in the text with the [img=name] tag
+func (t *Text) SetIconSet(icons *IconSet) {
+	t.icons = icons
+	if t.runs != nil {
+		t.uploadNewQuads()
+	}
+}
+
+// Update advances the time of the wave and shake effects
+func (t *Text) Update(deltaTime float64) {
+	t.time += float32(deltaTime)
+}
+
+// SetPosition moves the text, the anchor point of the layout box is placed
+// at position
+func (t *Text) SetPosition(position mgl32.Vec3) {
+	t.position = position
+	for _, batch := range t.batches {
+		batch.primitive.SetPosition(position)
+	}
+}
+
+// Position returns the position of the text
+func (t *Text) Position() mgl32.Vec3 {
+	return t.position
+}
+
+// SetSize changes the size in pixels of a line height
+func (t *Text) SetSize(size mgl32.Vec2) {
+	t.size = size
+	for _, batch := range t.batches {
+		batch.primitive.SetSize(size)
+	}
+}
+
+// Size returns the size in pixels of a line height
+func (t *Text) Size() mgl32.Vec2 {
+	return t.size
+}
+
+// SetFont changes the font and uploads new vertices/coordinates
+func (t *Text) SetFont(font *Font) {
+	if t.font != font {
+		t.font = font
+		t.uploadNewQuads()
+	}
+}
+
+// Font returns the font of the text
+func (t *Text) Font() *Font {
+	return t.font
+}
+
 // SetColor ...
 func (t *Text) SetColor(color graphics.Color) {
 	t.color = color
@@ -156,60 +403,161 @@ func (t *Text) SetPaddings(paddings mgl32.Vec4) {
 	t.uploadNewQuads()
 }
 
-// EnqueueForDrawing see Drawable.EnqueueForDrawing
+// SetLayoutOptions changes wrapping, alignment and truncation of the text.
+// Measures are relative to the line height, the paddings of the options are
+// ignored in favour of the ones of the text
+func (t *Text) SetLayoutOptions(options LayoutOptions) {
+	t.layoutOptions = options
+	t.uploadNewQuads()
+}
+
+// LayoutOptions returns the current layout options
+func (t *Text) LayoutOptions() LayoutOptions {
+	return t.layoutOptions
+}
+
+// SetWrapWidth enables word wrapping at the given width in pixels. A width
+// of 0 disables wrapping
+func (t *Text) SetWrapWidth(width float32) {
+	t.layoutOptions.MaxWidth = width / t.size.X()
+	t.layoutOptions.WordWrap = width > 0
+	t.uploadNewQuads()
+}
+
+// SetAlignment aligns the lines inside the layout box
+func (t *Text) SetAlignment(horizontal HorizontalAlignment, vertical VerticalAlignment) {
+	t.layoutOptions.HAlign = horizontal
+	t.layoutOptions.VAlign = vertical
+	t.uploadNewQuads()
+}
+
+// SetAnchor sets the point of the layout box placed at the text position,
+// relative to the box size: 0,0 is the top left corner (default), 0.5,0.5 is
+// the center and 1,1 the bottom right corner
+func (t *Text) SetAnchor(anchor mgl32.Vec2) {
+	t.anchor = anchor
+	t.uploadNewQuads()
+}
+
+// Layout returns the last computed layout, in line height units
+func (t *Text) Layout() *TextLayout {
+	return t.layout
+}
+
+// Bounds returns the top left corner, relative to the text position, and the
+// size in pixels of the laid out text
+func (t *Text) Bounds() (mgl32.Vec2, mgl32.Vec2) {
+	topLeft, size := t.layout.Bounds()
+	topLeft = topLeft.Add(t.anchorOffset())
+	return mgl32.Vec2{topLeft[0] * t.size[0], topLeft[1] * t.size[1]},
+		mgl32.Vec2{size[0] * t.size[0], size[1] * t.size[1]}
+}
+
+// CursorPosition returns the position in pixels, relative to the text
+// position, of the cursor placed before the rune at byte offset index
+func (t *Text) CursorPosition(index int) mgl32.Vec2 {
+	p := t.layout.CursorPosition(index).Add(t.anchorOffset())
+	return mgl32.Vec2{p[0] * t.size[0], p[1] * t.size[1]}
+}
+
+// IndexAtPosition returns the byte offset of the cursor position closest to
+// point, in pixels relative to the text position
+func (t *Text) IndexAtPosition(point mgl32.Vec2) int {
+	p := mgl32.Vec2{point[0] / t.size[0], point[1] / t.size[1]}
+	return t.layout.IndexAt(p.Sub(t.anchorOffset()))
+}
+
+// EnqueueForDrawing see Drawable.EnqueueForDrawing. Each texture used by
+// the text is enqueued separately so that it's batched with its texture
 func (t *Text) EnqueueForDrawing(context *graphics.Context) {
-	context.EnqueueForDrawing(t)
+	t.refresh()
+	for _, batch := range t.batches {
+		context.EnqueueForDrawing(batch)
+	}
 }
 
 // SetUniforms uploads relevant uniforms
 func (t *Text) SetUniforms() {
 	shaderProgram := t.Shader()
 	shaderProgram.SetUniform("textColor", &t.color)
+	shaderProgram.SetUniform("time", &t.time)
+	t.setStyleUniforms(shaderProgram)
 }
 
 // Drawable implementation
 
-// Texture returns drawable texture
+// Texture returns drawable texture, the one of the first glyph
 func (t *Text) Texture() *graphics.Texture {
-	return t.drawable.Texture()
+	if len(t.batches) == 0 {
+		return t.font.Page(0)
+	}
+	return t.batches[0].primitive.Texture()
 }
 
 // Shader returns shader program
 func (t *Text) Shader() *graphics.ShaderProgram {
-	return t.drawable.Shader()
+	return textShaderProgram
 }
 
 // Draw runs all the necessary routines to make drawable appear on screen
 func (t *Text) Draw(context *graphics.Context) {
-	shaderProgram := t.Shader()
-	gl.UseProgram(shaderProgram.Id())
-	t.SetUniforms()
-	t.drawable.Draw(context)
+	t.refresh()
+	for _, batch := range t.batches {
+		batch.Draw(context)
+	}
 }
 
-// DrawInBatch is like Draw() but without setting up texture and shader
+// DrawInBatch is like Draw() but without setting up texture and shader. The
+// glyphs of fallback fonts bind their own texture, the first one is restored
 func (t *Text) DrawInBatch(context *graphics.Context) {
-	t.SetUniforms()
-	t.drawable.DrawInBatch(context)
+	for i, batch := range t.batches {
+		if i > 0 {
+			context.BindTexture(batch.Texture())
+		}
+		batch.DrawInBatch(context)
+	}
+	if len(t.batches) > 1 {
+		context.BindTexture(t.Texture())
+	}
 }
 
 // Drawable end
 
-var (
-	fragmentDistanceFieldFont = `
-        #version 410 core
+// textBatch draws the glyphs of a Text sharing the same texture and channel
+type textBatch struct {
+	text      *Text
+	channel   mgl32.Vec4
+	uvScale   mgl32.Vec2
+	primitive *graphics.Primitive2D
+}
 
-        in vec2 uv_out;
-        out vec4 color;
+// setUniforms uploads the text uniforms and the ones depending on the texture
+func (b *textBatch) setUniforms() {
+	b.text.SetUniforms()
+	shadowOffset := b.text.shadowUVOffset(b.uvScale)
+	b.Shader().SetUniform("channelMask", &b.channel)
+	b.Shader().SetUniform("shadowOffset", &shadowOffset)
+}
 
-        uniform sampler2D tex;
-        uniform vec4 textColor;
+// Texture returns drawable texture
+func (b *textBatch) Texture() *graphics.Texture {
+	return b.primitive.Texture()
+}
 
-        void main() {
-          float dist = texture(tex, uv_out).a;
-          float width = fwidth(dist);
-					float alpha = smoothstep(0.5-width, 0.5+width, dist);
-          color = vec4(vec3(textColor),alpha*textColor.a);
-        }
-        ` + "\x00"
-)
+// Shader returns shader program
+func (b *textBatch) Shader() *graphics.ShaderProgram {
+	return b.primitive.Shader()
+}
+
+// Draw runs all the necessary routines to make drawable appear on screen
+func (b *textBatch) Draw(context *graphics.Context) {
+	gl.UseProgram(b.Shader().Id())
+	b.setUniforms()
+	b.primitive.Draw(context)
+}
+
+// DrawInBatch is like Draw() but without setting up texture and shader
+func (b *textBatch) DrawInBatch(context *graphics.Context) {
+	b.setUniforms()
+	b.primitive.DrawInBatch(context)
+}
@@ -0,0 +1,206 @@
+package ui
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// InputFilter returns true if a character can be typed in a TextInput
+type InputFilter func(r rune) bool
+
+var (
+	// FilterDigits accepts 0-9 only
+	FilterDigits InputFilter = func(r rune) bool {
+		return r >= '0' && r <= '9'
+	}
+	// FilterNumeric accepts the characters of signed decimal numbers
+	FilterNumeric InputFilter = func(r rune) bool {
+		return (r >= '0' && r <= '9') || r == '.' || r == '-' || r == '+'
+	}
+	// FilterAlphanumeric accepts letters and digits of any script
+	FilterAlphanumeric InputFilter = func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+)
+
+// textEdit is the editing state of a single line of text. Caret and anchor
+// are byte offsets at grapheme boundaries, the selection is between them
+type textEdit struct {
+	value     string
+	caret     int
+	anchor    int
+	maxLength int
+	filter    InputFilter
+}
+
+// selection returns the selected byte range, start <= end
+func (e *textEdit) selection() (int, int) {
+	if e.anchor < e.caret {
+		return e.anchor, e.caret
+	}
+	return e.caret, e.anchor
+}
+
+func (e *textEdit) hasSelection() bool {
+	return e.caret != e.anchor
+}
+
+func (e *textEdit) selectedText() string {
+	start, end := e.selection()
+	return e.value[start:end]
+}
+
+func (e *textEdit) setValue(value string) {
+	e.value = ""
+	e.caret, e.anchor = 0, 0
+	e.insert(value)
+}
+
+// moveTo places the caret at index, extending the selection or collapsing it
+func (e *textEdit) moveTo(index int, extend bool) {
+	if index < 0 {
+		index = 0
+	} else if index > len(e.value) {
+		index = len(e.value)
+	}
+	e.caret = index
+	if !extend {
+		e.anchor = index
+	}
+}
+
+// left and right move by grapheme, or by word. Without extend an existing
+// selection is collapsed to its start or end
+func (e *textEdit) left(word, extend bool) {
+	if !extend && !word && e.hasSelection() {
+		start, _ := e.selection()
+		e.moveTo(start, false)
+		return
+	}
+	if word {
+		e.moveTo(e.prevWord(e.caret), extend)
+	} else {
+		e.moveTo(PrevGrapheme(e.value, e.caret), extend)
+	}
+}
+
+func (e *textEdit) right(word, extend bool) {
+	if !extend && !word && e.hasSelection() {
+		_, end := e.selection()
+		e.moveTo(end, false)
+		return
+	}
+	if word {
+		e.moveTo(e.nextWord(e.caret), extend)
+	} else {
+		e.moveTo(NextGrapheme(e.value, e.caret), extend)
+	}
+}
+
+func (e *textEdit) selectAll() {
+	e.anchor = 0
+	e.caret = len(e.value)
+}
+
+// insert replaces the selection with s. Filtered out characters and line
+// breaks are dropped, s is truncated to the maximum length. It returns true
+// if the value changed
+func (e *textEdit) insert(s string) bool {
+	var accepted strings.Builder
+	for _, r := range s {
+		if r == '\n' || r == '\r' || (r != zeroWidthJoiner && unicode.IsControl(r)) {
+			continue
+		}
+		if e.filter != nil && !e.filter(r) {
+			continue
+		}
+		accepted.WriteRune(r)
+	}
+	s = accepted.String()
+
+	start, end := e.selection()
+	if e.maxLength > 0 {
+		available := e.maxLength - GraphemeCount(e.value[:start]) - GraphemeCount(e.value[end:])
+		cut := 0
+		for i := 0; i < available && cut < len(s); i++ {
+			cut = NextGrapheme(s, cut)
+		}
+		s = s[:cut]
+	}
+	if s == "" && start == end {
+		return false
+	}
+	e.value = e.value[:start] + s + e.value[end:]
+	e.moveTo(start+len(s), false)
+	return true
+}
+
+// deleteBackward removes the selection or what's before the caret: a
+// grapheme or a word
+func (e *textEdit) deleteBackward(word bool) bool {
+	if !e.hasSelection() {
+		if word {
+			e.anchor = e.prevWord(e.caret)
+		} else {
+			e.anchor = PrevGrapheme(e.value, e.caret)
+		}
+	}
+	return e.insert("")
+}
+
+// deleteForward removes the selection or what's after the caret
+func (e *textEdit) deleteForward(word bool) bool {
+	if !e.hasSelection() {
+		if word {
+			e.anchor = e.nextWord(e.caret)
+		} else {
+			e.anchor = NextGrapheme(e.value, e.caret)
+		}
+	}
+	return e.insert("")
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || isMark(r)
+}
+
+// prevWord returns the start of the word before index, skipping spaces and
+// punctuation first
+func (e *textEdit) prevWord(index int) int {
+	for index > 0 {
+		r, size := utf8.DecodeLastRuneInString(e.value[:index])
+		if isWordRune(r) {
+			break
+		}
+		index -= size
+	}
+	for index > 0 {
+		r, size := utf8.DecodeLastRuneInString(e.value[:index])
+		if !isWordRune(r) {
+			break
+		}
+		index -= size
+	}
+	return index
+}
+
+// nextWord returns the end of the word after index, skipping spaces and
+// punctuation first
+func (e *textEdit) nextWord(index int) int {
+	for index < len(e.value) {
+		r, size := utf8.DecodeRuneInString(e.value[index:])
+		if isWordRune(r) {
+			break
+		}
+		index += size
+	}
+	for index < len(e.value) {
+		r, size := utf8.DecodeRuneInString(e.value[index:])
+		if !isWordRune(r) {
+			break
+		}
+		index += size
+	}
+	return index
+}
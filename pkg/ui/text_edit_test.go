@@ -0,0 +1,93 @@
+package ui
+
+import "testing"
+
+func TestTextEditInsert(t *testing.T) {
+	e := &textEdit{maxLength: 5}
+	e.insert("he\nllo world")
+	if e.value != "hello" || e.caret != 5 {
+		t.Errorf("Got %q caret %d", e.value, e.caret)
+	}
+
+	// Replacing the selection frees room for the new text
+	e.moveTo(1, false)
+	e.moveTo(4, true)
+	if e.selectedText() != "ell" {
+		t.Errorf("Wrong selection %q", e.selectedText())
+	}
+	e.insert("EEEE")
+	if e.value != "hEEEo" || e.caret != 4 || e.hasSelection() {
+		t.Errorf("Got %q caret %d", e.value, e.caret)
+	}
+
+	// The limit counts grapheme clusters
+	e = &textEdit{maxLength: 2}
+	e.insert("e\u0301a\u0301b")
+	if e.value != "e\u0301a\u0301" {
+		t.Errorf("Got %q", e.value)
+	}
+
+	e = &textEdit{filter: FilterDigits}
+	if e.insert("a1b2") != true || e.value != "12" {
+		t.Errorf("Got %q", e.value)
+	}
+	if e.insert("x") {
+		t.Errorf("Filtered insert should not change the value")
+	}
+}
+
+func TestTextEditDelete(t *testing.T) {
+	e := &textEdit{}
+	e.setValue("one two, three")
+	e.deleteBackward(true)
+	if e.value != "one two, " {
+		t.Errorf("Got %q", e.value)
+	}
+	e.deleteBackward(false)
+	if e.value != "one two," {
+		t.Errorf("Got %q", e.value)
+	}
+	e.deleteBackward(true)
+	if e.value != "one " {
+		t.Errorf("Got %q", e.value)
+	}
+	e.moveTo(0, false)
+	e.deleteForward(true)
+	if e.value != " " || e.caret != 0 {
+		t.Errorf("Got %q caret %d", e.value, e.caret)
+	}
+
+	// Clusters are deleted as a whole
+	e.setValue("ae\u0301")
+	e.deleteBackward(false)
+	if e.value != "a" {
+		t.Errorf("Got %q", e.value)
+	}
+}
+
+func TestTextEditNavigation(t *testing.T) {
+	e := &textEdit{}
+	e.setValue("foo_bar  baz")
+	e.moveTo(0, false)
+	e.right(true, false)
+	if e.caret != 7 {
+		t.Errorf("Word right: caret %d", e.caret)
+	}
+	e.right(true, true)
+	if e.caret != 12 || e.selectedText() != "  baz" {
+		t.Errorf("Word right extending: caret %d selection %q", e.caret, e.selectedText())
+	}
+	// Moving without shift collapses the selection
+	e.left(false, false)
+	if e.caret != 7 || e.hasSelection() {
+		t.Errorf("Collapse: caret %d", e.caret)
+	}
+	e.left(true, false)
+	if e.caret != 0 {
+		t.Errorf("Word left: caret %d", e.caret)
+	}
+	e.selectAll()
+	if e.selectedText() != e.value {
+		t.Errorf("Wrong select all %q", e.selectedText())
+	}
+}
@@ -0,0 +1,359 @@
+package ui
+
+import (
+	"github.com/markov/gojira2d/pkg/graphics"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Seconds the caret is visible, then hidden, while blinking
+const caretBlinkPeriod = 0.53
+
+// TextInput is a single line text field. Characters come from the glfw char
+// callback, so keyboard layouts, dead keys and input methods work. Editing
+// keys repeat with the key repeat rate of the system
+type TextInput struct {
+	edit        textEdit
+	text        *Text
+	placeholder string
+	position    mgl32.Vec3
+	width       float32
+	size        mgl32.Vec2
+	color       graphics.Color
+	// Horizontal scroll in pixels keeping the caret inside the field
+	scroll float32
+
+	focused    bool
+	blinkTime  float64
+	caret      *graphics.Primitive2D
+	selection  *graphics.Primitive2D
+	caretWidth float32
+
+	window       *glfw.Window
+	previousChar glfw.CharCallback
+	previousKey  glfw.KeyCallback
+
+	onChange func(value string)
+	onSubmit func(value string)
+}
+
+// NewTextInput creates an empty text field. width is the width of the field
+// in pixels, size is the font size as for NewText
+func NewTextInput(
+	font *Font,
+	position mgl32.Vec3,
+	width float32,
+	size mgl32.Vec2,
+	color graphics.Color,
+) *TextInput {
+	i := &TextInput{
+		position:   position,
+		width:      width,
+		size:       size,
+		color:      color,
+		caretWidth: 2,
+	}
+	i.text = NewText("", font, position, size, color, mgl32.Vec4{})
+	i.caret = graphics.NewRectanglePrimitive(position, mgl32.Vec2{i.caretWidth, size.Y()})
+	i.caret.SetColor(color)
+	i.selection = graphics.NewRectanglePrimitive(position, mgl32.Vec2{0, size.Y()})
+	i.selection.SetColor(graphics.Color{color[0], color[1], color[2], color[3] * 0.3})
+	return i
+}
+
+// Attach installs the char and key callbacks of the window. Callbacks set
+// before are still called, so the input can coexist with a
+// KeyboardController attached first
+func (i *TextInput) Attach(window *glfw.Window) {
+	if i.window != nil {
+		i.Detach()
+	}
+	i.window = window
+	i.previousChar = window.SetCharCallback(func(w *glfw.Window, char rune) {
+		if i.previousChar != nil {
+			i.previousChar(w, char)
+		}
+		i.HandleChar(char)
+	})
+	i.previousKey = window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scanCode int, action glfw.Action, mods glfw.ModifierKey) {
+		if i.previousKey != nil {
+			i.previousKey(w, key, scanCode, action, mods)
+		}
+		i.HandleKey(key, action, mods)
+	})
+}
+
+// Detach restores the callbacks the window had before Attach
+func (i *TextInput) Detach() {
+	if i.window == nil {
+		return
+	}
+	i.window.SetCharCallback(i.previousChar)
+	i.window.SetKeyCallback(i.previousKey)
+	i.window = nil
+	i.previousChar = nil
+	i.previousKey = nil
+}
+
+// Focus makes the input receive the keyboard
+func (i *TextInput) Focus() {
+	i.focused = true
+	i.blinkTime = 0
+}
+
+// Blur stops receiving the keyboard
+func (i *TextInput) Blur() {
+	i.focused = false
+}
+
+// Focused returns true if the input receives the keyboard
+func (i *TextInput) Focused() bool {
+	return i.focused
+}
+
+// SetValue replaces the text, the filter and the max length apply
+func (i *TextInput) SetValue(value string) {
+	i.edit.setValue(value)
+	i.refresh()
+}
+
+// Value returns the current text
+func (i *TextInput) Value() string {
+	return i.edit.value
+}
+
+// SetPlaceholder sets the text shown, dimmed, while the input is empty
+func (i *TextInput) SetPlaceholder(placeholder string) {
+	i.placeholder = placeholder
+	i.refresh()
+}
+
+// SetMaxLength limits the number of characters (grapheme clusters), 0 is
+// unlimited. The current value is not truncated
+func (i *TextInput) SetMaxLength(maxLength int) {
+	i.edit.maxLength = maxLength
+}
+
+// SetFilter restricts the characters that can be typed or pasted, nil
+// accepts everything. See FilterDigits, FilterNumeric and FilterAlphanumeric
+func (i *TextInput) SetFilter(filter InputFilter) {
+	i.edit.filter = filter
+}
+
+// SetOnChange sets the function called after every edit
+func (i *TextInput) SetOnChange(onChange func(value string)) {
+	i.onChange = onChange
+}
+
+// SetOnSubmit sets the function called when enter is pressed
+func (i *TextInput) SetOnSubmit(onSubmit func(value string)) {
+	i.onSubmit = onSubmit
+}
+
+// Caret returns the byte offset of the caret in the value
+func (i *TextInput) Caret() int {
+	return i.edit.caret
+}
+
+// SetCaret moves the caret to a byte offset, removing the selection
+func (i *TextInput) SetCaret(index int) {
+	i.edit.moveTo(i.snap(index), false)
+	i.refresh()
+}
+
+// Selection returns the byte range of the selected text, empty if there is
+// no selection
+func (i *TextInput) Selection() (int, int) {
+	return i.edit.selection()
+}
+
+// SetSelection selects the text between two byte offsets, the caret is
+// placed at end
+func (i *TextInput) SetSelection(start, end int) {
+	i.edit.moveTo(i.snap(start), false)
+	i.edit.moveTo(i.snap(end), true)
+	i.refresh()
+}
+
+// SelectAll selects the whole text
+func (i *TextInput) SelectAll() {
+	i.edit.selectAll()
+	i.refresh()
+}
+
+// snap moves index to the start of the grapheme cluster containing it
+func (i *TextInput) snap(index int) int {
+	if index <= 0 {
+		return 0
+	}
+	if index >= len(i.edit.value) {
+		return len(i.edit.value)
+	}
+	return PrevGrapheme(i.edit.value, NextGrapheme(i.edit.value, index))
+}
+
+// ClickAt places the caret at the character closest to point, in pixels
+// relative to the input position. With extend the selection is extended
+// instead
+func (i *TextInput) ClickAt(point mgl32.Vec2, extend bool) {
+	if i.edit.value == "" {
+		return
+	}
+	index := i.text.IndexAtPosition(mgl32.Vec2{point[0] + i.scroll, point[1]})
+	i.edit.moveTo(index, extend)
+	i.blinkTime = 0
+	i.refresh()
+}
+
+// HandleChar inserts a typed character, called by the glfw char callback
+func (i *TextInput) HandleChar(char rune) {
+	if !i.focused {
+		return
+	}
+	i.changed(i.edit.insert(string(char)))
+}
+
+// HandleKey processes editing and navigation keys, called by the glfw key
+// callback. Repeat events are handled as presses
+func (i *TextInput) HandleKey(key glfw.Key, action glfw.Action, mods glfw.ModifierKey) {
+	if !i.focused || action == glfw.Release {
+		return
+	}
+	extend := mods&glfw.ModShift != 0
+	// Words are reached with control, or alt on macOS
+	word := mods&(glfw.ModControl|glfw.ModAlt) != 0
+	// Shortcuts use control, or command on macOS
+	shortcut := mods&(glfw.ModControl|glfw.ModSuper) != 0
+
+	switch key {
+	case glfw.KeyLeft:
+		if mods&glfw.ModSuper != 0 {
+			i.edit.moveTo(0, extend)
+		} else {
+			i.edit.left(word, extend)
+		}
+	case glfw.KeyRight:
+		if mods&glfw.ModSuper != 0 {
+			i.edit.moveTo(len(i.edit.value), extend)
+		} else {
+			i.edit.right(word, extend)
+		}
+	case glfw.KeyHome, glfw.KeyUp:
+		i.edit.moveTo(0, extend)
+	case glfw.KeyEnd, glfw.KeyDown:
+		i.edit.moveTo(len(i.edit.value), extend)
+	case glfw.KeyBackspace:
+		i.changed(i.edit.deleteBackward(word))
+	case glfw.KeyDelete:
+		i.changed(i.edit.deleteForward(word))
+	case glfw.KeyEnter, glfw.KeyKPEnter:
+		if i.onSubmit != nil && action == glfw.Press {
+			i.onSubmit(i.edit.value)
+		}
+	case glfw.KeyEscape:
+		i.Blur()
+	case glfw.KeyA:
+		if shortcut {
+			i.edit.selectAll()
+		}
+	case glfw.KeyC, glfw.KeyX:
+		if shortcut && i.edit.hasSelection() && i.window != nil {
+			i.window.SetClipboardString(i.edit.selectedText())
+			if key == glfw.KeyX {
+				i.changed(i.edit.insert(""))
+			}
+		}
+	case glfw.KeyV:
+		if shortcut && i.window != nil {
+			if clipboard, err := i.window.GetClipboardString(); err == nil {
+				i.changed(i.edit.insert(clipboard))
+			}
+		}
+	default:
+		return
+	}
+	i.blinkTime = 0
+	i.refresh()
+}
+
+// changed refreshes the text and notifies the change
+func (i *TextInput) changed(changed bool) {
+	if !changed {
+		return
+	}
+	i.blinkTime = 0
+	i.refresh()
+	if i.onChange != nil {
+		i.onChange(i.edit.value)
+	}
+}
+
+// refresh updates the text, the scroll, the caret and the selection
+func (i *TextInput) refresh() {
+	if i.edit.value == "" && i.placeholder != "" {
+		i.text.SetText(i.placeholder)
+		i.text.SetColor(graphics.Color{i.color[0], i.color[1], i.color[2], i.color[3] * 0.5})
+	} else {
+		i.text.SetText(i.edit.value)
+		i.text.SetColor(i.color)
+	}
+
+	caretX := float32(0)
+	if i.edit.value != "" {
+		caretX = i.text.CursorPosition(i.edit.caret).X()
+	}
+	if caretX-i.scroll > i.width-i.caretWidth {
+		i.scroll = caretX - i.width + i.caretWidth
+	} else if caretX < i.scroll {
+		i.scroll = caretX
+	}
+	if i.edit.value == "" {
+		i.scroll = 0
+	}
+	i.text.SetPosition(i.position.Sub(mgl32.Vec3{i.scroll, 0, 0}))
+
+	i.caret.SetPosition(mgl32.Vec3{i.position[0] + caretX - i.scroll, i.position[1], i.position[2] - 0.01})
+	if i.edit.hasSelection() {
+		start, end := i.edit.selection()
+		x0 := i.text.CursorPosition(start).X() - i.scroll
+		x1 := i.text.CursorPosition(end).X() - i.scroll
+		i.selection.SetPosition(mgl32.Vec3{i.position[0] + x0, i.position[1], i.position[2] + 0.01})
+		i.selection.SetSize(mgl32.Vec2{x1 - x0, i.size.Y()})
+	}
+}
+
+// SetPosition moves the input, position is its top left corner
+func (i *TextInput) SetPosition(position mgl32.Vec3) {
+	i.position = position
+	i.refresh()
+}
+
+// SetWidth changes the visible width of the field in pixels
+func (i *TextInput) SetWidth(width float32) {
+	i.width = width
+	i.refresh()
+}
+
+// Text returns the Text drawing the value, to change its style
+func (i *TextInput) Text() *Text {
+	return i.text
+}
+
+// Update blinks the caret
+func (i *TextInput) Update(deltaTime float64) {
+	i.blinkTime += deltaTime
+	i.text.Update(deltaTime)
+}
+
+// EnqueueForDrawing enqueues the text, the selection and the blinking caret.
+// Glyphs scrolled outside the field are not clipped
+func (i *TextInput) EnqueueForDrawing(context *graphics.Context) {
+	if i.focused && i.edit.hasSelection() {
+		context.EnqueueForDrawing(i.selection)
+	}
+	i.text.EnqueueForDrawing(context)
+	if i.focused && int(i.blinkTime/caretBlinkPeriod)%2 == 0 {
+		context.EnqueueForDrawing(i.caret)
+	}
+}
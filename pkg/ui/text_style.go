@@ -0,0 +1,219 @@
+package ui
+
+import (
+	"github.com/markov/gojira2d/pkg/graphics"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// GradientMode defines the direction of the fill gradient of a text
+type GradientMode int
+
+const (
+	GRADIENT_NONE GradientMode = iota
+	// From the top to the bottom of the whole text
+	GRADIENT_VERTICAL
+	// From the left to the right of the whole text
+	GRADIENT_HORIZONTAL
+	// From the top to the bottom of every line
+	GRADIENT_LINE
+)
+
+// TextStyle are the effects applied to the distance field glyphs, all drawn
+// in a single pass. Weight and widths are distance field values: 0.5 covers
+// the whole padding of the font around the glyph, effects larger than that
+// are clipped
+type TextStyle struct {
+	// Moves the edge of the glyphs, positive values make the text bolder
+	Weight float32
+
+	OutlineWidth float32
+	OutlineColor graphics.Color
+
+	// Offset in pixels of the shadow, blurred by the softness
+	ShadowOffset   mgl32.Vec2
+	ShadowSoftness float32
+	ShadowColor    graphics.Color
+
+	// Outer glow fading out from the edge (or the outline)
+	GlowWidth float32
+	GlowColor graphics.Color
+
+	// The fill goes from the text color to the gradient color
+	Gradient      GradientMode
+	GradientColor graphics.Color
+}
+
+// SetStyle sets outline, shadow, glow, weight and gradient of the text
+func (t *Text) SetStyle(style TextStyle) {
+	t.style = style
+}
+
+// Style returns the current text style
+func (t *Text) Style() TextStyle {
+	return t.style
+}
+
+// gradientUniforms returns the start and end points of the fill gradient, in
+// the same units of the vertices, and the vertical period for GRADIENT_LINE
+func (t *Text) gradientUniforms() (mgl32.Vec4, float32) {
+	if t.style.Gradient == GRADIENT_NONE || t.layout == nil || len(t.layout.Lines) == 0 {
+		return mgl32.Vec4{}, 0
+	}
+	topLeft, size := t.layout.Bounds()
+	topLeft = topLeft.Add(t.anchorOffset()).Add(mgl32.Vec2{t.paddings[2], t.paddings[0]})
+	switch t.style.Gradient {
+	case GRADIENT_VERTICAL:
+		return mgl32.Vec4{topLeft[0], topLeft[1], topLeft[0], topLeft[1] + size[1]}, 0
+	case GRADIENT_HORIZONTAL:
+		return mgl32.Vec4{topLeft[0], topLeft[1], topLeft[0] + size[0], topLeft[1]}, 0
+	case GRADIENT_LINE:
+		lineSpacing := 1 + t.paddings[1]
+		return mgl32.Vec4{topLeft[0], topLeft[1], topLeft[0], topLeft[1] + 1}, lineSpacing
+	}
+	return mgl32.Vec4{}, 0
+}
+
+// setStyleUniforms uploads the style uniforms shared by all the batches
+func (t *Text) setStyleUniforms(shaderProgram *graphics.ShaderProgram) {
+	style := &t.style
+	gradient, gradientRepeat := t.gradientUniforms()
+	shaderProgram.SetUniform("weight", &style.Weight)
+	shaderProgram.SetUniform("outlineWidth", &style.OutlineWidth)
+	shaderProgram.SetUniform("outlineColor", &style.OutlineColor)
+	shaderProgram.SetUniform("shadowSoftness", &style.ShadowSoftness)
+	shaderProgram.SetUniform("shadowColor", &style.ShadowColor)
+	shaderProgram.SetUniform("glowWidth", &style.GlowWidth)
+	shaderProgram.SetUniform("glowColor", &style.GlowColor)
+	shaderProgram.SetUniform("gradient", &gradient)
+	shaderProgram.SetUniform("gradientRepeat", &gradientRepeat)
+	shaderProgram.SetUniform("gradientColor", &style.GradientColor)
+}
+
+// shadowUVOffset converts the shadow offset from pixels to texture
+// coordinates, uvScale is the texture size of a line height
+func (t *Text) shadowUVOffset(uvScale mgl32.Vec2) mgl32.Vec2 {
+	return mgl32.Vec2{
+		t.style.ShadowOffset[0] / t.size[0] * uvScale[0],
+		t.style.ShadowOffset[1] / t.size[1] * uvScale[1],
+	}
+}
+
+var (
+	vertexShaderText = `
+        #version 410 core
+
+        uniform mat4 mModel;
+        uniform mat4 mProjection;
+        uniform float time;
+
+        layout(location=0) in vec2 vertex;
+        layout(location=1) in vec2 uv;
+        // Per glyph color and effects: wave, shake, phase, image flag
+        layout(location=2) in vec4 color;
+        layout(location=3) in vec4 effect;
+
+        out vec2 uv_out;
+        out vec2 position_out;
+        out vec4 color_out;
+        out float image_out;
+
+        float hash(float n) {
+            return fract(sin(n) * 43758.5453) * 2.0 - 1.0;
+        }
+
+        void main() {
+            vec2 p = vertex;
+            p.y += effect.x * sin(time * 6.0 + effect.z * 0.6);
+            float tick = floor(time * 20.0);
+            p += effect.y * vec2(hash(effect.z * 13.1 + tick), hash(effect.z * 7.7 + tick + 0.5));
+            vec4 vertex_world = mModel * vec4(p, 0, 1);
+            gl_Position = mProjection * vertex_world;
+            uv_out = uv;
+            position_out = vertex;
+            color_out = color;
+            image_out = effect.w;
+        }
+        ` + "\x00"
+
+	fragmentDistanceFieldFont = `
+        #version 410 core
+
+        in vec2 uv_out;
+        in vec2 position_out;
+        in vec4 color_out;
+        in float image_out;
+        out vec4 color;
+
+        uniform sampler2D tex;
+        uniform vec4 textColor;
+        uniform vec4 channelMask;
+
+        uniform float weight;
+        uniform float outlineWidth;
+        uniform vec4 outlineColor;
+        uniform vec2 shadowOffset;
+        uniform float shadowSoftness;
+        uniform vec4 shadowColor;
+        uniform float glowWidth;
+        uniform vec4 glowColor;
+        uniform vec4 gradient;
+        uniform float gradientRepeat;
+        uniform vec4 gradientColor;
+
+        // Layers are premultiplied
+        vec4 over(vec4 top, vec4 bottom) {
+          return top + bottom * (1.0 - top.a);
+        }
+
+        vec4 layer(vec4 c, float alpha) {
+          return vec4(c.rgb, 1.0) * c.a * alpha;
+        }
+
+        void main() {
+          // Inline icons are plain images
+          if (image_out > 0.5) {
+            color = texture(tex, uv_out) * color_out;
+            color.a *= textColor.a;
+            if (color.a <= 0.0) {
+              discard;
+            }
+            return;
+          }
+
+          float dist = dot(texture(tex, uv_out), channelMask);
+          float width = fwidth(dist);
+          float edge = 0.5 - weight;
+          float outerEdge = edge - outlineWidth;
+
+          vec4 fill = textColor;
+          vec2 axis = gradient.zw - gradient.xy;
+          if (dot(axis, axis) > 0.0) {
+            vec2 p = position_out - gradient.xy;
+            if (gradientRepeat > 0.0) {
+              p.y = mod(p.y, gradientRepeat);
+            }
+            fill = mix(textColor, gradientColor, clamp(dot(p, axis) / dot(axis, axis), 0.0, 1.0));
+          }
+          fill *= color_out;
+
+          vec4 result = layer(fill, smoothstep(edge - width, edge + width, dist));
+          if (outlineWidth > 0.0) {
+            result = over(result, layer(outlineColor, smoothstep(outerEdge - width, outerEdge + width, dist)));
+          }
+          if (glowWidth > 0.0) {
+            result = over(result, layer(glowColor, smoothstep(outerEdge - glowWidth, outerEdge, dist)));
+          }
+          if (shadowColor.a > 0.0) {
+            float shadowDist = dot(texture(tex, uv_out - shadowOffset), channelMask);
+            float softness = shadowSoftness + width;
+            result = over(result, layer(shadowColor, smoothstep(outerEdge - softness, outerEdge + softness, shadowDist)));
+          }
+
+          if (result.a <= 0.0) {
+            discard;
+          }
+          color = vec4(result.rgb / result.a, result.a);
+        }
+        ` + "\x00"
+)
@@ -0,0 +1,43 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestTextGradientUniforms(t *testing.T) {
+	text := &Text{
+		text:     "ab\ncd",
+		font:     newTestMonoFont(),
+		size:     mgl32.Vec2{90, 90},
+		paddings: mgl32.Vec4{0.5, 0.25, 0.1, 0},
+		anchor:   mgl32.Vec2{0.5, 0},
+	}
+	text.makeNewQuads()
+	// Anchored at the center, two characters wide
+	left := -monoAdvance + 0.1
+
+	var tests = []struct {
+		mode     GradientMode
+		gradient mgl32.Vec4
+		repeat   float32
+	}{
+		{GRADIENT_NONE, mgl32.Vec4{}, 0},
+		{GRADIENT_VERTICAL, mgl32.Vec4{left, 0.5, left, 2.75}, 0},
+		{GRADIENT_HORIZONTAL, mgl32.Vec4{left, 0.5, left + 2*monoAdvance, 0.5}, 0},
+		{GRADIENT_LINE, mgl32.Vec4{left, 0.5, left, 1.5}, 1.25},
+	}
+	for _, test := range tests {
+		text.SetStyle(TextStyle{Gradient: test.mode})
+		gradient, repeat := text.gradientUniforms()
+		if !vecNear(gradient[:], test.gradient[:]) || repeat != test.repeat {
+			t.Errorf("Mode %v: got %v %v, expecting %v %v", test.mode, gradient, repeat, test.gradient, test.repeat)
+		}
+	}
+
+	text.SetStyle(TextStyle{ShadowOffset: mgl32.Vec2{9, -18}})
+	if offset := text.shadowUVOffset(mgl32.Vec2{0.5, 0.25}); !vecNear(offset[:], []float32{0.05, -0.05}) {
+		t.Errorf("Got shadow offset %v", offset)
+	}
+}
@@ -0,0 +1,398 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/markov/gojira2d/pkg/graphics"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultStyleName is the style used for the widgets whose style is missing
+// from a theme
+const DefaultStyleName = "default"
+
+// Theme is a set of named widget styles. Widgets pick the style named after
+// their type: "panel", "label", "button", "checkbox", "slider",
+// "progress_bar", "dropdown", "scroll_panel" and "window", or the one set
+// with SetStyleName. Apply it with GUI.SetTheme
+type Theme struct {
+	styles map[string]*WidgetStyle
+}
+
+// NewTheme creates an empty theme
+func NewTheme() *Theme {
+	return &Theme{styles: make(map[string]*WidgetStyle)}
+}
+
+// NewThemeFromFile loads a theme in JSON (.json) or YAML (.yaml, .yml)
+// format. Fonts and images are loaded relative to the file:
+//
+//	fonts:
+//	  mono: {file: fonts/roboto-mono.fnt, texture: fonts/roboto-mono.png}
+//	  title: {file: fonts/title.ttf, size: 64}
+//	images:
+//	  frame: {file: ui/frame.png, region: [0, 0, 48, 48], insets: [16, 16, 16, 16]}
+//	styles:
+//	  default:
+//	    font: mono
+//	    font_size: 24
+//	    text: "#eeeeee"
+//	    background: {normal: "#333340e6", hover: "#4d4d61e6", disabled: "#33333399"}
+//	    accent: "#1e90ff"
+//	    padding: [6, 10]
+//	    transition: 0.1
+//	  button:
+//	    extends: default
+//	    background_image: frame
+//
+// Styles extending another one change only the properties they set.
+// Colors are the ones of ParseColor, given for all the states at once or
+// per state, missing states use the normal color. Paddings are 1 value for
+// all the sides, 2 for vertical and horizontal or 4 for top, bottom, left
+// and right. Insets are top, bottom, left, right too
+func NewThemeFromFile(filePath string) *Theme {
+	file, err := parseThemeFile(filePath)
+	if err != nil {
+		log.Panicf("Loading theme. %s", err)
+	}
+	fonts, images := file.load(filepath.Dir(filePath))
+	t, err := file.theme(fonts, images)
+	if err != nil {
+		log.Panicf("Error parsing theme %s: %v", filePath, err)
+	}
+	return t
+}
+
+// SetStyle adds or replaces a style. Widgets already styled by the theme
+// are restyled by GUI.SetTheme
+func (t *Theme) SetStyle(name string, style *WidgetStyle) {
+	t.styles[name] = style
+}
+
+// Style returns a style by name, the default one if missing, nil if the
+// theme has neither
+func (t *Theme) Style(name string) *WidgetStyle {
+	if style, ok := t.styles[name]; ok {
+		return style
+	}
+	return t.styles[DefaultStyleName]
+}
+
+// Names returns the names of the styles, sorted
+func (t *Theme) Names() []string {
+	names := make([]string, 0, len(t.styles))
+	for name := range t.styles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// themeColors are the StateColors of a theme file, a single color or one
+// per state
+type themeColors struct {
+	Normal   string `json:"normal" yaml:"normal"`
+	Hover    string `json:"hover" yaml:"hover"`
+	Pressed  string `json:"pressed" yaml:"pressed"`
+	Disabled string `json:"disabled" yaml:"disabled"`
+}
+
+func (c *themeColors) UnmarshalJSON(data []byte) error {
+	var color string
+	if err := json.Unmarshal(data, &color); err == nil {
+		*c = themeColors{Normal: color}
+		return nil
+	}
+	type colors themeColors
+	return json.Unmarshal(data, (*colors)(c))
+}
+
+func (c *themeColors) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var color string
+	if err := unmarshal(&color); err == nil {
+		*c = themeColors{Normal: color}
+		return nil
+	}
+	type colors themeColors
+	return unmarshal((*colors)(c))
+}
+
+// stateColors parses the colors, missing states use the normal color
+func (c *themeColors) stateColors() (StateColors, error) {
+	if c.Normal == "" {
+		return StateColors{}, fmt.Errorf("missing normal color")
+	}
+	var colors StateColors
+	for _, state := range []struct {
+		value string
+		color *graphics.Color
+	}{
+		{c.Normal, &colors.Normal},
+		{c.Hover, &colors.Hover},
+		{c.Pressed, &colors.Pressed},
+		{c.Disabled, &colors.Disabled},
+	} {
+		value := state.value
+		if value == "" {
+			value = c.Normal
+		}
+		color, ok := ParseColor(value)
+		if !ok {
+			return StateColors{}, fmt.Errorf("invalid color %q", value)
+		}
+		*state.color = color
+	}
+	return colors, nil
+}
+
+type themeFont struct {
+	// BMFont metadata, or a .ttf or .otf font
+	File string `json:"file" yaml:"file"`
+	// First page of a BMFont, the ones named in the metadata if empty
+	Texture string `json:"texture" yaml:"texture"`
+	// Pixels per em of the glyphs of a TrueType font
+	Size int `json:"size" yaml:"size"`
+}
+
+type themeImage struct {
+	File        string    `json:"file" yaml:"file"`
+	Region      []float32 `json:"region" yaml:"region"`
+	Insets      []float32 `json:"insets" yaml:"insets"`
+	BorderScale float32   `json:"border_scale" yaml:"border_scale"`
+	Tile        bool      `json:"tile" yaml:"tile"`
+}
+
+// themeStyle is a style of a theme file, nil properties are inherited
+type themeStyle struct {
+	Extends         string       `json:"extends" yaml:"extends"`
+	Font            *string      `json:"font" yaml:"font"`
+	FontSize        *float32     `json:"font_size" yaml:"font_size"`
+	Text            *themeColors `json:"text" yaml:"text"`
+	Background      *themeColors `json:"background" yaml:"background"`
+	Accent          *themeColors `json:"accent" yaml:"accent"`
+	Padding         []float32    `json:"padding" yaml:"padding"`
+	BackgroundImage *string      `json:"background_image" yaml:"background_image"`
+	Transition      *float64     `json:"transition" yaml:"transition"`
+}
+
+type themeFile struct {
+	Fonts  map[string]themeFont  `json:"fonts" yaml:"fonts"`
+	Images map[string]themeImage `json:"images" yaml:"images"`
+	Styles map[string]themeStyle `json:"styles" yaml:"styles"`
+}
+
+func parseThemeFile(filePath string) (*themeFile, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".yaml", ".yml":
+		return parseTheme(file, true)
+	default:
+		return parseTheme(file, false)
+	}
+}
+
+func parseTheme(reader io.Reader, isYAML bool) (*themeFile, error) {
+	var f themeFile
+	if isYAML {
+		data, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(data, &f); err != nil {
+			return nil, err
+		}
+	} else if err := json.NewDecoder(reader).Decode(&f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// load loads the fonts and the textures of the images, paths are relative
+// to dir
+func (f *themeFile) load(dir string) (map[string]*Font, map[string]*NineSliceImage) {
+	path := func(file string) string {
+		if filepath.IsAbs(file) {
+			return file
+		}
+		return filepath.Join(dir, file)
+	}
+	// Registered by path and size or texture, the same font is loaded once
+	loaded := make(map[string]*Font)
+	fonts := make(map[string]*Font)
+	for name, font := range f.Fonts {
+		file := path(font.File)
+		key := file
+		if font.Texture != "" {
+			key += "|" + path(font.Texture)
+		} else if font.Size > 0 {
+			key += fmt.Sprintf("|%d", font.Size)
+		}
+		if loadedFont, ok := loaded[key]; ok {
+			fonts[name] = loadedFont
+			continue
+		}
+		switch ext := strings.ToLower(filepath.Ext(file)); {
+		case ext == ".ttf" || ext == ".otf":
+			options := DefaultTTFOptions()
+			if font.Size > 0 {
+				options.Size = font.Size
+			}
+			fonts[name] = NewFontFromTTFFile(key, file, options)
+		case font.Texture != "":
+			fonts[name] = NewFontFromFiles(key, file, path(font.Texture))
+		default:
+			fonts[name] = NewFontFromFile(key, file)
+		}
+		loaded[key] = fonts[name]
+	}
+	textures := make(map[string]*graphics.Texture)
+	images := make(map[string]*NineSliceImage)
+	for name, image := range f.Images {
+		file := path(image.File)
+		texture, ok := textures[file]
+		if !ok {
+			texture = graphics.NewTextureFromFile(file)
+			textures[file] = texture
+		}
+		images[name] = &NineSliceImage{
+			Texture:     texture,
+			BorderScale: image.BorderScale,
+			Tile:        image.Tile,
+		}
+		if len(image.Region) == 4 {
+			images[name].Region = mgl32.Vec4{image.Region[0], image.Region[1], image.Region[2], image.Region[3]}
+		}
+		if len(image.Insets) == 4 {
+			images[name].Insets = graphics.Insets{
+				Top: image.Insets[0], Bottom: image.Insets[1], Left: image.Insets[2], Right: image.Insets[3],
+			}
+		}
+	}
+	return fonts, images
+}
+
+// theme resolves the styles with the loaded fonts and images
+func (f *themeFile) theme(fonts map[string]*Font, images map[string]*NineSliceImage) (*Theme, error) {
+	for name, image := range f.Images {
+		if image.Region != nil && len(image.Region) != 4 {
+			return nil, fmt.Errorf("image %s: region needs 4 values", name)
+		}
+		if image.Insets != nil && len(image.Insets) != 4 {
+			return nil, fmt.Errorf("image %s: insets need 4 values", name)
+		}
+	}
+	t := NewTheme()
+	for name := range f.Styles {
+		if _, err := f.resolve(name, t, fonts, images, map[string]bool{}); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// resolve builds a style after the one it extends
+func (f *themeFile) resolve(
+	name string,
+	t *Theme,
+	fonts map[string]*Font,
+	images map[string]*NineSliceImage,
+	visiting map[string]bool,
+) (*WidgetStyle, error) {
+	if style, ok := t.styles[name]; ok {
+		return style, nil
+	}
+	s, ok := f.Styles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown style %s", name)
+	}
+	if visiting[name] {
+		return nil, fmt.Errorf("style %s extends itself", name)
+	}
+	visiting[name] = true
+
+	style := &WidgetStyle{}
+	if s.Extends != "" {
+		parent, err := f.resolve(s.Extends, t, fonts, images, visiting)
+		if err != nil {
+			return nil, err
+		}
+		*style = *parent
+	}
+	if s.Font != nil {
+		font, ok := fonts[*s.Font]
+		if !ok {
+			return nil, fmt.Errorf("style %s: unknown font %s", name, *s.Font)
+		}
+		style.Font = font
+	}
+	if s.FontSize != nil {
+		style.FontSize = *s.FontSize
+	}
+	for _, colors := range []struct {
+		value *themeColors
+		style *StateColors
+	}{
+		{s.Text, &style.Text},
+		{s.Background, &style.Background},
+		{s.Accent, &style.Accent},
+	} {
+		if colors.value == nil {
+			continue
+		}
+		stateColors, err := colors.value.stateColors()
+		if err != nil {
+			return nil, fmt.Errorf("style %s: %v", name, err)
+		}
+		*colors.style = stateColors
+	}
+	if s.Padding != nil {
+		padding, err := parsePadding(s.Padding)
+		if err != nil {
+			return nil, fmt.Errorf("style %s: %v", name, err)
+		}
+		style.Padding = padding
+	}
+	if s.BackgroundImage != nil {
+		style.BackgroundImage = nil
+		if *s.BackgroundImage != "" {
+			image, ok := images[*s.BackgroundImage]
+			if !ok {
+				return nil, fmt.Errorf("style %s: unknown image %s", name, *s.BackgroundImage)
+			}
+			style.BackgroundImage = image
+		}
+	}
+	if s.Transition != nil {
+		style.Transition = *s.Transition
+	}
+	t.styles[name] = style
+	return style, nil
+}
+
+// parsePadding expands 1, 2 or 4 values to top, bottom, left, right
+func parsePadding(values []float32) (mgl32.Vec4, error) {
+	switch len(values) {
+	case 1:
+		return mgl32.Vec4{values[0], values[0], values[0], values[0]}, nil
+	case 2:
+		return mgl32.Vec4{values[0], values[0], values[1], values[1]}, nil
+	case 4:
+		return mgl32.Vec4{values[0], values[1], values[2], values[3]}, nil
+	}
+	return mgl32.Vec4{}, fmt.Errorf("padding needs 1, 2 or 4 values")
+}
@@ -0,0 +1,246 @@
+package ui
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/markov/gojira2d/pkg/graphics"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+const testThemeYAML = `
+fonts:
+  mono: {file: mono.fnt}
+images:
+  frame: {file: frame.png, region: [0, 0, 48, 48], insets: [16, 16, 8, 8]}
+styles:
+  default:
+    font: mono
+    font_size: 24
+    text: "#fff"
+    background: {normal: "#000", hover: "#f00"}
+    accent: blue
+    padding: [6, 10]
+  button:
+    extends: default
+    background_image: frame
+    transition: 0.25
+  big_button:
+    extends: button
+    font_size: 48
+    padding: [1, 2, 3, 4]
+`
+
+const testThemeJSON = `{
+  "styles": {
+    "default": {"font_size": 20, "text": "white", "padding": [5]},
+    "label": {"extends": "default", "text": {"normal": "#808080", "disabled": "#000"}}
+  }
+}`
+
+// loadTestTheme resolves a theme without loading its resources
+func loadTestTheme(t *testing.T, data string, isYAML bool, font *Font) *Theme {
+	t.Helper()
+	file, err := parseTheme(strings.NewReader(data), isYAML)
+	if err != nil {
+		t.Fatalf("Parsing: %v", err)
+	}
+	images := map[string]*NineSliceImage{"frame": {Insets: graphics.Insets{Top: 16}}}
+	theme, err := file.theme(map[string]*Font{"mono": font}, images)
+	if err != nil {
+		t.Fatalf("Resolving: %v", err)
+	}
+	return theme
+}
+
+func TestThemeYAML(t *testing.T) {
+	font := &Font{}
+	theme := loadTestTheme(t, testThemeYAML, true, font)
+	if names := strings.Join(theme.Names(), ","); names != "big_button,button,default" {
+		t.Errorf("Wrong names %s", names)
+	}
+
+	def := theme.Style("default")
+	if def.Font != font || def.FontSize != 24 || def.Padding != (mgl32.Vec4{6, 6, 10, 10}) {
+		t.Errorf("Wrong default style %+v", def)
+	}
+	// Missing states use the normal color
+	expected := StateColors{
+		Normal:   graphics.Color{0, 0, 0, 1},
+		Hover:    graphics.Color{1, 0, 0, 1},
+		Pressed:  graphics.Color{0, 0, 0, 1},
+		Disabled: graphics.Color{0, 0, 0, 1},
+	}
+	if def.Background != expected || def.Accent != SolidStateColors(graphics.Color{0, 0, 1, 1}) {
+		t.Errorf("Wrong colors %+v %+v", def.Background, def.Accent)
+	}
+
+	button := theme.Style("button")
+	if button.BackgroundImage == nil || button.BackgroundImage.Insets.Top != 16 || button.Transition != 0.25 {
+		t.Errorf("Wrong button style %+v", button)
+	}
+	if button.Font != font || button.Background != def.Background {
+		t.Errorf("Button didn't inherit the default style")
+	}
+	big := theme.Style("big_button")
+	if big.FontSize != 48 || big.Padding != (mgl32.Vec4{1, 2, 3, 4}) || big.Transition != 0.25 {
+		t.Errorf("Wrong big button style %+v", big)
+	}
+	if def.BackgroundImage != nil || def.Transition != 0 {
+		t.Errorf("Extending changed the parent style")
+	}
+
+	// Missing styles fall back to the default one
+	if theme.Style("slider") != def {
+		t.Errorf("No fallback to the default style")
+	}
+}
+
+func TestThemeJSON(t *testing.T) {
+	theme := loadTestTheme(t, testThemeJSON, false, nil)
+	label := theme.Style("label")
+	if label.FontSize != 20 || label.Padding != (mgl32.Vec4{5, 5, 5, 5}) {
+		t.Errorf("Wrong label style %+v", label)
+	}
+	gray := graphics.Color{128.0 / 255, 128.0 / 255, 128.0 / 255, 1}
+	if label.Text.Hover != gray || label.Text.Disabled != (graphics.Color{0, 0, 0, 1}) {
+		t.Errorf("Wrong label colors %+v", label.Text)
+	}
+}
+
+func TestThemeErrors(t *testing.T) {
+	var tests = []struct {
+		data  string
+		error string
+	}{
+		{`{"styles": {"a": {"extends": "b"}, "b": {"extends": "a"}}}`, "extends itself"},
+		{`{"styles": {"a": {"extends": "missing"}}}`, "unknown style"},
+		{`{"styles": {"a": {"font": "missing"}}}`, "unknown font"},
+		{`{"styles": {"a": {"background_image": "missing"}}}`, "unknown image"},
+		{`{"styles": {"a": {"text": "nope"}}}`, "invalid color"},
+		{`{"styles": {"a": {"text": {"hover": "red"}}}}`, "missing normal color"},
+		{`{"styles": {"a": {"padding": [1, 2, 3]}}}`, "padding"},
+		{`{"images": {"a": {"insets": [1, 2]}}}`, "insets"},
+	}
+	for _, test := range tests {
+		file, err := parseTheme(strings.NewReader(test.data), false)
+		if err != nil {
+			t.Fatalf("Parsing %s: %v", test.data, err)
+		}
+		_, err = file.theme(nil, nil)
+		if err == nil || !strings.Contains(err.Error(), test.error) {
+			t.Errorf("%s: expected %q error, got %v", test.data, test.error, err)
+		}
+	}
+}
+
+func TestThemeFontSizes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "theme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.ttf"), goregular.TTF, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	data := `{"fonts": {
+	  "small": {"file": "go.ttf", "size": 12},
+	  "large": {"file": "go.ttf", "size": 24},
+	  "body": {"file": "go.ttf", "size": 12}
+	}}`
+	file, err := parseTheme(strings.NewReader(data), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fonts, _ := file.load(dir)
+	for key := range FontRegistry {
+		if strings.HasPrefix(key, dir) {
+			delete(FontRegistry, key)
+		}
+	}
+	small, large := fonts["small"], fonts["large"]
+	if small == large || small.bm.size != 12 || large.bm.size != 24 {
+		t.Errorf("Loaded sizes %d and %d from one file", small.bm.size, large.bm.size)
+	}
+	if fonts["body"] != small {
+		t.Errorf("Loaded the same font twice")
+	}
+}
+
+func TestColorTransition(t *testing.T) {
+	var transition colorTransition
+	black, white := graphics.Color{0, 0, 0, 1}, graphics.Color{1, 1, 1, 1}
+	transition.set(black, 1, 0)
+	if transition.value(0) != black {
+		t.Errorf("The first color should be immediate")
+	}
+	transition.set(white, 1, 10)
+	if c := transition.value(10.25); !vecNear(c[:], []float32{0.25, 0.25, 0.25, 1}) {
+		t.Errorf("Wrong blend %v", c)
+	}
+	// Changing midway starts from the current blend
+	transition.set(black, 1, 10.5)
+	if c := transition.value(10.75); !vecNear(c[:], []float32{0.375, 0.375, 0.375, 1}) {
+		t.Errorf("Wrong blend %v", c)
+	}
+	if transition.value(12) != black {
+		t.Errorf("Transition not finished")
+	}
+	transition.set(white, 0, 13)
+	if transition.value(13) != white {
+		t.Errorf("No duration should be immediate")
+	}
+}
+
+func TestGUITheme(t *testing.T) {
+	small := &WidgetStyle{FontSize: 10}
+	large := &WidgetStyle{FontSize: 20}
+	special := &WidgetStyle{FontSize: 30}
+	theme := NewTheme()
+	theme.SetStyle(DefaultStyleName, small)
+	theme.SetStyle("special", special)
+
+	gui := NewGUI(800, 600)
+	before := newTestWidget(0, 0, 10, 10)
+	before.styleName = "test"
+	part := newTestWidget(0, 0, 10, 10)
+	before.Add(part)
+	gui.Add(before)
+	gui.SetTheme(theme)
+	if before.Style() != small || part.Style() != nil {
+		t.Errorf("Theme not applied to the tree")
+	}
+	if gui.Root().Style() != nil {
+		t.Errorf("Theme applied to the root")
+	}
+
+	// Widgets added later are styled, and their children
+	after := newTestWidget(0, 0, 10, 10)
+	after.styleName = "test"
+	child := newTestWidget(0, 0, 10, 10)
+	child.styleName = "special"
+	after.Add(child)
+	gui.Add(after)
+	if after.Style() != small || child.Style() != special {
+		t.Errorf("Theme not applied to the new widgets")
+	}
+
+	// Switching theme restyles everything
+	other := NewTheme()
+	other.SetStyle(DefaultStyleName, large)
+	gui.SetTheme(other)
+	if before.Style() != large || child.Style() != large {
+		t.Errorf("Theme not switched")
+	}
+	after.SetStyleName("special")
+	gui.SetTheme(theme)
+	if after.Style() != special {
+		t.Errorf("Style name not used")
+	}
+}
@@ -0,0 +1,274 @@
+package ui
+
+// Runtime rasterization of TrueType and OpenType fonts into signed distance
+// field atlas pages, an alternative to the pre-baked BMFont files
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// RuneRange is an inclusive range of characters
+type RuneRange struct {
+	First rune
+	Last  rune
+}
+
+var (
+	RangeASCII    = RuneRange{0x20, 0x7e}
+	RangeLatin1   = RuneRange{0xa0, 0xff}
+	RangeGreek    = RuneRange{0x370, 0x3ff}
+	RangeCyrillic = RuneRange{0x400, 0x4ff}
+)
+
+// TTFOptions controls how a TrueType or OpenType font is rasterized
+type TTFOptions struct {
+	// Size in pixels of the em square
+	Size int
+	// Distance in pixels from the outline encoded in the field, on each side.
+	// Larger values allow wider outlines and glows
+	Spread int
+	// Width and maximum height of the atlas pages. Pages start at a quarter
+	// of the height and grow when needed
+	PageWidth  int
+	PageHeight int
+	// Characters rasterized when loading, the others are added on demand
+	Ranges []RuneRange
+}
+
+// DefaultTTFOptions returns options suited for most UI text
+func DefaultTTFOptions() TTFOptions {
+	return TTFOptions{
+		Size:       48,
+		Spread:     6,
+		PageWidth:  512,
+		PageHeight: 512,
+		Ranges:     []RuneRange{RangeASCII},
+	}
+}
+
+// ttfSource rasterizes the glyphs of a Font on demand
+type ttfSource struct {
+	font     *sfnt.Font
+	buffer   sfnt.Buffer
+	ppem     fixed.Int26_6
+	spread   int
+	atlas    *glyphAtlas
+	indices  map[int32]sfnt.GlyphIndex
+	missing  map[rune]bool
+	revision int
+}
+
+// NewFontFromTTFFile creates a Font from a .ttf or .otf file. Glyphs are
+// rasterized to distance fields, so the font works with Text like the
+// BMFont ones
+func NewFontFromTTFFile(name, path string, options TTFOptions) *Font {
+	if f, ok := FontRegistry[name]; ok {
+		return f
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Panicf("Loading font. %s", err)
+	}
+	f, err := newFontFromTTF(data, options)
+	if err != nil {
+		log.Panicf("Error parsing font %s: %v", path, err)
+	}
+	FontRegistry[name] = f
+	return f
+}
+
+func newFontFromTTF(data []byte, options TTFOptions) (*Font, error) {
+	if options.Size <= 0 || options.PageWidth <= 0 || options.PageHeight <= 0 {
+		return nil, fmt.Errorf("invalid options %+v", options)
+	}
+	parsed, err := sfnt.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &ttfSource{
+		font:    parsed,
+		ppem:    fixed.I(options.Size),
+		spread:  options.Spread,
+		atlas:   newGlyphAtlas(options.PageWidth, options.PageHeight/4, options.PageHeight),
+		indices: make(map[int32]sfnt.GlyphIndex),
+		missing: make(map[rune]bool),
+	}
+	metrics, err := parsed.Metrics(&s.buffer, s.ppem, font.HintingNone)
+	if err != nil {
+		return nil, err
+	}
+	lineHeight := metrics.Height.Ceil()
+	if lineHeight <= 0 {
+		lineHeight = (metrics.Ascent + metrics.Descent).Ceil()
+	}
+	if lineHeight <= 0 {
+		return nil, fmt.Errorf("invalid font metrics %+v", metrics)
+	}
+
+	bm := &BmFont{
+		pageFiles:  make(map[int]string),
+		Characters: make(map[int32]*BmChar),
+		size:       options.Size,
+		unicode:    true,
+		smooth:     true,
+		padding:    [4]int{s.spread, s.spread, s.spread, s.spread},
+		lineHeight: lineHeight,
+		base:       metrics.Ascent.Round(),
+		pageWidth:  options.PageWidth,
+		pageHeight: options.PageHeight,
+	}
+	bm.face, _ = parsed.Name(&s.buffer, sfnt.NameIDFamily)
+	bm.f32scaleLine = 1.0 / float32(bm.lineHeight)
+	bm.f32scaleW = 1.0 / float32(bm.pageWidth)
+	bm.f32scaleH = 1.0 / float32(bm.pageHeight)
+
+	for _, r := range options.Ranges {
+		for c := r.First; c <= r.Last; c++ {
+			s.addGlyph(bm, c)
+		}
+	}
+	return &Font{bm: bm, ttf: s}, nil
+}
+
+// hasRune returns true if the font has an outline for r
+func (s *ttfSource) hasRune(r rune) bool {
+	index, err := s.font.GlyphIndex(&s.buffer, r)
+	return err == nil && index != 0
+}
+
+// addGlyph rasterizes r and adds it to the font characters. It returns nil
+// if the font has no outline for r
+func (s *ttfSource) addGlyph(bm *BmFont, r rune) *BmChar {
+	if c, ok := bm.Characters[r]; ok {
+		return c
+	}
+	if s.missing[r] {
+		return nil
+	}
+	index, err := s.font.GlyphIndex(&s.buffer, r)
+	if err != nil || index == 0 {
+		s.missing[r] = true
+		return nil
+	}
+	segments, err := s.font.LoadGlyph(&s.buffer, index, s.ppem, nil)
+	if err != nil {
+		log.Printf("ERR: loading glyph %v (%U): %v", string(r), r, err)
+		s.missing[r] = true
+		return nil
+	}
+	advance, err := s.font.GlyphAdvance(&s.buffer, index, s.ppem, font.HintingNone)
+	if err != nil {
+		log.Printf("ERR: loading glyph %v (%U): %v", string(r), r, err)
+		s.missing[r] = true
+		return nil
+	}
+
+	c := &BmChar{
+		id:             r,
+		letter:         string(r),
+		advanceX:       advance.Round(),
+		textureChannel: 8,
+		kernings:       make(map[int32]int),
+		f32kernings:    make(map[int32]float32),
+	}
+	outline := ttfOutline(segments)
+	x0, y0, x1, y1 := outline.bounds()
+	if x1 > x0 && y1 > y0 {
+		width := x1 - x0 + 2*s.spread
+		height := y1 - y0 + 2*s.spread
+		page, x, y, grown, ok := s.atlas.insert(width, height)
+		if ok {
+			origin := mgl32.Vec2{float32(x0 - s.spread), float32(y0 - s.spread)}
+			s.atlas.draw(page, x, y, width, height, outline.render(origin, width, height, float32(s.spread)))
+			c.x, c.y, c.width, c.height = x, y, width, height
+			c.pageIndex = page
+			// Outline coordinates are relative to the baseline
+			c.offsetX = x0 - s.spread
+			c.offsetY = bm.base + y0 - s.spread
+			if grown {
+				s.rescalePage(bm, page)
+			}
+		} else {
+			log.Printf("ERR: glyph %v (%U) doesn't fit in the atlas page", string(r), r)
+		}
+	}
+	s.scaleChar(bm, c)
+	s.addKernings(bm, c, index)
+	bm.Characters[r] = c
+	bm.numPages = len(s.atlas.pages)
+	return c
+}
+
+// scaleChar computes the scaled values of a character, the texture
+// coordinates depend on the current height of its page
+func (s *ttfSource) scaleChar(bm *BmFont, c *BmChar) {
+	bm.scaleChar(c)
+	if c.height > 0 {
+		pageHeight := float32(s.atlas.pageHeight(c.pageIndex))
+		c.f32y = float32(c.y) / pageHeight
+		c.f32height = float32(c.height) / pageHeight
+	}
+}
+
+// rescalePage updates the texture coordinates of the characters of a page
+// after it grew. Texts using the font rebuild their quads
+func (s *ttfSource) rescalePage(bm *BmFont, page int) {
+	for _, c := range bm.Characters {
+		if c.pageIndex == page {
+			s.scaleChar(bm, c)
+		}
+	}
+	s.revision++
+}
+
+// addKernings looks up the kerning pairs of a new character with the ones
+// already loaded. Only the kern table is supported, not GPOS
+func (s *ttfSource) addKernings(bm *BmFont, c *BmChar, index sfnt.GlyphIndex) {
+	for id, other := range s.indices {
+		if k, err := s.font.Kern(&s.buffer, index, other, s.ppem, font.HintingNone); err == nil && k != 0 {
+			c.kernings[id] = k.Round()
+			c.f32kernings[id] = float32(k) / 64 * bm.f32scaleLine
+		}
+		if k, err := s.font.Kern(&s.buffer, other, index, s.ppem, font.HintingNone); err == nil && k != 0 {
+			bm.Characters[id].kernings[c.id] = k.Round()
+			bm.Characters[id].f32kernings[c.id] = float32(k) / 64 * bm.f32scaleLine
+		}
+	}
+	if k, err := s.font.Kern(&s.buffer, index, index, s.ppem, font.HintingNone); err == nil && k != 0 {
+		c.kernings[c.id] = k.Round()
+		c.f32kernings[c.id] = float32(k) / 64 * bm.f32scaleLine
+	}
+	s.indices[c.id] = index
+}
+
+// ttfOutline converts the segments of a glyph, in 26.6 fixed point pixels
+// with y pointing down, to a flattened outline
+func ttfOutline(segments sfnt.Segments) *sdfOutline {
+	point := func(p fixed.Point26_6) mgl32.Vec2 {
+		return mgl32.Vec2{float32(p.X) / 64, float32(p.Y) / 64}
+	}
+	outline := &sdfOutline{}
+	for _, segment := range segments {
+		switch segment.Op {
+		case sfnt.SegmentOpMoveTo:
+			outline.moveTo(point(segment.Args[0]))
+		case sfnt.SegmentOpLineTo:
+			outline.lineTo(point(segment.Args[0]))
+		case sfnt.SegmentOpQuadTo:
+			outline.quadTo(point(segment.Args[0]), point(segment.Args[1]))
+		case sfnt.SegmentOpCubeTo:
+			outline.cubeTo(point(segment.Args[0]), point(segment.Args[1]), point(segment.Args[2]))
+		}
+	}
+	outline.close()
+	return outline
+}
@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+const zeroWidthJoiner = '\u200d'
+
+// isMark returns true for combining marks drawn over the previous character
+func isMark(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me)
+}
+
+// isInvisible returns true for format characters without a glyph
+func isInvisible(r rune) bool {
+	return r == zeroWidthJoiner || r == '\u200c' || r == '\ufeff' ||
+		unicode.Is(unicode.Variation_Selector, r)
+}
+
+// extendsCluster returns true if r belongs to the grapheme cluster of the
+// previous rune: combining marks, variation selectors and zero width joiners
+func extendsCluster(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc) ||
+		unicode.Is(unicode.Variation_Selector, r) ||
+		r == zeroWidthJoiner ||
+		(r >= 0x1f3fb && r <= 0x1f3ff) // emoji skin tone modifiers
+}
+
+// NextGrapheme returns the byte offset of the grapheme cluster following the
+// one starting at index. Clusters are approximated as a base rune followed by
+// combining marks, variation selectors and zero width joiner sequences
+func NextGrapheme(s string, index int) int {
+	if index >= len(s) {
+		return len(s)
+	}
+	previous, size := utf8.DecodeRuneInString(s[index:])
+	index += size
+	for index < len(s) {
+		r, size := utf8.DecodeRuneInString(s[index:])
+		if !extendsCluster(r) && previous != zeroWidthJoiner {
+			break
+		}
+		previous = r
+		index += size
+	}
+	return index
+}
+
+// PrevGrapheme returns the byte offset of the grapheme cluster preceding index
+func PrevGrapheme(s string, index int) int {
+	if index > len(s) {
+		index = len(s)
+	}
+	start := 0
+	for i := 0; i < index; {
+		next := NextGrapheme(s, i)
+		if next >= index {
+			return i
+		}
+		start = next
+		i = next
+	}
+	return start
+}
+
+// GraphemeCount returns the number of grapheme clusters of s
+func GraphemeCount(s string) int {
+	count := 0
+	for i := 0; i < len(s); i = NextGrapheme(s, i) {
+		count++
+	}
+	return count
+}
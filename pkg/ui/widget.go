@@ -0,0 +1,475 @@
+package ui
+
+import (
+	"github.com/markov/gojira2d/pkg/graphics"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// EventType identifies the events dispatched by a GUI to its widgets
+type EventType int
+
+const (
+	// The pointer entered or left the widget
+	EVENT_HOVER EventType = iota
+	EVENT_LEAVE
+	// The mouse button or the activate button went down or up on the widget
+	EVENT_PRESS
+	EVENT_RELEASE
+	// A press followed by a release on the same widget
+	EVENT_CLICK
+	// The pointer moved while the widget is pressed
+	EVENT_DRAG
+	EVENT_SCROLL
+	EVENT_FOCUS
+	EVENT_BLUR
+	// A navigation direction while the widget has the focus. Widgets handle
+	// it to keep the focus, e.g. a slider changing its value
+	EVENT_NAVIGATE
+	// The cancel button while the widget has the focus
+	EVENT_CANCEL
+	// The value of a Checkbox, Slider or Dropdown changed
+	EVENT_CHANGE
+)
+
+// bubbles returns true if the event goes up to the parents of the target
+// until it is handled
+func (t EventType) bubbles() bool {
+	switch t {
+	case EVENT_HOVER, EVENT_LEAVE, EVENT_FOCUS, EVENT_BLUR, EVENT_CHANGE:
+		return false
+	}
+	return true
+}
+
+// NavDirection is a direction of controller or keyboard navigation
+type NavDirection int
+
+const (
+	NAV_UP NavDirection = iota
+	NAV_DOWN
+	NAV_LEFT
+	NAV_RIGHT
+)
+
+// Event is dispatched to the target widget and, for bubbling events, to its
+// parents
+type Event struct {
+	Type   EventType
+	Target Widget
+	// Pointer position in pixels, delta of drags and scrolls
+	Position  mgl32.Vec2
+	Delta     mgl32.Vec2
+	Direction NavDirection
+	// Set it to stop the event from reaching the parents
+	Handled bool
+}
+
+// EventListener is called after the widget handled the event
+type EventListener func(event *Event)
+
+// WidgetState is the interaction state used to pick the colors of a widget
+type WidgetState int
+
+const (
+	STATE_NORMAL WidgetState = iota
+	STATE_HOVER
+	STATE_PRESSED
+	STATE_DISABLED
+)
+
+// Widget is an element of a GUI tree. Widgets embed WidgetBase, which
+// implements the tree, the state and default no-op behaviours
+type Widget interface {
+	Base() *WidgetBase
+	// Arrange places the primitives of the widget, called by the GUI when
+	// its position, size or depth changed
+	Arrange()
+	Update(deltaTime float64)
+	EnqueueForDrawing(context *graphics.Context)
+	// HandleEvent implements the behaviour of the widget, it's called before
+	// the listeners
+	HandleEvent(event *Event)
+}
+
+// WidgetBase holds the state common to all the widgets
+type WidgetBase struct {
+	self     Widget
+	parent   Widget
+	gui      *GUI
+	children []Widget
+	// Position relative to the parent, in pixels
+	position mgl32.Vec2
+	size     mgl32.Vec2
+	// Size last set with SetSize, layouts start from it
+	preferred mgl32.Vec2
+	// Offset of the children, the scroll of a ScrollPanel
+	contentOffset mgl32.Vec2
+	depth         float32
+
+	hidden      bool
+	disabled    bool
+	focusable   bool
+	interactive bool
+	// Children outside the bounds are not drawn nor hit
+	clipChildren bool
+
+	hovered bool
+	pressed bool
+	focused bool
+
+	style *WidgetStyle
+	// Name of the style of the widget in the theme of the GUI, empty for
+	// the parts of other widgets, styled by them
+	styleName string
+
+	listeners map[EventType][]EventListener
+
+	dirty         bool
+	arranged      mgl32.Vec4
+	arrangedDepth float32
+}
+
+// init must be called by the widget constructors with the widget itself
+func (b *WidgetBase) init(self Widget) {
+	b.self = self
+	b.dirty = true
+}
+
+// SetStyle changes the look of the widget
+func (b *WidgetBase) SetStyle(style *WidgetStyle) {
+	b.style = style
+	b.dirty = true
+}
+
+// Style returns the style of the widget
+func (b *WidgetBase) Style() *WidgetStyle {
+	return b.style
+}
+
+// SetStyleName sets the name of the style picked from the theme of the
+// GUI, the widget type by default. Empty names keep the style
+func (b *WidgetBase) SetStyleName(name string) {
+	b.styleName = name
+	if gui := b.rootGUI(); gui != nil {
+		gui.applyTheme(b.self)
+	}
+}
+
+// StyleName returns the name of the style picked from the theme
+func (b *WidgetBase) StyleName() string {
+	return b.styleName
+}
+
+// rootGUI returns the GUI of the tree the widget is part of, if any
+func (b *WidgetBase) rootGUI() *GUI {
+	w := b
+	for w.parent != nil {
+		w = w.parent.Base()
+	}
+	return w.gui
+}
+
+// now returns the time of the GUI in seconds, for the style transitions
+func (b *WidgetBase) now() float64 {
+	if gui := b.rootGUI(); gui != nil {
+		return gui.time
+	}
+	return 0
+}
+
+// Base returns the WidgetBase of a widget
+func (b *WidgetBase) Base() *WidgetBase {
+	return b
+}
+
+// Arrange does nothing by default
+func (b *WidgetBase) Arrange() {}
+
+// HandleEvent does nothing by default
+func (b *WidgetBase) HandleEvent(event *Event) {}
+
+// Update updates the children
+func (b *WidgetBase) Update(deltaTime float64) {
+	for _, child := range b.children {
+		if !child.Base().hidden {
+			child.Update(deltaTime)
+		}
+	}
+}
+
+// EnqueueForDrawing enqueues the visible children. Widgets drawing
+// something enqueue their primitives and then call it
+func (b *WidgetBase) EnqueueForDrawing(context *graphics.Context) {
+	for _, child := range b.children {
+		if child.Base().hidden || (b.clipChildren && !b.overlaps(child)) {
+			continue
+		}
+		child.EnqueueForDrawing(context)
+	}
+}
+
+// overlaps returns true if a child is at least partly inside the bounds
+func (b *WidgetBase) overlaps(child Widget) bool {
+	position, size := b.AbsolutePosition(), b.size
+	childPosition, childSize := child.Base().AbsolutePosition(), child.Base().size
+	return childPosition[0] < position[0]+size[0] && childPosition[0]+childSize[0] > position[0] &&
+		childPosition[1] < position[1]+size[1] && childPosition[1]+childSize[1] > position[1]
+}
+
+// Add appends a child, drawn above the previous ones
+func (b *WidgetBase) Add(child Widget) {
+	if parent := child.Base().parent; parent != nil {
+		parent.Base().Remove(child)
+	}
+	child.Base().parent = b.self
+	child.Base().dirty = true
+	b.children = append(b.children, child)
+	child.Base().invalidateLayout()
+	if gui := b.rootGUI(); gui != nil {
+		gui.applyTheme(child)
+	}
+}
+
+// Remove detaches a child
+func (b *WidgetBase) Remove(child Widget) {
+	for i, c := range b.children {
+		if c == child {
+			b.children = append(b.children[:i], b.children[i+1:]...)
+			child.Base().invalidateLayout()
+			child.Base().parent = nil
+			child.Base().gui = nil
+			return
+		}
+	}
+}
+
+// RemoveAll detaches all the children
+func (b *WidgetBase) RemoveAll() {
+	if len(b.children) > 0 {
+		b.children[0].Base().invalidateLayout()
+	}
+	for _, child := range b.children {
+		child.Base().parent = nil
+		child.Base().gui = nil
+	}
+	b.children = nil
+}
+
+// moveToFront draws a child above its siblings
+func (b *WidgetBase) moveToFront(child Widget) {
+	for i, c := range b.children {
+		if c == child {
+			b.children = append(append(b.children[:i], b.children[i+1:]...), child)
+			return
+		}
+	}
+}
+
+// Children returns the children, in drawing order
+func (b *WidgetBase) Children() []Widget {
+	return b.children
+}
+
+// Parent returns the parent widget, nil for roots and overlays
+func (b *WidgetBase) Parent() Widget {
+	return b.parent
+}
+
+// GUI returns the GUI the widget is attached to, after its first update
+func (b *WidgetBase) GUI() *GUI {
+	return b.gui
+}
+
+// SetPosition sets the position of the top left corner relative to the
+// parent, in pixels
+func (b *WidgetBase) SetPosition(position mgl32.Vec2) {
+	b.position = position
+	b.dirty = true
+}
+
+// Position returns the position relative to the parent
+func (b *WidgetBase) Position() mgl32.Vec2 {
+	return b.position
+}
+
+// SetSize sets the size in pixels. Inside a panel with a layout it sets
+// the preferred size, the layout can stretch or shrink the widget
+func (b *WidgetBase) SetSize(size mgl32.Vec2) {
+	if size != b.preferred {
+		b.invalidateLayout()
+	}
+	b.preferred = size
+	b.size = size
+	b.dirty = true
+}
+
+// PreferredSize returns the size the layouts start from
+func (b *WidgetBase) PreferredSize() mgl32.Vec2 {
+	return b.preferred
+}
+
+// setLayoutSize sets the size assigned by a layout, keeping the preferred
+func (b *WidgetBase) setLayoutSize(size mgl32.Vec2) {
+	if size != b.size {
+		b.size = size
+		b.dirty = true
+	}
+}
+
+// invalidateLayout arranges the parent again after the preferred size or
+// the visibility changed, and its parents while they fit their content
+func (b *WidgetBase) invalidateLayout() {
+	for w := b.parent; w != nil; w = w.Base().parent {
+		w.Base().dirty = true
+		if p, ok := w.(*Panel); !ok || !p.fitsContent() {
+			return
+		}
+	}
+}
+
+// Size returns the size in pixels
+func (b *WidgetBase) Size() mgl32.Vec2 {
+	return b.size
+}
+
+// AbsolutePosition returns the position of the top left corner in the GUI
+func (b *WidgetBase) AbsolutePosition() mgl32.Vec2 {
+	if b.parent == nil {
+		return b.position
+	}
+	parent := b.parent.Base()
+	return parent.AbsolutePosition().Add(b.position).Sub(parent.contentOffset)
+}
+
+// Contains returns true if an absolute point is inside the widget
+func (b *WidgetBase) Contains(point mgl32.Vec2) bool {
+	position := b.AbsolutePosition()
+	return point[0] >= position[0] && point[0] < position[0]+b.size[0] &&
+		point[1] >= position[1] && point[1] < position[1]+b.size[1]
+}
+
+// Center returns the absolute position of the center of the widget
+func (b *WidgetBase) Center() mgl32.Vec2 {
+	return b.AbsolutePosition().Add(b.size.Mul(0.5))
+}
+
+// Depth returns the z of the widget, assigned by the GUI in drawing order
+func (b *WidgetBase) Depth() float32 {
+	return b.depth
+}
+
+// layerDepth returns the z of the layer-th primitive of the widget, layers
+// are drawn above the previous ones
+func (b *WidgetBase) layerDepth(layer int) float32 {
+	return b.depth - float32(layer)*guiDepthStep/guiDepthLayers
+}
+
+// SetVisible shows or hides the widget and its children
+func (b *WidgetBase) SetVisible(visible bool) {
+	if visible == b.hidden {
+		b.invalidateLayout()
+	}
+	b.hidden = !visible
+	b.dirty = true
+}
+
+// Visible returns true if the widget is not hidden
+func (b *WidgetBase) Visible() bool {
+	return !b.hidden
+}
+
+// SetEnabled enables or disables the widget, disabled widgets don't receive
+// events
+func (b *WidgetBase) SetEnabled(enabled bool) {
+	b.disabled = !enabled
+	b.dirty = true
+}
+
+// Enabled returns false if the widget or one of its parents is disabled
+func (b *WidgetBase) Enabled() bool {
+	for w := b; w != nil; {
+		if w.disabled {
+			return false
+		}
+		if w.parent == nil {
+			break
+		}
+		w = w.parent.Base()
+	}
+	return true
+}
+
+// SetFocusable sets if the widget can get the focus
+func (b *WidgetBase) SetFocusable(focusable bool) {
+	b.focusable = focusable
+}
+
+// Focusable returns true if the widget can get the focus
+func (b *WidgetBase) Focusable() bool {
+	return b.focusable
+}
+
+// Hovered returns true if the pointer is over the widget
+func (b *WidgetBase) Hovered() bool {
+	return b.hovered
+}
+
+// Pressed returns true while the widget is pressed
+func (b *WidgetBase) Pressed() bool {
+	return b.pressed
+}
+
+// Focused returns true if the widget has the focus
+func (b *WidgetBase) Focused() bool {
+	return b.focused
+}
+
+// State returns the interaction state, focused widgets look hovered
+func (b *WidgetBase) State() WidgetState {
+	switch {
+	case !b.Enabled():
+		return STATE_DISABLED
+	case b.pressed:
+		return STATE_PRESSED
+	case b.hovered || b.focused:
+		return STATE_HOVER
+	}
+	return STATE_NORMAL
+}
+
+// On adds a listener for an event type
+func (b *WidgetBase) On(eventType EventType, listener EventListener) {
+	if b.listeners == nil {
+		b.listeners = make(map[EventType][]EventListener)
+	}
+	b.listeners[eventType] = append(b.listeners[eventType], listener)
+}
+
+// Invalidate makes the GUI arrange the widget again
+func (b *WidgetBase) Invalidate() {
+	b.dirty = true
+}
+
+// emit lets the widget handle the event and calls the listeners
+func (b *WidgetBase) emit(event *Event) {
+	b.self.HandleEvent(event)
+	for _, listener := range b.listeners[event.Type] {
+		listener(event)
+	}
+}
+
+// changed emits an EVENT_CHANGE from the widget
+func (b *WidgetBase) changed() {
+	b.emit(&Event{Type: EVENT_CHANGE, Target: b.self})
+}
+
+// isAncestorOf returns true if w is b or one of its descendants
+func (b *WidgetBase) isAncestorOf(w Widget) bool {
+	for ; w != nil; w = w.Base().parent {
+		if w.Base() == b {
+			return true
+		}
+	}
+	return false
+}
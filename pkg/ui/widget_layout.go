@@ -0,0 +1,587 @@
+package ui
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// WidgetLayout places the children of a Panel. It runs when the panel is
+// arranged: when it's resized, e.g. by GUI.SetSize, or when a child changes
+// preferred size or visibility
+type WidgetLayout interface {
+	// Measure returns the size needed by the children, padding included
+	Measure(children []Widget) mgl32.Vec2
+	// Apply sets the positions and sizes of the children inside a panel of
+	// the given size
+	Apply(children []Widget, size mgl32.Vec2)
+}
+
+// ownedLayout is implemented by the layouts arranging their panel again
+// when their settings change
+type ownedLayout interface {
+	setOwner(panel *Panel)
+}
+
+// layoutOwner is embedded by the layouts to invalidate their panel
+type layoutOwner struct {
+	owner *Panel
+}
+
+func (o *layoutOwner) setOwner(panel *Panel) {
+	o.owner = panel
+}
+
+func (o *layoutOwner) invalidate() {
+	if o.owner != nil {
+		o.owner.layoutChanged()
+	}
+}
+
+// LayoutDirection is the main axis of a FlexLayout
+type LayoutDirection int
+
+const (
+	LAYOUT_HORIZONTAL LayoutDirection = iota
+	LAYOUT_VERTICAL
+)
+
+// ItemAlignment places a child across the main axis of a FlexLayout, or
+// inside its cell of a GridLayout
+type ItemAlignment int
+
+const (
+	// Uses the alignment of the layout, only meaningful for FlexItem
+	ITEMS_AUTO ItemAlignment = iota
+	ITEMS_START
+	ITEMS_CENTER
+	ITEMS_END
+	// The child fills the available space
+	ITEMS_STRETCH
+)
+
+// Justify distributes the free space along the main axis of a FlexLayout
+type Justify int
+
+const (
+	JUSTIFY_START Justify = iota
+	JUSTIFY_CENTER
+	JUSTIFY_END
+	// Free space between the children, none at the edges
+	JUSTIFY_SPACE_BETWEEN
+	// Free space around each child, half of it at the edges
+	JUSTIFY_SPACE_AROUND
+)
+
+// Anchor is a set of parent edges a child of an AnchorLayout keeps its
+// distance from. Anchored to both opposite edges it stretches, to none it
+// is centered on that axis
+type Anchor int
+
+const (
+	ANCHOR_LEFT Anchor = 1 << iota
+	ANCHOR_RIGHT
+	ANCHOR_TOP
+	ANCHOR_BOTTOM
+
+	ANCHOR_CENTER       Anchor = 0
+	ANCHOR_TOP_LEFT            = ANCHOR_TOP | ANCHOR_LEFT
+	ANCHOR_TOP_RIGHT           = ANCHOR_TOP | ANCHOR_RIGHT
+	ANCHOR_BOTTOM_LEFT         = ANCHOR_BOTTOM | ANCHOR_LEFT
+	ANCHOR_BOTTOM_RIGHT        = ANCHOR_BOTTOM | ANCHOR_RIGHT
+	ANCHOR_FILL                = ANCHOR_LEFT | ANCHOR_RIGHT | ANCHOR_TOP | ANCHOR_BOTTOM
+)
+
+// preferredSize returns the size a layout starts from for a widget
+func preferredSize(w Widget) mgl32.Vec2 {
+	if m, ok := w.(interface {
+		measure() mgl32.Vec2
+	}); ok {
+		return m.measure()
+	}
+	return w.Base().preferred
+}
+
+// edges returns the start and end of a top, bottom, left, right vector
+// along an axis, 0 for x and 1 for y
+func edges(v mgl32.Vec4, axis int) (float32, float32) {
+	if axis == 0 {
+		return v[2], v[3]
+	}
+	return v[0], v[1]
+}
+
+// alignIn returns the offset and the size of an item of a given size in
+// the available space
+func alignIn(alignment ItemAlignment, size, available float32) (float32, float32) {
+	switch alignment {
+	case ITEMS_STRETCH:
+		return 0, available
+	case ITEMS_CENTER:
+		return (available - size) / 2, size
+	case ITEMS_END:
+		return available - size, size
+	}
+	return 0, size
+}
+
+// visibleChildren returns the children taking part in a layout
+func visibleChildren(children []Widget) []Widget {
+	visible := make([]Widget, 0, len(children))
+	for _, child := range children {
+		if !child.Base().hidden {
+			visible = append(visible, child)
+		}
+	}
+	return visible
+}
+
+// FlexItem controls how a child of a FlexLayout is sized. The zero value
+// doesn't grow nor shrink
+type FlexItem struct {
+	// Share of the free space the child takes
+	Grow float32
+	// Share of the missing space the child gives up, weighted by its basis
+	Shrink float32
+	// Size along the main axis before growing or shrinking, 0 means the
+	// preferred size
+	Basis float32
+	// Alignment across the main axis, ITEMS_AUTO uses the one of the layout
+	Align ItemAlignment
+	// Space around the child: top, bottom, left, right
+	Margin mgl32.Vec4
+}
+
+// FlexLayout places the children in a row or a column, in order, like a
+// CSS flexbox without wrapping
+type FlexLayout struct {
+	layoutOwner
+	direction LayoutDirection
+	gap       float32
+	padding   mgl32.Vec4
+	justify   Justify
+	align     ItemAlignment
+	items     map[Widget]FlexItem
+}
+
+// NewFlexLayout creates a layout along a direction, stretching the
+// children across it
+func NewFlexLayout(direction LayoutDirection) *FlexLayout {
+	return &FlexLayout{
+		direction: direction,
+		align:     ITEMS_STRETCH,
+		items:     make(map[Widget]FlexItem),
+	}
+}
+
+// NewHBox creates a horizontal stack with a gap between the children
+func NewHBox(gap float32) *FlexLayout {
+	l := NewFlexLayout(LAYOUT_HORIZONTAL)
+	l.gap = gap
+	return l
+}
+
+// NewVBox creates a vertical stack with a gap between the children
+func NewVBox(gap float32) *FlexLayout {
+	l := NewFlexLayout(LAYOUT_VERTICAL)
+	l.gap = gap
+	return l
+}
+
+// SetGap sets the space between the children
+func (l *FlexLayout) SetGap(gap float32) {
+	l.gap = gap
+	l.invalidate()
+}
+
+// SetPadding sets the space inside the panel: top, bottom, left, right
+func (l *FlexLayout) SetPadding(padding mgl32.Vec4) {
+	l.padding = padding
+	l.invalidate()
+}
+
+// SetJustify sets how the free space is distributed along the main axis
+func (l *FlexLayout) SetJustify(justify Justify) {
+	l.justify = justify
+	l.invalidate()
+}
+
+// SetAlignment sets how the children are placed across the main axis
+func (l *FlexLayout) SetAlignment(align ItemAlignment) {
+	l.align = align
+	l.invalidate()
+}
+
+// SetItem sets how a child is sized
+func (l *FlexLayout) SetItem(child Widget, item FlexItem) {
+	l.items[child] = item
+	l.invalidate()
+}
+
+// Item returns how a child is sized
+func (l *FlexLayout) Item(child Widget) FlexItem {
+	return l.items[child]
+}
+
+// axes returns the index of the main and the cross axis
+func (l *FlexLayout) axes() (int, int) {
+	if l.direction == LAYOUT_VERTICAL {
+		return 1, 0
+	}
+	return 0, 1
+}
+
+// basis returns the main size of a child before growing or shrinking
+func (l *FlexLayout) basis(child Widget, item FlexItem, main int) float32 {
+	if item.Basis > 0 {
+		return item.Basis
+	}
+	return preferredSize(child)[main]
+}
+
+// Measure see WidgetLayout.Measure
+func (l *FlexLayout) Measure(children []Widget) mgl32.Vec2 {
+	main, cross := l.axes()
+	var size mgl32.Vec2
+	visible := visibleChildren(children)
+	for i, child := range visible {
+		item := l.items[child]
+		start, end := edges(item.Margin, main)
+		size[main] += l.basis(child, item, main) + start + end
+		if i > 0 {
+			size[main] += l.gap
+		}
+		start, end = edges(item.Margin, cross)
+		if s := preferredSize(child)[cross] + start + end; s > size[cross] {
+			size[cross] = s
+		}
+	}
+	for axis := 0; axis < 2; axis++ {
+		start, end := edges(l.padding, axis)
+		size[axis] += start + end
+	}
+	return size
+}
+
+// Apply see WidgetLayout.Apply
+func (l *FlexLayout) Apply(children []Widget, size mgl32.Vec2) {
+	main, cross := l.axes()
+	visible := visibleChildren(children)
+	if len(visible) == 0 {
+		return
+	}
+	paddingStart, paddingEnd := edges(l.padding, main)
+	crossStart, crossEnd := edges(l.padding, cross)
+	available := size[main] - paddingStart - paddingEnd
+	crossAvailable := size[cross] - crossStart - crossEnd
+
+	// Main sizes before distributing the free space
+	sizes := make([]float32, len(visible))
+	free := available - l.gap*float32(len(visible)-1)
+	var grow, shrink float32
+	for i, child := range visible {
+		item := l.items[child]
+		sizes[i] = l.basis(child, item, main)
+		start, end := edges(item.Margin, main)
+		free -= sizes[i] + start + end
+		grow += item.Grow
+		shrink += item.Shrink * sizes[i]
+	}
+	if free > 0 && grow > 0 {
+		for i, child := range visible {
+			sizes[i] += free * l.items[child].Grow / grow
+		}
+		free = 0
+	} else if free < 0 && shrink > 0 {
+		for i, child := range visible {
+			sizes[i] += free * l.items[child].Shrink * sizes[i] / shrink
+			if sizes[i] < 0 {
+				sizes[i] = 0
+			}
+		}
+		free = 0
+	}
+
+	// Free space left to the justification
+	offset, spacing := float32(0), l.gap
+	if free > 0 {
+		switch l.justify {
+		case JUSTIFY_CENTER:
+			offset = free / 2
+		case JUSTIFY_END:
+			offset = free
+		case JUSTIFY_SPACE_BETWEEN:
+			if len(visible) > 1 {
+				spacing += free / float32(len(visible)-1)
+			}
+		case JUSTIFY_SPACE_AROUND:
+			around := free / float32(len(visible))
+			offset = around / 2
+			spacing += around
+		}
+	}
+
+	position := paddingStart + offset
+	for i, child := range visible {
+		item := l.items[child]
+		align := item.Align
+		if align == ITEMS_AUTO {
+			align = l.align
+		}
+		var childPosition, childSize mgl32.Vec2
+		start, end := edges(item.Margin, main)
+		childPosition[main] = position + start
+		childSize[main] = sizes[i]
+		position += start + sizes[i] + end + spacing
+
+		start, end = edges(item.Margin, cross)
+		childOffset, childCross := alignIn(align, preferredSize(child)[cross], crossAvailable-start-end)
+		childPosition[cross] = crossStart + start + childOffset
+		childSize[cross] = childCross
+
+		child.Base().SetPosition(childPosition)
+		child.Base().setLayoutSize(childSize)
+	}
+}
+
+// GridLayout places the children in rows of a fixed number of columns, in
+// order. Columns are as wide as their widest child and rows as tall as
+// their tallest, uniform grids split the panel in equal cells
+type GridLayout struct {
+	layoutOwner
+	columns int
+	gap     mgl32.Vec2
+	padding mgl32.Vec4
+	align   ItemAlignment
+	uniform bool
+}
+
+// NewGridLayout creates a grid stretching the children to their cells
+func NewGridLayout(columns int, gap mgl32.Vec2) *GridLayout {
+	if columns < 1 {
+		columns = 1
+	}
+	return &GridLayout{columns: columns, gap: gap, align: ITEMS_STRETCH}
+}
+
+// SetColumns sets the number of columns
+func (l *GridLayout) SetColumns(columns int) {
+	if columns < 1 {
+		columns = 1
+	}
+	l.columns = columns
+	l.invalidate()
+}
+
+// SetGap sets the space between columns and rows
+func (l *GridLayout) SetGap(gap mgl32.Vec2) {
+	l.gap = gap
+	l.invalidate()
+}
+
+// SetPadding sets the space inside the panel: top, bottom, left, right
+func (l *GridLayout) SetPadding(padding mgl32.Vec4) {
+	l.padding = padding
+	l.invalidate()
+}
+
+// SetAlignment sets how the children are placed inside their cells, on
+// both axes
+func (l *GridLayout) SetAlignment(align ItemAlignment) {
+	l.align = align
+	l.invalidate()
+}
+
+// SetUniform makes all the cells the same size, splitting the panel
+func (l *GridLayout) SetUniform(uniform bool) {
+	l.uniform = uniform
+	l.invalidate()
+}
+
+// tracks returns the widths of the columns and the heights of the rows
+// fitting the children
+func (l *GridLayout) tracks(visible []Widget) ([]float32, []float32) {
+	rows := (len(visible) + l.columns - 1) / l.columns
+	widths := make([]float32, l.columns)
+	heights := make([]float32, rows)
+	var largest mgl32.Vec2
+	for i, child := range visible {
+		size := preferredSize(child)
+		column, row := i%l.columns, i/l.columns
+		if size[0] > widths[column] {
+			widths[column] = size[0]
+		}
+		if size[1] > heights[row] {
+			heights[row] = size[1]
+		}
+		for axis := range largest {
+			if size[axis] > largest[axis] {
+				largest[axis] = size[axis]
+			}
+		}
+	}
+	if l.uniform {
+		for i := range widths {
+			widths[i] = largest[0]
+		}
+		for i := range heights {
+			heights[i] = largest[1]
+		}
+	}
+	return widths, heights
+}
+
+// Measure see WidgetLayout.Measure
+func (l *GridLayout) Measure(children []Widget) mgl32.Vec2 {
+	widths, heights := l.tracks(visibleChildren(children))
+	var size mgl32.Vec2
+	for axis, track := range [][]float32{widths, heights} {
+		for i, s := range track {
+			size[axis] += s
+			if i > 0 {
+				size[axis] += l.gap[axis]
+			}
+		}
+		start, end := edges(l.padding, axis)
+		size[axis] += start + end
+	}
+	return size
+}
+
+// Apply see WidgetLayout.Apply
+func (l *GridLayout) Apply(children []Widget, size mgl32.Vec2) {
+	visible := visibleChildren(children)
+	if len(visible) == 0 {
+		return
+	}
+	widths, heights := l.tracks(visible)
+	if l.uniform {
+		for axis, track := range [][]float32{widths, heights} {
+			start, end := edges(l.padding, axis)
+			cell := (size[axis] - start - end - l.gap[axis]*float32(len(track)-1)) / float32(len(track))
+			for i := range track {
+				track[i] = cell
+			}
+		}
+	}
+	left, _ := edges(l.padding, 0)
+	top, _ := edges(l.padding, 1)
+	cell := mgl32.Vec2{left, top}
+	for i, child := range visible {
+		column, row := i%l.columns, i/l.columns
+		if column == 0 && i > 0 {
+			cell = mgl32.Vec2{left, cell[1] + heights[row-1] + l.gap[1]}
+		}
+		preferred := preferredSize(child)
+		var childPosition, childSize mgl32.Vec2
+		for axis, track := range []float32{widths[column], heights[row]} {
+			offset, s := alignIn(l.align, preferred[axis], track)
+			childPosition[axis] = cell[axis] + offset
+			childSize[axis] = s
+		}
+		child.Base().SetPosition(childPosition)
+		child.Base().setLayoutSize(childSize)
+		cell[0] += widths[column] + l.gap[0]
+	}
+}
+
+// anchorItem is how a child of an AnchorLayout is placed
+type anchorItem struct {
+	anchor Anchor
+	margin mgl32.Vec4
+}
+
+// AnchorLayout keeps the children at a distance from the edges of the
+// panel, e.g. a score in the top right corner of the screen. Children
+// without an anchor keep their position
+type AnchorLayout struct {
+	layoutOwner
+	padding mgl32.Vec4
+	anchors map[Widget]anchorItem
+}
+
+// NewAnchorLayout creates a layout without anchored children
+func NewAnchorLayout() *AnchorLayout {
+	return &AnchorLayout{anchors: make(map[Widget]anchorItem)}
+}
+
+// SetPadding sets the space inside the panel: top, bottom, left, right
+func (l *AnchorLayout) SetPadding(padding mgl32.Vec4) {
+	l.padding = padding
+	l.invalidate()
+}
+
+// SetAnchor anchors a child to edges of the panel, margin is the distance
+// from them: top, bottom, left, right
+func (l *AnchorLayout) SetAnchor(child Widget, anchor Anchor, margin mgl32.Vec4) {
+	l.anchors[child] = anchorItem{anchor, margin}
+	l.invalidate()
+}
+
+// RemoveAnchor makes a child keep its position again
+func (l *AnchorLayout) RemoveAnchor(child Widget) {
+	delete(l.anchors, child)
+	l.invalidate()
+}
+
+// anchoredTo returns if a child is anchored to the start and the end of an
+// axis
+func (a anchorItem) anchoredTo(axis int) (bool, bool) {
+	if axis == 0 {
+		return a.anchor&ANCHOR_LEFT != 0, a.anchor&ANCHOR_RIGHT != 0
+	}
+	return a.anchor&ANCHOR_TOP != 0, a.anchor&ANCHOR_BOTTOM != 0
+}
+
+// Measure see WidgetLayout.Measure
+func (l *AnchorLayout) Measure(children []Widget) mgl32.Vec2 {
+	var size mgl32.Vec2
+	for _, child := range visibleChildren(children) {
+		preferred := preferredSize(child)
+		item, anchored := l.anchors[child]
+		for axis := 0; axis < 2; axis++ {
+			s := child.Base().position[axis] + preferred[axis]
+			if anchored {
+				start, end := edges(item.margin, axis)
+				paddingStart, paddingEnd := edges(l.padding, axis)
+				s = paddingStart + start + preferred[axis] + end + paddingEnd
+			}
+			if s > size[axis] {
+				size[axis] = s
+			}
+		}
+	}
+	return size
+}
+
+// Apply see WidgetLayout.Apply
+func (l *AnchorLayout) Apply(children []Widget, size mgl32.Vec2) {
+	for _, child := range visibleChildren(children) {
+		preferred := preferredSize(child)
+		item, anchored := l.anchors[child]
+		if !anchored {
+			child.Base().setLayoutSize(preferred)
+			continue
+		}
+		var childPosition, childSize mgl32.Vec2
+		for axis := 0; axis < 2; axis++ {
+			paddingStart, paddingEnd := edges(l.padding, axis)
+			start, end := edges(item.margin, axis)
+			start += paddingStart
+			end += paddingEnd
+			toStart, toEnd := item.anchoredTo(axis)
+			childSize[axis] = preferred[axis]
+			switch {
+			case toStart && toEnd:
+				childPosition[axis] = start
+				childSize[axis] = size[axis] - start - end
+			case toStart:
+				childPosition[axis] = start
+			case toEnd:
+				childPosition[axis] = size[axis] - end - preferred[axis]
+			default:
+				childPosition[axis] = (size[axis]-preferred[axis])/2 + (start-end)/2
+			}
+		}
+		child.Base().SetPosition(childPosition)
+		child.Base().setLayoutSize(childSize)
+	}
+}
@@ -0,0 +1,168 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// checkBounds compares the position and the size of widgets
+func checkBounds(t *testing.T, name string, w Widget, position, size mgl32.Vec2) {
+	t.Helper()
+	p, s := w.Base().Position(), w.Base().Size()
+	if !vecNear(p[:], position[:]) || !vecNear(s[:], size[:]) {
+		t.Errorf("%s: expected %v %v, got %v %v", name, position, size, p, s)
+	}
+}
+
+func TestFlexLayout(t *testing.T) {
+	gui := NewGUI(800, 600)
+	row := NewPanel(nil)
+	row.SetSize(mgl32.Vec2{400, 100})
+	layout := NewHBox(10)
+	layout.SetPadding(mgl32.Vec4{5, 5, 20, 20})
+	row.SetLayout(layout)
+	a := newTestWidget(0, 0, 50, 30)
+	b := newTestWidget(0, 0, 100, 40)
+	c := newTestWidget(0, 0, 50, 20)
+	row.Add(a)
+	row.Add(b)
+	row.Add(c)
+	gui.Add(row)
+
+	// Stretched across, 360 - 200 - 20 free
+	gui.Update(0)
+	checkBounds(t, "a", a, mgl32.Vec2{20, 5}, mgl32.Vec2{50, 90})
+	checkBounds(t, "b", b, mgl32.Vec2{80, 5}, mgl32.Vec2{100, 90})
+	checkBounds(t, "c", c, mgl32.Vec2{190, 5}, mgl32.Vec2{50, 90})
+
+	// Growing takes the free space, margins are outside the children
+	layout.SetItem(b, FlexItem{Grow: 1, Align: ITEMS_CENTER, Margin: mgl32.Vec4{0, 0, 5, 5}})
+	layout.SetItem(c, FlexItem{Grow: 3, Align: ITEMS_END})
+	gui.Update(0)
+	checkBounds(t, "a", a, mgl32.Vec2{20, 5}, mgl32.Vec2{50, 90})
+	checkBounds(t, "b", b, mgl32.Vec2{85, 30}, mgl32.Vec2{132.5, 40})
+	checkBounds(t, "c", c, mgl32.Vec2{232.5, 75}, mgl32.Vec2{147.5, 20})
+
+	// Shrinking is weighted by the basis, 110 missing
+	layout.SetItem(b, FlexItem{Shrink: 1})
+	layout.SetItem(c, FlexItem{Shrink: 1, Basis: 300})
+	gui.Update(0)
+	checkBounds(t, "a", a, mgl32.Vec2{20, 5}, mgl32.Vec2{50, 90})
+	checkBounds(t, "b", b, mgl32.Vec2{80, 5}, mgl32.Vec2{72.5, 90})
+	checkBounds(t, "c", c, mgl32.Vec2{162.5, 5}, mgl32.Vec2{217.5, 90})
+
+	// The free space is justified when nothing grows
+	layout.SetItem(b, FlexItem{})
+	layout.SetItem(c, FlexItem{})
+	layout.SetJustify(JUSTIFY_SPACE_BETWEEN)
+	gui.Update(0)
+	checkBounds(t, "a", a, mgl32.Vec2{20, 5}, mgl32.Vec2{50, 90})
+	checkBounds(t, "b", b, mgl32.Vec2{150, 5}, mgl32.Vec2{100, 90})
+	checkBounds(t, "c", c, mgl32.Vec2{330, 5}, mgl32.Vec2{50, 90})
+	layout.SetJustify(JUSTIFY_END)
+	gui.Update(0)
+	checkBounds(t, "a", a, mgl32.Vec2{160, 5}, mgl32.Vec2{50, 90})
+
+	// Hidden children are skipped, the others are placed again
+	b.SetVisible(false)
+	gui.Update(0)
+	checkBounds(t, "a", a, mgl32.Vec2{270, 5}, mgl32.Vec2{50, 90})
+	checkBounds(t, "c", c, mgl32.Vec2{330, 5}, mgl32.Vec2{50, 90})
+
+	measured := layout.Measure(row.Children())
+	if !vecNear(measured[:], []float32{50 + 10 + 50 + 40, 30 + 10}) {
+		t.Errorf("Wrong measure %v", measured)
+	}
+}
+
+func TestFitContent(t *testing.T) {
+	gui := NewGUI(800, 600)
+	column := NewPanel(nil)
+	column.SetLayout(NewVBox(5))
+	column.SetFitContent(true)
+	a := newTestWidget(0, 0, 100, 20)
+	b := newTestWidget(0, 0, 60, 30)
+	column.Add(a)
+	column.Add(b)
+	row := NewPanel(nil)
+	row.SetLayout(NewHBox(0))
+	row.SetFitContent(true)
+	row.Add(column)
+	side := newTestWidget(0, 0, 10, 10)
+	row.Add(side)
+	gui.Add(row)
+
+	gui.Update(0)
+	checkBounds(t, "column", column, mgl32.Vec2{0, 0}, mgl32.Vec2{100, 55})
+	checkBounds(t, "b", b, mgl32.Vec2{0, 25}, mgl32.Vec2{100, 30})
+	checkBounds(t, "row", row, mgl32.Vec2{0, 0}, mgl32.Vec2{110, 55})
+
+	// Resizing a nested child resizes the fitting parents
+	b.SetSize(mgl32.Vec2{150, 30})
+	gui.Update(0)
+	checkBounds(t, "column", column, mgl32.Vec2{0, 0}, mgl32.Vec2{150, 55})
+	checkBounds(t, "side", side, mgl32.Vec2{150, 0}, mgl32.Vec2{10, 55})
+	checkBounds(t, "row", row, mgl32.Vec2{0, 0}, mgl32.Vec2{160, 55})
+}
+
+func TestGridLayout(t *testing.T) {
+	gui := NewGUI(800, 600)
+	grid := NewPanel(nil)
+	grid.SetSize(mgl32.Vec2{300, 200})
+	layout := NewGridLayout(2, mgl32.Vec2{10, 5})
+	grid.SetLayout(layout)
+	cells := []*testWidget{
+		newTestWidget(0, 0, 50, 20),
+		newTestWidget(0, 0, 80, 10),
+		newTestWidget(0, 0, 30, 40),
+	}
+	for _, cell := range cells {
+		grid.Add(cell)
+	}
+	gui.Add(grid)
+
+	gui.Update(0)
+	checkBounds(t, "0", cells[0], mgl32.Vec2{0, 0}, mgl32.Vec2{50, 20})
+	checkBounds(t, "1", cells[1], mgl32.Vec2{60, 0}, mgl32.Vec2{80, 20})
+	checkBounds(t, "2", cells[2], mgl32.Vec2{0, 25}, mgl32.Vec2{50, 40})
+	if m := layout.Measure(grid.Children()); !vecNear(m[:], []float32{140, 65}) {
+		t.Errorf("Wrong measure %v", m)
+	}
+
+	layout.SetUniform(true)
+	layout.SetAlignment(ITEMS_CENTER)
+	gui.Update(0)
+	// 145 x 97.5 cells
+	checkBounds(t, "1", cells[1], mgl32.Vec2{155 + 32.5, 43.75}, mgl32.Vec2{80, 10})
+	checkBounds(t, "2", cells[2], mgl32.Vec2{57.5, 102.5 + 28.75}, mgl32.Vec2{30, 40})
+}
+
+func TestAnchorLayout(t *testing.T) {
+	gui := NewGUI(800, 600)
+	layout := NewAnchorLayout()
+	gui.Root().SetLayout(layout)
+	score := newTestWidget(0, 0, 100, 30)
+	bar := newTestWidget(0, 0, 50, 40)
+	dialog := newTestWidget(0, 0, 200, 100)
+	free := newTestWidget(7, 8, 10, 10)
+	for _, w := range []Widget{score, bar, dialog, free} {
+		gui.Add(w)
+	}
+	layout.SetAnchor(score, ANCHOR_TOP_RIGHT, mgl32.Vec4{10, 0, 0, 20})
+	layout.SetAnchor(bar, ANCHOR_BOTTOM|ANCHOR_LEFT|ANCHOR_RIGHT, mgl32.Vec4{0, 40, 10, 10})
+	layout.SetAnchor(dialog, ANCHOR_CENTER, mgl32.Vec4{})
+
+	gui.Update(0)
+	checkBounds(t, "score", score, mgl32.Vec2{680, 10}, mgl32.Vec2{100, 30})
+	checkBounds(t, "bar", bar, mgl32.Vec2{10, 520}, mgl32.Vec2{780, 40})
+	checkBounds(t, "dialog", dialog, mgl32.Vec2{300, 250}, mgl32.Vec2{200, 100})
+	checkBounds(t, "free", free, mgl32.Vec2{7, 8}, mgl32.Vec2{10, 10})
+
+	// Resizing the GUI places the anchored widgets again
+	gui.SetSize(1280, 720)
+	gui.Update(0)
+	checkBounds(t, "score", score, mgl32.Vec2{1160, 10}, mgl32.Vec2{100, 30})
+	checkBounds(t, "bar", bar, mgl32.Vec2{10, 640}, mgl32.Vec2{1260, 40})
+	checkBounds(t, "dialog", dialog, mgl32.Vec2{540, 310}, mgl32.Vec2{200, 100})
+}
@@ -0,0 +1,220 @@
+package ui
+
+import (
+	"github.com/markov/gojira2d/pkg/graphics"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// StateColors are the colors of an element in each WidgetState
+type StateColors struct {
+	Normal   graphics.Color
+	Hover    graphics.Color
+	Pressed  graphics.Color
+	Disabled graphics.Color
+}
+
+// Color returns the color for a state
+func (c *StateColors) Color(state WidgetState) graphics.Color {
+	switch state {
+	case STATE_HOVER:
+		return c.Hover
+	case STATE_PRESSED:
+		return c.Pressed
+	case STATE_DISABLED:
+		return c.Disabled
+	}
+	return c.Normal
+}
+
+// SolidStateColors returns the same color for all the states
+func SolidStateColors(color graphics.Color) StateColors {
+	return StateColors{color, color, color, color}
+}
+
+// WidgetStyle is the look of a widget
+type WidgetStyle struct {
+	Font *Font
+	// Height in pixels of a line of text
+	FontSize   float32
+	Text       StateColors
+	Background StateColors
+	// Check marks, slider thumbs, progress bars and title bars
+	Accent StateColors
+	// Space between the border and the content, in pixels. Order: top,
+	// bottom, left, right like the paddings of Text
+	Padding mgl32.Vec4
+	// Drawn instead of the background rectangle, tinted by the Background
+	// colors. Nil draws a solid rectangle
+	BackgroundImage *NineSliceImage
+	// Seconds the colors take to change with the state, 0 is immediate
+	Transition float64
+}
+
+// NineSliceImage is a background scaled keeping the size of its borders
+type NineSliceImage struct {
+	Texture *graphics.Texture
+	// x, y, width, height in pixels, all zeros is the whole texture
+	Region mgl32.Vec4
+	Insets graphics.Insets
+	// Scale of the borders on screen, 0 is 1
+	BorderScale float32
+	// Tile the edges and the center instead of stretching them
+	Tile bool
+}
+
+// DefaultWidgetStyle returns a dark style using font
+func DefaultWidgetStyle(font *Font) *WidgetStyle {
+	return &WidgetStyle{
+		Font:     font,
+		FontSize: 24,
+		Text: StateColors{
+			Normal:   graphics.Color{0.9, 0.9, 0.9, 1},
+			Hover:    graphics.Color{1, 1, 1, 1},
+			Pressed:  graphics.Color{1, 1, 1, 1},
+			Disabled: graphics.Color{0.5, 0.5, 0.5, 1},
+		},
+		Background: StateColors{
+			Normal:   graphics.Color{0.2, 0.2, 0.25, 0.9},
+			Hover:    graphics.Color{0.3, 0.3, 0.38, 0.9},
+			Pressed:  graphics.Color{0.15, 0.15, 0.2, 0.9},
+			Disabled: graphics.Color{0.2, 0.2, 0.2, 0.6},
+		},
+		Accent: StateColors{
+			Normal:   graphics.Color{0.3, 0.6, 1, 1},
+			Hover:    graphics.Color{0.45, 0.7, 1, 1},
+			Pressed:  graphics.Color{0.2, 0.45, 0.85, 1},
+			Disabled: graphics.Color{0.4, 0.4, 0.45, 1},
+		},
+		Padding: mgl32.Vec4{6, 6, 10, 10},
+	}
+}
+
+// paddingSize returns the horizontal and vertical padding
+func (s *WidgetStyle) paddingSize() mgl32.Vec2 {
+	return mgl32.Vec2{s.Padding[2] + s.Padding[3], s.Padding[0] + s.Padding[1]}
+}
+
+// colorTransition blends the previous color into a new one over time
+type colorTransition struct {
+	from     graphics.Color
+	to       graphics.Color
+	start    float64
+	duration float64
+	started  bool
+}
+
+// set starts blending to color at time now. The first color is immediate
+func (t *colorTransition) set(color graphics.Color, duration, now float64) {
+	if !t.started {
+		t.from, t.to, t.started = color, color, true
+		return
+	}
+	if color == t.to {
+		return
+	}
+	t.from = t.value(now)
+	t.to = color
+	t.start = now
+	t.duration = duration
+}
+
+// value returns the color at time now
+func (t *colorTransition) value(now float64) graphics.Color {
+	if t.duration <= 0 || now >= t.start+t.duration {
+		return t.to
+	}
+	f := float32((now - t.start) / t.duration)
+	if f < 0 {
+		f = 0
+	}
+	var color graphics.Color
+	for i := range color {
+		color[i] = t.from[i] + (t.to[i]-t.from[i])*f
+	}
+	return color
+}
+
+// box is a rectangle of a widget drawn with a solid color, or with the
+// background image of the style for background boxes. Colors change with
+// the transition of the style. Fully transparent boxes are not drawn
+type box struct {
+	owner      *WidgetBase
+	background bool
+	rect       *graphics.Primitive2D
+	slice      *graphics.NineSlice
+	image      *NineSliceImage
+	position   mgl32.Vec3
+	size       mgl32.Vec2
+	color      colorTransition
+}
+
+func newBox(owner *WidgetBase) *box {
+	return &box{owner: owner}
+}
+
+// newBackgroundBox creates a box drawn with the background image of the
+// style of owner, if any
+func newBackgroundBox(owner *WidgetBase) *box {
+	return &box{owner: owner, background: true}
+}
+
+// set moves and resizes the box, position is absolute
+func (b *box) set(position mgl32.Vec2, depth float32, size mgl32.Vec2) {
+	b.position = mgl32.Vec3{position[0], position[1], depth}
+	b.size = size
+}
+
+// setColor changes the color, blending it with the transition of the style
+func (b *box) setColor(color graphics.Color) {
+	var duration float64
+	if b.owner.style != nil {
+		duration = b.owner.style.Transition
+	}
+	b.color.set(color, duration, b.owner.now())
+}
+
+func (b *box) enqueue(context *graphics.Context) {
+	color := b.color.value(b.owner.now())
+	if color[3] <= 0 {
+		return
+	}
+	var image *NineSliceImage
+	if b.background && b.owner.style != nil {
+		image = b.owner.style.BackgroundImage
+	}
+	if image == nil {
+		if b.rect == nil {
+			b.rect = graphics.NewRectanglePrimitive(b.position, b.size)
+		}
+		b.rect.SetPosition(b.position)
+		b.rect.SetSize(b.size)
+		b.rect.SetColor(color)
+		b.rect.EnqueueForDrawing(context)
+		return
+	}
+	if image != b.image {
+		b.image = image
+		b.slice = newNineSlice(image, b.position, b.size)
+	}
+	b.slice.SetPosition(b.position)
+	b.slice.SetSize(b.size)
+	b.slice.SetColor(color)
+	b.slice.EnqueueForDrawing(context)
+}
+
+// newNineSlice creates the primitive drawing a NineSliceImage
+func newNineSlice(image *NineSliceImage, position mgl32.Vec3, size mgl32.Vec2) *graphics.NineSlice {
+	region := image.Region
+	if region == (mgl32.Vec4{}) {
+		region = mgl32.Vec4{0, 0, float32(image.Texture.Width()), float32(image.Texture.Height())}
+	}
+	slice := graphics.NewNineSliceFromRegion(image.Texture, region, image.Insets, position, size)
+	if image.BorderScale > 0 {
+		slice.SetBorderScale(image.BorderScale)
+	}
+	if image.Tile {
+		slice.SetModes(graphics.NINE_SLICE_TILE, graphics.NINE_SLICE_TILE)
+	}
+	return slice
+}
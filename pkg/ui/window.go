@@ -0,0 +1,141 @@
+package ui
+
+import (
+	"github.com/markov/gojira2d/pkg/graphics"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Window is a panel with a title bar. It's dragged by the title bar and
+// comes to the front when pressed. Widgets added to a window go in its
+// content panel, below the title bar
+type Window struct {
+	WidgetBase
+	titleBar *box
+	title    *Label
+	content  *Panel
+	close    *Button
+	dragging bool
+	onClose  func()
+}
+
+// NewWindow creates a window of the given size, title bar included
+func NewWindow(title string, size mgl32.Vec2, style *WidgetStyle) *Window {
+	w := &Window{}
+	w.init(w)
+	w.style = style
+	w.styleName = "window"
+	w.titleBar = newBox(&w.WidgetBase)
+	w.interactive = true
+	w.title = NewLabel(title, style)
+	w.title.stateSource = w
+	w.title.styleName = ""
+	w.WidgetBase.Add(w.title)
+	w.content = NewPanel(style)
+	w.content.styleName = ""
+	w.WidgetBase.Add(w.content)
+	w.SetSize(size)
+	return w
+}
+
+// Add adds a widget to the content panel
+func (w *Window) Add(child Widget) {
+	w.content.Add(child)
+}
+
+// Content returns the panel below the title bar
+func (w *Window) Content() *Panel {
+	return w.content
+}
+
+// SetTitle changes the text of the title bar
+func (w *Window) SetTitle(title string) {
+	w.title.SetText(title)
+	w.Invalidate()
+}
+
+// SetClosable adds or removes the close button of the title bar
+func (w *Window) SetClosable(closable bool) {
+	if closable && w.close == nil {
+		w.close = NewButton("x", w.style)
+		w.close.styleName = ""
+		w.close.SetOnClick(w.Close)
+		w.WidgetBase.Add(w.close)
+	} else if !closable && w.close != nil {
+		w.WidgetBase.Remove(w.close)
+		w.close = nil
+	}
+	w.Invalidate()
+}
+
+// SetStyle changes the look of the window and of its title bar
+func (w *Window) SetStyle(style *WidgetStyle) {
+	w.WidgetBase.SetStyle(style)
+	w.title.SetStyle(style)
+	w.content.SetStyle(style)
+	if w.close != nil {
+		w.close.SetStyle(style)
+	}
+}
+
+// SetOnClose sets the function called when the window is closed
+func (w *Window) SetOnClose(onClose func()) {
+	w.onClose = onClose
+}
+
+// Close hides the window
+func (w *Window) Close() {
+	w.SetVisible(false)
+	if w.onClose != nil {
+		w.onClose()
+	}
+}
+
+// titleBarHeight returns the height in pixels of the title bar
+func (w *Window) titleBarHeight() float32 {
+	return w.style.FontSize + w.style.Padding[0] + w.style.Padding[1]
+}
+
+// HandleEvent see Widget.HandleEvent
+func (w *Window) HandleEvent(event *Event) {
+	switch event.Type {
+	case EVENT_PRESS:
+		if w.parent != nil {
+			w.parent.Base().moveToFront(w)
+		}
+		w.dragging = event.Target == Widget(w) &&
+			event.Position[1] < w.AbsolutePosition()[1]+w.titleBarHeight()
+	case EVENT_DRAG:
+		if w.dragging && event.Target == Widget(w) {
+			w.SetPosition(w.position.Add(event.Delta))
+			event.Handled = true
+		}
+	case EVENT_RELEASE:
+		w.dragging = false
+	}
+}
+
+// Arrange see Widget.Arrange
+func (w *Window) Arrange() {
+	barHeight := w.titleBarHeight()
+	w.titleBar.set(w.AbsolutePosition(), w.layerDepth(0), mgl32.Vec2{w.size[0], barHeight})
+	w.title.SetPosition(mgl32.Vec2{w.style.Padding[2], centerVertically(mgl32.Vec2{0, barHeight}, w.title.Size()[1])})
+	w.content.SetPosition(mgl32.Vec2{0, barHeight})
+	w.content.setLayoutSize(mgl32.Vec2{w.size[0], w.size[1] - barHeight})
+	if w.close != nil {
+		side := barHeight
+		w.close.setLayoutSize(mgl32.Vec2{side, side})
+		w.close.SetPosition(mgl32.Vec2{w.size[0] - side, 0})
+	}
+}
+
+// EnqueueForDrawing see Widget.EnqueueForDrawing
+func (w *Window) EnqueueForDrawing(context *graphics.Context) {
+	state := STATE_NORMAL
+	if !w.Enabled() {
+		state = STATE_DISABLED
+	}
+	w.titleBar.setColor(w.style.Accent.Color(state))
+	w.titleBar.enqueue(context)
+	w.WidgetBase.EnqueueForDrawing(context)
+}